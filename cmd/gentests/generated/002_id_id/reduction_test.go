@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_002_id_id_Reduction(t *testing.T) {
+	gentests.Categories(t, "linear", "affine", "relevant")
 	gentests.CheckLambdaReduction(t, "002_id_id", input, output)
 }
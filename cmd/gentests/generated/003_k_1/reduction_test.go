@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_003_k_1_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "003_k_1", input, output)
 }
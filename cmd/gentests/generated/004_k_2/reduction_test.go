@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_004_k_2_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "004_k_2", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_005_erase_complex_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "005_erase_complex", input, output)
 }
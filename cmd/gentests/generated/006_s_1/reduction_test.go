@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_006_s_1_Reduction(t *testing.T) {
+	gentests.Categories(t, "sharing", "erasure")
 	gentests.CheckLambdaReduction(t, "006_s_1", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_007_s_2_Reduction(t *testing.T) {
+	gentests.Categories(t, "sharing", "erasure")
 	gentests.CheckLambdaReduction(t, "007_s_2", input, output)
 }
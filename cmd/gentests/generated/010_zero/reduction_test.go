@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_010_zero_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "010_zero", input, output)
 }
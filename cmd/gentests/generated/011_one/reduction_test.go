@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_011_one_Reduction(t *testing.T) {
+	gentests.Categories(t, "linear", "affine", "relevant")
 	gentests.CheckLambdaReduction(t, "011_one", input, output)
 }
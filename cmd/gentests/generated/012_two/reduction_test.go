@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_012_two_Reduction(t *testing.T) {
+	gentests.Categories(t, "relevant", "sharing")
 	gentests.CheckLambdaReduction(t, "012_two", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_013_succ_0_Reduction(t *testing.T) {
+	gentests.Categories(t, "sharing", "erasure")
 	gentests.CheckLambdaReduction(t, "013_succ_0", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_020_true_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "020_true", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_021_false_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "021_false", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_022_not_true_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "022_not_true", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_023_not_false_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "023_not_false", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_024_and_true_true_Reduction(t *testing.T) {
+	gentests.Categories(t, "sharing", "erasure")
 	gentests.CheckLambdaReduction(t, "024_and_true_true", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_025_and_true_false_Reduction(t *testing.T) {
+	gentests.Categories(t, "sharing", "erasure")
 	gentests.CheckLambdaReduction(t, "025_and_true_false", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_030_pair_fst_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "030_pair_fst", input, output)
 }
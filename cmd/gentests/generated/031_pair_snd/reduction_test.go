@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_031_pair_snd_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "031_pair_snd", input, output)
 }
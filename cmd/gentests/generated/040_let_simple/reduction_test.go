@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_040_let_simple_Reduction(t *testing.T) {
+	gentests.Categories(t, "linear", "affine", "relevant")
 	gentests.CheckLambdaReduction(t, "040_let_simple", input, output)
 }
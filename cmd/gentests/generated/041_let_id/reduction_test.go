@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_041_let_id_Reduction(t *testing.T) {
+	gentests.Categories(t, "linear", "affine", "relevant")
 	gentests.CheckLambdaReduction(t, "041_let_id", input, output)
 }
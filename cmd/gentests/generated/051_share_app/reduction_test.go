@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_051_share_app_Reduction(t *testing.T) {
+	gentests.Categories(t, "relevant", "sharing")
 	gentests.CheckLambdaReduction(t, "051_share_app", input, output)
 }
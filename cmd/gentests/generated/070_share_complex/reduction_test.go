@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_070_share_complex_Reduction(t *testing.T) {
+	gentests.Categories(t, "relevant", "sharing")
 	gentests.CheckLambdaReduction(t, "070_share_complex", input, output)
 }
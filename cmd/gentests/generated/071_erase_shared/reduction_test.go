@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_071_erase_shared_Reduction(t *testing.T) {
+	gentests.Categories(t, "affine", "erasure")
 	gentests.CheckLambdaReduction(t, "071_erase_shared", input, output)
 }
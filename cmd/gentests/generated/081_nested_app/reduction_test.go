@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_081_nested_app_Reduction(t *testing.T) {
+	gentests.Categories(t, "linear", "affine", "relevant")
 	gentests.CheckLambdaReduction(t, "081_nested_app", input, output)
 }
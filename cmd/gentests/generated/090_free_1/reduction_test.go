@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_090_free_1_Reduction(t *testing.T) {
+	gentests.Categories(t, "linear", "affine", "relevant")
 	gentests.CheckLambdaReduction(t, "090_free_1", input, output)
 }
@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_091_free_app_Reduction(t *testing.T) {
+	gentests.Categories(t, "linear", "affine", "relevant")
 	gentests.CheckLambdaReduction(t, "091_free_app", input, output)
 }
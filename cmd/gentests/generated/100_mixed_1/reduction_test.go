@@ -11,5 +11,6 @@ var input string
 var output string
 
 func Test_100_mixed_1_Reduction(t *testing.T) {
+	gentests.Categories(t, "linear", "affine", "relevant")
 	gentests.CheckLambdaReduction(t, "100_mixed_1", input, output)
 }
@@ -0,0 +1,73 @@
+package gentests
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+// Test categories
+//
+// The generator tags each test with the categories of its input term, and
+// Categories skips the tests the -categories flag leaves out:
+//
+//	go test ./cmd/gentests/... -args -categories=sharing
+//	go test ./cmd/gentests/... -args -categories=-stress
+//
+// The flag is a comma-separated list. Plain names select the tests having
+// any of them; names prefixed with "-" exclude the tests having them. An
+// empty list selects every test. Stress tests are also skipped by -short.
+
+// Categories a test can be tagged with.
+const (
+	CategoryLinear   = "linear"   // Every bound variable is used exactly once
+	CategoryAffine   = "affine"   // Every bound variable is used at most once
+	CategoryRelevant = "relevant" // Every bound variable is used at least once
+	CategorySharing  = "sharing"  // Some bound variable is used more than once
+	CategoryErasure  = "erasure"  // Some bound variable is unused
+	CategoryStress   = "stress"   // Heavy, kept out of the quick regression set
+)
+
+var categories = flag.String("categories", "",
+	"comma-separated test categories to run; prefix a category with - to exclude it")
+
+// Categories skips t unless a test tagged with tags is selected by the
+// -categories flag.
+func Categories(t *testing.T, tags ...string) {
+	t.Helper()
+	if testing.Short() && contains(tags, CategoryStress) {
+		t.Skip("stress test skipped in short mode")
+	}
+	if !Selected(*categories, tags) {
+		t.Skipf("categories %v not selected by -categories=%s", tags, *categories)
+	}
+}
+
+// Selected reports whether a test tagged with tags is selected by spec, in
+// the syntax of the -categories flag.
+func Selected(spec string, tags []string) bool {
+	included, wanted := false, false
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.TrimSpace(name)
+		switch {
+		case name == "":
+		case strings.HasPrefix(name, "-"):
+			if contains(tags, name[1:]) {
+				return false
+			}
+		default:
+			wanted = true
+			included = included || contains(tags, name)
+		}
+	}
+	return included || !wanted
+}
+
+func contains(tags []string, name string) bool {
+	for _, tag := range tags {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
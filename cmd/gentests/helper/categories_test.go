@@ -0,0 +1,20 @@
+package gentests
+
+import "testing"
+
+func TestSelected(t *testing.T) {
+	tags := []string{CategoryRelevant, CategorySharing}
+	for spec, want := range map[string]bool{
+		"":                  true,
+		"sharing":           true,
+		"erasure":           false,
+		"erasure, relevant": true,
+		"-stress":           true,
+		"-sharing":          false,
+		"relevant,-sharing": false,
+	} {
+		if got := Selected(spec, tags); got != want {
+			t.Errorf("Selected(%q, %v) = %v, want %v", spec, tags, got, want)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package gentests
 import (
 	"fmt"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -10,6 +11,13 @@ import (
 	"github.com/vic/godnet/pkg/lambda"
 )
 
+// shared is the network every check of a test binary evaluates on, reset
+// between terms.
+var (
+	sharedMu sync.Mutex
+	shared   = deltanet.NewNetwork()
+)
+
 func CheckLambdaReduction(t *testing.T, testName string, inputStr string, outputStr string) {
 	expectedOutput := strings.TrimSpace(outputStr)
 
@@ -19,12 +27,10 @@ func CheckLambdaReduction(t *testing.T, testName string, inputStr string, output
 		t.Fatalf("Parse error for expected output: %v", err)
 	}
 
-	// Instead of round-tripping expected output through DeltaNet (which
-	// loses original free variable names and produces implementation
-	// placeholders), we normalize both expected and actual terms to a
-	// structural, alpha-renamed form and compare those. Normalization
-	// replaces all free variable names with the placeholder "<free>"
-	// and renames bound variables to a canonical sequence x0, x1, ...
+	// Normalize both expected and actual terms to a structural,
+	// alpha-renamed form and compare those. Bound variables are renamed
+	// to a canonical sequence x0, x1, ...; free variables keep their
+	// original names, which Readback recovers from the translation.
 	normalize := func(t lambda.Term) lambda.Term {
 		// mapping from original bound name -> canonical name
 		bindings := make(map[string]string)
@@ -36,7 +42,7 @@ func CheckLambdaReduction(t *testing.T, testName string, inputStr string, output
 				if name, ok := bindings[v.Name]; ok {
 					return lambda.Var{Name: name}
 				}
-				return lambda.Var{Name: "<free>"}
+				return v
 			case lambda.Abs:
 				canon := fmt.Sprintf("x%d", idx)
 				idx++
@@ -66,13 +72,19 @@ func CheckLambdaReduction(t *testing.T, testName string, inputStr string, output
 	}
 
 	// Convert input to Net
-	net := deltanet.NewNetwork()
+	sharedMu.Lock()
+	defer sharedMu.Unlock()
+	net := shared
+	net.Reset()
 	// net.EnableTrace(1000) // Debug
-	root, port, varNames := lambda.ToDeltaNet(term, net)
-
-	// Connect to output interface
-	output := net.NewVar()
-	net.Link(root, port, output, 0)
+	// Expected outputs never contain erased positions: report them as
+	// errors rather than comparing placeholder strings.
+	tr := lambda.NewTranslator(lambda.TranslatorOptions{Erased: lambda.ErasedError})
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		t.Fatalf("Translation error: %v", err)
+	}
+	output := translation.Output
 
 	// Reduce
 	start := time.Now()
@@ -94,8 +106,11 @@ func CheckLambdaReduction(t *testing.T, testName string, inputStr string, output
 
 	// Read back into a Term
 	resNode, resPort = net.GetLink(output, 0)
-	t.Logf("%s: root node before FromDeltaNet: %v id=%d port=%d", testName, resNode.Type(), resNode.ID(), resPort)
-	actualTerm := lambda.FromDeltaNet(net, resNode, resPort, varNames)
+	t.Logf("%s: root node before readback: %v id=%d port=%d", testName, resNode.Type(), resNode.ID(), resPort)
+	actualTerm, err := tr.ReadbackChecked(net, translation)
+	if err != nil {
+		t.Errorf("%s: %v in %s", testName, err, actualTerm)
+	}
 
 	// If expected is a simple free variable, collapse any top-level
 	// unused abstractions that canonicalization may have missed. This
@@ -145,6 +160,10 @@ func CheckLambdaReduction(t *testing.T, testName string, inputStr string, output
 
 	// Optional: Check stats if stats.nix exists
 	// For now, we just log them
-	stats := net.GetStats()
-	t.Logf("%s: %d reductions in %v", testName, stats.TotalReductions, elapsed)
+	report := net.Report()
+	report.Result = actualTerm.String()
+	report.Elapsed = elapsed
+	var buf strings.Builder
+	report.WriteText(&buf)
+	t.Logf("%s:%s", testName, buf.String())
 }
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
+	gentests "github.com/vic/godnet/cmd/gentests/helper"
+	"github.com/vic/godnet/pkg/deltanet"
 	"github.com/vic/godnet/pkg/lambda"
 )
 
@@ -14,20 +18,62 @@ type TestCase struct {
 	Output string
 }
 
-const testTemplate = `
-package gentests
+// stress lists the heavy tests, tagged CategoryStress so quick runs can
+// leave them out.
+var stress = map[string]bool{
+	"050_deep_app": true,
+	"060_pow_2_3":  true,
+}
+
+const testTemplate = `package gentests
+
 import _ "embed"
 import "testing"
 import "github.com/vic/godnet/cmd/gentests/helper"
+
 //go:embed input.nix
 var input string
+
 //go:embed output.nix
 var output string
+
 func Test_%s_Reduction(t *testing.T) {
+	gentests.Categories(t, %s)
 	gentests.CheckLambdaReduction(t, "%s", input, output)
 }
 `
 
+// categories tags a test with the subsystems its input belongs to, the
+// sharing and erasure they imply, and whether it is a stress test.
+func categories(name string, term lambda.Term) []string {
+	in := func(sub lambda.Subsystem) bool {
+		tr := lambda.NewTranslator(lambda.TranslatorOptions{Subsystem: sub})
+		_, err := tr.Translate(term, deltanet.NewNetwork())
+		return !errors.Is(err, lambda.ErrSubsystem)
+	}
+	var tags []string
+	if in(lambda.SubsystemLinear) {
+		tags = append(tags, gentests.CategoryLinear)
+	}
+	affine, relevant := in(lambda.SubsystemAffine), in(lambda.SubsystemRelevant)
+	if affine {
+		tags = append(tags, gentests.CategoryAffine)
+	}
+	if relevant {
+		tags = append(tags, gentests.CategoryRelevant)
+	}
+	if !affine {
+		tags = append(tags, gentests.CategorySharing)
+	}
+	if !relevant {
+		tags = append(tags, gentests.CategoryErasure)
+	}
+	if stress[name] {
+		tags = append(tags, gentests.CategoryStress)
+	}
+	return tags
+}
+
 func main() {
 	tests := []TestCase{
 		// Identity
@@ -101,6 +147,7 @@ func main() {
 	baseDir := "cmd/gentests/generated"
 	os.MkdirAll(baseDir, 0755)
 
+	seen := make(map[uint64]TestCase)
 	for _, tc := range tests {
 		dir := filepath.Join(baseDir, tc.Name)
 		os.MkdirAll(dir, 0755)
@@ -111,6 +158,10 @@ func main() {
 			fmt.Printf("Error parsing input for %s: %v\n", tc.Name, err)
 			continue
 		}
+		if other, ok := seen[lambda.Hash(inTerm)]; ok {
+			fmt.Printf("Warning: %s duplicates %s (alpha-equivalent inputs)\n", tc.Name, other.Name)
+		}
+		seen[lambda.Hash(inTerm)] = tc
 
 		// Normalize Output
 		outTerm, err := lambda.Parse(tc.Output)
@@ -119,7 +170,11 @@ func main() {
 			continue
 		}
 
-		testGo := fmt.Sprintf(testTemplate, tc.Name, tc.Name)
+		var tags []string
+		for _, tag := range categories(tc.Name, inTerm) {
+			tags = append(tags, fmt.Sprintf("%q", tag))
+		}
+		testGo := fmt.Sprintf(testTemplate, tc.Name, strings.Join(tags, ", "), tc.Name)
 
 		os.WriteFile(filepath.Join(dir, "input.nix"), []byte(inTerm.String()), 0644)
 		os.WriteFile(filepath.Join(dir, "output.nix"), []byte(outTerm.String()), 0644)
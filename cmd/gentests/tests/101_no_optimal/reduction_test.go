@@ -15,5 +15,6 @@ var output string
 // This term is from the paper: ((λg.(g (g λx.x))) (λh.((λf.(f (f λz.z))) (λw.(h (w λy.y))))))
 // It demonstrates Delta-Nets' ability to handle sharing optimally without unnecessary reductions.
 func Test_101_no_optimal_Reduction(t *testing.T) {
+	gentests.Categories(t, "relevant", "sharing")
 	gentests.CheckLambdaReduction(t, "101_no_optimal", input, output)
 }
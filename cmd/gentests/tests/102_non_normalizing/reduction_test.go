@@ -4,11 +4,13 @@ import _ "embed"
 import "testing"
 import "github.com/vic/godnet/pkg/lambda"
 import "github.com/vic/godnet/pkg/deltanet"
+import "github.com/vic/godnet/cmd/gentests/helper"
 
 //go:embed input.nix
 var input string
 
 func Test_102_non_normalizing_ConstantMemory(t *testing.T) {
+	gentests.Categories(t, "relevant", "sharing")
 	// Parse input
 	term, err := lambda.Parse(input)
 	if err != nil {
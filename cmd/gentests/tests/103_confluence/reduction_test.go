@@ -25,6 +25,7 @@ var output string
 //  3. The two-phase reduction strategy (Phase 1: LMO + Canonicalization, Phase 2: Aux Fan Replication)
 //     produces a canonical result
 func Test_103_confluence(t *testing.T) {
+	gentests.Categories(t, "relevant", "sharing")
 	// Parse the input term
 	term, err := lambda.Parse(input)
 	if err != nil {
@@ -79,8 +80,7 @@ func Test_103_confluence(t *testing.T) {
 
 	for _, workers := range workerConfigs {
 		t.Run(fmt.Sprintf("Workers_%d", workers), func(t *testing.T) {
-			net := deltanet.NewNetwork()
-			net.SetWorkers(workers)
+			net := deltanet.NewNetworkWith(deltanet.WithWorkers(workers))
 
 			root, port, varNames := lambda.ToDeltaNet(term, net)
 			outputNode := net.NewVar()
@@ -158,6 +158,7 @@ func Test_103_confluence(t *testing.T) {
 // Note: Perfect confluence applies to the CORE interaction system (without canonicalization).
 // The full system with canonicalization rules is Church-Rosser confluent.
 func Test_103_confluence_PerfectConfluence(t *testing.T) {
+	gentests.Categories(t, "linear", "affine", "relevant")
 	// For this test, we use a linear lambda term (no erasure, no sharing)
 	// to verify perfect confluence in the Delta-L subsystem
 	linearInput := "(x: x) (y: y)"
@@ -174,8 +175,7 @@ func Test_103_confluence_PerfectConfluence(t *testing.T) {
 	baselineSet := false
 
 	for workers := 1; workers <= 4; workers++ {
-		net := deltanet.NewNetwork()
-		net.SetWorkers(workers)
+		net := deltanet.NewNetworkWith(deltanet.WithWorkers(workers))
 
 		root, port, _ := lambda.ToDeltaNet(term, net)
 		outputNode := net.NewVar()
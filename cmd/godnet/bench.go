@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/vic/godnet/pkg/bench"
+)
+
+// runBench runs workloads of the standard benchmark corpus (see
+// pkg/bench), checking their results, and prints one line per workload
+// with the time spent reducing. Without names it runs the whole corpus.
+func runBench() {
+	fs := flag.NewFlagSet("bench", flag.ExitOnError)
+	list := fs.Bool("list", false, "list the workloads and exit")
+	source := fs.Bool("source", false, "print the sources of the workloads instead of running them")
+	count := fs.Int("count", 1, "run each workload this many times and report the fastest")
+	fs.Parse(os.Args[2:])
+
+	workloads := bench.Corpus()
+	if fs.NArg() > 0 {
+		workloads = workloads[:0]
+		for _, name := range fs.Args() {
+			w, ok := bench.Lookup(name)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Unknown workload %q; see godnet bench -list\n", name)
+				os.Exit(1)
+			}
+			workloads = append(workloads, w)
+		}
+	}
+
+	if *list {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, wl := range workloads {
+			fmt.Fprintf(w, "%s\t%s\n", wl.Name, wl.Description)
+		}
+		w.Flush()
+		return
+	}
+	if *source {
+		for _, wl := range workloads {
+			fmt.Printf("# %s\n%s\n", wl.Name, wl.Source)
+		}
+		return
+	}
+
+	failed := false
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "workload\tinteractions\tpeak nodes\ttime\tresult\t")
+	for _, wl := range workloads {
+		var best *bench.Result
+		for i := 0; i < max(*count, 1); i++ {
+			res, err := wl.Run()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if best == nil || res.Report.Elapsed < best.Report.Elapsed {
+				best = res
+			}
+		}
+		status := "ok"
+		if err := wl.Check(best.Term); err != nil {
+			status = "WRONG"
+			failed = true
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%v\t%s\t\n", wl.Name, best.Report.Stats.TotalReductions, best.Report.PeakNodes,
+			best.Report.Elapsed.Round(time.Microsecond), status)
+	}
+	w.Flush()
+	if failed {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/frontend"
+	"github.com/vic/godnet/pkg/lambda"
+	"github.com/vic/godnet/pkg/natives"
+)
+
+const debugHelp = `Commands:
+  :step [n]       reduce n interactions (default 1)
+  :run            reduce until no active pairs remain
+  :pairs          list the active pairs, next to reduce first
+  :pick i         reduce active pair i out of order
+  :show           read back the current net
+  :peek           read back the current net without entering redexes
+  :stats          print the reduction report
+  :dump           list the live nodes and their links
+  :census         break the live nodes down by type, level and arity
+  :save name      save a checkpoint of the current net
+  :restore name   go back to a checkpoint
+  :checkpoints    list checkpoints
+  :help           show this help
+  :quit           exit
+`
+
+// runDebug reduces a term step by step under commands read from stdin.
+// Checkpoints let the user go back to a point of the reduction and try a
+// different continuation from it.
+func runDebug() {
+	fs := flag.NewFlagSet("debug", flag.ExitOnError)
+	syntax := fs.String("syntax", frontend.Default, "source syntax: "+strings.Join(frontend.Names(), ", "))
+	fs.Parse(os.Args[2:])
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: godnet debug [-syntax name] <source>\n")
+		os.Exit(1)
+	}
+
+	input, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	term, err := frontend.Parse(*syntax, string(input))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
+		os.Exit(1)
+	}
+
+	net := deltanet.NewNetworkWith(deltanet.WithNatives(natives.Register))
+	tr := lambda.NewTranslator(lambda.TranslatorOptions{})
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Translation error: %v\n", err)
+		os.Exit(1)
+	}
+	d := &debugger{net: net, tr: tr, translation: translation, checkpoints: make(map[string]*deltanet.Snapshot)}
+	d.loop(os.Stdin, os.Stdout)
+}
+
+type debugger struct {
+	net         *deltanet.Network
+	tr          *lambda.Translator
+	translation *lambda.Translation
+	checkpoints map[string]*deltanet.Snapshot
+}
+
+func (d *debugger) loop(in io.Reader, out io.Writer) {
+	fmt.Fprint(out, debugHelp)
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if fields[0] == ":quit" {
+			return
+		}
+		if err := d.command(fields[0], fields[1:], out); err != nil {
+			fmt.Fprintf(out, "Error: %v\n", err)
+		}
+	}
+}
+
+func (d *debugger) command(cmd string, args []string, out io.Writer) error {
+	arg := func() (string, error) {
+		if len(args) != 1 {
+			return "", fmt.Errorf("%s takes one argument", cmd)
+		}
+		return args[0], nil
+	}
+	switch cmd {
+	case ":step":
+		steps := uint64(1)
+		if len(args) > 0 {
+			n, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid step count %q", args[0])
+			}
+			steps = n
+		}
+		for i := uint64(0); i < steps; i++ {
+			event, ok := d.net.Step()
+			if !ok {
+				fmt.Fprintln(out, "No active pairs")
+				break
+			}
+			printEvent(out, event)
+		}
+	case ":run":
+		var done uint64
+		for {
+			n := d.net.ReduceWithLimit(10000)
+			done += n
+			if n == 0 {
+				break
+			}
+		}
+		fmt.Fprintf(out, "%d interactions (%d total)\n", done, d.net.GetStats().TotalReductions)
+	case ":pairs":
+		for i, p := range d.net.ActivePairs() {
+			fmt.Fprintf(out, "%3d  %-12s %v#%d <-> %v#%d at depth %d\n",
+				i, p.Rule, p.A.Type(), p.A.ID(), p.B.Type(), p.B.ID(), p.Depth)
+		}
+	case ":pick":
+		s, err := arg()
+		if err != nil {
+			return err
+		}
+		pairs := d.net.ActivePairs()
+		i, err := strconv.Atoi(s)
+		if err != nil || i < 0 || i >= len(pairs) {
+			return fmt.Errorf("no active pair %q (see :pairs)", s)
+		}
+		event, ok := d.net.ReducePair(pairs[i])
+		if !ok {
+			return fmt.Errorf("pair %d is no longer active", i)
+		}
+		printEvent(out, event)
+	case ":show":
+		fmt.Fprintln(out, d.tr.Readback(d.net, d.translation))
+	case ":peek":
+		fmt.Fprintln(out, d.tr.ReadbackPartial(d.net, d.translation))
+	case ":stats":
+		return d.net.Report().WriteText(out)
+	case ":dump":
+		return d.net.Dump(out)
+	case ":census":
+		return d.net.Census().WriteText(out)
+	case ":save":
+		name, err := arg()
+		if err != nil {
+			return err
+		}
+		d.checkpoints[name] = d.net.Snapshot()
+		fmt.Fprintf(out, "Saved %s at %d interactions\n", name, d.net.GetStats().TotalReductions)
+	case ":restore":
+		name, err := arg()
+		if err != nil {
+			return err
+		}
+		snap, ok := d.checkpoints[name]
+		if !ok {
+			return fmt.Errorf("no checkpoint %q", name)
+		}
+		output := d.translation.Output.ID()
+		d.net.Restore(snap)
+		d.translation.Output = d.net.NodeByID(output)
+		fmt.Fprintf(out, "Restored %s at %d interactions\n", name, d.net.GetStats().TotalReductions)
+	case ":checkpoints":
+		names := make([]string, 0, len(d.checkpoints))
+		for name := range d.checkpoints {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(out, name)
+		}
+	case ":help":
+		fmt.Fprint(out, debugHelp)
+	default:
+		return fmt.Errorf("unknown command %s (see :help)", cmd)
+	}
+	return nil
+}
+
+// printEvent prints one interaction of the debugger.
+func printEvent(out io.Writer, e deltanet.TraceEvent) {
+	fmt.Fprintf(out, "#%d %s: %v#%d <-> %v#%d at depth %d\n", e.Step, e.Rule, e.AType, e.AID, e.BType, e.BID, e.Depth)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/frontend"
+	"github.com/vic/godnet/pkg/lambda"
+	"github.com/vic/godnet/pkg/natives"
+)
+
+// inlineRun is the outcome of reducing one source with a translator.
+type inlineRun struct {
+	nodes        int
+	interactions uint64
+	result       string
+}
+
+// runInline compares the nets and reductions of a corpus with and without
+// the inlining pass (see lambda.Inline).
+func runInline() {
+	fs := flag.NewFlagSet("inline", flag.ExitOnError)
+	size := fs.Int("size", 8, "largest combinator copied to its uses")
+	syntax := fs.String("syntax", frontend.Default, "source syntax: "+strings.Join(frontend.Names(), ", "))
+	fs.Parse(os.Args[2:])
+	if fs.NArg() == 0 {
+		fmt.Fprintf(os.Stderr, "Usage: godnet inline [-size N] [-syntax name] <source>...\n")
+		os.Exit(1)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "source\tnodes\tinlined\tinteractions\tinlined\t")
+	var total, totalInlined inlineRun
+	failed := false
+	for _, path := range fs.Args() {
+		input, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			os.Exit(1)
+		}
+		term, err := frontend.Parse(*syntax, string(input))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: parse error: %v\n", path, err)
+			os.Exit(1)
+		}
+		plain, err := reduceWith(term, lambda.TranslatorOptions{})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			os.Exit(1)
+		}
+		inlined, err := reduceWith(term, lambda.TranslatorOptions{Inline: *size})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			os.Exit(1)
+		}
+		if plain.result != inlined.result {
+			fmt.Fprintf(os.Stderr, "%s: inlining changed the result from %s to %s\n", path, plain.result, inlined.result)
+			failed = true
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t\n", filepath.Base(filepath.Dir(path))+"/"+filepath.Base(path),
+			plain.nodes, inlined.nodes, plain.interactions, inlined.interactions)
+		total.nodes += plain.nodes
+		total.interactions += plain.interactions
+		totalInlined.nodes += inlined.nodes
+		totalInlined.interactions += inlined.interactions
+	}
+	fmt.Fprintf(w, "total\t%d\t%d\t%d\t%d\t\n", total.nodes, totalInlined.nodes, total.interactions, totalInlined.interactions)
+	w.Flush()
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// reduceWith translates term with opts, reduces it to normal form and
+// reads the result back.
+func reduceWith(term lambda.Term, opts lambda.TranslatorOptions) (inlineRun, error) {
+	net := deltanet.NewNetworkWith(deltanet.WithNatives(natives.Register))
+	defer net.Close()
+	tr := lambda.NewTranslator(opts)
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		return inlineRun{}, err
+	}
+	run := inlineRun{nodes: net.NodeCount()}
+	net.ReduceToNormalForm()
+	run.interactions = net.GetStats().TotalReductions
+	run.result = tr.Readback(net, translation).String()
+	return run, nil
+}
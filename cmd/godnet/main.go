@@ -1,40 +1,99 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"runtime/pprof"
+	"strings"
 
 	"time"
 
 	"github.com/vic/godnet/pkg/compiler"
 	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/frontend"
 	"github.com/vic/godnet/pkg/lambda"
+	"github.com/vic/godnet/pkg/natives"
+	"github.com/vic/godnet/pkg/program"
 )
 
 func main() {
+	// Networks log to the default logger; DELTA_DEBUG shows debug messages.
+	if os.Getenv("DELTA_DEBUG") != "" {
+		slog.SetLogLoggerLevel(slog.LevelDebug)
+	}
+
 	// Check for compile subcommand
 	if len(os.Args) > 1 && os.Args[1] == "compile" {
 		runCompile()
 		return
 	}
+	if len(os.Args) > 1 && os.Args[1] == "pack" {
+		runPack()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		runProgram()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "trace" {
+		runTrace()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		runVerify()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		runServe()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "anonymize" {
+		runAnonymize()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "debug" {
+		runDebug()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "script" {
+		runScript()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "inline" {
+		runInline()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rules" {
+		runRules()
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "bench" {
+		runBench()
+		return
+	}
 
 	// Default: eval mode
 	runEval()
 }
 
 func runCompile() {
-	if len(os.Args) < 3 {
-		fmt.Fprintf(os.Stderr, "Usage: godnet compile <source.lam> [go build flags...]\n")
+	fs := flag.NewFlagSet("compile", flag.ExitOnError)
+	prelude := fs.String("prelude", "", "file of let bindings the source is compiled in scope of")
+	quiet := fs.Bool("quiet", false, "the program prints its result without statistics")
+	fs.Parse(os.Args[2:])
+	if fs.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Usage: godnet compile [-prelude file] [-quiet] <source.lam> [go build flags...]\n")
 		os.Exit(1)
 	}
 
-	sourceFile := os.Args[2]
-	goFlags := os.Args[3:]
-
 	c := compiler.Compiler{
-		SourceFile: sourceFile,
-		GoFlags:    goFlags,
+		SourceFile: fs.Arg(0),
+		GoFlags:    fs.Args()[1:],
+		Prelude:    *prelude,
+		Quiet:      *quiet,
 	}
 
 	outputName, err := c.Compile()
@@ -46,109 +105,201 @@ func runCompile() {
 	fmt.Fprintf(os.Stderr, "Successfully compiled to: %s\n", outputName)
 }
 
-func runEval() {
-	var input []byte
-	var err error
+// runPack writes the program artifact for a source file to stdout.
+func runPack() {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: godnet pack <source.lam> > program.json\n")
+		os.Exit(1)
+	}
 
-	if len(os.Args) > 1 {
-		input, err = os.ReadFile(os.Args[1])
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
-			os.Exit(1)
-		}
-	} else {
-		input, err = io.ReadAll(os.Stdin)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
-			os.Exit(1)
-		}
+	sourceFile := os.Args[2]
+	source, err := os.ReadFile(sourceFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
 	}
 
-	term, err := lambda.Parse(string(input))
+	p, err := program.Compile(source, lambda.TranslatorOptions{})
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Pack failed: %v\n", err)
 		os.Exit(1)
 	}
+	p.Meta = map[string]string{"source": sourceFile}
 
-	net := deltanet.NewNetwork()
-	root, port, varNames := lambda.ToDeltaNet(term, net)
+	if err := p.Encode(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing program: %v\n", err)
+		os.Exit(1)
+	}
+}
 
-	// Connect root to a dummy interface node to allow reduction at the root
-	output := net.NewVar()
-	net.Link(root, port, output, 0)
+// runProgram loads a program artifact, validates it and evaluates it.
+func runProgram() {
+	if len(os.Args) < 3 {
+		fmt.Fprintf(os.Stderr, "Usage: godnet run <program.json>\n")
+		os.Exit(1)
+	}
 
-	start := time.Now()
-	net.ReduceAll()
-	elapsed := time.Since(start)
+	f, err := os.Open(os.Args[2])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	p, err := program.Decode(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading program: %v\n", err)
+		os.Exit(1)
+	}
 
-	// Read back from the output node
-	resNode, resPort := net.GetLink(output, 0)
-	res := lambda.FromDeltaNet(net, resNode, resPort, varNames)
-	fmt.Println(res)
+	net := deltanet.NewNetworkWith(deltanet.WithNatives(natives.Register))
+	translation, err := p.Load(net)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid program: %v\n", err)
+		os.Exit(1)
+	}
 
-	stats := net.GetStats()
-	seconds := elapsed.Seconds()
+	evaluate(net, lambda.NewTranslator(lambda.TranslatorOptions{}), translation)
+}
 
-	fmt.Fprintf(os.Stderr, "\nStats:\n")
-	fmt.Fprintf(os.Stderr, "Time: %v\n", elapsed)
-	fmt.Fprintf(os.Stderr, "Total Reductions: %d", stats.TotalReductions)
-	if seconds > 0 {
-		fmt.Fprintf(os.Stderr, " (%.2f ops/sec)", float64(stats.TotalReductions)/seconds)
+// runVerify cross-validates a term: it is reduced with and without phase 2
+// and both readbacks must agree.
+func runVerify() {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	syntax := fs.String("syntax", frontend.Default, "source syntax: "+strings.Join(frontend.Names(), ", "))
+	fs.Parse(os.Args[2:])
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: godnet verify [-syntax name] <source>\n")
+		os.Exit(1)
 	}
-	fmt.Fprintf(os.Stderr, "\n")
 
-	fmt.Fprintf(os.Stderr, "\nBreakdown:\n")
-	fmt.Fprintf(os.Stderr, "  Fan Annihilation:        %6d", stats.FanAnnihilation)
-	if seconds > 0 {
-		fmt.Fprintf(os.Stderr, " (%.2f ops/sec)", float64(stats.FanAnnihilation)/seconds)
+	input, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	term, err := frontend.Parse(*syntax, string(input))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
+		os.Exit(1)
 	}
-	fmt.Fprintf(os.Stderr, "\n")
 
-	fmt.Fprintf(os.Stderr, "  Replicator Annihilation: %6d", stats.RepAnnihilation)
-	if seconds > 0 {
-		fmt.Fprintf(os.Stderr, " (%.2f ops/sec)", float64(stats.RepAnnihilation)/seconds)
+	newNet := func() *deltanet.Network {
+		return deltanet.NewNetworkWith(deltanet.WithNatives(natives.Register))
+	}
+	check, err := lambda.NewTranslator(lambda.TranslatorOptions{}).CrossValidate(term, newNet)
+	if check != nil {
+		fmt.Printf("Phase 1:     %s\n", check.Phase1)
+		fmt.Printf("Normal form: %s\n", check.Full)
 	}
-	fmt.Fprintf(os.Stderr, "\n")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !check.Compared {
+		fmt.Fprintf(os.Stderr, "Not compared: a result has erased or unresolved positions\n")
+	}
+}
 
-	fmt.Fprintf(os.Stderr, "  Replicator Commutation:  %6d", stats.RepCommutation)
-	if seconds > 0 {
-		fmt.Fprintf(os.Stderr, " (%.2f ops/sec)", float64(stats.RepCommutation)/seconds)
+// runAnonymize prints a source term with identifiers renamed and literals
+// blanked, for sharing failing inputs. Calls to natives are kept.
+func runAnonymize() {
+	fs := flag.NewFlagSet("anonymize", flag.ExitOnError)
+	syntax := fs.String("syntax", frontend.Default, "source syntax: "+strings.Join(frontend.Names(), ", "))
+	fs.Parse(os.Args[2:])
+	if fs.NArg() != 1 {
+		fmt.Fprintf(os.Stderr, "Usage: godnet anonymize [-syntax name] <source>\n")
+		os.Exit(1)
 	}
-	fmt.Fprintf(os.Stderr, "\n")
 
-	fmt.Fprintf(os.Stderr, "  Fan-Rep Commutation:     %6d", stats.FanRepCommutation)
-	if seconds > 0 {
-		fmt.Fprintf(os.Stderr, " (%.2f ops/sec)", float64(stats.FanRepCommutation)/seconds)
+	input, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	term, err := frontend.Parse(*syntax, string(input))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
+		os.Exit(1)
 	}
-	fmt.Fprintf(os.Stderr, "\n")
 
-	fmt.Fprintf(os.Stderr, "  Erasure:                 %6d", stats.Erasure)
-	if seconds > 0 {
-		fmt.Fprintf(os.Stderr, " (%.2f ops/sec)", float64(stats.Erasure)/seconds)
+	net := deltanet.NewNetworkWith(deltanet.WithNatives(natives.Register))
+	isNative := func(name string) bool {
+		_, ok := net.NativeCapability(name)
+		return ok
 	}
-	fmt.Fprintf(os.Stderr, "\n")
+	fmt.Println(lambda.Anonymize(term, isNative))
+}
 
-	if stats.RepDecay > 0 {
-		fmt.Fprintf(os.Stderr, "  Replicator Decay:        %6d", stats.RepDecay)
-		if seconds > 0 {
-			fmt.Fprintf(os.Stderr, " (%.2f ops/sec)", float64(stats.RepDecay)/seconds)
+func runEval() {
+	fs := flag.NewFlagSet("godnet", flag.ExitOnError)
+	syntax := fs.String("syntax", frontend.Default, "source syntax: "+strings.Join(frontend.Names(), ", "))
+	deadCode := fs.Bool("dce", false, "drop unreachable let bindings before translation")
+	inline := fs.Int("inline", 0, "inline single-use bindings and combinators up to this size")
+	eta := fs.Bool("eta", false, "eta-contract the net and the result")
+	cpuProfile := fs.String("cpuprofile", "", "write a CPU profile of the reduction, labeled by rule and depth, to this file")
+	fs.Parse(os.Args[1:])
+
+	var input []byte
+	var err error
+
+	if fs.NArg() > 0 {
+		input, err = os.ReadFile(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			os.Exit(1)
+		}
+	} else {
+		input, err = io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading stdin: %v\n", err)
+			os.Exit(1)
 		}
-		fmt.Fprintf(os.Stderr, "\n")
 	}
 
-	if stats.RepMerge > 0 {
-		fmt.Fprintf(os.Stderr, "  Replicator Merge:        %6d", stats.RepMerge)
-		if seconds > 0 {
-			fmt.Fprintf(os.Stderr, " (%.2f ops/sec)", float64(stats.RepMerge)/seconds)
-		}
-		fmt.Fprintf(os.Stderr, "\n")
+	term, err := frontend.Parse(*syntax, string(input))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
+		os.Exit(1)
 	}
 
-	if stats.AuxFanRep > 0 {
-		fmt.Fprintf(os.Stderr, "  Aux Fan-Rep:             %6d", stats.AuxFanRep)
-		if seconds > 0 {
-			fmt.Fprintf(os.Stderr, " (%.2f ops/sec)", float64(stats.AuxFanRep)/seconds)
+	net := deltanet.NewNetwork()
+	net.SetEtaContraction(*eta)
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating profile: %v\n", err)
+			os.Exit(1)
 		}
-		fmt.Fprintf(os.Stderr, "\n")
+		defer f.Close()
+		net.SetProfileLabels(true)
+		pprof.StartCPUProfile(f)
+		defer pprof.StopCPUProfile()
+	}
+	tr := lambda.NewTranslator(lambda.TranslatorOptions{DeadCode: *deadCode, Inline: *inline, Eta: *eta})
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Translation error: %v\n", err)
+		os.Exit(1)
 	}
+
+	evaluate(net, tr, translation)
+}
+
+// evaluate reduces the net, prints the result and reports statistics.
+func evaluate(net *deltanet.Network, tr *lambda.Translator, translation *lambda.Translation) {
+	start := time.Now()
+	if err := net.ReduceMonitored(translation.Output, deltanet.DivergenceMonitor{}); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	elapsed := time.Since(start)
+
+	// Read back from the output node
+	report := tr.Report(net, translation)
+	report.Elapsed = elapsed
+	fmt.Println(report.Result)
+
+	// The result already went to stdout; stderr gets the statistics.
+	report.Result = ""
+	report.WriteText(os.Stderr)
 }
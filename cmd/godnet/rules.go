@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// runRules prints the interaction rule matrix the reducer dispatches on
+// (see deltanet.RuleTable).
+func runRules() {
+	if len(os.Args) > 2 {
+		fmt.Fprintf(os.Stderr, "Usage: godnet rules\n")
+		os.Exit(1)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "phase\tnodes\tlevels\trule\tstatistic")
+	for _, e := range deltanet.RuleTable() {
+		levels := e.Levels
+		if levels == "" {
+			levels = "-"
+		}
+		fmt.Fprintf(w, "%d\t%v-%v\t%s\t%v\t%s\n", e.Phase, e.A, e.B, levels, e.Rule, e.Stat)
+	}
+	w.Flush()
+	fmt.Println("\nCanonical rules, applied between phases and at the end:")
+	fmt.Printf("  %v (RepDecay), %v (RepMerge), %v (EtaContraction)\n",
+		deltanet.RuleRepDecay, deltanet.RuleRepMerge, deltanet.RuleEta)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/frontend"
+	"github.com/vic/godnet/pkg/lambda"
+	"github.com/vic/godnet/pkg/natives"
+)
+
+const scriptHelp = `A script holds one entry per line; a line ending in \ continues on the
+next one. Blank lines and lines starting with # are skipped. Entries:
+  term              evaluate a term and print its normal form
+  :let name = term  bind name in the entries that follow
+  :expect term      check that the last result equals term (up to renaming)
+  :stats            print the reduction report of the last evaluation
+  :echo text        print text
+`
+
+// runScript runs the entries of a script file. A failing entry is reported
+// and the script goes on; a summary is printed at the end and the exit
+// status is 1 if any entry failed.
+func runScript() {
+	fs := flag.NewFlagSet("script", flag.ExitOnError)
+	syntax := fs.String("syntax", frontend.Default, "source syntax: "+strings.Join(frontend.Names(), ", "))
+	timeout := fs.Duration("timeout", 10*time.Second, "wall time limit of each evaluation, 0 for none")
+	maxSteps := fs.Uint64("max-interactions", 0, "interaction limit of each evaluation, 0 for none")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: godnet script [flags] <file>\n\n%s\nFlags:\n", scriptHelp)
+		fs.PrintDefaults()
+	}
+	fs.Parse(os.Args[2:])
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	s := &script{
+		syntax: *syntax,
+		budget: deltanet.Budget{MaxInteractions: *maxSteps, Timeout: *timeout},
+	}
+	if err := s.run(f, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	s.summarize(os.Stderr)
+	if len(s.failures) > 0 {
+		os.Exit(1)
+	}
+}
+
+type script struct {
+	syntax   string
+	budget   deltanet.Budget
+	defs     []lambda.Binding
+	last     lambda.Term // Result of the last evaluation, nil before the first
+	report   *deltanet.Report
+	entries  int
+	failures []string // "line N: error"
+}
+
+// run executes every entry read from in.
+func (s *script) run(in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	var entry strings.Builder
+	line, start := 0, 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if entry.Len() == 0 {
+			start = line
+			trimmed := strings.TrimSpace(text)
+			if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+		}
+		if cont, ok := strings.CutSuffix(text, `\`); ok {
+			entry.WriteString(cont)
+			entry.WriteString("\n")
+			continue
+		}
+		entry.WriteString(text)
+		s.entry(start, strings.TrimSpace(entry.String()), out)
+		entry.Reset()
+	}
+	if entry.Len() > 0 {
+		s.entry(start, strings.TrimSpace(entry.String()), out)
+	}
+	return scanner.Err()
+}
+
+// entry executes one entry, recording its failure.
+func (s *script) entry(line int, text string, out io.Writer) {
+	s.entries++
+	fmt.Fprintf(out, "> %s\n", text)
+	if err := s.execute(text, out); err != nil {
+		fmt.Fprintf(out, "Error: %v\n", err)
+		s.failures = append(s.failures, fmt.Sprintf("line %d: %v", line, err))
+	}
+}
+
+func (s *script) execute(text string, out io.Writer) error {
+	if !strings.HasPrefix(text, ":") {
+		return s.evaluate(text, out)
+	}
+	cmd, arg, _ := strings.Cut(text, " ")
+	arg = strings.TrimSpace(arg)
+	switch cmd {
+	case ":let":
+		name, source, ok := strings.Cut(arg, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("usage: :let name = term")
+		}
+		val, err := frontend.Parse(s.syntax, source)
+		if err != nil {
+			return fmt.Errorf("parse error: %w", err)
+		}
+		s.defs = append(s.defs, lambda.Binding{Name: name, Val: val})
+	case ":expect":
+		if s.last == nil {
+			return fmt.Errorf("nothing evaluated yet")
+		}
+		want, err := frontend.Parse(s.syntax, arg)
+		if err != nil {
+			return fmt.Errorf("parse error: %w", err)
+		}
+		if !lambda.AlphaEqual(s.last, want) {
+			return fmt.Errorf("expected %s, got %s", want, s.last)
+		}
+		fmt.Fprintln(out, "ok")
+	case ":stats":
+		if s.report == nil {
+			return fmt.Errorf("nothing evaluated yet")
+		}
+		return s.report.WriteText(out)
+	case ":echo":
+		fmt.Fprintln(out, arg)
+	case ":help":
+		fmt.Fprint(out, scriptHelp)
+	default:
+		return fmt.Errorf("unknown command %s (see :help)", cmd)
+	}
+	return nil
+}
+
+// evaluate reduces a term under the definitions so far and prints its
+// normal form.
+func (s *script) evaluate(source string, out io.Writer) error {
+	s.last, s.report = nil, nil
+	term, err := frontend.Parse(s.syntax, source)
+	if err != nil {
+		return fmt.Errorf("parse error: %w", err)
+	}
+	for i := len(s.defs) - 1; i >= 0; i-- {
+		term = lambda.Let{Name: s.defs[i].Name, Val: s.defs[i].Val, Body: term}
+	}
+
+	net := deltanet.NewNetworkWith(deltanet.WithNatives(natives.Register))
+	defer net.Close()
+	tr := lambda.NewTranslator(lambda.TranslatorOptions{})
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		return fmt.Errorf("translation error: %w", err)
+	}
+	start := time.Now()
+	if err := net.ReduceWithBudget(context.Background(), s.budget); err != nil {
+		return err
+	}
+	report := tr.Report(net, translation)
+	report.Elapsed = time.Since(start)
+	s.last, s.report = tr.Readback(net, translation), &report
+	fmt.Fprintln(out, report.Result)
+	return nil
+}
+
+// summarize prints the number of entries and the failures.
+func (s *script) summarize(w io.Writer) {
+	fmt.Fprintf(w, "\n%d entries, %d ok, %d failed\n", s.entries, s.entries-len(s.failures), len(s.failures))
+	for _, failure := range s.failures {
+		fmt.Fprintf(w, "  %s\n", failure)
+	}
+}
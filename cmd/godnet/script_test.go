@@ -0,0 +1,132 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/frontend"
+)
+
+func TestScript(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		output   string
+		failures []string
+	}{
+		{
+			name:   "evaluate",
+			input:  "(x: x) a\n",
+			output: "> (x: x) a\na\n",
+		},
+		{
+			name:   "comments and blank lines",
+			input:  "# comment\n\n  # indented\n(x: x) a\n",
+			output: "> (x: x) a\na\n",
+		},
+		{
+			name:   "continuation",
+			input:  "(x: \\\n  x) d\n",
+			output: "> (x: \n  x) d\nd\n",
+		},
+		{
+			name:   "continuation at end of input",
+			input:  "(x: x) \\",
+			output: "> (x: x)\n(x0: x0)\n",
+		},
+		{
+			name:   "let and expect",
+			input:  ":let id = x: x\nid b\n:expect b\n",
+			output: "> :let id = x: x\n> id b\nb\n> :expect b\nok\n",
+		},
+		{
+			name:     "expect mismatch",
+			input:    "(x: x) b\n:expect c\n",
+			output:   "> (x: x) b\nb\n> :expect c\nError: expected c, got b\n",
+			failures: []string{"line 2: expected c, got b"},
+		},
+		{
+			name:     "expect before evaluating",
+			input:    ":expect a\n:stats\n",
+			output:   "> :expect a\nError: nothing evaluated yet\n> :stats\nError: nothing evaluated yet\n",
+			failures: []string{"line 1: nothing evaluated yet", "line 2: nothing evaluated yet"},
+		},
+		{
+			name:     "bad let",
+			input:    ":let id\n:let a b = c\n",
+			output:   "> :let id\nError: usage: :let name = term\n> :let a b = c\nError: usage: :let name = term\n",
+			failures: []string{"line 1: usage: :let name = term", "line 2: usage: :let name = term"},
+		},
+		{
+			name:     "unknown command",
+			input:    ":bogus\n",
+			output:   "> :bogus\nError: unknown command :bogus (see :help)\n",
+			failures: []string{"line 1: unknown command :bogus (see :help)"},
+		},
+		{
+			name:   "echo",
+			input:  ":echo hello world\n",
+			output: "> :echo hello world\nhello world\n",
+		},
+		{
+			name:     "failures do not stop the script",
+			input:    ":expect (\n(x: x) a\n",
+			output:   "> :expect (\nError: nothing evaluated yet\n> (x: x) a\na\n",
+			failures: []string{"line 1: nothing evaluated yet"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &script{syntax: frontend.Default, budget: deltanet.Budget{MaxInteractions: 50}}
+			var out strings.Builder
+			if err := s.run(strings.NewReader(tt.input), &out); err != nil {
+				t.Fatalf("run: %v", err)
+			}
+			if got := out.String(); got != tt.output {
+				t.Errorf("output:\n%s\nwant:\n%s", got, tt.output)
+			}
+			if !reflect.DeepEqual(s.failures, tt.failures) {
+				t.Errorf("failures %q, want %q", s.failures, tt.failures)
+			}
+		})
+	}
+}
+
+// TestScriptStats tests that :stats prints the report of the last
+// evaluation, and the summary counts entries and failures.
+func TestScriptStats(t *testing.T) {
+	s := &script{syntax: frontend.Default}
+	var out strings.Builder
+	if err := s.run(strings.NewReader("(x: x) a\n:stats\n:bogus\n"), &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if !strings.Contains(out.String(), "> :stats\n") || s.report == nil {
+		t.Fatalf("expected a report, got:\n%s", out.String())
+	}
+	if strings.Contains(out.String(), "> :stats\nError") {
+		t.Errorf(":stats failed:\n%s", out.String())
+	}
+	var summary strings.Builder
+	s.summarize(&summary)
+	want := "\n3 entries, 2 ok, 1 failed\n  line 3: unknown command :bogus (see :help)\n"
+	if summary.String() != want {
+		t.Errorf("summary %q, want %q", summary.String(), want)
+	}
+}
+
+// TestScriptBudget tests that an evaluation over the budget fails and
+// leaves no result to check.
+func TestScriptBudget(t *testing.T) {
+	s := &script{syntax: frontend.Default, budget: deltanet.Budget{MaxInteractions: 50}}
+	var out strings.Builder
+	if err := s.run(strings.NewReader("(x: x x) (x: x x)\n:expect a\n"), &out); err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(s.failures) != 2 ||
+		!strings.HasPrefix(s.failures[0], "line 1: reduction budget exceeded: interactions limit after 50 interactions") ||
+		s.failures[1] != "line 2: nothing evaluated yet" {
+		t.Errorf("unexpected failures %q", s.failures)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/server"
+)
+
+// runServe evaluates terms posted as JSON over HTTP (see package server).
+func runServe() {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "listen address")
+	evaluators := fs.Int("evaluators", 0, "concurrent evaluations (default NumCPU)")
+	queue := fs.Int("queue", 0, "jobs waiting for an evaluator before requests get 429 (default 4 per evaluator)")
+	maxInteractions := fs.Uint64("max-interactions", 0, "per-job interaction limit (default 10M)")
+	timeout := fs.Duration("timeout", 0, "per-job wall time limit (default 10s)")
+	profile := fs.String("profile", deltanet.ProfilePure.Name, "natives visible to jobs: pure, console or full")
+	fs.Parse(os.Args[2:])
+
+	p, ok := deltanet.ProfileByName(*profile)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown profile %q\n", *profile)
+		os.Exit(1)
+	}
+	s := server.New(server.Config{
+		Evaluators: *evaluators,
+		QueueSize:  *queue,
+		Budget:     server.Budget{MaxInteractions: *maxInteractions, Timeout: *timeout},
+		Profile:    p,
+	})
+	defer s.Close()
+
+	fmt.Fprintf(os.Stderr, "Listening on %s\n", *addr)
+	if err := http.ListenAndServe(*addr, s); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
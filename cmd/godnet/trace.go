@@ -0,0 +1,227 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/frontend"
+	"github.com/vic/godnet/pkg/lambda"
+)
+
+const traceUsage = `Usage:
+  godnet trace record [-capacity N] [-every N] [-syntax name] <source.lam> > trace.jsonl
+  godnet trace query [-rule r1,r2] [-node ID] [-depth MIN:MAX] [-count|-first] <trace.jsonl>
+  godnet trace beta [-capacity N] [-syntax name] <source.lam>
+  godnet trace chrome <trace.jsonl> > trace.json
+`
+
+// runTrace records reduction traces and queries serialized traces.
+func runTrace() {
+	if len(os.Args) < 3 {
+		fmt.Fprint(os.Stderr, traceUsage)
+		os.Exit(1)
+	}
+	switch os.Args[2] {
+	case "record":
+		runTraceRecord(os.Args[3:])
+	case "query":
+		runTraceQuery(os.Args[3:])
+	case "beta":
+		runTraceBeta(os.Args[3:])
+	case "chrome":
+		runTraceChrome(os.Args[3:])
+	default:
+		fmt.Fprint(os.Stderr, traceUsage)
+		os.Exit(1)
+	}
+}
+
+func runTraceRecord(args []string) {
+	fs := flag.NewFlagSet("trace record", flag.ExitOnError)
+	capacity := fs.Int("capacity", 1<<20, "maximum number of events recorded")
+	every := fs.Int("every", 1, "record only every Nth interaction")
+	syntax := fs.String("syntax", frontend.Default, "source syntax: "+strings.Join(frontend.Names(), ", "))
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprint(os.Stderr, traceUsage)
+		os.Exit(1)
+	}
+
+	input, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	term, err := frontend.Parse(*syntax, string(input))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
+		os.Exit(1)
+	}
+
+	net := deltanet.NewNetworkWith(deltanet.WithTrace(*capacity), deltanet.WithTraceSampling(*every), deltanet.WithWorkers(1))
+	if _, err := lambda.NewTranslator(lambda.TranslatorOptions{}).Translate(term, net); err != nil {
+		fmt.Fprintf(os.Stderr, "Translation error: %v\n", err)
+		os.Exit(1)
+	}
+	net.ReduceAll()
+
+	if err := deltanet.WriteTrace(os.Stdout, net.TraceSnapshot()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing trace: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func runTraceQuery(args []string) {
+	fs := flag.NewFlagSet("trace query", flag.ExitOnError)
+	rules := fs.String("rule", "", "comma-separated rules, e.g. fan-fan,erasure")
+	node := fs.Uint64("node", 0, "only events involving this node ID")
+	depth := fs.String("depth", "", "depth range MIN:MAX (either bound may be omitted)")
+	count := fs.Bool("count", false, "print the number of matching events")
+	first := fs.Bool("first", false, "print only the first matching event")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprint(os.Stderr, traceUsage)
+		os.Exit(1)
+	}
+
+	q := deltanet.TraceQuery{NodeID: *node}
+	if *rules != "" {
+		for _, name := range strings.Split(*rules, ",") {
+			rule, err := deltanet.ParseRuleKind(strings.TrimSpace(name))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Invalid query: %v\n", err)
+				os.Exit(1)
+			}
+			q.Rules = append(q.Rules, rule)
+		}
+	}
+	if *depth != "" {
+		lo, hi, _ := strings.Cut(*depth, ":")
+		var err error
+		if lo != "" {
+			q.MinDepth, err = strconv.ParseUint(lo, 10, 64)
+		}
+		if err == nil && hi != "" {
+			q.MaxDepth, err = strconv.ParseUint(hi, 10, 64)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid depth range %q: %v\n", *depth, err)
+			os.Exit(1)
+		}
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+
+	matched := 0
+	err = q.Scan(f, func(e deltanet.TraceEvent) bool {
+		matched++
+		if !*count {
+			printTraceEvent(e)
+		}
+		return !*first
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading trace: %v\n", err)
+		os.Exit(1)
+	}
+	if *count {
+		fmt.Println(matched)
+	}
+}
+
+// runTraceBeta reduces a term and prints its reduction as the β-steps of
+// the source, each with the abstraction and application it contracts.
+func runTraceBeta(args []string) {
+	fs := flag.NewFlagSet("trace beta", flag.ExitOnError)
+	capacity := fs.Int("capacity", 1<<20, "maximum number of events recorded")
+	syntax := fs.String("syntax", frontend.Default, "source syntax: "+strings.Join(frontend.Names(), ", "))
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprint(os.Stderr, traceUsage)
+		os.Exit(1)
+	}
+
+	input, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	term, err := frontend.Parse(*syntax, string(input))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Parse error: %v\n", err)
+		os.Exit(1)
+	}
+
+	net := deltanet.NewNetworkWith(deltanet.WithTrace(*capacity), deltanet.WithWorkers(1))
+	tr := lambda.NewTranslator(lambda.TranslatorOptions{SourceSpans: true, LayoutHints: true})
+	if _, err := tr.Translate(term, net); err != nil {
+		fmt.Fprintf(os.Stderr, "Translation error: %v\n", err)
+		os.Exit(1)
+	}
+	net.ReduceAll()
+
+	for _, s := range lambda.BetaSteps(net, net.TraceSnapshot()) {
+		fmt.Printf("%d\tβ %s\t%v\t%s\n", s.Step, s.Var, s.App, excerpt(input, s.App))
+	}
+}
+
+// runTraceChrome converts a trace to Chrome's trace_event format, for
+// chrome://tracing or Perfetto.
+func runTraceChrome(args []string) {
+	if len(args) != 1 {
+		fmt.Fprint(os.Stderr, traceUsage)
+		os.Exit(1)
+	}
+	f, err := os.Open(args[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+		os.Exit(1)
+	}
+	defer f.Close()
+	events, err := deltanet.ReadTrace(f)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading trace: %v\n", err)
+		os.Exit(1)
+	}
+	if err := deltanet.WriteChromeTrace(os.Stdout, events); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing trace: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// excerpt returns the source text of span on one line, shortened to a
+// readable length.
+func excerpt(input []byte, span lambda.Span) string {
+	if span.IsZero() || span.End.Offset > len(input) {
+		return ""
+	}
+	text := strings.Join(strings.Fields(string(input[span.Start.Offset:span.End.Offset])), " ")
+	if r := []rune(text); len(r) > 60 {
+		text = string(r[:57]) + "..."
+	}
+	return text
+}
+
+func printTraceEvent(e deltanet.TraceEvent) {
+	pair := fmt.Sprintf("%v#%d", e.AType, e.AID)
+	if e.BID != 0 {
+		pair += fmt.Sprintf(" <-> %v#%d", e.BType, e.BID)
+	}
+	if len(e.New) > 0 {
+		ids := make([]string, len(e.New))
+		for i, id := range e.New {
+			ids[i] = fmt.Sprintf("#%d", id)
+		}
+		pair += "\tnew=" + strings.Join(ids, ",")
+	}
+	fmt.Printf("%d\t%v\tdepth=%d\t%s\n", e.Step, e.Rule, e.Depth, pair)
+}
@@ -0,0 +1,84 @@
+//go:build cgo
+
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"context"
+	"encoding/json"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/vic/godnet/pkg/server"
+)
+
+var (
+	evaluatorOnce sync.Once
+	evaluator     *server.Server
+)
+
+// allocated counts the responses handed out and not yet freed.
+var allocated atomic.Int64
+
+// evalC is godnet_eval: it reads the C strings term and options, either of
+// which may be nil, and returns the response as a C string the caller
+// releases with freeC.
+func evalC(term, options unsafe.Pointer) unsafe.Pointer {
+	resp := eval(goString(term), goString(options))
+	allocated.Add(1)
+	return unsafe.Pointer(C.CString(string(resp)))
+}
+
+// freeC is godnet_free: it releases a response of evalC. Freeing nil does
+// nothing.
+func freeC(s unsafe.Pointer) {
+	if s == nil {
+		return
+	}
+	allocated.Add(-1)
+	C.free(s)
+}
+
+// goString returns the C string s, or "" for nil.
+func goString(s unsafe.Pointer) string {
+	if s == nil {
+		return ""
+	}
+	return C.GoString((*C.char)(s))
+}
+
+// eval evaluates source with the options encoded in the JSON object opts
+// and returns the encoded response.
+func eval(source, opts string) []byte {
+	evaluatorOnce.Do(func() {
+		// Callers block in Eval, so the queue only bounds waiting threads.
+		evaluator = server.New(server.Config{QueueSize: 1024 * runtime.NumCPU()})
+	})
+
+	var req server.Request
+	if opts != "" {
+		if err := json.Unmarshal([]byte(opts), &req); err != nil {
+			return encode(server.Response{Error: "invalid options: " + err.Error()})
+		}
+	}
+	req.Source = source
+	resp, err := evaluator.Eval(context.Background(), req)
+	if err != nil {
+		resp = server.Response{Error: err.Error()}
+	}
+	return encode(resp)
+}
+
+func encode(resp server.Response) []byte {
+	out, err := json.Marshal(resp)
+	if err != nil {
+		out, _ = json.Marshal(server.Response{Error: err.Error()})
+	}
+	return out
+}
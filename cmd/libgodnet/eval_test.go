@@ -0,0 +1,97 @@
+//go:build cgo
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unsafe"
+
+	"github.com/vic/godnet/pkg/server"
+)
+
+// cstr returns s as a NUL-terminated string for evalC, or nil for nil.
+func cstr(s *string) unsafe.Pointer {
+	if s == nil {
+		return nil
+	}
+	b := append([]byte(*s), 0)
+	return unsafe.Pointer(&b[0])
+}
+
+func ptr(s string) *string { return &s }
+
+func TestEval(t *testing.T) {
+	tests := []struct {
+		name    string
+		term    *string
+		options *string
+		result  string
+		err     string // Substring of the expected error
+	}{
+		{"identity", ptr("(x: x) a"), ptr("{}"), "a", ""},
+		{"nil options", ptr("(x: x) a"), nil, "a", ""},
+		{"options", ptr("(x: x) a"), ptr(`{"syntax": "lambda", "max_interactions": 100}`), "a", ""},
+		{"invalid options", ptr("(x: x) a"), ptr("{"), "", "invalid options"},
+		{"unknown syntax", ptr("(x: x) a"), ptr(`{"syntax": "nope"}`), "", "nope"},
+		{"parse error", ptr("(x:"), nil, "", "unexpected token"},
+		{"nil term", nil, nil, "", "unexpected token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := evalC(cstr(tt.term), cstr(tt.options))
+			if out == nil {
+				t.Fatal("evalC returned nil")
+			}
+			raw := goString(out)
+			freeC(out)
+
+			var resp server.Response
+			if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+				t.Fatalf("invalid response %q: %v", raw, err)
+			}
+			if tt.err != "" {
+				if !strings.Contains(resp.Error, tt.err) {
+					t.Errorf("expected an error containing %q, got %+v", tt.err, resp)
+				}
+				if resp.Result != "" || resp.Report != nil {
+					t.Errorf("expected no result with an error, got %+v", resp)
+				}
+				return
+			}
+			if resp.Error != "" {
+				t.Fatalf("unexpected error: %s", resp.Error)
+			}
+			if resp.Result != tt.result {
+				t.Errorf("expected %q, got %q", tt.result, resp.Result)
+			}
+			if resp.Report == nil {
+				t.Error("expected a report")
+			}
+		})
+	}
+	if n := allocated.Load(); n != 0 {
+		t.Errorf("%d responses not freed", n)
+	}
+}
+
+// TestFree tests that every response is counted until freed, and that
+// freeing nil does nothing.
+func TestFree(t *testing.T) {
+	term := "(x: x) a"
+	var out []unsafe.Pointer
+	for i := 0; i < 3; i++ {
+		out = append(out, evalC(cstr(&term), nil))
+	}
+	if n := allocated.Load(); n != 3 {
+		t.Errorf("expected 3 live responses, got %d", n)
+	}
+	freeC(nil)
+	for _, p := range out {
+		freeC(p)
+	}
+	if n := allocated.Load(); n != 0 {
+		t.Errorf("expected every response freed, %d live", n)
+	}
+}
@@ -0,0 +1,39 @@
+//go:build cgo
+
+// Command libgodnet is the reducer as a C shared library, so other
+// languages can evaluate terms in-process:
+//
+//	go build -buildmode=c-shared -o libgodnet.so ./cmd/libgodnet
+//
+// This also writes libgodnet.h. godnet_eval takes the term source and a
+// JSON object of options, the fields of server.Request other than source
+// (syntax, max_interactions, timeout_ms), and returns a JSON
+// server.Response: the result and report, or an error. Either argument may
+// be NULL. The returned string belongs to the caller, who releases it with
+// godnet_free. From Python:
+//
+//	lib = ctypes.CDLL("./libgodnet.so")
+//	lib.godnet_eval.restype = ctypes.c_void_p
+//	ptr = lib.godnet_eval(b"(x: x) a", b"{}")
+//	result = json.loads(ctypes.string_at(ptr))
+//	lib.godnet_free(ptr)
+//
+// Calls are safe from several threads; they share one evaluation server
+// (see package server) with its default budget.
+package main
+
+import "C"
+
+import "unsafe"
+
+//export godnet_eval
+func godnet_eval(term, options *C.char) *C.char {
+	return (*C.char)(evalC(unsafe.Pointer(term), unsafe.Pointer(options)))
+}
+
+//export godnet_free
+func godnet_free(s *C.char) {
+	freeC(unsafe.Pointer(s))
+}
+
+func main() {}
@@ -0,0 +1,222 @@
+// Package bench is the standard benchmark suite: canonical λ-terms whose
+// reduction exercises the parts of the reducer that matter for
+// performance, from plain Church arithmetic to the deep sharing optimal
+// reduction exists for. The same corpus backs the package's benchmarks
+// and the bench command of cmd/godnet, so regressions show up both in go
+// test -bench and when comparing builds by hand. Both time the reduction
+// alone: parsing, translation and readback are left out.
+package bench
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/deltanet/testsupport"
+	"github.com/vic/godnet/pkg/lambda"
+)
+
+// Workload is a term of the corpus and its normal form.
+type Workload struct {
+	Name        string
+	Description string
+	// Source is the term in the default surface syntax.
+	Source string
+	// Expected is the source of the normal form, compared to the readback
+	// up to alpha-equivalence.
+	Expected string
+	// Heavy marks a workload that takes seconds or more to reduce. Tests
+	// leave it to the benchmarks unless asked to run it.
+	Heavy bool
+}
+
+// Result is the outcome of running a workload once. Report.Elapsed is the
+// time spent reducing.
+type Result struct {
+	Term   lambda.Term
+	Report deltanet.Report
+}
+
+// Instance is a workload translated into a network, ready to be reduced.
+type Instance struct {
+	Workload    Workload
+	Net         *deltanet.Network
+	tr          *lambda.Translator
+	translation *lambda.Translation
+}
+
+// Corpus returns the standard workloads, cheapest first.
+func Corpus() []Workload {
+	return []Workload{
+		NoOptimalStrategy(),
+		ChurchExp(3, 5),
+		ChurchExp(2, 12),
+		SharingChain(12),
+		heavy(Ackermann(3, 3)),
+		heavy(SharingChain(14)),
+		heavy(Ackermann(3, 4)),
+	}
+}
+
+func heavy(w Workload) Workload {
+	w.Heavy = true
+	return w
+}
+
+// Lookup returns the workload of the corpus with the given name.
+func Lookup(name string) (Workload, bool) {
+	for _, w := range Corpus() {
+		if w.Name == name {
+			return w, true
+		}
+	}
+	return Workload{}, false
+}
+
+// ChurchExp computes base^exp on Church numerals, applying the numeral exp
+// to base. The result has base^exp applications, built by repeatedly
+// duplicating the shared numeral.
+func ChurchExp(base, exp int) Workload {
+	return Workload{
+		Name:        fmt.Sprintf("exp-%d-%d", base, exp),
+		Description: fmt.Sprintf("Church exponentiation %d^%d", base, exp),
+		Source:      fmt.Sprintf("(m: n: n m) (%s) (%s)", testsupport.ChurchSource(base), testsupport.ChurchSource(exp)),
+		Expected:    testsupport.ChurchSource(pow(base, exp)),
+	}
+}
+
+// Ackermann computes the Ackermann function on Church numerals, iterating
+// the higher-order step m times over the successor. Its terms grow
+// quickly: A(3, 2) is 29.
+func Ackermann(m, n int) Workload {
+	const (
+		succ = "(n: f: x: f (n f x))"
+		ack  = "(m: m (g: n: n g (g (f: x: f x))) " + succ + ")"
+	)
+	return Workload{
+		Name:        fmt.Sprintf("ackermann-%d-%d", m, n),
+		Description: fmt.Sprintf("Ackermann function A(%d, %d) on Church numerals", m, n),
+		Source:      fmt.Sprintf("%s (%s) (%s)", ack, testsupport.ChurchSource(m), testsupport.ChurchSource(n)),
+		Expected:    testsupport.ChurchSource(ackermann(m, n)),
+	}
+}
+
+// NoOptimalStrategy is the term of the Δ-nets paper that has no optimal
+// reduction strategy in the λ-calculus: whichever redex is contracted
+// first, some work is either duplicated or wasted, while sharing reduces
+// each redex once.
+func NoOptimalStrategy() Workload {
+	return Workload{
+		Name:        "no-optimal-strategy",
+		Description: "the paper's term without an optimal strategy in the λ-calculus",
+		Source:      "(g: g (g (x: x))) (h: (f: f (f (z: z))) (w: h (w (y: y))))",
+		Expected:    "x: x",
+	}
+}
+
+// SharingChain applies a shared Church two to the identity n times in a
+// row. Every application goes through the replicators of the same
+// shared function, so the chain of replicators is n deep; the interactions
+// needed double with every link.
+func SharingChain(n int) Workload {
+	return Workload{
+		Name:        fmt.Sprintf("sharing-chain-%d", n),
+		Description: fmt.Sprintf("a shared function applied %d times in a row", n),
+		Source:      fmt.Sprintf("(t: %s(x: x)%s) (f: x: f (f x))", strings.Repeat("t (", n), strings.Repeat(")", n)),
+		Expected:    "x: x",
+	}
+}
+
+// Prepare parses the workload and translates it into a network built
+// with opts. The caller must Close the instance.
+func (w Workload) Prepare(opts ...deltanet.Option) (*Instance, error) {
+	term, err := lambda.Parse(w.Source)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", w.Name, err)
+	}
+	net := deltanet.NewNetworkWith(opts...)
+	tr := lambda.NewTranslator(lambda.TranslatorOptions{})
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		net.Close()
+		return nil, fmt.Errorf("%s: %w", w.Name, err)
+	}
+	return &Instance{Workload: w, Net: net, tr: tr, translation: translation}, nil
+}
+
+// monitor watches the workloads for divergence. The Ackermann ones run
+// long in bounded space before their result reaches the root, which the
+// default window takes for divergence.
+var monitor = deltanet.DivergenceMonitor{Window: 1 << 20}
+
+// Reduce reduces the network with ReduceMonitored, as godnet evaluates
+// terms, so a workload that stops terminating fails instead of hanging.
+func (i *Instance) Reduce() error {
+	if err := i.Net.ReduceMonitored(i.translation.Output, monitor); err != nil {
+		return fmt.Errorf("%s: %w", i.Workload.Name, err)
+	}
+	return nil
+}
+
+// Result reads the reduced term back along with the network's report.
+func (i *Instance) Result() *Result {
+	term := i.tr.Readback(i.Net, i.translation)
+	report := i.Net.Report()
+	report.Result = term.String()
+	return &Result{Term: term, Report: report}
+}
+
+// Close releases the network.
+func (i *Instance) Close() {
+	i.Net.Close()
+}
+
+// Run prepares the workload, reduces it and reads the result back,
+// timing the reduction alone.
+func (w Workload) Run(opts ...deltanet.Option) (*Result, error) {
+	inst, err := w.Prepare(opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer inst.Close()
+	start := time.Now()
+	if err := inst.Reduce(); err != nil {
+		return nil, err
+	}
+	elapsed := time.Since(start)
+	res := inst.Result()
+	res.Report.Elapsed = elapsed
+	return res, nil
+}
+
+// Check reports whether term is the workload's normal form.
+func (w Workload) Check(term lambda.Term) error {
+	expected, err := lambda.Parse(w.Expected)
+	if err != nil {
+		return fmt.Errorf("%s: expected result: %w", w.Name, err)
+	}
+	if !lambda.AlphaEqual(term, expected) {
+		return fmt.Errorf("%s: expected %s, got %s", w.Name, expected, term)
+	}
+	return nil
+}
+
+func pow(base, exp int) int {
+	r := 1
+	for ; exp > 0; exp-- {
+		r *= base
+	}
+	return r
+}
+
+func ackermann(m, n int) int {
+	switch {
+	case m == 0:
+		return n + 1
+	case n == 0:
+		return ackermann(m-1, 1)
+	default:
+		return ackermann(m-1, ackermann(m, n-1))
+	}
+}
@@ -0,0 +1,57 @@
+package bench
+
+import (
+	"os"
+	"testing"
+)
+
+// TestCorpus tests that every workload reduces to its expected normal form.
+// Heavy workloads are only reduced when DELTA_BENCH_HEAVY is set; they are
+// always run by BenchmarkCorpus.
+func TestCorpus(t *testing.T) {
+	if testing.Short() {
+		t.Skip("reduces the whole corpus")
+	}
+	runHeavy := os.Getenv("DELTA_BENCH_HEAVY") != ""
+	for _, w := range Corpus() {
+		if w.Heavy && !runHeavy {
+			t.Logf("%s: skipped, set DELTA_BENCH_HEAVY to run it", w.Name)
+			continue
+		}
+		res, err := w.Run()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Check(res.Term); err != nil {
+			t.Error(err)
+		}
+		t.Logf("%s: %d interactions, %d peak nodes, %v", w.Name, res.Report.Stats.TotalReductions, res.Report.PeakNodes, res.Report.Elapsed)
+	}
+}
+
+// BenchmarkCorpus runs every workload of the corpus as a sub-benchmark,
+// timing the reduction alone and reporting interactions per run.
+func BenchmarkCorpus(b *testing.B) {
+	for _, w := range Corpus() {
+		b.Run(w.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			var interactions uint64
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				inst, err := w.Prepare()
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if err := inst.Reduce(); err != nil {
+					b.Fatal(err)
+				}
+				b.StopTimer()
+				interactions = inst.Net.GetStats().TotalReductions
+				inst.Close()
+				b.StartTimer()
+			}
+			b.ReportMetric(float64(interactions), "interactions/op")
+		})
+	}
+}
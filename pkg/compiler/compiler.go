@@ -16,6 +16,8 @@ type Compiler struct {
 	OutputName string
 	GoFlags    []string // Passed directly to go build
 	KeepTemp   bool     // For debugging
+	Prelude    string   // File of let bindings the source is compiled in scope of
+	Quiet      bool     // The program prints no statistics
 }
 
 // Compile translates the lambda source to Go code and builds it.
@@ -31,11 +33,33 @@ func (c *Compiler) Compile() (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("parse error: %w", err)
 	}
+	if c.Prelude != "" {
+		if term, err = withPrelude(c.Prelude, string(source)); err != nil {
+			return "", err
+		}
+	}
+
+	// A program that performs effects needs the linked handlers
+	effects := inferEffects(term)
+	if len(effects) > 0 {
+		if err := checkHandlers(goFiles(c.GoFlags), effects); err != nil {
+			return "", err
+		}
+	}
+
+	// Free variables may name the natives linked files register
+	natives, err := definesNatives(goFiles(c.GoFlags))
+	if err != nil {
+		return "", err
+	}
 
 	// Generate Go code
 	gen := CodeGenerator{
 		SourceFile: c.SourceFile,
 		SourceText: string(source),
+		Effects:    effects,
+		Natives:    natives,
+		Quiet:      c.Quiet,
 	}
 	goCode := gen.Generate(term)
 
@@ -147,6 +171,21 @@ func (c *Compiler) Compile() (string, error) {
 	return outputName, nil
 }
 
+// withPrelude parses source in scope of the bindings of the prelude file.
+// The source was parsed on its own first, so its errors point into it.
+func withPrelude(path, source string) (lambda.Term, error) {
+	prelude, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prelude: %w", err)
+	}
+	bindings := strings.TrimSuffix(strings.TrimSpace(string(prelude)), ";")
+	term, err := lambda.Parse("let " + bindings + "\nin " + source)
+	if err != nil {
+		return nil, fmt.Errorf("prelude %s: %w", path, err)
+	}
+	return term, nil
+}
+
 // findGoModDir searches for go.mod starting from the given path
 func findGoModDir(startPath string) string {
 	dir := filepath.Dir(startPath)
@@ -40,6 +40,10 @@ func TestCompileSCombinator(t *testing.T) {
 		"(d d)")
 }
 
+func TestCompileLiteral(t *testing.T) {
+	testCompile(t, "literal", `(x: y: x) 1/3 "unused"`, "1/3")
+}
+
 func testCompile(t *testing.T, name string, source string, expected string) {
 	t.Helper()
 
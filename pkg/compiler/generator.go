@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/vic/godnet/pkg/deltanet"
 	"github.com/vic/godnet/pkg/lambda"
 )
 
@@ -11,6 +12,9 @@ import (
 type CodeGenerator struct {
 	SourceFile string
 	SourceText string
+	Effects    deltanet.EffectRow // When not empty, main installs the linked handlers and runs the effect runner
+	Natives    bool               // Main registers the linked natives, which free variables may name
+	Quiet      bool               // Main prints the result only, without statistics
 	buf        strings.Builder
 	nodeCount  int
 	vars       map[string]*varInfo
@@ -42,8 +46,12 @@ func (g *CodeGenerator) writeHeader() {
 	g.writeLine("")
 	g.writeLine("import (")
 	g.writeLine("\t\"fmt\"")
-	g.writeLine("\t\"os\"")
-	g.writeLine("\t\"time\"")
+	if !g.Quiet || len(g.Effects) > 0 {
+		g.writeLine("\t\"os\"")
+	}
+	if !g.Quiet {
+		g.writeLine("\t\"time\"")
+	}
 	g.writeLine("\t\"github.com/vic/godnet/pkg/deltanet\"")
 	g.writeLine("\t\"github.com/vic/godnet/pkg/lambda\"")
 	g.writeLine(")")
@@ -62,23 +70,77 @@ func (g *CodeGenerator) writeBuildNetFunction(term lambda.Term) {
 	g.writeLine("\treturn %s, %d, varNames", rootNode, rootPort)
 	g.writeLine("}")
 	g.writeLine("")
+	if g.Natives {
+		g.writeFreeVarFunction()
+	}
+}
+
+// writeFreeVarFunction writes freeVar, which builds a free variable, or
+// the native registered under its name.
+func (g *CodeGenerator) writeFreeVarFunction() {
+	g.writeLine("func freeVar(net *deltanet.Network, varNames map[uint64]string, name string) deltanet.Node {")
+	g.writeLine("\tif _, ok := net.NativeCapability(name); ok {")
+	g.writeLine("\t\treturn net.NewNative(name)")
+	g.writeLine("\t}")
+	g.writeLine("\tv := net.NewVar()")
+	g.writeLine("\tvarNames[v.ID()] = name")
+	g.writeLine("\treturn v")
+	g.writeLine("}")
+	g.writeLine("")
 }
 
 func (g *CodeGenerator) writeMainFunction() {
+	if len(g.Effects) > 0 {
+		g.writeRequiredEffects()
+	}
 	g.writeLine("func main() {")
-	g.writeLine("\tnet := deltanet.NewNetwork()")
+	var opts []string
+	if g.Natives {
+		opts = append(opts, "deltanet.WithNatives(registerNatives)")
+	}
+	if len(g.Effects) > 0 {
+		opts = append(opts, "deltanet.WithHandlers(installHandlers())")
+	}
+	if len(opts) > 0 {
+		g.writeLine("\tnet := deltanet.NewNetworkWith(%s)", strings.Join(opts, ", "))
+	} else {
+		g.writeLine("\tnet := deltanet.NewNetwork()")
+	}
+	if len(g.Effects) > 0 {
+		g.writeLine("\tfor _, name := range requiredEffects {")
+		g.writeLine("\t\tif !net.Handlers().CanHandle(name) {")
+		g.writeLine("\t\t\tfmt.Fprintf(os.Stderr, \"Error: no handler for effect %%q\\n\", name)")
+		g.writeLine("\t\t\tos.Exit(1)")
+		g.writeLine("\t\t}")
+		g.writeLine("\t}")
+	}
 	g.writeLine("\troot, port, varNames := buildNet(net)")
 	g.writeLine("")
 	g.writeLine("\toutput := net.NewVar()")
 	g.writeLine("\tnet.Link(root, port, output, 0)")
 	g.writeLine("")
-	g.writeLine("\tstart := time.Now()")
-	g.writeLine("\tnet.ReduceAll()")
-	g.writeLine("\telapsed := time.Since(start)")
+	if !g.Quiet {
+		g.writeLine("\tstart := time.Now()")
+	}
+	if len(g.Effects) > 0 {
+		g.writeLine("\tif err := net.RunEffects(net.Handlers()); err != nil {")
+		g.writeLine("\t\tfmt.Fprintf(os.Stderr, \"Error: %%v\\n\", err)")
+		g.writeLine("\t\tos.Exit(1)")
+		g.writeLine("\t}")
+	} else {
+		g.writeLine("\tnet.ReduceAll()")
+	}
+	if !g.Quiet {
+		g.writeLine("\telapsed := time.Since(start)")
+	}
 	g.writeLine("")
-	g.writeLine("\tresultNode, resultPort := net.GetLink(output, 0)")
-	g.writeLine("\tresult := lambda.FromDeltaNet(net, resultNode, resultPort, varNames)")
+	g.writeLine("\ttranslation := &lambda.Translation{Output: output, VarNames: varNames}")
+	g.writeLine("\tresult := lambda.NewTranslator(lambda.TranslatorOptions{}).Readback(net, translation)")
 	g.writeLine("\tfmt.Println(result)")
+	if g.Quiet {
+		g.writeLine("}")
+		return
+	}
 	g.writeLine("")
 	g.writeLine("\tstats := net.GetStats()")
 	g.writeLine("\tseconds := elapsed.Seconds()")
@@ -92,6 +154,17 @@ func (g *CodeGenerator) writeMainFunction() {
 	g.writeLine("}")
 }
 
+// writeRequiredEffects lists the effect row, checked against the installed
+// handlers before reduction.
+func (g *CodeGenerator) writeRequiredEffects() {
+	g.writeLine("var requiredEffects = []string{")
+	for _, name := range g.Effects {
+		g.writeLine("\t%q,", name)
+	}
+	g.writeLine("}")
+	g.writeLine("")
+}
+
 func (g *CodeGenerator) translateTerm(term lambda.Term, level int, depth uint64) (string, int) {
 	switch t := term.(type) {
 	case lambda.Var:
@@ -100,6 +173,10 @@ func (g *CodeGenerator) translateTerm(term lambda.Term, level int, depth uint64)
 		return g.genAbs(t, level, depth)
 	case lambda.App:
 		return g.genApp(t, level, depth)
+	case lambda.Lit:
+		return g.genLit(t)
+	case lambda.Perform:
+		return g.genPerform(t)
 	case lambda.Let:
 		// Desugar: let x = v in b  →  (λx. b) v
 		desugared := lambda.App{
@@ -107,6 +184,8 @@ func (g *CodeGenerator) translateTerm(term lambda.Term, level int, depth uint64)
 			Arg: t.Val,
 		}
 		return g.translateTerm(desugared, level, depth)
+	case lambda.LetRec:
+		return g.translateTerm(t.Desugar(), level, depth)
 	default:
 		panic(fmt.Sprintf("unknown term type: %T", term))
 	}
@@ -125,12 +204,15 @@ func (g *CodeGenerator) genVar(v lambda.Var, level int, depth uint64) (string, i
 			nextPort := info.uses + 1 // Next aux port index (1-based, since port 0 is principal)
 
 			g.writeLine("\t// Expand replicator for variable '%s' (use #%d)", v.Name, info.uses+1)
-			g.writeLine("\t%s := net.NewReplicator(%d, append(%s.Deltas(), %d))",
-				newRepName, info.level, oldRepName, delta)
-
-			// Move principal connection
-			g.writeLine("\tsourceNode, sourcePort := net.GetLink(%s, 0)", oldRepName)
-			g.writeLine("\tnet.LinkAt(%s, 0, sourceNode, sourcePort, %d)", newRepName, depth)
+			g.writeLine("\t%s := net.NewReplicator(%s.Level(), append(%s.Deltas(), %d))",
+				newRepName, oldRepName, oldRepName, delta)
+
+			// Move principal connection, in a block of its own as a
+			// variable can be expanded any number of times
+			g.writeLine("\t{")
+			g.writeLine("\t\tsourceNode, sourcePort := net.GetLink(%s, 0)", oldRepName)
+			g.writeLine("\t\tnet.LinkAt(%s, 0, sourceNode, sourcePort, %d)", newRepName, depth)
+			g.writeLine("\t}")
 
 			// Move existing aux ports
 			g.writeLine("\tfor i := 0; i < len(%s.Deltas()); i++ {", oldRepName)
@@ -163,8 +245,12 @@ func (g *CodeGenerator) genVar(v lambda.Var, level int, depth uint64) (string, i
 		varName := g.nextNode("var")
 		repName := g.nextNode("rep")
 
-		g.writeLine("\t%s := net.NewVar()", varName)
-		g.writeLine("\tvarNames[%s.ID()] = \"%s\"", varName, v.Name)
+		if g.Natives {
+			g.writeLine("\t%s := freeVar(net, varNames, %q)", varName, v.Name)
+		} else {
+			g.writeLine("\t%s := net.NewVar()", varName)
+			g.writeLine("\tvarNames[%s.ID()] = \"%s\"", varName, v.Name)
+		}
 		g.writeLine("\t%s := net.NewReplicator(0, []int{%d})", repName, level-1)
 		g.writeLine("\tnet.LinkAt(%s, 0, %s, 0, %d)", repName, varName, depth)
 
@@ -172,6 +258,7 @@ func (g *CodeGenerator) genVar(v lambda.Var, level int, depth uint64) (string, i
 			nodeName: repName,
 			port:     0,
 			level:    0,
+			uses:     1,
 		}
 
 		return repName, 1
@@ -227,6 +314,37 @@ func (g *CodeGenerator) genApp(app lambda.App, level int, depth uint64) (string,
 	return fanName, 1
 }
 
+func (g *CodeGenerator) genLit(lit lambda.Lit) (string, int) {
+	g.writeComment("Literal: %s", lit)
+
+	dataName := g.nextNode("data")
+	g.writeLine("\t%s := net.NewData(%s)", dataName, literalExpr(lit.Value))
+	return dataName, 0
+}
+
+func (g *CodeGenerator) genPerform(p lambda.Perform) (string, int) {
+	g.writeComment("Effect: %s", p)
+
+	ioName := g.nextNode("io")
+	payload := "nil"
+	if p.Payload != nil {
+		payload = literalExpr(p.Payload)
+	}
+	g.writeLine("\t%s := net.NewIO(&deltanet.Effect{Name: %q, Payload: %s}, deltanet.EffectRow{%q})",
+		ioName, p.Name, payload, p.Name)
+	return ioName, 0
+}
+
+// literalExpr returns a Go expression evaluating to a literal value.
+func literalExpr(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return fmt.Sprintf("%q", s)
+	}
+	// Numbers are parsed back from their literal syntax, so big integers
+	// and rationals keep their exact value.
+	return fmt.Sprintf("func() interface{} { v, _ := lambda.ParseLiteral(%q); return v }()", lambda.FormatLiteral(value))
+}
+
 func (g *CodeGenerator) nextNode(prefix string) string {
 	g.nodeCount++
 	return fmt.Sprintf("%s_%d", prefix, g.nodeCount)
@@ -0,0 +1,107 @@
+package compiler
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/lambda"
+)
+
+// ErrMissingHandler is returned by Compile when a program performs effects
+// and the linked Go files do not provide a handler for one of them.
+var ErrMissingHandler = errors.New("missing effect handler")
+
+// handlersFunc is the function linked handler packages define. It takes no
+// arguments and returns the *deltanet.HandlerScope installed as the root
+// handler scope of the generated program.
+const handlersFunc = "installHandlers"
+
+// inferEffects returns the effect row of a term: the effects it performs,
+// read from the term without translating it.
+func inferEffects(term lambda.Term) deltanet.EffectRow {
+	return deltanet.EffectRow(lambda.Effects(term))
+}
+
+// checkHandlers verifies that the Go files define installHandlers and
+// register a handler for every effect in row. Registrations are read from
+// the string literals passed to the HandlerScope methods; when a name is
+// not a literal, the coverage check is left to the generated program.
+func checkHandlers(files []string, row deltanet.EffectRow) error {
+	fset := token.NewFileSet()
+	defined := false
+	dynamic := false
+	var patterns deltanet.EffectRow
+	for _, path := range files {
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv != nil || fn.Name.Name != handlersFunc {
+				continue
+			}
+			if fn.Type.Params.NumFields() != 0 || fn.Type.Results.NumFields() != 1 {
+				return fmt.Errorf("%w: %s in %s must be func() *deltanet.HandlerScope",
+					ErrMissingHandler, handlersFunc, path)
+			}
+			defined = true
+		}
+		ast.Inspect(file, func(node ast.Node) bool {
+			call, ok := node.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok {
+				return true
+			}
+			switch sel.Sel.Name {
+			case "Register", "RegisterNamespace", "RegisterWithCapability", "Forward":
+			default:
+				return true
+			}
+			lit, ok := call.Args[0].(*ast.BasicLit)
+			if !ok || lit.Kind != token.STRING {
+				dynamic = true
+				return true
+			}
+			pattern, _ := strconv.Unquote(lit.Value)
+			if sel.Sel.Name == "RegisterNamespace" {
+				pattern += ".*"
+			}
+			patterns = append(patterns, pattern)
+			return true
+		})
+	}
+	if !defined {
+		return fmt.Errorf("%w: the program performs %s but no linked file defines %s",
+			ErrMissingHandler, strings.Join(row, ", "), handlersFunc)
+	}
+	if dynamic {
+		return nil
+	}
+	for _, name := range row {
+		if !patterns.Covers(name) {
+			return fmt.Errorf("%w: no linked handler for effect %q", ErrMissingHandler, name)
+		}
+	}
+	return nil
+}
+
+// goFiles returns the Go source files among the compiler's go flags.
+func goFiles(flags []string) []string {
+	var files []string
+	for _, flag := range flags {
+		if strings.HasSuffix(flag, ".go") {
+			files = append(files, flag)
+		}
+	}
+	return files
+}
@@ -0,0 +1,132 @@
+package compiler
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/lambda"
+)
+
+func TestCheckHandlers(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, code string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	handlers := write("handlers.go", matrixHandlers)
+	state := write("state.go", `package main
+
+import "github.com/vic/godnet/pkg/deltanet"
+
+func registerState(scope *deltanet.HandlerScope, get deltanet.EffectHandler) {
+	scope.RegisterNamespace("State", get)
+}
+`)
+	withArgs := write("args.go", `package main
+
+import "github.com/vic/godnet/pkg/deltanet"
+
+func installHandlers(net *deltanet.Network) *deltanet.HandlerScope { return nil }
+`)
+
+	for _, tc := range []struct {
+		name  string
+		files []string
+		row   deltanet.EffectRow
+		ok    bool
+	}{
+		{"covered", []string{handlers}, deltanet.EffectRow{"Log"}, true},
+		{"namespace", []string{handlers, state}, deltanet.EffectRow{"Log", "State.Get"}, true},
+		{"missing_effect", []string{handlers}, deltanet.EffectRow{"Log", "State.Get"}, false},
+		{"missing_func", []string{state}, deltanet.EffectRow{"State.Get"}, false},
+		{"wrong_signature", []string{withArgs}, deltanet.EffectRow{"Log"}, false},
+	} {
+		err := checkHandlers(tc.files, tc.row)
+		if tc.ok && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrMissingHandler) {
+			t.Errorf("%s: got %v, want ErrMissingHandler", tc.name, err)
+		}
+	}
+}
+
+const echoHandlers = `package main
+
+import (
+	"fmt"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+func installHandlers() *deltanet.HandlerScope {
+	scope := deltanet.NewHandlerScope()
+	scope.Register("Log", func(eff deltanet.Effect, cont *deltanet.Continuation) (interface{}, error) {
+		fmt.Println(eff.Payload)
+		return cont.Resume(len(eff.Payload.(string)))
+	})
+	return scope
+}
+`
+
+// TestCompileEffects compiles a program performing an effect against linked
+// handlers and runs it.
+func TestCompileEffects(t *testing.T) {
+	cwd, _ := os.Getwd()
+	tmpDir, err := os.MkdirTemp(filepath.Join(cwd, "../.."), "test_build_*")
+	if err != nil {
+		t.Fatalf("Failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	write := func(name, code string) string {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	source := write("effects.lam", `(x: y: x) (!Log "hello") 7`)
+	handlers := write("src/handlers.go", echoHandlers)
+
+	// Without handlers the program is rejected before it is built.
+	c := Compiler{SourceFile: source, OutputName: filepath.Join(tmpDir, "effects")}
+	if _, err := c.Compile(); !errors.Is(err, ErrMissingHandler) {
+		t.Fatalf("compiling without handlers: got %v, want ErrMissingHandler", err)
+	}
+
+	c.GoFlags = []string{handlers}
+	binary, err := c.Compile()
+	if err != nil {
+		t.Fatalf("Compilation failed: %v", err)
+	}
+	output, err := exec.Command(binary).Output()
+	if err != nil {
+		t.Fatalf("Binary execution failed: %v", err)
+	}
+	if got, want := strings.TrimSpace(string(output)), "hello\n5"; got != want {
+		t.Errorf("Expected output:\n%s\nGot:\n%s", want, got)
+	}
+}
+
+func TestInferEffects(t *testing.T) {
+	term, err := lambda.Parse(`(x: !State.Get) (!Log "a")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := inferEffects(term); !reflect.DeepEqual(got, deltanet.EffectRow{"Log", "State.Get"}) {
+		t.Errorf("inferred %v", got)
+	}
+}
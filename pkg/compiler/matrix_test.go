@@ -0,0 +1,203 @@
+package compiler
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// matrixProgram is a program of the compiler test matrix and the prefix its
+// binary must print. Programs using natives, effects or prelude bindings
+// are compiled only with the variants providing them.
+type matrixProgram struct {
+	name   string
+	source string
+	want   string
+	needs  matrixFeature
+}
+
+// matrixFeature is a set of features a variant provides.
+type matrixFeature int
+
+const (
+	needsNatives matrixFeature = 1 << iota
+	needsHandlers
+	needsPrelude
+)
+
+// matrixVariant is a combination of compiler options every program is
+// compiled with. Files are extra Go sources linked into the binary, the way
+// users provide natives and effect handlers, and prelude the bindings of
+// the prelude file.
+type matrixVariant struct {
+	name     string
+	keepTemp bool
+	quiet    bool
+	files    map[string]string
+	prelude  string
+}
+
+// provides returns the features the variant provides.
+func (v matrixVariant) provides() matrixFeature {
+	var f matrixFeature
+	if _, ok := v.files["natives.go"]; ok {
+		f |= needsNatives
+	}
+	if _, ok := v.files["handlers.go"]; ok {
+		f |= needsHandlers
+	}
+	if v.prelude != "" {
+		f |= needsPrelude
+	}
+	return f
+}
+
+const matrixNatives = `package main
+
+import "github.com/vic/godnet/pkg/deltanet"
+
+func registerNatives(net *deltanet.Network) {
+	net.RegisterNative("inc", func(a interface{}) (interface{}, error) {
+		return a.(int) + 1, nil
+	})
+}
+`
+
+const matrixHandlers = `package main
+
+import (
+	"fmt"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+func installHandlers() *deltanet.HandlerScope {
+	scope := deltanet.NewHandlerScope()
+	scope.Register("Log", func(eff deltanet.Effect, cont *deltanet.Continuation) (interface{}, error) {
+		fmt.Println(eff.Payload)
+		return cont.Resume(len(eff.Payload.(string)))
+	})
+	return scope
+}
+`
+
+const matrixPrelude = `succ = n: f: x: f (n f x);
+zero = f: x: x;
+`
+
+var matrixVariants = []matrixVariant{
+	{name: "plain"},
+	{name: "keep_temp", keepTemp: true},
+	{name: "quiet", quiet: true},
+	{name: "natives", files: map[string]string{"natives.go": matrixNatives}},
+	{name: "handlers", files: map[string]string{"handlers.go": matrixHandlers}},
+	{name: "prelude", prelude: matrixPrelude},
+	{name: "natives_handlers", files: map[string]string{"natives.go": matrixNatives, "handlers.go": matrixHandlers}},
+	{name: "prelude_quiet", prelude: matrixPrelude, quiet: true},
+	{name: "all", keepTemp: true, quiet: true, prelude: matrixPrelude,
+		files: map[string]string{"natives.go": matrixNatives, "handlers.go": matrixHandlers}},
+}
+
+// runMatrix compiles and runs every program with every variant, each pair
+// in its own parallel subtest. The builds share the go build cache: the
+// first one, run before the others start, compiles the runtime packages,
+// so the rest only compile their generated file and link.
+func runMatrix(t *testing.T, programs []matrixProgram, variants []matrixVariant) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("compiler matrix builds binaries")
+	}
+	cwd, _ := os.Getwd()
+	projectRoot := filepath.Join(cwd, "../..")
+
+	run := func(t *testing.T, p matrixProgram, v matrixVariant) {
+		// Builds need the module, so they run inside the project.
+		tmpDir, err := os.MkdirTemp(projectRoot, "test_build_*")
+		if err != nil {
+			t.Fatalf("Failed to create temp dir: %v", err)
+		}
+		defer os.RemoveAll(tmpDir)
+
+		sourceFile := filepath.Join(tmpDir, p.name+".lam")
+		if err := os.WriteFile(sourceFile, []byte(p.source), 0644); err != nil {
+			t.Fatalf("Failed to write source file: %v", err)
+		}
+		var goFlags []string
+		for name, code := range v.files {
+			// Outside the build dir, so Compile copies them next to the output.
+			path := filepath.Join(tmpDir, "src", name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(code), 0644); err != nil {
+				t.Fatalf("Failed to write %s: %v", name, err)
+			}
+			goFlags = append(goFlags, path)
+		}
+
+		var prelude string
+		if v.prelude != "" {
+			prelude = filepath.Join(tmpDir, "src", "prelude.lam")
+			if err := os.MkdirAll(filepath.Dir(prelude), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(prelude, []byte(v.prelude), 0644); err != nil {
+				t.Fatalf("Failed to write prelude: %v", err)
+			}
+		}
+
+		c := Compiler{
+			SourceFile: sourceFile,
+			OutputName: filepath.Join(tmpDir, p.name),
+			GoFlags:    goFlags,
+			KeepTemp:   v.keepTemp,
+			Prelude:    prelude,
+			Quiet:      v.quiet,
+		}
+		builtFile, err := c.Compile()
+		if err != nil {
+			t.Fatalf("Compilation failed: %v", err)
+		}
+		var stderr bytes.Buffer
+		cmd := exec.Command(builtFile)
+		cmd.Stderr = &stderr
+		output, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("Binary execution failed: %v\nStderr: %s", err, stderr.String())
+		}
+		if result := strings.TrimSpace(string(output)); !strings.HasPrefix(result, p.want) {
+			t.Errorf("Expected output to start with:\n%s\nGot:\n%s", p.want, result)
+		}
+		if stats := strings.Contains(stderr.String(), "Stats:"); stats == v.quiet {
+			t.Errorf("quiet=%v, got stderr:\n%s", v.quiet, stderr.String())
+		}
+	}
+
+	t.Run("warm", func(t *testing.T) { run(t, programs[0], variants[0]) })
+	t.Run("matrix", func(t *testing.T) {
+		for _, p := range programs {
+			for _, v := range variants {
+				if p.needs&^v.provides() != 0 {
+					continue
+				}
+				t.Run(p.name+"/"+v.name, func(t *testing.T) {
+					t.Parallel()
+					run(t, p, v)
+				})
+			}
+		}
+	})
+}
+
+func TestCompileMatrix(t *testing.T) {
+	runMatrix(t, []matrixProgram{
+		{"church_succ", "let succ = n: f: x: f (n f x); zero = f: x: x in succ zero", "(x0: (x1: (x0", 0},
+		{"literal", `(x: y: x) 1/3 "unused"`, "1/3", 0},
+		{"native", "(f: f 41) inc", "42", needsNatives},
+		{"effect", `(x: y: x) (!Log "hello") 7`, "hello\n5", needsHandlers},
+		{"prelude", "succ (succ zero)", "(x0: (x1: (x0 (x0", needsPrelude},
+	}, matrixVariants)
+}
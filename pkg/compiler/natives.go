@@ -0,0 +1,31 @@
+package compiler
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+)
+
+// nativesFunc is the function linked native packages define. It takes the
+// *deltanet.Network and registers natives on it. The generated program
+// calls it before building the net, so that free variables named after a
+// registered native denote the native.
+const nativesFunc = "registerNatives"
+
+// definesNatives reports whether the Go files define registerNatives.
+func definesNatives(files []string) (bool, error) {
+	fset := token.NewFileSet()
+	for _, path := range files {
+		file, err := parser.ParseFile(fset, path, nil, parser.SkipObjectResolution)
+		if err != nil {
+			return false, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		for _, decl := range file.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok && fn.Recv == nil && fn.Name.Name == nativesFunc {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
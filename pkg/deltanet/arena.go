@@ -0,0 +1,183 @@
+package deltanet
+
+import "sync"
+
+// Arena allocation
+//
+// With WithArena, fans, erasers, vars, replicators, their ports and the
+// wires between them are carved out of per-network slabs instead of being
+// allocated one by one, so a large reduction makes a few big allocations
+// rather than millions of small ones for the GC to track. A slab stays
+// alive while any of its objects is reachable; reset drops the network's
+// references to all of them at once. Data, native, effect and handler
+// nodes are rare and keep using the heap.
+//
+// The slabs of a network share one lock, so parallel workers contend on
+// every allocation, and a chunk is only freed once all of its objects are
+// unreachable: a few long-lived nodes pin whole chunks. Slabs are therefore
+// off by default; they pay off for single-worker reductions that build and
+// drop many nodes (see BenchmarkArena).
+
+// arenaChunk is the number of objects allocated at once per slab.
+const arenaChunk = 1024
+
+// slab hands out consecutive elements of preallocated chunks.
+type slab[T any] struct {
+	buf []T
+}
+
+// alloc returns k zeroed elements from the current chunk, starting a new
+// chunk when it has no room left.
+func (s *slab[T]) alloc(k int) []T {
+	if cap(s.buf)-len(s.buf) < k {
+		s.buf = make([]T, 0, max(arenaChunk, k))
+	}
+	start := len(s.buf)
+	s.buf = s.buf[:start+k]
+	return s.buf[start : start+k : start+k]
+}
+
+type arena struct {
+	mu    sync.Mutex
+	bases slab[BaseNode]
+	reps  slab[ReplicatorNode]
+	ports slab[Port]
+	refs  slab[*Port]
+	wires slab[Wire]
+}
+
+// reset forgets all chunks. Objects already handed out stay valid for as
+// long as they are referenced.
+func (a *arena) reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.bases = slab[BaseNode]{}
+	a.reps = slab[ReplicatorNode]{}
+	a.ports = slab[Port]{}
+	a.refs = slab[*Port]{}
+	a.wires = slab[Wire]{}
+}
+
+// WithArena enables or disables slab allocation of nodes, ports and wires
+// (see above). It is disabled by default.
+func WithArena(on bool) Option {
+	return func(n *Network) {
+		if on {
+			n.arena = &arena{}
+		} else {
+			n.arena = nil
+		}
+	}
+}
+
+func (n *Network) newBaseNode() *BaseNode {
+	if n.arena == nil {
+		return &BaseNode{}
+	}
+	n.arena.mu.Lock()
+	defer n.arena.mu.Unlock()
+	return &n.arena.bases.alloc(1)[0]
+}
+
+func (n *Network) newReplicatorNode() *ReplicatorNode {
+	if n.arena == nil {
+		return &ReplicatorNode{}
+	}
+	n.arena.mu.Lock()
+	defer n.arena.mu.Unlock()
+	return &n.arena.reps.alloc(1)[0]
+}
+
+// newPorts allocates the k ports of node.
+func (n *Network) newPorts(node Node, k int) []*Port {
+	if n.arena == nil {
+		refs := make([]*Port, k)
+		for i := range refs {
+			refs[i] = &Port{Node: node, Index: i}
+		}
+		return refs
+	}
+	n.arena.mu.Lock()
+	ports := n.arena.ports.alloc(k)
+	refs := n.arena.refs.alloc(k)
+	n.arena.mu.Unlock()
+	for i := range ports {
+		ports[i].Node = node
+		ports[i].Index = i
+		refs[i] = &ports[i]
+	}
+	return refs
+}
+
+// newWire returns a wire at depth, counting it in stats, or in the first
+// set of counters when stats is nil.
+func (n *Network) newWire(depth uint64, stats *workerStats) *Wire {
+	if stats == nil {
+		stats = n.statsFor(0)
+	}
+	stats.add(statWires)
+	if n.wirePool != nil {
+		if w, ok := n.wirePool.Get().(*Wire); ok {
+			w.depth = depth
+			return w
+		}
+	}
+	if n.arena == nil {
+		return &Wire{depth: depth}
+	}
+	n.arena.mu.Lock()
+	w := &n.arena.wires.alloc(1)[0]
+	n.arena.mu.Unlock()
+	w.depth = depth
+	return w
+}
+
+// Wire pooling
+//
+// Every interaction discards the wire of its active pair. With a wire pool,
+// the wires of pairs taken from the scheduler go back to the pool for
+// newWire to reuse. A reduced wire may still be referred to, since a pair
+// reduced by ReduceAt or ReducePair stays queued and a pair can be queued
+// twice; reusing it would make the stale entry reduce whatever the wire
+// joins next. So each wire counts its queued, parked and postponed
+// entries, as well as the entries popped and not yet reduced, and only
+// wires without any are reused. Under parallel reduction
+// other workers may hold a reduced wire they loaded from a port, so wires
+// are not reused there.
+
+// WithWirePool enables or disables reusing the wires of reduced pairs (see
+// above). It is disabled by default.
+func WithWirePool(on bool) Option {
+	return func(n *Network) {
+		if on {
+			n.wirePool = &sync.Pool{}
+		} else {
+			n.wirePool = nil
+		}
+	}
+}
+
+// freeWire puts w, the wire of a pair just reduced, back in the pool
+// unless some entry still refers to it.
+func (n *Network) freeWire(w *Wire) {
+	if n.wirePool != nil && !n.parallel && w.refs.Load() == 0 {
+		n.wiresPooled.Add(1)
+		n.wirePool.Put(w)
+	}
+}
+
+// hold counts an entry referring to w: queued, parked or postponed.
+func (n *Network) hold(w *Wire) {
+	if n.wirePool != nil {
+		w.refs.Add(1)
+	}
+}
+
+// release uncounts an entry referring to w, which may be nil, and returns
+// w.
+func (n *Network) release(w *Wire) *Wire {
+	if n.wirePool != nil && w != nil {
+		w.refs.Add(-1)
+	}
+	return w
+}
@@ -0,0 +1,129 @@
+package deltanet
+
+import (
+	"fmt"
+	"testing"
+)
+
+// buildCommutations links n fan-replicator pairs, each commutation
+// allocating two replicators, two fans and their wires.
+func buildCommutations(net *Network, n int) {
+	for i := 0; i < n; i++ {
+		fan := net.NewFan()
+		rep := net.NewReplicator(0, []int{0, 0})
+		net.Link(fan, 0, rep, 0)
+		for port := 1; port < 3; port++ {
+			net.Link(fan, port, net.NewVar(), 0)
+			net.Link(rep, port, net.NewVar(), 0)
+		}
+	}
+}
+
+// TestArenaSlab tests that slabs hand out disjoint elements and start a
+// new chunk when full.
+func TestArenaSlab(t *testing.T) {
+	var s slab[int]
+	a := s.alloc(arenaChunk - 1)
+	b := s.alloc(1)
+	c := s.alloc(2)
+	a[len(a)-1], b[0], c[0] = 1, 2, 3
+	if a[len(a)-1] != 1 || b[0] != 2 || c[0] != 3 {
+		t.Errorf("slab elements overlap")
+	}
+	if cap(a) != len(a) || cap(c) != 2 {
+		t.Errorf("slices must not be appendable into each other")
+	}
+}
+
+// TestArenaDisabled tests that the arena is off by default and that
+// reduction gives the same result with and without it.
+func TestArenaDisabled(t *testing.T) {
+	if NewNetwork().arena != nil {
+		t.Error("arena enabled by default")
+	}
+	for _, on := range []bool{true, false} {
+		net := NewNetworkWith(WithArena(on), WithWorkers(2))
+		buildCommutations(net, 100)
+		net.ReduceAll()
+		if got := net.GetStats().FanRepCommutation; got != 100 {
+			t.Errorf("arena %v: expected 100 commutations, got %d", on, got)
+		}
+	}
+}
+
+// TestWirePool tests that reusing reduced wires gives the same reduction,
+// and that a wire still queued is not reused.
+func TestWirePool(t *testing.T) {
+	want := NewNetworkWith(WithWorkers(1))
+	buildCommutations(want, 100)
+	want.ReduceAll()
+
+	n := NewNetworkWith(WithWorkers(1), WithWirePool(true))
+	buildCommutations(n, 100)
+	n.ReduceAll()
+	if got := n.GetStats(); got.Interactions() != want.GetStats().Interactions() || n.ActiveNodeCount() != want.ActiveNodeCount() {
+		t.Errorf("got %+v with %d nodes, want %+v with %d", got, n.ActiveNodeCount(), want.GetStats(), want.ActiveNodeCount())
+	}
+	if n.wiresPooled.Load() == 0 {
+		t.Errorf("expected reduced wires in the pool")
+	}
+
+	// ReduceAt leaves the pair queued: its wire is held until popped.
+	n = NewNetworkWith(WithWorkers(1), WithWirePool(true))
+	fan := n.NewFan()
+	rep := n.NewReplicator(0, []int{0, 0})
+	n.Link(fan, 0, rep, 0)
+	w := fan.Ports()[0].Wire.Load()
+	n.ReduceAt(fan)
+	n.freeWire(w)
+	if w.refs.Load() != 1 {
+		t.Errorf("expected the queued wire to be held, got %d entries", w.refs.Load())
+	}
+	if got, ok := n.wirePool.Get().(*Wire); ok && got == w {
+		t.Errorf("a queued wire was reused")
+	}
+
+	// A pair queued twice is popped by two workers: the wire stays held by
+	// the second while the first reduces it.
+	n = NewNetworkWith(WithWorkers(2), WithWirePool(true))
+	fan = n.NewFan()
+	rep = n.NewReplicator(0, []int{0, 0})
+	n.Link(fan, 0, rep, 0)
+	w = fan.Ports()[0].Wire.Load()
+	n.pairs.Add(1)
+	n.schedule(w, w.depth, nil)
+	first, second := n.scheduler.TryPop(), n.scheduler.TryPop()
+	if first != w || second != w {
+		t.Fatalf("expected the wire queued twice")
+	}
+	n.serve(0, first, n.statsFor(0))
+	if got := n.wiresPooled.Load(); got != 0 {
+		t.Errorf("a wire popped by another worker was pooled")
+	}
+	n.serve(1, second, n.statsFor(1))
+	if got := w.refs.Load(); got != 0 {
+		t.Errorf("expected no entries after both were served, got %d", got)
+	}
+}
+
+// BenchmarkArena compares slab and heap allocation with one worker and
+// with parallel workers, which contend on the slabs' lock.
+func BenchmarkArena(b *testing.B) {
+	for _, workers := range []int{1, 8} {
+		for _, on := range []bool{true, false} {
+			b.Run(fmt.Sprintf("workers=%d/arena=%v", workers, on), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					opts := []Option{WithArena(on), WithWorkers(workers)}
+					if workers > 1 {
+						opts = append(opts, WithParallel())
+					}
+					net := NewNetworkWith(opts...)
+					buildCommutations(net, 1000)
+					net.ReduceAll()
+					net.Close()
+				}
+			})
+		}
+	}
+}
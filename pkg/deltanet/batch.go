@@ -0,0 +1,34 @@
+package deltanet
+
+// Batched pair processing
+//
+// By default a worker takes one active pair per scheduler acquisition, so
+// every pair it reduces is the shallowest one queued at that moment and a
+// single-worker trace follows leftmost-outermost order exactly. With a
+// batch size k > 1 a worker takes up to k queued pairs of the same depth
+// from one shard under a single lock and reduces them in turn, which
+// amortizes the scheduler's synchronization over k interactions. Pairs
+// created meanwhile at a shallower depth wait until the batch is done, so
+// the order of interactions may differ from strict LMO; the normal form
+// and the number of interactions do not (see parallel.go). Keep the batch
+// size at 1 when a deterministic LMO trace is required.
+
+// SetBatch sets how many queued pairs of one depth a worker takes at once.
+// Values below 1 mean 1, the strict one-at-a-time default. It must be
+// called before reduction starts.
+func (n *Network) SetBatch(k int) {
+	if k < 1 {
+		k = 1
+	}
+	n.batch = k
+}
+
+// Batch returns the number of pairs a worker takes at once.
+func (n *Network) Batch() int {
+	return n.batch
+}
+
+// WithBatch sets the worker batch size (see SetBatch).
+func WithBatch(k int) Option {
+	return func(n *Network) { n.SetBatch(k) }
+}
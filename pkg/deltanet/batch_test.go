@@ -0,0 +1,50 @@
+package deltanet
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestBatch tests that workers taking several pairs at once reach the same
+// result with the same interactions as one pair at a time.
+func TestBatch(t *testing.T) {
+	want := NewNetworkWith(WithWorkers(1))
+	buildCommutations(want, 200)
+	want.ReduceAll()
+
+	for _, k := range []int{0, 1, 4, 64} {
+		for _, parallel := range []bool{false, true} {
+			opts := []Option{WithWorkers(4), WithBatch(k), WithWirePool(true)}
+			if parallel {
+				opts = append(opts, WithParallel())
+			}
+			n := NewNetworkWith(opts...)
+			buildCommutations(n, 200)
+			n.ReduceAll()
+			if k < 1 && n.Batch() != 1 {
+				t.Errorf("batch %d: expected size 1, got %d", k, n.Batch())
+			}
+			if got, exp := n.GetStats().Interactions(), want.GetStats().Interactions(); got != exp {
+				t.Errorf("batch %d, parallel %v: stats %+v, expected %+v", k, parallel, got, exp)
+			}
+			if got := n.PendingPairs(); got != 0 {
+				t.Errorf("batch %d, parallel %v: %d pairs still pending", k, parallel, got)
+			}
+		}
+	}
+}
+
+// BenchmarkBatch measures fan-replicator commutations reduced by four
+// workers taking pairs one at a time and in batches.
+func BenchmarkBatch(b *testing.B) {
+	for _, k := range []int{1, 8, 64} {
+		b.Run(fmt.Sprintf("batch=%d", k), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				n := NewNetworkWith(WithWorkers(4), WithParallel(), WithBatch(k))
+				buildCommutations(n, 1000)
+				n.ReduceAll()
+				n.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package deltanet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBudgetExceeded is wrapped by the *BudgetError ReduceWithBudget returns
+// when a limit of its Budget is reached.
+var ErrBudgetExceeded = errors.New("reduction budget exceeded")
+
+// Budget bounds a single call of ReduceWithBudget. Zero fields are
+// unlimited.
+type Budget struct {
+	// MaxInteractions bounds the interactions of the call, counted as in
+	// Stats.TotalReductions.
+	MaxInteractions uint64
+	// MaxNodes bounds the nodes registered at once, the net's memory.
+	// Dead nodes count until they are collected.
+	MaxNodes int
+	// Timeout bounds the wall time of the call.
+	Timeout time.Duration
+}
+
+// Budget limits, as reported by BudgetError.Limit.
+const (
+	LimitInteractions = "interactions"
+	LimitNodes        = "nodes"
+	LimitTime         = "time"
+)
+
+// BudgetError reports the limit that stopped a reduction and the state of
+// the network at that point. The net is left partially reduced.
+type BudgetError struct {
+	Limit string // LimitInteractions, LimitNodes or LimitTime
+	Stats Stats
+	Nodes int // Registered nodes when reduction stopped
+}
+
+func (e *BudgetError) Error() string {
+	return fmt.Sprintf("%v: %s limit after %d interactions with %d nodes",
+		ErrBudgetExceeded, e.Limit, e.Stats.TotalReductions, e.Nodes)
+}
+
+func (e *BudgetError) Unwrap() error {
+	return ErrBudgetExceeded
+}
+
+func (n *Network) budgetError(limit string) *BudgetError {
+	return &BudgetError{Limit: limit, Stats: n.GetStats(), Nodes: n.NodeCount()}
+}
+
+// ReduceWithBudget reduces the network with its strategy (see Reduce)
+// within b and ctx. When a limit of b is reached it returns a *BudgetError,
+// which wraps ErrBudgetExceeded; when ctx is done, ctx.Err(); when the
+// network's own interaction cap is reached, an error wrapping
+// ErrReductionLimit. In all these cases the net is left partially reduced
+// and a later call continues from there.
+func (n *Network) ReduceWithBudget(ctx context.Context, b Budget) error {
+	parent := ctx
+	if b.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.Timeout)
+		defer cancel()
+	}
+	if b.MaxInteractions > 0 {
+		n.limit.interactions.Store(n.stat(statOps) + b.MaxInteractions)
+		defer n.limit.interactions.Store(0)
+	}
+	if b.MaxNodes > 0 {
+		n.limit.nodes.Store(int64(b.MaxNodes))
+		defer n.limit.nodes.Store(0)
+	}
+
+	var err error
+	switch n.strategy {
+	case StrategyNormalForm:
+		err = n.ReduceToNormalFormContext(ctx)
+	default:
+		err = n.reduceAllContext(ctx)
+	}
+	if errors.Is(err, context.DeadlineExceeded) && parent.Err() == nil {
+		return n.budgetError(LimitTime)
+	}
+	return err
+}
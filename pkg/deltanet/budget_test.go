@@ -0,0 +1,40 @@
+package deltanet
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestBudgetLimits tests that each limit of a Budget stops reduction with
+// a BudgetError naming it, and that a later call continues.
+func TestBudgetLimits(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(1))
+	buildCommutations(n, 200)
+	start := n.NodeCount()
+
+	err := n.ReduceWithBudget(context.Background(), Budget{MaxInteractions: 30})
+	var be *BudgetError
+	if !errors.As(err, &be) || !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("expected a BudgetError, got %v", err)
+	}
+	if be.Limit != LimitInteractions || be.Stats.TotalReductions != 30 {
+		t.Errorf("expected the interactions limit after 30, got %s after %d", be.Limit, be.Stats.TotalReductions)
+	}
+
+	// The budget is per call.
+	err = n.ReduceWithBudget(context.Background(), Budget{MaxInteractions: 30, MaxNodes: start + 100})
+	if !errors.As(err, &be) || be.Limit != LimitNodes {
+		t.Fatalf("expected the nodes limit, got %v", err)
+	}
+	if be.Nodes < start+100 {
+		t.Errorf("stopped at %d nodes, below the limit of %d", be.Nodes, start+100)
+	}
+
+	if err := n.ReduceWithBudget(context.Background(), Budget{}); err != nil {
+		t.Fatalf("unlimited call: %v", err)
+	}
+	if got := n.GetStats().FanRepCommutation; got != 200 {
+		t.Errorf("expected 200 commutations in total, got %d", got)
+	}
+}
@@ -5,8 +5,10 @@ import (
 )
 
 func TestUnpairedReplicatorDecay(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	n := NewNetworkWith(WithTrace(100))
 
 	// Create a Replicator with 1 aux port, delta 0
 	// This is the identity replicator that should decay
@@ -30,7 +32,7 @@ func TestUnpairedReplicatorDecay(t *testing.T) {
 
 	// Check if Rep is gone and v1 is connected to v2
 	// Wait for async ops
-	n.wg.Wait()
+	n.pairs.Wait()
 
 	// Verify connection
 	if !n.IsConnected(v1, 0, v2, 0) {
@@ -52,8 +54,10 @@ func TestUnpairedReplicatorDecay(t *testing.T) {
 }
 
 func TestUnpairedReplicatorMerging(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	n := NewNetworkWith(WithTrace(100))
 
 	// Create Rep A: Level 0, Deltas [1] (Not identity, won't decay)
 	repA := n.NewReplicator(0, []int{1})
@@ -73,7 +77,7 @@ func TestUnpairedReplicatorMerging(t *testing.T) {
 
 	// Trigger merging
 	n.ApplyCanonicalRules()
-	// n.wg.Wait()
+	// n.pairs.Wait()
 
 	// Expectation: A and B merge into a single Replicator.
 	// v1 should be connected to the new Replicator's Principal
@@ -121,8 +125,10 @@ func TestUnpairedReplicatorMerging(t *testing.T) {
 }
 
 func TestPhase2AuxFanReplication(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	n := NewNetworkWith(WithTrace(100))
 
 	// Setup: Fan connected to Replicator
 	// Fan Principal -> Rep Principal (Active Pair)
@@ -167,3 +173,27 @@ func TestPhase2AuxFanReplication(t *testing.T) {
 		t.Errorf("RuleAuxFanRep not found in trace")
 	}
 }
+
+// TestCanonicalSweepAccounting tests that sweeps report their work and that
+// Report sums them.
+func TestCanonicalSweepAccounting(t *testing.T) {
+	n := NewNetwork()
+	for i := 0; i < 3; i++ {
+		rep := n.NewReplicator(0, []int{0})
+		n.Link(n.NewVar(), 0, rep, 0)
+		n.Link(n.NewVar(), 0, rep, 1)
+	}
+
+	sweep := n.ApplyCanonicalRules()
+	if sweep.Decays != 3 || sweep.Merges != 0 || sweep.Visited != 9 || !sweep.Changed() {
+		t.Errorf("first sweep: got %+v", sweep)
+	}
+	if again := n.ApplyCanonicalRules(); again.Changed() {
+		t.Errorf("second sweep changed the net: %+v", again)
+	}
+
+	c := n.Report().Canonical
+	if c.Sweeps != 2 || c.Decays != 3 || c.Visited < 9 {
+		t.Errorf("report: got %+v", c)
+	}
+}
@@ -0,0 +1,89 @@
+package deltanet
+
+import (
+	"io"
+	"sort"
+	"strings"
+)
+
+// NodeCensus breaks the live nodes of a net down by type, and its
+// replicators by level and arity (number of auxiliary ports), to spot
+// replicator blow-ups. Network.Census takes it.
+type NodeCensus struct {
+	Live    int            `json:"live"`
+	Dead    int            `json:"dead"`     // Registered but dead, until CollectGarbage
+	ByType  map[string]int `json:"by_type"`  // NodeType name -> live nodes
+	ByLevel map[int]int    `json:"by_level"` // Replicator level -> live replicators
+	ByArity map[int]int    `json:"by_arity"` // Replicator arity -> live replicators
+}
+
+// Census counts the registered nodes. It may be taken at any point,
+// including while workers reduce, in which case the counts are a close
+// approximation of a moment of the reduction rather than an exact cut.
+func (n *Network) Census() NodeCensus {
+	c := NodeCensus{
+		ByType:  make(map[string]int),
+		ByLevel: make(map[int]int),
+		ByArity: make(map[int]int),
+	}
+	n.nodes.each(func(node Node) {
+		if node.IsDead() {
+			c.Dead++
+			return
+		}
+		c.Live++
+		c.ByType[node.Type().String()]++
+		if node.Type() == NodeTypeReplicator {
+			c.ByLevel[node.Level()]++
+			c.ByArity[len(node.Deltas())]++
+		}
+	})
+	return c
+}
+
+// censusBar is the width of the longest histogram bar of WriteText.
+const censusBar = 40
+
+// WriteText writes the census as histograms: nodes per type in NodeType
+// order, replicators per level and per arity in increasing order.
+func (c NodeCensus) WriteText(w io.Writer) error {
+	ew := &errWriter{w: w}
+	ew.printf("Live nodes: %d (%d dead)\n", c.Live, c.Dead)
+	var types []string
+	for t := NodeTypeFan; t <= NodeTypeHandler; t++ {
+		if c.ByType[t.String()] > 0 {
+			types = append(types, t.String())
+		}
+	}
+	histogram(ew, "By type", types, c.ByType)
+	if len(c.ByLevel) > 0 {
+		histogram(ew, "Replicators by level", sortedInts(c.ByLevel), c.ByLevel)
+		histogram(ew, "Replicators by arity", sortedInts(c.ByArity), c.ByArity)
+	}
+	return ew.err
+}
+
+// histogram prints one bar per key, scaled to the largest count.
+func histogram[K comparable](ew *errWriter, title string, keys []K, counts map[K]int) {
+	ew.printf("\n%s:\n", title)
+	most := 0
+	for _, k := range keys {
+		most = max(most, counts[k])
+	}
+	for _, k := range keys {
+		bar := 0
+		if most > 0 {
+			bar = (counts[k]*censusBar + most - 1) / most
+		}
+		ew.printf("  %-12v %8d %s\n", k, counts[k], strings.Repeat("#", bar))
+	}
+}
+
+func sortedInts(m map[int]int) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
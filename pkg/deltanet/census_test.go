@@ -0,0 +1,68 @@
+package deltanet
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCensus(t *testing.T) {
+	n := NewNetwork()
+	n.NewFan()
+	n.NewVar()
+	n.NewReplicator(0, []int{0, 0})
+	n.NewReplicator(0, []int{0, 1})
+	n.NewReplicator(2, []int{0, 1, 2})
+
+	c := n.Census()
+	if c.Live != 5 || c.Dead != 0 {
+		t.Errorf("live %d dead %d, want 5 and 0", c.Live, c.Dead)
+	}
+	if want := map[string]int{"Fan": 1, "Var": 1, "Replicator": 3}; !reflect.DeepEqual(c.ByType, want) {
+		t.Errorf("by type %v, want %v", c.ByType, want)
+	}
+	if want := map[int]int{0: 2, 2: 1}; !reflect.DeepEqual(c.ByLevel, want) {
+		t.Errorf("by level %v, want %v", c.ByLevel, want)
+	}
+	if want := map[int]int{2: 2, 3: 1}; !reflect.DeepEqual(c.ByArity, want) {
+		t.Errorf("by arity %v, want %v", c.ByArity, want)
+	}
+
+	var buf strings.Builder
+	if err := c.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"Live nodes: 5 (0 dead)",
+		"  Replicator          3 " + strings.Repeat("#", censusBar),
+		"  2                   2 " + strings.Repeat("#", censusBar),
+		"  3                   1 " + strings.Repeat("#", censusBar/2),
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("missing %q in\n%s", line, buf.String())
+		}
+	}
+}
+
+// TestCensusDuringReduction tests that a census can be taken while workers
+// reduce.
+func TestCensusDuringReduction(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(4), WithParallel())
+	buildCommutations(n, 5000)
+	done := make(chan struct{})
+	go func() {
+		n.ReduceAll()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			if c := n.Census(); c.Live+c.Dead != n.NodeCount() {
+				t.Errorf("census covers %d nodes, want %d", c.Live+c.Dead, n.NodeCount())
+			}
+			return
+		default:
+			n.Census()
+		}
+	}
+}
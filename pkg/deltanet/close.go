@@ -0,0 +1,56 @@
+package deltanet
+
+import "errors"
+
+// ErrClosed is returned by the reduction methods of a closed network. Those
+// reporting progress instead of an error (Step, ReducePair, ReduceAt,
+// ReduceWithLimit and ReduceToWHNF) report none, and Err returns ErrClosed.
+var ErrClosed = errors.New("network is closed")
+
+// Close stops the network's workers and discards the active pairs still
+// queued, releasing callers blocked in ReduceAll. Pairs being reduced when
+// Close is called are finished first. Afterwards the reduction methods
+// return without reducing and report ErrClosed (see there). The net itself
+// can still be inspected and read back. Close also closes the Events
+// channel. It is safe to call more than once.
+func (n *Network) Close() error {
+	if !n.closed.CompareAndSwap(false, true) {
+		return nil
+	}
+	// Workers discard what they pop once closed, and exit when the queue
+	// is empty. Paused workers are woken to do so.
+	n.pause.mu.Lock()
+	n.wakePaused()
+	n.pause.mu.Unlock()
+	n.scheduler.Close()
+	n.running.Wait()
+	// Without workers nothing pops the remaining pairs.
+	for n.scheduler.TryPop() != nil {
+		n.pairs.Done()
+	}
+	n.limit.mu.Lock()
+	n.limit.parked = nil
+	n.limit.mu.Unlock()
+	n.closeEvents()
+	return nil
+}
+
+// Closed reports whether Close was called.
+func (n *Network) Closed() bool {
+	return n.closed.Load()
+}
+
+// Err returns ErrClosed once Close was called, or the *FanOutError or
+// *LevelError of the replicator commutation over a limit that stopped the
+// last reduction (see commutation.go), and nil otherwise. It tells these
+// apart from a net without active pairs when Step, ReducePair, ReduceAt,
+// ReduceWithLimit or ReduceToWHNF report no progress.
+func (n *Network) Err() error {
+	if n.closed.Load() {
+		return ErrClosed
+	}
+	if n.limit.halted.Load() && haltReason(n.limit.reason.Load()) == haltCommutation {
+		return n.commutationError()
+	}
+	return nil
+}
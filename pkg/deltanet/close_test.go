@@ -0,0 +1,126 @@
+package deltanet
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestCloseStopsWorkers tests that Close ends the worker goroutines and
+// that reduction is refused afterwards.
+func TestCloseStopsWorkers(t *testing.T) {
+	before := runtime.NumGoroutine()
+	n := NewNetworkWith(WithWorkers(8))
+	buildCommutations(n, 10)
+	n.ReduceAll()
+	if err := n.Close(); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := runtime.NumGoroutine(); got > before {
+		t.Errorf("expected %d goroutines after Close, got %d", before, got)
+	}
+
+	buildCommutations(n, 10)
+	n.ReduceAll() // Must not block on the new pairs
+	if got := n.ReduceWithLimit(10); got != 0 {
+		t.Errorf("expected no reductions after Close, got %d", got)
+	}
+	if err := n.ReduceToNormalFormContext(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	if err := n.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
+
+// TestCloseDiscardsQueuedPairs tests that closing a network that never
+// started drains its queue.
+func TestCloseDiscardsQueuedPairs(t *testing.T) {
+	n := NewNetwork()
+	buildCommutations(n, 10)
+	n.Close()
+	if w := n.scheduler.TryPop(); w != nil {
+		t.Errorf("expected an empty queue after Close")
+	}
+	done := make(chan struct{})
+	go func() {
+		n.pairs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("queued pairs still counted as pending after Close")
+	}
+}
+
+// TestClosedEntryPoints tests that every reduction method of a closed
+// network leaves the net alone and reports ErrClosed, either as its error
+// or, for those reporting progress, through Err.
+func TestClosedEntryPoints(t *testing.T) {
+	ctx := context.Background()
+	errs := map[string]func(n *Network) error{
+		"ReduceAll":                 (*Network).ReduceAll,
+		"Reduce":                    (*Network).Reduce,
+		"ReducePhase1":              (*Network).ReducePhase1,
+		"ReduceToNormalForm":        (*Network).ReduceToNormalForm,
+		"ReduceToNormalFormContext": func(n *Network) error { return n.ReduceToNormalFormContext(ctx) },
+		"ReduceWithBudget":          func(n *Network) error { return n.ReduceWithBudget(ctx, Budget{}) },
+		"ReduceMonitored":           func(n *Network) error { return n.ReduceMonitored(n.NewVar(), DivergenceMonitor{}) },
+		"RunEffects":                func(n *Network) error { return n.RunEffects(NewHandlerScope()) },
+		"ReduceToWHNFContext": func(n *Network) error {
+			_, err := n.ReduceToWHNFContext(ctx, n.NewVar())
+			return err
+		},
+	}
+	progress := map[string]func(n *Network, pair PairInfo, node Node) bool{
+		"Step":            func(n *Network, _ PairInfo, _ Node) bool { _, ok := n.Step(); return ok },
+		"ReducePair":      func(n *Network, pair PairInfo, _ Node) bool { _, ok := n.ReducePair(pair); return ok },
+		"ReduceAt":        func(n *Network, _ PairInfo, node Node) bool { return n.ReduceAt(node) },
+		"ReduceWithLimit": func(n *Network, _ PairInfo, _ Node) bool { return n.ReduceWithLimit(10) > 0 },
+		"ReduceToWHNF":    func(n *Network, _ PairInfo, node Node) bool { return n.ReduceToWHNF(node) > 0 },
+	}
+
+	closed := func() (*Network, PairInfo, Node) {
+		n := NewNetworkWith(WithWorkers(1))
+		if n.Err() != nil {
+			t.Fatalf("open network: Err returned %v", n.Err())
+		}
+		buildCommutations(n, 1)
+		pairs := n.ActivePairs()
+		if len(pairs) != 1 {
+			t.Fatalf("expected 1 active pair, got %d", len(pairs))
+		}
+		root := n.NewVar()
+		n.Link(root, 0, pairs[0].A, 1)
+		n.Close()
+		return n, pairs[0], pairs[0].A
+	}
+	for name, reduce := range errs {
+		n, _, _ := closed()
+		if err := reduce(n); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s: expected ErrClosed, got %v", name, err)
+		}
+		if ops := n.GetStats().TotalReductions; ops != 0 {
+			t.Errorf("%s: %d interactions on a closed network", name, ops)
+		}
+	}
+	for name, reduce := range progress {
+		n, pair, node := closed()
+		if reduce(n, pair, node) {
+			t.Errorf("%s: reported progress on a closed network", name)
+		}
+		if !errors.Is(n.Err(), ErrClosed) {
+			t.Errorf("%s: Err returned %v", name, n.Err())
+		}
+		if ops := n.GetStats().TotalReductions; ops != 0 {
+			t.Errorf("%s: %d interactions on a closed network", name, ops)
+		}
+	}
+}
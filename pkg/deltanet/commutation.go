@@ -0,0 +1,75 @@
+package deltanet
+
+import "sync"
+
+// Commutation limits
+//
+// SetMaxFanOut and SetMaxLevel bound what a single replicator commutation
+// may create, and SetRules the rules reduction may apply. guard checks
+// them before every interaction, whichever path reduces the pair: the workers, Step, ReducePair, ReduceAt and
+// ReduceWithLimit. A pair over a limit or needing a rule outside the
+// allowed set is left unreduced and halts
+// reduction. The workers and Step park it, to be queued again by the next
+// reduction; the error describing it is returned by ReduceAll and the
+// context and budget variants, and by Err after the calls that report no
+// error of their own.
+
+// commutationLimits bounds replicator commutations and the rules
+// reduction applies.
+type commutationLimits struct {
+	fanOut int    // 0 is unlimited, see SetMaxFanOut
+	level  int    // 0 is unlimited, see SetMaxLevel
+	rules  []bool // Indexed by RuleKind, nil allows every rule, see SetRules
+
+	mu   sync.Mutex
+	pair [2]Node // First pair stopped since the last resume
+	err  error   // Its *FanOutError, *LevelError or *RuleError
+}
+
+// guard reports whether w holds a replicator commutation over a limit or
+// a pair needing a rule that is not allowed, halting reduction the first
+// time.
+func (n *Network) guard(w *Wire) bool {
+	l := &n.commute
+	if l.fanOut == 0 && l.level == 0 && l.rules == nil {
+		return false
+	}
+	p0, p1 := w.P0.Load(), w.P1.Load()
+	if p0 == nil || p1 == nil {
+		return false
+	}
+	a, b := p0.Node, p1.Node
+	var err error
+	if e := n.ruleOver(a, b); e != nil {
+		err = e
+	} else if a.Type() != NodeTypeReplicator || b.Type() != NodeTypeReplicator || a.Level() == b.Level() {
+		return false
+	} else if e := fanOutOver(a, b, l.fanOut); e != nil {
+		err = e
+	} else if e := n.levelOver(a, b, l.level); e != nil {
+		err = e
+	} else {
+		return false
+	}
+	l.mu.Lock()
+	if l.err == nil {
+		l.pair = [2]Node{a, b}
+		l.err = err
+	}
+	l.mu.Unlock()
+	n.limit.reason.Store(int32(haltCommutation))
+	n.limit.halted.Store(true)
+	return true
+}
+
+// commutationError returns the commutation that halted reduction,
+// completing a *LevelError with its diagnostic dump.
+func (n *Network) commutationError() error {
+	l := &n.commute
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.err.(*LevelError); ok {
+		e.complete(n, l.pair)
+	}
+	return l.err
+}
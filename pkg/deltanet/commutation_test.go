@@ -0,0 +1,97 @@
+package deltanet
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestCommutationLimitsEveryPath tests that every reduction path refuses
+// a replicator commutation over a limit and reports it, and reduces it
+// once the limit is lifted.
+func TestCommutationLimitsEveryPath(t *testing.T) {
+	limits := []struct {
+		name  string
+		opt   Option
+		lift  func(*Network)
+		match error
+	}{
+		{"fan-out", WithMaxFanOut(1), func(n *Network) { n.SetMaxFanOut(0) }, ErrFanOutLimit},
+		{"level", WithMaxLevel(4), func(n *Network) { n.SetMaxLevel(0) }, ErrLevelLimit},
+		{"rules", WithRules(RuleFanFan), func(n *Network) { n.SetRules(nil) }, ErrRuleNotAllowed},
+	}
+	paths := []struct {
+		name   string
+		reduce func(n *Network, a Node) bool // Reports whether anything was reduced
+	}{
+		{"ReduceAll", func(n *Network, a Node) bool {
+			n.ReduceAll()
+			return n.GetStats().TotalReductions > 0
+		}},
+		{"ReduceWithLimit", func(n *Network, a Node) bool { return n.ReduceWithLimit(10) > 0 }},
+		{"Step", func(n *Network, a Node) bool {
+			_, ok := n.Step()
+			return ok
+		}},
+		{"ReducePair", func(n *Network, a Node) bool {
+			pairs := n.ActivePairs()
+			if len(pairs) != 1 {
+				t.Fatalf("expected 1 active pair, got %d", len(pairs))
+			}
+			_, ok := n.ReducePair(pairs[0])
+			return ok
+		}},
+		{"ReduceAt", func(n *Network, a Node) bool { return n.ReduceAt(a) }},
+	}
+	for _, limit := range limits {
+		for _, path := range paths {
+			n := NewNetworkWith(limit.opt)
+			a := n.NewReplicator(0, []int{0, 3})
+			b := n.NewReplicator(2, []int{0, 0})
+			n.Link(a, 0, b, 0)
+			for port := 1; port < 3; port++ {
+				n.Link(a, port, n.NewVar(), 0)
+				n.Link(b, port, n.NewVar(), 0)
+			}
+
+			if path.reduce(n, a) {
+				t.Errorf("%s %s: reduced a commutation over the limit", limit.name, path.name)
+			}
+			if err := n.Err(); !errors.Is(err, limit.match) {
+				t.Errorf("%s %s: expected Err to wrap %v, got %v", limit.name, path.name, limit.match, err)
+			}
+			if got := n.GetStats().TotalReductions; got != 0 {
+				t.Errorf("%s %s: expected no interactions, got %d", limit.name, path.name, got)
+			}
+
+			limit.lift(n)
+			if !path.reduce(n, a) {
+				t.Errorf("%s %s: commutation not reduced after lifting the limit", limit.name, path.name)
+			}
+			if err := n.Err(); err != nil {
+				t.Errorf("%s %s: unexpected error after lifting the limit: %v", limit.name, path.name, err)
+			}
+			n.Close()
+		}
+	}
+}
+
+// TestReduceAllReportsCommutationLimit tests that ReduceAll returns the
+// error of the commutation that stopped it.
+func TestReduceAllReportsCommutationLimit(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(4), WithMaxFanOut(1))
+	defer n.Close()
+	a := n.NewReplicator(0, []int{0, 0})
+	b := n.NewReplicator(1, []int{0, 0})
+	n.Link(a, 0, b, 0)
+	for port := 1; port < 3; port++ {
+		n.Link(a, port, n.NewVar(), 0)
+		n.Link(b, port, n.NewVar(), 0)
+	}
+	var fanOut *FanOutError
+	if err := n.ReduceAll(); !errors.As(err, &fanOut) {
+		t.Fatalf("expected a *FanOutError, got %v", err)
+	}
+	if fanOut.Wires != 4 || fanOut.Limit != 1 {
+		t.Errorf("expected 4 wires over limit 1, got %+v", fanOut)
+	}
+}
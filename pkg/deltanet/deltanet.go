@@ -1,10 +1,14 @@
 package deltanet
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"runtime"
 	"sync"
 	"sync/atomic"
+	"time"
 	"unsafe"
 )
 
@@ -81,18 +85,21 @@ type Wire struct {
 	P1    atomic.Pointer[Port]
 	depth uint64
 	mu    sync.Mutex
+	refs  atomic.Int32 // Queued, parked and postponed entries, see WithWirePool
 }
 
 // BaseNode contains common fields.
 type BaseNode struct {
-	id    uint64
+	id    uint32 // See ids.go
+	dead  int32
 	typ   NodeType
 	ports []*Port
-	dead  int32
+
+	registry *nodeRegistry // Counts the node's death while registered
 }
 
 func (n *BaseNode) Type() NodeType                 { return n.typ }
-func (n *BaseNode) ID() uint64                     { return n.id }
+func (n *BaseNode) ID() uint64                     { return uint64(n.id) }
 func (n *BaseNode) Ports() []*Port                 { return n.ports }
 func (n *BaseNode) Level() int                     { return 0 }
 func (n *BaseNode) Deltas() []int                  { return nil }
@@ -104,7 +111,13 @@ func (n *BaseNode) GetContinuation() *Continuation { return nil }
 func (n *BaseNode) GetHandlerScope() *HandlerScope { return nil }
 
 func (n *BaseNode) SetDead() bool {
-	return atomic.CompareAndSwapInt32(&n.dead, 0, 1)
+	if !atomic.CompareAndSwapInt32(&n.dead, 0, 1) {
+		return false
+	}
+	if n.registry != nil {
+		n.registry.dead.Add(1)
+	}
+	return true
 }
 
 func (n *BaseNode) IsDead() bool {
@@ -112,7 +125,9 @@ func (n *BaseNode) IsDead() bool {
 }
 
 func (n *BaseNode) Revive() {
-	atomic.StoreInt32(&n.dead, 0)
+	if atomic.CompareAndSwapInt32(&n.dead, 1, 0) && n.registry != nil {
+		n.registry.dead.Add(-1)
+	}
 }
 
 // ReplicatorNode specific fields.
@@ -144,6 +159,13 @@ func (n *NativeNode) GetName() string { return n.name }
 // NativeFunc is a pure function that takes data and returns data or error.
 type NativeFunc func(interface{}) (interface{}, error)
 
+// NetBuilder is a native result that expands into net structure instead of
+// a Data node, e.g. a Church boolean. Build returns the port that takes the
+// place of the application result.
+type NetBuilder interface {
+	Build(net *Network) (Node, int)
+}
+
 // IONode represents an algebraic effect to be performed.
 // It's a description of an effect, not the execution.
 // Carries effect row information and continuation capture point.
@@ -169,39 +191,81 @@ func (n *HandlerNode) GetHandlerScope() *HandlerScope { return n.scope }
 
 // Network manages the graph of nodes and interactions.
 type Network struct {
-	nextID      uint64
+	ids         idAllocator // See ids.go
 	scheduler   *Scheduler
-	wg          sync.WaitGroup
+	arena       *arena     // Slab allocator, nil when disabled (see arena.go)
+	wirePool    *sync.Pool // Reduced wires for reuse, nil when disabled (see arena.go)
+	wiresPooled atomic.Uint64
 	workers     int
+	batch       int          // Pairs a worker takes at once, see batch.go
+	pairs       pendingPairs // Active pairs queued or being reduced, see pending.go
 	startOnce   sync.Once
+	started     atomic.Bool    // Set by Start, see SetWorkers
+	running     sync.WaitGroup // Started workers, see Close
+	closed      atomic.Bool
 	reductionMu sync.Mutex // Ensures only one reduction at a time for LMO order
 
+	// Parallel mode replaces reductionMu with a depth gate (see parallel.go)
+	parallel bool
+	gate     depthGate
+
 	// Stats
-	ops uint64 // Total reductions
-
-	// Detailed stats
-	statFanAnn     uint64
-	statRepAnn     uint64
-	statRepComm    uint64
-	statFanRepComm uint64
-	statErasure    uint64
-	statRepDecay   uint64
-	statRepMerge   uint64
-	statAuxFanRep  uint64
+	stats         []workerStats  // Interaction counters per worker, see stats.go
+	statPruned    uint64         // Nodes removed by Canonicalize and ApplyErasureCanonization
+	statCollected uint64         // Dead nodes removed by CollectGarbage
+	statGCs       uint64         // CollectGarbage runs
+	phaseTime     [2]int64       // Nanoseconds in reduceAll, by phase
+	sweeps        CanonicalSweep // Totals of ApplyCanonicalRules
+	sweepsMu      sync.Mutex
 	// Registry of created nodes (used for canonicalization)
-	nodes   map[uint64]Node
-	nodesMu sync.Mutex
+	nodes nodeRegistry // See registry.go
+	// Registered interfaces (see roots.go)
+	roots   []Root
+	rootsMu sync.Mutex
 
 	// Native function registry
-	natives   map[string]NativeFunc
-	nativesMu sync.RWMutex
-
-	traceBuf []TraceEvent
-	traceCap uint64
-	traceIdx uint64
-	traceOn  uint32
-
-	phase int
+	natives    map[string]NativeFunc
+	nativeCaps map[string]Capability
+	partials   map[string]int // Live nodes of each partial native (see release.go)
+	partialSeq atomic.Uint64  // Numbers partial native names; never reset
+	nativesMu  sync.RWMutex
+	profile    *Profile
+
+	// Root handler scope and reduction strategy (see options.go)
+	handlers *HandlerScope
+	strategy Strategy
+
+	// Node metadata side table (see meta.go)
+	meta   map[uint64]interface{}
+	metaMu sync.RWMutex
+	metaOn uint32
+
+	traceBuf   []TraceEvent
+	traceCap   uint64
+	traceIdx   uint64
+	traceOn    uint32
+	traceEvery uint64      // Record every Nth event, see SetTraceSampling
+	traceSeen  uint64      // Events seen while sampling
+	traceRing  bool        // Overwrite the oldest events, see EnableTraceRing
+	traceStart time.Time   // Origin of TraceEvent.Time
+	stream     traceStream // See tracestream.go
+
+	timing  timingStats       // See timing.go
+	limit   reductionLimit    // See limit.go
+	gc      gcPolicy          // See gc.go
+	pause   safepoint         // See pause.go
+	memory  memoryThreshold   // See memory.go
+	commute commutationLimits // See commutation.go
+	checks  validation        // See validate.go
+	hooks   reductionHooks    // See hooks.go
+	events  eventBus          // See events.go
+	labels  profileLabels     // See pprof.go
+	eta     bool              // Eta rule enabled, see eta.go
+
+	logger atomic.Pointer[slog.Logger] // See log.go
+
+	phase    int
+	maxPhase int // Highest phase entered, see Report
 }
 
 // Stats holds reduction statistics.
@@ -215,23 +279,45 @@ type Stats struct {
 	RepDecay          uint64
 	RepMerge          uint64
 	AuxFanRep         uint64
+	DataCopy          uint64 // Replicators copying Data and native nodes
+	NativeCalls       uint64 // Fans applied to native nodes
+	EtaContraction    uint64 // Eta rules applied, see SetEtaContraction
+
+	// Memory and time, besides the interaction counts
+	PeakNodes     uint64        // Largest number of nodes registered at once
+	WiresCreated  uint64        // Wires created by linking and by the rules
+	Collections   uint64        // CollectGarbage runs, compactions included
+	Phase1Time    time.Duration // Wall time reducing in phase 1
+	CanonicalTime time.Duration // Wall time in ApplyCanonicalRules
+	Phase2Time    time.Duration // Wall time reducing in phase 2
 }
 
 func NewNetwork() *Network {
 	n := &Network{
-		scheduler: NewScheduler(),
-		workers:   runtime.NumCPU(),
-		nodes:     make(map[uint64]Node),
-		natives:   make(map[string]NativeFunc),
-		phase:     1,
-	}
+		scheduler:  NewScheduler(),
+		workers:    runtime.NumCPU(),
+		batch:      1,
+		gc:         gcPolicy{interval: defaultGCInterval, ratio: defaultGCRatio},
+		stats:      newWorkerStats(runtime.NumCPU()),
+		natives:    make(map[string]NativeFunc),
+		nativeCaps: make(map[string]Capability),
+		partials:   make(map[string]int),
+		phase:      1,
+		maxPhase:   1,
+	}
+	n.pause.setWorkers(n.workers)
 	return n
 }
 
 func (n *Network) Start() {
+	if n.closed.Load() {
+		return
+	}
 	n.startOnce.Do(func() {
+		n.started.Store(true)
+		n.running.Add(n.workers)
 		for i := 0; i < n.workers; i++ {
-			go n.worker()
+			go n.worker(i)
 		}
 	})
 }
@@ -245,73 +331,68 @@ func (n *Network) Phase() int {
 	return n.phase
 }
 
+// GetStats returns the counters summed over the workers (see
+// SnapshotStats).
 func (n *Network) GetStats() Stats {
-	return Stats{
-		TotalReductions:   atomic.LoadUint64(&n.ops),
-		FanAnnihilation:   atomic.LoadUint64(&n.statFanAnn),
-		RepAnnihilation:   atomic.LoadUint64(&n.statRepAnn),
-		RepCommutation:    atomic.LoadUint64(&n.statRepComm),
-		FanRepCommutation: atomic.LoadUint64(&n.statFanRepComm),
-		Erasure:           atomic.LoadUint64(&n.statErasure),
-		RepDecay:          atomic.LoadUint64(&n.statRepDecay),
-		RepMerge:          atomic.LoadUint64(&n.statRepMerge),
-		AuxFanRep:         atomic.LoadUint64(&n.statAuxFanRep),
-	}
+	return n.SnapshotStats().Stats
+}
+
+// Queued returns the number of active pairs waiting in the scheduler.
+func (n *Network) Queued() int {
+	return n.scheduler.Len()
+}
+
+// Workers returns the number of reduction workers (see SetWorkers).
+func (n *Network) Workers() int {
+	return n.workers
 }
 
 func (n *Network) NodeCount() int {
-	n.nodesMu.Lock()
-	defer n.nodesMu.Unlock()
-	return len(n.nodes)
+	return int(n.nodes.count.Load())
 }
 
 // ActiveNodeCount returns the count of nodes that are not marked as dead
 func (n *Network) ActiveNodeCount() int {
-	n.nodesMu.Lock()
-	defer n.nodesMu.Unlock()
-	count := 0
-	for _, node := range n.nodes {
-		if !node.IsDead() {
-			count++
-		}
-	}
-	return count
+	return n.nodes.live()
 }
 
-// CollectGarbage removes dead nodes from the nodes map to prevent memory growth
+// CollectGarbage removes dead nodes from the registry to prevent memory growth
 func (n *Network) CollectGarbage() int {
-	n.nodesMu.Lock()
-	defer n.nodesMu.Unlock()
-	collected := 0
-	for id, node := range n.nodes {
-		if node.IsDead() {
-			delete(n.nodes, id)
-			collected++
-		}
-	}
+	var ids []uint32
+	var removed func(Node)
+	if n.recycling() {
+		removed = func(node Node) { ids = append(ids, uint32(node.ID())) }
+	}
+	collected := n.nodes.collect(Node.IsDead, removed)
+	n.recycleIDs(ids)
+	atomic.AddUint64(&n.statCollected, uint64(collected))
+	atomic.AddUint64(&n.statGCs, 1)
+	n.emit(Event{Kind: EventGC, Collected: collected})
 	return collected
 }
 
-func (n *Network) nextNodeID() uint64 {
-	return atomic.AddUint64(&n.nextID, 1)
+// register adds a node to the registry, which records the peak registry
+// size.
+func (n *Network) register(node Node) {
+	n.nodes.add(node)
+}
+
+// snapshotNodes returns the registered nodes without holding registry
+// locks afterwards, so the caller can mutate the network while iterating.
+func (n *Network) snapshotNodes() []Node {
+	return n.nodes.snapshot()
+}
+
+func (n *Network) nextNodeID() uint32 {
+	return n.ids.take(n.recycling())
 }
 
 func (n *Network) addNodeInternal(typ NodeType, numPorts int) *BaseNode {
-	id := n.nextNodeID()
-	node := &BaseNode{
-		id:    id,
-		typ:   typ,
-		ports: make([]*Port, numPorts),
-	}
-	for i := 0; i < numPorts; i++ {
-		node.ports[i] = &Port{Node: node, Index: i}
-	}
-	n.nodesMu.Lock()
-	if n.nodes == nil {
-		n.nodes = make(map[uint64]Node)
-	}
-	n.nodes[node.id] = node
-	n.nodesMu.Unlock()
+	node := n.newBaseNode()
+	node.id = n.nextNodeID()
+	node.typ = typ
+	node.ports = n.newPorts(node, numPorts)
+	n.register(node)
 	return node
 }
 
@@ -324,26 +405,14 @@ func (n *Network) NewEraser() Node {
 }
 
 func (n *Network) NewReplicator(level int, deltas []int) Node {
-	id := n.nextNodeID()
 	numPorts := 1 + len(deltas) // 0: Principal, 1..n: Aux
-	node := &ReplicatorNode{
-		BaseNode: BaseNode{
-			id:    id,
-			typ:   NodeTypeReplicator,
-			ports: make([]*Port, numPorts),
-		},
-		level:  level,
-		deltas: deltas,
-	}
-	for i := 0; i < numPorts; i++ {
-		node.ports[i] = &Port{Node: node, Index: i}
-	}
-	n.nodesMu.Lock()
-	if n.nodes == nil {
-		n.nodes = make(map[uint64]Node)
-	}
-	n.nodes[node.id] = node
-	n.nodesMu.Unlock()
+	node := n.newReplicatorNode()
+	node.id = n.nextNodeID()
+	node.typ = NodeTypeReplicator
+	node.level = level
+	node.deltas = deltas
+	node.ports = n.newPorts(node, numPorts)
+	n.register(node)
 	return node
 }
 
@@ -363,12 +432,7 @@ func (n *Network) NewData(value interface{}) Node {
 		value: value,
 	}
 	node.ports[0] = &Port{Node: node, Index: 0}
-	n.nodesMu.Lock()
-	if n.nodes == nil {
-		n.nodes = make(map[uint64]Node)
-	}
-	n.nodes[node.id] = node
-	n.nodesMu.Unlock()
+	n.register(node)
 	return node
 }
 
@@ -383,28 +447,48 @@ func (n *Network) NewNative(name string) Node {
 		name: name,
 	}
 	node.ports[0] = &Port{Node: node, Index: 0}
-	n.nodesMu.Lock()
-	if n.nodes == nil {
-		n.nodes = make(map[uint64]Node)
-	}
-	n.nodes[node.id] = node
-	n.nodesMu.Unlock()
+	n.register(node)
+	n.retainNative(name)
 	return node
 }
 
+// RegisterNative registers a pure native function.
 func (n *Network) RegisterNative(name string, fn NativeFunc) {
+	n.RegisterNativeWithCapability(name, fn, CapPure)
+}
+
+// RegisterNativeWithCapability registers a native function together with the
+// capability it requires. Natives whose capability is not allowed by the
+// active Profile are invisible to GetNative.
+func (n *Network) RegisterNativeWithCapability(name string, fn NativeFunc, capability Capability) {
 	n.nativesMu.Lock()
 	defer n.nativesMu.Unlock()
 	n.natives[name] = fn
+	n.nativeCaps[name] = capability
 }
 
+// GetNative looks up a native function, honoring the active Profile.
 func (n *Network) GetNative(name string) (NativeFunc, bool) {
 	n.nativesMu.RLock()
 	defer n.nativesMu.RUnlock()
 	fn, ok := n.natives[name]
+	if !ok {
+		return nil, false
+	}
+	if !n.profile.Allows(n.nativeCaps[name]) {
+		return nil, false
+	}
 	return fn, ok
 }
 
+// NativeCapability returns the capability a registered native requires.
+func (n *Network) NativeCapability(name string) (Capability, bool) {
+	n.nativesMu.RLock()
+	defer n.nativesMu.RUnlock()
+	c, ok := n.nativeCaps[name]
+	return c, ok
+}
+
 // NewIO creates an IO node representing an algebraic effect.
 // The effect is a pure description - no side effects occur during reduction.
 func (n *Network) NewIO(effect *Effect, effectRow EffectRow) Node {
@@ -420,12 +504,7 @@ func (n *Network) NewIO(effect *Effect, effectRow EffectRow) Node {
 		continuation: nil, // Set during reduction when effect is performed
 	}
 	node.ports[0] = &Port{Node: node, Index: 0}
-	n.nodesMu.Lock()
-	if n.nodes == nil {
-		n.nodes = make(map[uint64]Node)
-	}
-	n.nodes[node.id] = node
-	n.nodesMu.Unlock()
+	n.register(node)
 	return node
 }
 
@@ -444,12 +523,7 @@ func (n *Network) NewHandler(scope *HandlerScope) Node {
 	for i := range node.ports {
 		node.ports[i] = &Port{Node: node, Index: i}
 	}
-	n.nodesMu.Lock()
-	if n.nodes == nil {
-		n.nodes = make(map[uint64]Node)
-	}
-	n.nodes[node.id] = node
-	n.nodesMu.Unlock()
+	n.register(node)
 	return node
 }
 
@@ -474,88 +548,16 @@ func (n *Network) PerformEffect(effect *Effect, effectRow EffectRow, captureStat
 		continuation: continuation,
 	}
 	node.ports[0] = &Port{Node: node, Index: 0}
-	n.nodesMu.Lock()
-	if n.nodes == nil {
-		n.nodes = make(map[uint64]Node)
-	}
-	n.nodes[node.id] = node
-	n.nodesMu.Unlock()
+	n.register(node)
 	return node
 }
 
-// Canonicalize prunes all nodes not reachable from the given root (node, port).
-// For every unreachable node, all its connected wires are replaced by erasers.
+// Canonicalize prunes all nodes not reachable from the given root (node, port)
+// or from the registered roots (see SetRoot). For every unreachable node, all
+// its connected wires are replaced by erasers.
 func (n *Network) Canonicalize(root Node, rootPort int) {
-	if n.nodes == nil {
-		return
-	}
-
-	visited := make(map[uint64]bool)
-	var stack []struct {
-		node Node
-		port int
-	}
-	stack = append(stack, struct {
-		node Node
-		port int
-	}{root, rootPort})
-
-	for len(stack) > 0 {
-		el := stack[len(stack)-1]
-		stack = stack[:len(stack)-1]
-		if el.node == nil {
-			continue
-		}
-		id := el.node.ID()
-		if visited[id] {
-			continue
-		}
-		visited[id] = true
-
-		// Visit all neighbor ports connected to this node
-		for _, p := range el.node.Ports() {
-			w := p.Wire.Load()
-			if w == nil {
-				continue
-			}
-			other := w.Other(p)
-			if other == nil {
-				continue
-			}
-			stack = append(stack, struct {
-				node Node
-				port int
-			}{other.Node, other.Index})
-		}
-	}
-
-	// Snapshot nodes to avoid holding lock while mutating the network
-	n.nodesMu.Lock()
-	nodesSnapshot := make([]Node, 0, len(n.nodes))
-	for _, node := range n.nodes {
-		nodesSnapshot = append(nodesSnapshot, node)
-	}
-	n.nodesMu.Unlock()
-
-	// For every node not visited, replace its connections with erasers
-	for _, node := range nodesSnapshot {
-		id := node.ID()
-		if visited[id] {
-			continue
-		}
-		// (debug) previously printed pruned node info here; removed for cleanliness
-		// For each of the node's ports, if connected, splice an eraser in place
-		for _, p := range node.Ports() {
-			w := p.Wire.Load()
-			if w == nil {
-				continue
-			}
-			// Replace the port in the wire with an eraser principal
-			newEra := n.NewEraser()
-			n.splice(newEra.Ports()[0], p)
-		}
-		n.removeNode(node)
-	}
+	roots := append([]Root{{Node: root, Port: rootPort}}, n.Roots()...)
+	n.newErasure(roots).Sweep()
 }
 
 // Link connects two ports.
@@ -563,12 +565,15 @@ func (n *Network) Link(node1 Node, port1 int, node2 Node, port2 int) {
 	n.LinkAt(node1, port1, node2, port2, 0)
 }
 
-// LinkAt connects two ports with a specified depth.
+// LinkAt connects two ports with a specified depth. Ports already linked
+// are unlinked first, leaving their former peers unlinked.
 func (n *Network) LinkAt(node1 Node, port1 int, node2 Node, port2 int, depth uint64) {
 	p1 := node1.Ports()[port1]
 	p2 := node2.Ports()[port2]
+	unlink(p1)
+	unlink(p2)
 
-	wire := &Wire{depth: depth}
+	wire := n.newWire(depth, nil)
 	wire.P0.Store(p1)
 	wire.P1.Store(p2)
 
@@ -577,8 +582,21 @@ func (n *Network) LinkAt(node1 Node, port1 int, node2 Node, port2 int, depth uin
 
 	// Check if this forms an active pair
 	if port1 == 0 && port2 == 0 && isActive(node1) && isActive(node2) {
-		n.wg.Add(1)
-		n.scheduler.Push(wire, int(depth))
+		n.pairs.Add(1)
+		n.schedule(wire, depth, nil)
+	}
+}
+
+// unlink detaches p and its peer from their wire, if any.
+func unlink(p *Port) {
+	w := p.Wire.Load()
+	if w == nil {
+		return
+	}
+	for _, end := range []*Port{w.P0.Swap(nil), w.P1.Swap(nil)} {
+		if end != nil {
+			end.Wire.CompareAndSwap(w, nil)
+		}
 	}
 }
 
@@ -600,16 +618,29 @@ func (n *Network) IsConnected(node1 Node, port1 int, node2 Node, port2 int) bool
 
 // GetLink returns the node connected to the given port.
 func (n *Network) GetLink(node Node, port int) (Node, int) {
-	p := node.Ports()[port]
-	w := p.Wire.Load()
-	if w == nil {
-		return nil, -1
+	if other := node.Ports()[port].Peer(); other != nil {
+		return other.Node, other.Index
 	}
-	other := w.Other(p)
-	if other == nil {
-		return nil, -1
+	return nil, -1
+}
+
+// LinkDepth returns the depth of the wire at the given port, or 0 when the
+// port is unconnected.
+func (n *Network) LinkDepth(node Node, port int) uint64 {
+	if w := node.Ports()[port].Wire.Load(); w != nil {
+		return w.depth
+	}
+	return 0
+}
+
+// Peer returns the port p is linked to, or nil. Hot paths that walk the
+// net port by port use it instead of GetLink: it inlines to two loads and
+// makes no interface call.
+func (p *Port) Peer() *Port {
+	if w := p.Wire.Load(); w != nil {
+		return w.Other(p)
 	}
-	return other.Node, other.Index
+	return nil
 }
 
 func (w *Wire) Other(p *Port) *Port {
@@ -620,87 +651,280 @@ func (w *Wire) Other(p *Port) *Port {
 	return p0
 }
 
-// ReduceAll reduces the network until no more active pairs exist.
-func (n *Network) ReduceAll() {
+// ReduceAll reduces the network until no more active pairs exist. It
+// returns ErrClosed, without reducing, on a closed network, and the
+// *FanOutError or *LevelError of a replicator commutation over a limit
+// that stopped it early (see commutation.go).
+func (n *Network) ReduceAll() error {
+	if n.closed.Load() {
+		return ErrClosed
+	}
+	n.resume()
+	n.reduceAll()
+	return n.Err()
+}
+
+func (n *Network) reduceAll() {
+	if n.closed.Load() {
+		return
+	}
 	n.Start()
-	// Wait for all active pairs to be processed
-	n.wg.Wait()
+	defer n.timePhase(time.Now())
+	// Wait for all active pairs to be processed, compacting the net
+	// whenever it crosses the memory threshold and collecting dead nodes
+	// as the GC policy asks, then for the pairs the interceptor postponed.
+	for {
+		n.pairs.Wait()
+		for n.compacted() || n.collecting() {
+			if n.compacted() {
+				n.compact()
+			} else {
+				n.collect()
+			}
+			n.pairs.Wait()
+		}
+		if !n.requeuePostponed() {
+			return
+		}
+	}
 }
 
 // ReduceWithLimit reduces the network for at most maxReductions steps.
 // Returns the number of reductions performed.
 // Periodically collects garbage (dead nodes) to maintain constant memory for cyclic terms.
+// Reduction runs on the calling goroutine and stops early when no active
+// pairs remain, or at a replicator commutation over a limit, which Err then
+// reports. Background workers are not started, so the net is left exactly
+// as it was after the last step.
 func (n *Network) ReduceWithLimit(maxReductions uint64) uint64 {
-	if maxReductions == 0 {
+	if maxReductions == 0 || n.closed.Load() {
 		return 0
 	}
+	defer n.timePhase(time.Now())
 
-	startCount := n.GetStats().TotalReductions
-	n.Start()
-
-	const gcInterval = 10 // Collect garbage every N reductions
+	n.resume()
+	start := n.GetStats()
 
 	// Process at most maxReductions
 	for i := uint64(0); i < maxReductions; i++ {
-		wire := n.scheduler.Pop()
+		wire := n.tryPop()
 		if wire == nil {
 			break // No more active pairs
 		}
+		if n.guard(wire) {
+			n.park(wire)
+			n.release(wire)
+			break
+		}
 
-		n.reductionMu.Lock()
-		n.reducePair(wire)
+		n.lockReduction()
+		_, ok := n.reducePair(wire, n.statsFor(0))
+		n.release(wire)
+		if ok {
+			n.freeWire(wire)
+		}
 		n.reductionMu.Unlock()
-		n.wg.Done()
+		n.pairs.Done()
 
 		// Periodically collect dead nodes to maintain constant memory
-		if (i+1)%gcInterval == 0 {
+		if n.gc.interval > 0 && (i+1)%n.gc.interval == 0 {
 			n.CollectGarbage()
 		}
 	}
 
-	endCount := n.GetStats().TotalReductions
-	return endCount - startCount
+	return n.StatsSince(start).TotalReductions
+}
+
+// ReduceAt reduces the active pair on node's principal port, if there is
+// one, and reports whether the node was consumed by an interaction. The
+// pair's wire may still be queued; the scheduler skips it once reduced. A
+// replicator commutation over a limit is not reduced, and Err reports it.
+func (n *Network) ReduceAt(node Node) bool {
+	if n.closed.Load() {
+		return false
+	}
+	p := node.Ports()[0]
+	w := p.Wire.Load()
+	if w == nil {
+		return false
+	}
+	other := w.Other(p)
+	if other == nil || other.Index != 0 || !isActive(node) || !isActive(other.Node) {
+		return false
+	}
+	n.resume()
+	if n.guard(w) {
+		return false
+	}
+	n.lockReduction()
+	_, ok := n.reducePair(w, n.statsFor(0))
+	n.reductionMu.Unlock()
+	return ok
+}
+
+// ReduceToWHNF reduces only the active pairs needed to bring the term
+// connected to root's port 0 to weak head normal form, and returns the
+// number of interactions performed. The term is in WHNF once the node
+// facing root presents its principal port (an abstraction, data or
+// native), or when its principal chain ends at an interface Var (a free
+// variable in head position). Pairs below the head are left unreduced.
+// It applies to phase 1 nets, where abstractions face their parent through
+// the principal port. A term without a WHNF, such as Ω, is reduced until
+// the interaction cap (see SetMaxInteractions); ReduceToWHNFContext also
+// stops when a context is done.
+func (n *Network) ReduceToWHNF(root Node) uint64 {
+	count, _ := n.ReduceToWHNFContext(context.Background(), root)
+	return count
+}
+
+// ReduceToWHNFContext is ReduceToWHNF stopping when ctx is done, the
+// interaction cap is reached or the head redex is a replicator commutation
+// over a limit, in which case it returns ctx.Err(), an error wrapping
+// ErrReductionLimit or the commutation's error along with the interactions
+// performed. The head is then left partially reduced.
+func (n *Network) ReduceToWHNFContext(ctx context.Context, root Node) (uint64, error) {
+	if n.closed.Load() {
+		return 0, ErrClosed
+	}
+	var count uint64
+	for {
+		if err := ctx.Err(); err != nil {
+			return count, err
+		}
+		if ops := n.stat(statOps); n.limit.max > 0 && ops >= n.limit.max {
+			return count, fmt.Errorf("%w: %d interactions", ErrReductionLimit, ops)
+		}
+		node, port := n.GetLink(root, 0)
+		if node == nil || port == 0 {
+			return count, nil
+		}
+		// Follow the principal chain down to the head redex.
+		reduced := false
+		seen := make(map[uint64]bool)
+		for !seen[node.ID()] {
+			seen[node.ID()] = true
+			next, nextPort := n.GetLink(node, 0)
+			if next == nil || !isActive(next) {
+				break
+			}
+			if nextPort == 0 {
+				reduced = n.ReduceAt(node)
+				break
+			}
+			node = next
+		}
+		if !reduced {
+			return count, n.Err()
+		}
+		count++
+	}
 }
 
-func (n *Network) worker() {
+func (n *Network) worker(id int) {
+	defer n.running.Done()
+	stats := n.statsFor(id)
+	batch := make([]*Wire, 0, n.batch)
 	for {
-		wire := n.scheduler.Pop()
+		batch = n.popBatch(id, batch[:0])
+		if len(batch) == 0 {
+			return // Closed
+		}
+		for i, wire := range batch {
+			if !n.serve(id, wire, stats) {
+				// Paused: queue the rest of the batch again too, so the
+				// paused net has all its pending pairs in the scheduler.
+				for _, w := range batch[i:] {
+					n.schedule(n.release(w), w.depth, stats)
+				}
+				n.awaitResume()
+				break
+			}
+		}
+		clear(batch)
+	}
+}
+
+// serve reduces a pair taken from the scheduler by worker id, unless the
+// network is closed, halted or intercepts it. It reports false, leaving
+// the pair to the caller, when the network is paused. The pair stays held
+// until it is reduced: another worker may be waiting to reduce the same
+// wire, queued twice, and freeWire must not pool it meanwhile.
+func (n *Network) serve(id int, wire *Wire, stats *workerStats) bool {
+	if n.closed.Load() {
+		n.release(wire)
+		n.pairs.Done()
+		return true
+	}
+	if n.halted() || n.guard(wire) {
+		n.park(wire)
+		n.release(wire)
+		return true
+	}
+	if n.intercepted(wire) {
+		n.release(wire)
+		return true
+	}
+	if !n.enterSafepoint(id) {
+		return false
+	}
+	if n.parallel && !n.checks.on {
+		// Pairs at the same depth reduce concurrently
+		n.enterGate(wire.depth)
+		n.reducePair(wire, stats)
+		n.release(wire)
+		n.gate.leave()
+	} else {
 		// Lock to ensure only one reduction at a time (strict LMO order)
-		n.reductionMu.Lock()
-		n.reducePair(wire)
+		n.lockReduction()
+		_, ok := n.reducePair(wire, stats)
+		n.release(wire)
+		if ok {
+			n.freeWire(wire)
+		}
 		n.reductionMu.Unlock()
-		n.wg.Done()
 	}
+	n.leaveSafepoint(id)
+	n.pairs.Done()
+	return true
 }
 
-func (n *Network) reducePair(w *Wire) {
+// reducePair reduces the active pair on w, counting the interaction in
+// stats, and returns the interaction as a trace event without its Step. It
+// reports false when w no longer holds a redex.
+func (n *Network) reducePair(w *Wire, stats *workerStats) (TraceEvent, bool) {
 	w.mu.Lock()
 	p0 := w.P0.Load()
 	p1 := w.P1.Load()
 
 	if p0 == nil || p1 == nil {
 		w.mu.Unlock()
-		return // Already handled?
+		return TraceEvent{}, false // Already handled?
 	}
 
 	// Verify consistency
 	if p0.Wire.Load() != w || p1.Wire.Load() != w {
 		w.mu.Unlock()
-		return
+		return TraceEvent{}, false
 	}
 
 	a := p0.Node
 	b := p1.Node
 
+	rule, stat, ok := pairRule(a.Type(), b.Type(), n.phase, a.Level() == b.Level())
+	if !ok {
+		w.mu.Unlock()
+		return TraceEvent{}, false
+	}
+
 	// Try to claim nodes
 	if !a.SetDead() {
 		w.mu.Unlock()
-		return
+		return TraceEvent{}, false
 	}
 	if !b.SetDead() {
 		a.Revive()
 		w.mu.Unlock()
-		return
+		return TraceEvent{}, false
 	}
 
 	// Disconnect to prevent double processing
@@ -711,85 +935,64 @@ func (n *Network) reducePair(w *Wire) {
 	w.mu.Unlock()
 
 	depth := w.depth
-
-	// Dispatch based on types
-	atomic.AddUint64(&n.ops, 1)
-	rule := RuleUnknown
-	switch {
-	case a.Type() == b.Type():
-		// Annihilation
-		if a.Type() == NodeTypeReplicator {
-			// Check levels
-			if a.Level() == b.Level() {
-				atomic.AddUint64(&n.statRepAnn, 1)
-				rule = RuleRepRep
-				n.annihilate(a, b)
-			} else {
-				atomic.AddUint64(&n.statRepComm, 1)
-				rule = RuleRepRepComm
-				n.commuteReplicators(a, b, depth)
-			}
-		} else {
-			atomic.AddUint64(&n.statFanAnn, 1)
-			rule = RuleFanFan
-			n.annihilate(a, b)
-		}
-	case a.Type() == NodeTypeEraser || b.Type() == NodeTypeEraser:
-		atomic.AddUint64(&n.statErasure, 1)
-		if a.Type() == NodeTypeEraser {
-			rule = RuleErasure
-			n.erase(a, b)
-		} else {
-			rule = RuleErasure
-			n.erase(b, a)
-		}
-	case (a.Type() == NodeTypeFan && b.Type() == NodeTypeReplicator) || (a.Type() == NodeTypeReplicator && b.Type() == NodeTypeFan):
-		if n.phase == 2 {
-			atomic.AddUint64(&n.statAuxFanRep, 1)
-			rule = RuleAuxFanRep
-			if a.Type() == NodeTypeFan {
-				n.auxFanReplication(a, b, depth)
-			} else {
-				n.auxFanReplication(b, a, depth)
-			}
-		} else {
-			atomic.AddUint64(&n.statFanRepComm, 1)
-			if a.Type() == NodeTypeFan {
-				rule = RuleFanRep
-				n.commuteFanReplicator(a, b, depth)
-			} else {
-				rule = RuleFanRep
-				n.commuteFanReplicator(b, a, depth)
-			}
-		}
-	case (a.Type() == NodeTypeFan && b.Type() == NodeTypePure) || (a.Type() == NodeTypePure && b.Type() == NodeTypeFan):
-		// Fan-Pure interaction: Application of pure function
-		rule = RuleFanNative
-		if a.Type() == NodeTypeFan {
-			n.applyNative(a, b, depth)
+	labeled := n.labelRule(rule, depth)
+	start := n.clock()
+	made := n.traceCreated()
+
+	// Count the interaction together with its rule, so snapshots never see
+	// one without the other.
+	if stat == statOps {
+		stats.add(statOps)
+	} else {
+		stats.interaction(stat)
+	}
+	switch rule {
+	case RuleRepRep, RuleFanFan:
+		n.annihilate(a, b, stats)
+	case RuleRepRepComm:
+		n.commuteReplicators(a, b, depth, stats, made)
+	case RuleErasure:
+		eraser, victim := first(NodeTypeEraser, a, b)
+		n.erase(eraser, victim, stats, made)
+	case RuleAuxFanRep:
+		fan, rep := first(NodeTypeFan, a, b)
+		n.auxFanReplication(fan, rep, depth, stats, made)
+	case RuleFanRep:
+		fan, rep := first(NodeTypeFan, a, b)
+		n.commuteFanReplicator(fan, rep, depth, stats, made)
+	case RuleFanNative:
+		fan, fn := first(NodeTypeFan, a, b)
+		if fn.Type() == NodeTypeData {
+			n.applyData(fan, fn, stats, made)
 		} else {
-			n.applyNative(b, a, depth)
+			n.applyNative(fan, fn, depth, stats, made)
 		}
-	case (a.Type() == NodeTypeFan && b.Type() == NodeTypeData) || (a.Type() == NodeTypeData && b.Type() == NodeTypeFan):
-		// Fan-Data: should not happen in normal reduction (Data comes after Native)
-		// But if it does, treat Data as inert (like Var)
-		rule = RuleUnknown
-		fmt.Printf("Warning: Fan-Data interaction (Fan %d <-> Data %d)\n", a.ID(), b.ID())
-		a.Revive()
-		b.Revive()
+	case RuleRepCopy:
+		rep, leaf := first(NodeTypeReplicator, a, b)
+		n.copyLeaf(rep, leaf, stats, made)
 	default:
-		fmt.Printf("Unknown interaction: %v <-> %v\n", a.Type(), b.Type())
+		n.Logger().Warn("unknown interaction", "a", a.Type(), "b", b.Type())
+	}
+	n.ruleDone(rule, start)
+	unlabel(labeled)
+	n.recordTrace(rule, a, b, depth, made)
+	event := TraceEvent{Rule: rule, AType: a.Type(), AID: a.ID(), BType: b.Type(), BID: b.ID(), Depth: depth}
+	if n.checks.on {
+		n.checkRule(event)
+	}
+	for _, fn := range n.hooks.onReduce {
+		fn(event)
 	}
-	n.recordTrace(rule, a, b)
+	return event, true
 }
 
 // Helper to connect two ports with a NEW wire
 // Internal wires created during commutation get incremented depth for proper LMO ordering
-func (n *Network) connect(p1, p2 *Port, depth uint64) {
+func (n *Network) connect(p1, p2 *Port, depth uint64, stats *workerStats) {
 	// Increment depth for internal structure created during commutation
 	// This ensures inner reductions have lower priority than outer ones (LMO)
 	newDepth := depth + 1
-	wire := &Wire{depth: newDepth}
+	wire := n.newWire(newDepth, stats)
 	wire.P0.Store(p1)
 	wire.P1.Store(p2)
 	p1.Wire.Store(wire)
@@ -797,14 +1000,14 @@ func (n *Network) connect(p1, p2 *Port, depth uint64) {
 
 	// Check for new active pair
 	if p1.Index == 0 && p2.Index == 0 && isActive(p1.Node) && isActive(p2.Node) {
-		n.wg.Add(1)
-		n.scheduler.Push(wire, int(newDepth))
+		n.pairs.Add(1)
+		n.schedule(wire, newDepth, stats)
 	}
 }
 
 // Helper to splice a new port into an existing wire.
 // pNew replaces pOld in the wire.
-func (n *Network) splice(pNew, pOld *Port) {
+func (n *Network) splice(pNew, pOld *Port, stats *workerStats) {
 	for {
 		w := pOld.Wire.Load()
 		if w == nil {
@@ -843,8 +1046,8 @@ func (n *Network) splice(pNew, pOld *Port) {
 		// Check if this forms active pair
 		neighbor := w.Other(pNew)
 		if neighbor != nil && pNew.Index == 0 && neighbor.Index == 0 && isActive(pNew.Node) && isActive(neighbor.Node) {
-			n.wg.Add(1)
-			n.scheduler.Push(w, int(w.depth))
+			n.pairs.Add(1)
+			n.schedule(w, w.depth, stats)
 		}
 
 		w.mu.Unlock()
@@ -853,7 +1056,7 @@ func (n *Network) splice(pNew, pOld *Port) {
 }
 
 // Helper to fuse two existing wires (Annihilation)
-func (n *Network) fuse(p1, p2 *Port) {
+func (n *Network) fuse(p1, p2 *Port, stats *workerStats) {
 	for {
 		w1 := p1.Wire.Load()
 		w2 := p2.Wire.Load()
@@ -927,8 +1130,8 @@ func (n *Network) fuse(p1, p2 *Port) {
 		// Check for new active pair
 		if neighborP1 != nil && neighborP2 != nil {
 			if neighborP1.Index == 0 && neighborP2.Index == 0 && isActive(neighborP1.Node) && isActive(neighborP2.Node) {
-				n.wg.Add(1)
-				n.scheduler.Push(w1, int(w1.depth))
+				n.pairs.Add(1)
+				n.schedule(w1, w1.depth, stats)
 			}
 		}
 
@@ -944,7 +1147,7 @@ func (n *Network) removeNode(node Node) {
 	// No-op in lock-free version (GC handles memory)
 }
 
-func (n *Network) annihilate(a, b Node) {
+func (n *Network) annihilate(a, b Node, stats *workerStats) {
 	// Link corresponding aux ports
 	count := len(a.Ports())
 	if len(b.Ports()) < count {
@@ -952,78 +1155,132 @@ func (n *Network) annihilate(a, b Node) {
 	}
 
 	for i := 1; i < count; i++ {
-		n.fuse(a.Ports()[i], b.Ports()[i])
+		n.fuse(a.Ports()[i], b.Ports()[i], stats)
 	}
 }
 
-func (n *Network) erase(eraser, victim Node) {
-	for i := 1; i < len(victim.Ports()); i++ {
-		// Create new Eraser
-		newEra := n.NewEraser()
-		// Connect new Eraser (Principal 0) to Victim's neighbor (via Aux i)
-		n.splice(newEra.Ports()[0], victim.Ports()[i])
+// erase applies the erasure rule. Fans, replicators and handlers pass
+// erasure on: a new eraser takes the place of each auxiliary port. Data,
+// native and effect nodes have no auxiliary ports, so erasure ends with
+// them. Either way the payload of the victim is released.
+func (n *Network) erase(eraser, victim Node, stats *workerStats, made *createdNodes) {
+	switch victim.Type() {
+	case NodeTypeData, NodeTypePure, NodeTypeEffect:
+		// Leaves: nothing left to erase
+	default:
+		for i := 1; i < len(victim.Ports()); i++ {
+			// Create new Eraser
+			newEra := made.add(n.NewEraser())
+			// Connect new Eraser (Principal 0) to Victim's neighbor (via Aux i)
+			n.splice(newEra.Ports()[0], victim.Ports()[i], stats)
+		}
 	}
 
+	n.releasePayload(victim)
 	n.removeNode(eraser)
 	n.removeNode(victim)
 }
 
-func (n *Network) commuteFanReplicator(fan, rep Node, depth uint64) {
-	// Create copies
-	r1 := n.createReplicatorCopy(rep)
+// copyLeaf connects a copy of a Data or native node to each auxiliary port
+// of rep.
+func (n *Network) copyLeaf(rep, leaf Node, stats *workerStats, made *createdNodes) {
+	for i := 1; i < len(rep.Ports()); i++ {
+		var copy Node
+		if leaf.Type() == NodeTypeData {
+			copy = n.NewData(leaf.GetValue())
+		} else {
+			copy = n.NewNative(leaf.GetName())
+		}
+		n.inheritMeta(copy, leaf)
+		made.add(copy)
+		n.splice(copy.Ports()[0], rep.Ports()[i], stats)
+	}
+
+	n.releasePayload(leaf)
+	n.removeNode(rep)
+	n.removeNode(leaf)
+}
+
+func (n *Network) commuteFanReplicator(fan, rep Node, depth uint64, stats *workerStats, made *createdNodes) {
+	// Create copies; the pair's own nodes serve as the first ones when they
+	// can be reused
+	reuse := n.reusable()
+	var r1 Node
+	if reuse {
+		r1 = n.revive(rep)
+	} else {
+		r1 = n.createReplicatorCopy(rep)
+	}
 	r2 := n.createReplicatorCopy(rep)
+	made.add(r1)
+	made.add(r2)
 
 	// Connect R1, R2 principal to Fan's neighbors
 	if fan.Ports()[1].Wire.Load() != nil {
-		n.splice(r1.Ports()[0], fan.Ports()[1])
+		n.splice(r1.Ports()[0], fan.Ports()[1], stats)
 	}
 	if fan.Ports()[2].Wire.Load() != nil {
-		n.splice(r2.Ports()[0], fan.Ports()[2])
+		n.splice(r2.Ports()[0], fan.Ports()[2], stats)
 	}
 
 	// Create Fan copies
 	numRepAux := len(rep.Ports()) - 1
 	for i := 0; i < numRepAux; i++ {
-		f := n.createFanCopy()
+		var f Node
+		if i == 0 && reuse {
+			f = n.revive(fan)
+		} else {
+			f = n.createFanCopy(fan)
+		}
+		made.add(f)
 
 		// Connect Fan principal to Rep's neighbor
 		if rep.Ports()[i+1].Wire.Load() != nil {
-			n.splice(f.Ports()[0], rep.Ports()[i+1])
+			n.splice(f.Ports()[0], rep.Ports()[i+1], stats)
 		}
 
 		// Connect Fan aux to Rep copies aux
-		n.connect(f.Ports()[1], r1.Ports()[i+1], depth)
-		n.connect(f.Ports()[2], r2.Ports()[i+1], depth)
+		n.connect(f.Ports()[1], r1.Ports()[i+1], depth, stats)
+		n.connect(f.Ports()[2], r2.Ports()[i+1], depth, stats)
 	}
 
 	n.removeNode(fan)
 	n.removeNode(rep)
 }
 
-func (n *Network) auxFanReplication(fan, rep Node, depth uint64) {
+func (n *Network) auxFanReplication(fan, rep Node, depth uint64, stats *workerStats, made *createdNodes) {
 	// In Phase 2, fans are rotated, so the interaction is structurally standard
 	// but semantically "Aux Fan Replication".
-	n.commuteFanReplicator(fan, rep, depth)
+	n.commuteFanReplicator(fan, rep, depth, stats, made)
 }
 
-func (n *Network) commuteReplicators(a, b Node, depth uint64) {
+func (n *Network) commuteReplicators(a, b Node, depth uint64, stats *workerStats, made *createdNodes) {
 	if a.Level() > b.Level() {
-		n.commuteReplicators(b, a, depth)
+		n.commuteReplicators(b, a, depth, stats, made)
 		return
 	}
 
 	// A replicates B
-	// Create N copies of B (B1...BN)
+	// Create N copies of B (B1...BN), reusing B itself as B1 and A as A1
+	// when they can be
+	reuse := n.reusable()
+	level := b.Level()
 	numAAux := len(a.Ports()) - 1
 	bCopies := make([]Node, numAAux)
 	for i := 0; i < numAAux; i++ {
 		delta := a.Deltas()[i]
-		bCopy := n.createReplicatorCopyWithLevel(b, b.Level()+delta)
-		bCopies[i] = bCopy
+		var bCopy Node
+		if r, ok := b.(*ReplicatorNode); ok && i == 0 && reuse {
+			r.level = level + delta
+			bCopy = n.revive(r)
+		} else {
+			bCopy = n.createReplicatorCopyWithLevel(b, level+delta)
+		}
+		bCopies[i] = made.add(bCopy)
 
 		// Connect B_i principal to A's neighbor
 		if a.Ports()[i+1].Wire.Load() != nil {
-			n.splice(bCopy.Ports()[0], a.Ports()[i+1])
+			n.splice(bCopy.Ports()[0], a.Ports()[i+1], stats)
 		}
 	}
 
@@ -1031,17 +1288,22 @@ func (n *Network) commuteReplicators(a, b Node, depth uint64) {
 	numBAux := len(b.Ports()) - 1
 	aCopies := make([]Node, numBAux)
 	for i := 0; i < numBAux; i++ {
-		aCopy := n.createReplicatorCopy(a)
-		aCopies[i] = aCopy
+		var aCopy Node
+		if i == 0 && reuse {
+			aCopy = n.revive(a)
+		} else {
+			aCopy = n.createReplicatorCopy(a)
+		}
+		aCopies[i] = made.add(aCopy)
 
 		// Connect A_i principal to B's neighbor
 		if b.Ports()[i+1].Wire.Load() != nil {
-			n.splice(aCopy.Ports()[0], b.Ports()[i+1])
+			n.splice(aCopy.Ports()[0], b.Ports()[i+1], stats)
 		}
 
 		// Connect A_i aux to B copies aux
 		for k := 0; k < len(bCopies); k++ {
-			n.connect(aCopy.Ports()[k+1], bCopies[k].Ports()[i+1], depth)
+			n.connect(aCopy.Ports()[k+1], bCopies[k].Ports()[i+1], depth, stats)
 		}
 	}
 
@@ -1049,52 +1311,83 @@ func (n *Network) commuteReplicators(a, b Node, depth uint64) {
 	n.removeNode(b)
 }
 
-func (n *Network) createFanCopy() Node {
-	return n.NewFan()
+// reusable reports whether commutations may rewire the nodes of their pair
+// into the result instead of abandoning them for fresh copies. The rules
+// splice each of the pair's auxiliary wires away before reconnecting the
+// port, so a reused node takes the place of its first copy unchanged.
+// Under parallel reduction other workers may still hold ports of the pair
+// they loaded from a wire, so nodes are reused only one pair at a time.
+func (n *Network) reusable() bool {
+	return !n.parallel || n.checks.on
+}
+
+// revive brings back a node of the pair being reduced as one of the
+// rule's results.
+func (n *Network) revive(node Node) Node {
+	node.Revive()
+	return node
+}
+
+func (n *Network) createFanCopy(original Node) Node {
+	f := n.NewFan()
+	n.inheritMeta(f, original)
+	return f
 }
 
 func (n *Network) createReplicatorCopy(original Node) Node {
-	return n.NewReplicator(original.Level(), original.Deltas())
+	return n.createReplicatorCopyWithLevel(original, original.Level())
 }
 
 func (n *Network) createReplicatorCopyWithLevel(original Node, newLevel int) Node {
-	return n.NewReplicator(newLevel, original.Deltas())
+	r := n.NewReplicator(newLevel, original.Deltas())
+	n.inheritMeta(r, original)
+	return r
 }
 
 // applyNative executes a native function application: Fan-Native interaction
 // Fan represents application: Fan.0 = function, Fan.2 = argument, Fan.1 = result
 // Native is the function node
-func (n *Network) applyNative(fan, native Node, depth uint64) {
+func (n *Network) applyNative(fan, native Node, depth uint64, stats *workerStats, made *createdNodes) {
 	// Get the native function
 	nativeName := native.GetName()
 	fn, ok := n.GetNative(nativeName)
 	if !ok {
-		fmt.Printf("Error: Native function %q not registered\n", nativeName)
+		var lookupErr error
+		if capability, registered := n.NativeCapability(nativeName); registered {
+			// Registered, but hidden by the active profile
+			lookupErr = fmt.Errorf("native function %q requires %v: %w", nativeName, capability, ErrCapabilityDenied)
+		} else {
+			n.Logger().Error("native function not registered", "native", nativeName)
+			lookupErr = fmt.Errorf("native function %q not found", nativeName)
+		}
 		// Create error data node
-		errData := n.NewData(fmt.Errorf("native function %q not found", nativeName))
+		errData := made.add(n.NewData(n.locate(fan, lookupErr)))
 		// Connect result to error
 		if fan.Ports()[1].Wire.Load() != nil {
-			n.splice(errData.Ports()[0], fan.Ports()[1])
+			n.splice(errData.Ports()[0], fan.Ports()[1], stats)
 		}
+		n.releasePayload(native)
 		n.removeNode(fan)
 		n.removeNode(native)
 		return
 	}
 
 	// Get the argument from Fan.2
-	argNode, _ := n.GetLink(fan, 2)
+	argPort := fan.Ports()[2].Peer()
 
 	// Check if argument is Data
-	if argNode == nil {
-		fmt.Printf("Error: Native %q applied to nil argument\n", nativeName)
-		errData := n.NewData(fmt.Errorf("nil argument"))
+	if argPort == nil {
+		n.Logger().Error("native applied to nil argument", "native", nativeName)
+		errData := made.add(n.NewData(fmt.Errorf("nil argument")))
 		if fan.Ports()[1].Wire.Load() != nil {
-			n.splice(errData.Ports()[0], fan.Ports()[1])
+			n.splice(errData.Ports()[0], fan.Ports()[1], stats)
 		}
+		n.releasePayload(native)
 		n.removeNode(fan)
 		n.removeNode(native)
 		return
 	}
+	argNode := argPort.Node
 
 	if argNode.Type() == NodeTypeData {
 		// Execute native function with data
@@ -1102,17 +1395,23 @@ func (n *Network) applyNative(fan, native Node, depth uint64) {
 		result, err := fn(value)
 
 		var resultNode Node
+		resultPort := 0
 		if err != nil {
 			// Return error as data
-			resultNode = n.NewData(err)
+			resultNode = n.NewData(n.locate(fan, err))
 		} else {
 			// Check if result is a function (for currying)
 			if resultFn, isFn := result.(func(interface{}) (interface{}, error)); isFn {
 				// Result is a partially applied function - create new Native node
 				// Register it with a unique name
-				partialName := fmt.Sprintf("%s$partial$%d", nativeName, n.nextNodeID())
-				n.RegisterNative(partialName, resultFn)
+				// Partials inherit the capability of the native that produced them.
+				partialName := n.partialName(nativeName)
+				capability, _ := n.NativeCapability(nativeName)
+				n.RegisterNativeWithCapability(partialName, resultFn, capability)
 				resultNode = n.NewNative(partialName)
+			} else if builder, isBuilder := result.(NetBuilder); isBuilder {
+				// Result is structure - build it in place
+				resultNode, resultPort = builder.Build(n)
 			} else {
 				// Result is data
 				resultNode = n.NewData(result)
@@ -1120,11 +1419,14 @@ func (n *Network) applyNative(fan, native Node, depth uint64) {
 		}
 
 		// Connect result to Fan.1
+		made.add(resultNode)
 		if fan.Ports()[1].Wire.Load() != nil {
-			n.splice(resultNode.Ports()[0], fan.Ports()[1])
+			n.splice(resultNode.Ports()[resultPort], fan.Ports()[1], stats)
 		}
 
 		// Remove processed nodes
+		n.releasePayload(native)
+		n.releasePayload(argNode)
 		n.removeNode(fan)
 		n.removeNode(native)
 		n.removeNode(argNode)
@@ -1132,7 +1434,7 @@ func (n *Network) applyNative(fan, native Node, depth uint64) {
 		// Argument is not Data yet - the argument needs to reduce first
 		// This shouldn't happen in normal execution since we reduce arguments before functions
 		// But if it does, we treat this as an error case
-		fmt.Printf("Warning: Native %q applied to non-Data argument (type %v)\n", nativeName, argNode.Type())
+		n.Logger().Warn("native applied to non-data argument", "native", nativeName, "type", argNode.Type())
 
 		// For now, just leave the structure as-is
 		// The reduction will continue with other active wires
@@ -1141,26 +1443,102 @@ func (n *Network) applyNative(fan, native Node, depth uint64) {
 	}
 }
 
+// ErrNotFunction is the error a Data node applied as a function reduces to.
+var ErrNotFunction = errors.New("data applied as a function")
+
+// applyData reduces an application whose function is a Data node: a value
+// cannot be applied, so the result becomes an error Data node wrapping
+// ErrNotFunction and the argument, which is never used, is erased.
+func (n *Network) applyData(fan, data Node, stats *workerStats, made *createdNodes) {
+	err := n.locate(fan, fmt.Errorf("%w: %v", ErrNotFunction, data.GetValue()))
+	n.Logger().Warn("data applied as a function", "fan", fan.ID(), "data", data.ID())
+	errData := made.add(n.NewData(err))
+	if fan.Ports()[1].Wire.Load() != nil {
+		n.splice(errData.Ports()[0], fan.Ports()[1], stats)
+	}
+	if fan.Ports()[2].Wire.Load() != nil {
+		n.splice(made.add(n.NewEraser()).Ports()[0], fan.Ports()[2], stats)
+	}
+	n.releasePayload(data)
+	n.removeNode(fan)
+	n.removeNode(data)
+}
+
 func (n *Network) SetPhase(p int) {
+	if p != n.phase {
+		n.emit(Event{Kind: EventPhase, Phase: p})
+	}
 	if p == 2 && n.phase == 1 {
+		n.annihilatePendingFans()
 		n.phase = 2
 		n.rotateAllFans()
 	} else {
 		n.phase = p
 	}
+	if n.phase > n.maxPhase {
+		n.maxPhase = n.phase
+	}
 }
 
-func (n *Network) rotateAllFans() {
-	n.nodesMu.Lock()
-	nodesSnapshot := make([]Node, 0, len(n.nodes))
-	for _, node := range n.nodes {
-		nodesSnapshot = append(nodesSnapshot, node)
+// annihilatePendingFans reduces the fan pairs still facing each other on
+// their principal ports. Phase 2 has no fan annihilation: once rotated,
+// such a pair would face through its old principal ports, which are
+// auxiliary ones then, and never interact.
+func (n *Network) annihilatePendingFans() {
+	for {
+		progress := false
+		for _, node := range n.snapshotNodes() {
+			if node.Type() != NodeTypeFan || node.IsDead() {
+				continue
+			}
+			w := node.Ports()[0].Wire.Load()
+			if w == nil {
+				continue
+			}
+			other := w.Other(node.Ports()[0])
+			if other == nil || other.Index != 0 || other.Node.Type() != NodeTypeFan {
+				continue
+			}
+			if _, ok := n.reduceWire(w); ok {
+				progress = true
+			}
+		}
+		if !progress {
+			return
+		}
 	}
-	n.nodesMu.Unlock()
+}
+
+func (n *Network) rotateAllFans() {
+	nodesSnapshot := n.snapshotNodes()
 
+	var fans []*BaseNode
 	for _, node := range nodesSnapshot {
 		if node.Type() == NodeTypeFan {
-			n.rotateFan(node.(*BaseNode)) // Assuming Fan is BaseNode, need to check
+			fan := node.(*BaseNode) // Fans are plain BaseNodes
+			n.rotateFan(fan)
+			fans = append(fans, fan)
+		}
+	}
+
+	// Schedule the pairs formed by the new principal ports only once every
+	// fan is rotated: a fan rotated later may turn away a port that faced
+	// an earlier one, and workers must not see a half-rotated net. Two
+	// fans facing each other form one pair, scheduled once.
+	scheduled := make(map[*Wire]bool)
+	for _, fan := range fans {
+		if !isActive(fan) {
+			continue
+		}
+		w := fan.ports[0].Wire.Load()
+		if w == nil || scheduled[w] {
+			continue
+		}
+		other := w.Other(fan.ports[0])
+		if other != nil && other.Index == 0 && isActive(other.Node) {
+			scheduled[w] = true
+			n.pairs.Add(1)
+			n.schedule(w, w.depth, nil)
 		}
 	}
 }
@@ -1182,31 +1560,43 @@ func (n *Network) rotateFan(fan *BaseNode) {
 	fan.ports[0].Index = 0
 	fan.ports[1].Index = 1
 	fan.ports[2].Index = 2
+}
 
-	// Check for active pair on new Principal (p1)
-	if isActive(fan) {
-		w := fan.ports[0].Wire.Load()
-		if w != nil {
-			other := w.Other(fan.ports[0])
-			if other != nil && other.Index == 0 && isActive(other.Node) {
-				n.wg.Add(1)
-				n.scheduler.Push(w, int(w.depth))
-			}
-		}
-	}
+// CanonicalSweep accounts for the work of ApplyCanonicalRules. Report sums
+// the sweeps of a network.
+type CanonicalSweep struct {
+	Sweeps  int           `json:"sweeps"`
+	Visited int           `json:"visited"` // Registered nodes examined
+	Decays  uint64        `json:"decays"`
+	Merges  uint64        `json:"merges"`
+	Etas    uint64        `json:"etas,omitempty"` // See SetEtaContraction
+	Elapsed time.Duration `json:"elapsed_ns"`
+}
+
+// Changed reports whether the sweep decayed or merged any replicator or
+// contracted an eta-redex.
+func (s CanonicalSweep) Changed() bool {
+	return s.Decays > 0 || s.Merges > 0 || s.Etas > 0
 }
 
-// ApplyCanonicalRules applies decay and merge rules to all nodes.
-func (n *Network) ApplyCanonicalRules() bool {
-	startDecay := atomic.LoadUint64(&n.statRepDecay)
-	startMerge := atomic.LoadUint64(&n.statRepMerge)
+func (s *CanonicalSweep) add(o CanonicalSweep) {
+	s.Sweeps += o.Sweeps
+	s.Visited += o.Visited
+	s.Decays += o.Decays
+	s.Merges += o.Merges
+	s.Etas += o.Etas
+	s.Elapsed += o.Elapsed
+}
 
-	n.nodesMu.Lock()
-	nodes := make([]Node, 0, len(n.nodes))
-	for _, node := range n.nodes {
-		nodes = append(nodes, node)
-	}
-	n.nodesMu.Unlock()
+// ApplyCanonicalRules applies decay and merge rules to all nodes, waits for
+// the active pairs they create to be reduced, and returns the work done.
+func (n *Network) ApplyCanonicalRules() CanonicalSweep {
+	began := time.Now()
+	startDecay := n.stat(statRepDecay)
+	startMerge := n.stat(statRepMerge)
+	startEta := n.stat(statEta)
+
+	nodes := n.snapshotNodes()
 
 	for _, node := range nodes {
 		// Check if node is still valid (might have been removed by previous rule)
@@ -1219,85 +1609,47 @@ func (n *Network) ApplyCanonicalRules() bool {
 		}
 
 		if node.Type() == NodeTypeReplicator {
+			start := n.clock()
 			// Check for Decay
 			if len(node.Ports()) == 2 && node.Deltas()[0] == 0 {
 				n.reduceRepDecay(node)
+				n.ruleDone(RuleRepDecay, start)
 				continue
 			}
 			// Check for Merge
 			n.reduceRepMerge(node)
+			n.ruleDone(RuleRepMerge, start)
+		}
+		if n.eta && node.Type() == NodeTypeFan {
+			n.reduceEta(node)
 		}
 	}
 
-	n.wg.Wait()
+	n.pairs.Wait()
 
-	endDecay := atomic.LoadUint64(&n.statRepDecay)
-	endMerge := atomic.LoadUint64(&n.statRepMerge)
-	return endDecay > startDecay || endMerge > startMerge
+	sweep := CanonicalSweep{
+		Sweeps:  1,
+		Visited: len(nodes),
+		Decays:  n.stat(statRepDecay) - startDecay,
+		Merges:  n.stat(statRepMerge) - startMerge,
+		Etas:    n.stat(statEta) - startEta,
+		Elapsed: time.Since(began),
+	}
+	n.sweepsMu.Lock()
+	n.sweeps.add(sweep)
+	n.sweepsMu.Unlock()
+	return sweep
 }
 
 // ApplyErasureCanonization applies the erasure canonicalization step described
 // in the paper: "all parent-child wires starting from the root are traversed
 // and nodes are marked. All non-marked nodes are then erased."
 // This removes disconnected subnets that result from K combinator applications.
+// Traversal starts from the registered roots (see SetRoot); without any, every
+// Var node is taken as a potential root. See ErasureCanonization to run it
+// in bounded steps.
 func (n *Network) ApplyErasureCanonization() {
-	// Find the root node (typically a Var node that's not connected as parent)
-	// For now, mark all nodes and traverse from accessible ones
-	n.nodesMu.Lock()
-	allNodes := make(map[uint64]Node)
-	for id, node := range n.nodes {
-		allNodes[id] = node
-	}
-	n.nodesMu.Unlock()
-
-	// Mark nodes reachable from any Var that could be a root
-	marked := make(map[uint64]bool)
-	var traverse func(Node, int)
-	traverse = func(node Node, port int) {
-		if node == nil || node.IsDead() {
-			return
-		}
-		nodeID := node.ID()
-		if marked[nodeID] {
-			return
-		}
-		marked[nodeID] = true
-
-		// Traverse all connected nodes
-		for i := 0; i < len(node.Ports()); i++ {
-			target, targetPort := n.GetLink(node, i)
-			if target != nil && !target.IsDead() {
-				traverse(target, targetPort)
-			}
-		}
-	}
-
-	// Start traversal from all Var nodes (potential roots/interfaces)
-	for _, node := range allNodes {
-		if node.Type() == NodeTypeVar && !node.IsDead() {
-			traverse(node, 0)
-		}
-	}
-
-	// Erase all unmarked nodes by replacing with erasers
-	for id, node := range allNodes {
-		if !marked[id] && !node.IsDead() {
-			// Replace connections with erasers
-			for i := 0; i < len(node.Ports()); i++ {
-				target, targetPort := n.GetLink(node, i)
-				if target != nil && !target.IsDead() {
-					eraser := n.NewEraser()
-					n.Link(target, targetPort, eraser, 0)
-				}
-			}
-			// Mark node as dead by clearing its wires
-			for i := 0; i < len(node.Ports()); i++ {
-				node.Ports()[i].Wire.Store(nil)
-			}
-		}
-	}
-
-	n.wg.Wait()
+	n.NewErasureCanonization().Sweep()
 }
 
 func (n *Network) reduceRepMerge(rep Node) {
@@ -1353,6 +1705,7 @@ func (n *Network) reduceRepMerge(rep Node) {
 }
 func (n *Network) mergeReplicators(repA, repB Node, auxIndexA int) {
 	// repA Aux[auxIndexA] <-> repB Principal
+	depth := n.LinkDepth(repB, 0)
 
 	// New Deltas
 	newDeltas := make([]int, 0)
@@ -1370,13 +1723,15 @@ func (n *Network) mergeReplicators(repA, repB Node, auxIndexA int) {
 	}
 
 	// Create New Replicator
-	newRep := n.NewReplicator(repA.Level(), newDeltas)
+	made := n.traceCreated()
+	newRep := made.add(n.NewReplicator(repA.Level(), newDeltas))
+	n.inheritMeta(newRep, repA)
 
 	// Connect Principal
 	// repA Principal neighbor <-> newRep Principal
 	pA0 := repA.Ports()[0]
 	if w := pA0.Wire.Load(); w != nil {
-		n.splice(newRep.Ports()[0], pA0)
+		n.splice(newRep.Ports()[0], pA0, nil)
 	}
 
 	// Connect Aux ports
@@ -1387,7 +1742,7 @@ func (n *Network) mergeReplicators(repA, repB Node, auxIndexA int) {
 			for m := 0; m < len(repB.Deltas()); m++ {
 				pB := repB.Ports()[m+1]
 				if w := pB.Wire.Load(); w != nil {
-					n.splice(newRep.Ports()[newPortIdx], pB)
+					n.splice(newRep.Ports()[newPortIdx], pB, nil)
 				}
 				newPortIdx++
 			}
@@ -1395,7 +1750,7 @@ func (n *Network) mergeReplicators(repA, repB Node, auxIndexA int) {
 			// Connect to repA's aux neighbor
 			pA := repA.Ports()[k+1]
 			if w := pA.Wire.Load(); w != nil {
-				n.splice(newRep.Ports()[newPortIdx], pA)
+				n.splice(newRep.Ports()[newPortIdx], pA, nil)
 			}
 			newPortIdx++
 		}
@@ -1403,8 +1758,8 @@ func (n *Network) mergeReplicators(repA, repB Node, auxIndexA int) {
 
 	n.removeNode(repA)
 	n.removeNode(repB)
-	atomic.AddUint64(&n.statRepMerge, 1)
-	n.recordTrace(RuleRepMerge, repA, repB)
+	n.statsFor(0).add(statRepMerge)
+	n.recordTrace(RuleRepMerge, repA, repB, depth, made)
 }
 
 func (n *Network) reduceRepDecay(rep Node) {
@@ -1475,14 +1830,14 @@ func (n *Network) reduceRepDecay(rep Node) {
 		// Check active pair
 		if neighbor0 != nil && neighbor1 != nil {
 			if neighbor0.Index == 0 && neighbor1.Index == 0 && isActive(neighbor0.Node) && isActive(neighbor1.Node) {
-				n.wg.Add(1)
-				n.scheduler.Push(w0, int(w0.depth))
+				n.pairs.Add(1)
+				n.schedule(w0, w0.depth, nil)
 			}
 		}
 
 		n.removeNode(rep)
-		atomic.AddUint64(&n.statRepDecay, 1)
-		n.recordTrace(RuleRepDecay, rep, nil)
+		n.statsFor(0).add(statRepDecay)
+		n.recordTrace(RuleRepDecay, rep, nil, w0.depth, nil)
 
 		if first != second {
 			second.mu.Unlock()
@@ -1496,33 +1851,59 @@ func (n *Network) reduceRepDecay(rep Node) {
 // 1. Phase 1 (LMO interactions + Canonical Rules) until convergence.
 // 2. Phase 2 (Aux Fan Replication).
 // 3. Final Canonicalization (Erasure/Decay).
-func (n *Network) ReduceToNormalForm() {
-	// Phase 1
+//
+// With an interaction cap (see SetMaxInteractions) it stops once the cap is
+// reached. It returns the errors of ReduceToNormalFormContext, ErrClosed
+// on a closed network among them.
+func (n *Network) ReduceToNormalForm() error {
+	return n.ReduceToNormalFormContext(context.Background())
+}
+
+// ReducePhase1 runs only the first phase of ReduceToNormalForm: LMO
+// interactions and canonical rules until neither makes progress. Fans are
+// not rotated and aux fan replication never happens, so the net can be
+// read back as a phase 1 net. Like ReduceToNormalForm it returns
+// ErrClosed on a closed network, or an error wrapping ErrReductionLimit when
+// the interaction cap stops it.
+func (n *Network) ReducePhase1() error {
+	if n.closed.Load() {
+		return ErrClosed
+	}
+	return n.reducePhase1(context.Background())
+}
+
+func (n *Network) reducePhase1(ctx context.Context) error {
 	n.SetPhase(1)
+	n.resume()
 	for {
-		prevOps := atomic.LoadUint64(&n.ops)
-		n.ReduceAll()
-		changed := n.ApplyCanonicalRules()
+		prevOps := n.stat(statOps)
+		n.reduceAll()
+		if err := n.stopped(ctx); err != nil {
+			return err
+		}
+		changed := n.ApplyCanonicalRules().Changed()
 
-		currOps := atomic.LoadUint64(&n.ops)
+		currOps := n.stat(statOps)
 		if currOps == prevOps && !changed {
 			// No progress
-			break
+			return nil
 		}
 	}
-
-	// Phase 2
-	n.SetPhase(2)
-	n.ReduceAll()
-
-	// Final Canonicalization (Decay/Merge)
-	for n.ApplyCanonicalRules() {
-	}
 }
 
+// SetWorkers sets the number of reduction workers, each with its own
+// scheduler shard, statistics counters and safepoint lock. It must be
+// called before reduction starts: it panics once the workers are started
+// (see Start), as they would keep serving the old shards.
 func (n *Network) SetWorkers(w int) {
+	if n.started.Load() {
+		panic("deltanet: SetWorkers called after Start")
+	}
 	if w < 1 {
 		w = 1
 	}
 	n.workers = w
+	n.scheduler.setShards(w)
+	n.setStatWorkers(w)
+	n.pause.setWorkers(w)
 }
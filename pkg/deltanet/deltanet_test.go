@@ -197,7 +197,9 @@ func TestEraserEraserInteraction(t *testing.T) {
 	e2 := net.NewEraser()
 	net.Link(e1, 0, e2, 0)
 	net.ReduceAll()
-	// Success if no hang/crash.
+	if s := net.GetStats(); s.Erasure != 1 || s.FanAnnihilation != 0 {
+		t.Errorf("expected one erasure, got %+v", s)
+	}
 }
 
 // TestEraserReplicatorInteraction tests Eraser erasing a Replicator.
@@ -520,3 +522,109 @@ func verifyEraserConnection(t *testing.T, net *Network, n Node) {
 		t.Errorf("Node should be connected to Eraser, got %v", l)
 	}
 }
+
+// TestReduceWithLimitStopsAtNormalForm tests that ReduceWithLimit returns
+// when no active pairs remain instead of waiting for more work.
+func TestReduceWithLimitStopsAtNormalForm(t *testing.T) {
+	net := NewNetwork()
+	a := net.NewFan()
+	b := net.NewFan()
+	net.Link(a, 0, b, 0)
+	for i := 1; i <= 2; i++ {
+		net.Link(a, i, b, i)
+	}
+
+	if steps := net.ReduceWithLimit(100); steps != 1 {
+		t.Errorf("Expected 1 reduction, got %d", steps)
+	}
+}
+
+// TestReduceAt tests reducing a single chosen active pair.
+func TestReduceAt(t *testing.T) {
+	net := NewNetwork()
+	a := net.NewFan()
+	b := net.NewFan()
+	net.Link(a, 0, b, 0)
+	x, y := net.NewVar(), net.NewVar()
+	net.Link(a, 1, x, 0)
+	net.Link(b, 1, y, 0)
+	net.Link(a, 2, net.NewVar(), 0)
+	net.Link(b, 2, net.NewVar(), 0)
+
+	if net.ReduceAt(x) {
+		t.Error("Var has no active pair")
+	}
+	if !net.ReduceAt(a) {
+		t.Fatal("Expected fan pair to be reduced")
+	}
+	if other, _ := net.GetLink(x, 0); other != y {
+		t.Errorf("Expected annihilation to connect x and y, got %v", other)
+	}
+	// The stale queued wire is skipped by later reductions.
+	net.ReduceAll()
+	if net.GetStats().FanAnnihilation != 1 {
+		t.Errorf("Expected a single annihilation, got %d", net.GetStats().FanAnnihilation)
+	}
+}
+
+// TestReplicatorCopiesData tests that a replicator meeting a Data or native
+// node connects a copy to each of its uses.
+func TestReplicatorCopiesData(t *testing.T) {
+	net := NewNetwork()
+	rep := net.NewReplicator(0, []int{0, 0})
+	a, b := net.NewVar(), net.NewVar()
+	net.Link(rep, 1, a, 0)
+	net.Link(rep, 2, b, 0)
+	net.Link(rep, 0, net.NewData(5), 0)
+	net.ReduceAll()
+
+	for _, v := range []Node{a, b} {
+		if data, _ := net.GetLink(v, 0); data == nil || data.Type() != NodeTypeData || data.GetValue() != 5 {
+			t.Errorf("expected a copy of Data 5, got %v", data)
+		}
+	}
+
+	rep = net.NewReplicator(0, []int{0, 0})
+	net.Link(rep, 1, a, 0)
+	net.Link(rep, 2, b, 0)
+	net.Link(rep, 0, net.NewNative("neg"), 0)
+	net.ReduceAll()
+	for _, v := range []Node{a, b} {
+		if native, _ := net.GetLink(v, 0); native == nil || native.GetName() != "neg" {
+			t.Errorf("expected a copy of native neg, got %v", native)
+		}
+	}
+	if net.GetStats().DataCopy != 2 {
+		t.Errorf("expected 2 copies, got %d", net.GetStats().DataCopy)
+	}
+}
+
+// TestLinkAtRelinks tests that linking a port that is already linked moves
+// it to the new wire and leaves its former peer unlinked rather than on a
+// wire that no longer holds the port, which Validate would report.
+func TestLinkAtRelinks(t *testing.T) {
+	net := NewNetwork()
+	fan := net.NewFan()
+	old, fresh := net.NewVar(), net.NewVar()
+	net.Link(fan, 1, old, 0)
+	net.Link(fan, 1, fresh, 0)
+
+	if !net.IsConnected(fan, 1, fresh, 0) {
+		t.Error("relinked port is not on the new wire")
+	}
+	if w := old.Ports()[0].Wire.Load(); w != nil {
+		t.Errorf("former peer kept a wire holding %v", w.Other(old.Ports()[0]))
+	}
+	if err := net.Validate(); err != nil {
+		t.Errorf("relinking left an invalid net: %v", err)
+	}
+
+	// Relinking an end of a queued pair cancels it.
+	a, b := net.NewEraser(), net.NewFan()
+	net.Link(a, 0, b, 0)
+	net.Link(b, 0, net.NewVar(), 0)
+	net.ReduceAll()
+	if a.IsDead() || b.IsDead() || net.GetStats().Erasure != 0 {
+		t.Error("the pair relinked away was reduced")
+	}
+}
@@ -0,0 +1,88 @@
+package deltanet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrLikelyDivergent is returned by ReduceMonitored when reduction shows no
+// sign of reaching a normal form.
+var ErrLikelyDivergent = errors.New("reduction is likely divergent")
+
+// DivergenceMonitor configures the heuristic used by ReduceMonitored.
+// Reduction is observed in windows of Window interactions. A window is
+// suspicious when no new head constructor reached the root during it and
+// the live net stayed within SizeSlack nodes of its size when the
+// suspicious streak began, the signature of Ω-like terms that rewrite
+// themselves forever in bounded space. Reduction stops after Repeats
+// consecutive suspicious windows, or as soon as a suspicious window ends
+// with a net whose Fingerprint was already seen during the streak. Zero
+// fields take their defaults.
+type DivergenceMonitor struct {
+	Window    uint64 // Interactions per window (default 10000)
+	Repeats   int    // Suspicious windows before stopping (default 3)
+	SizeSlack int    // Live node growth still considered bounded (default 64)
+}
+
+func (m DivergenceMonitor) withDefaults() DivergenceMonitor {
+	if m.Window == 0 {
+		m.Window = 10000
+	}
+	if m.Repeats <= 0 {
+		m.Repeats = 3
+	}
+	if m.SizeSlack <= 0 {
+		m.SizeSlack = 64
+	}
+	return m
+}
+
+// ReduceMonitored reduces the network like ReduceWithLimit, window by
+// window, until no active pairs remain or the monitor flags probable
+// divergence, in which case it returns an error wrapping
+// ErrLikelyDivergent. Root progress is observed on the node connected to
+// root's port 0. The heuristic can flag long computations that run in
+// bounded space without producing output; raise Window or Repeats for
+// those.
+func (n *Network) ReduceMonitored(root Node, m DivergenceMonitor) error {
+	if n.closed.Load() {
+		return ErrClosed
+	}
+	m = m.withDefaults()
+	var total uint64
+	lastHead, _ := n.GetLink(root, 0)
+	streak, baseline := 0, 0
+	seen := make(map[Fingerprint]bool)
+	for {
+		steps := n.ReduceWithLimit(m.Window)
+		total += steps
+		if steps < m.Window {
+			return n.Err()
+		}
+
+		head, port := n.GetLink(root, 0)
+		progress := head != nil && port == 0 && head != lastHead
+		lastHead = head
+
+		n.CollectGarbage()
+		live := n.ActiveNodeCount()
+		switch {
+		case progress:
+			streak = 0
+			continue
+		case streak == 0 || live > baseline+m.SizeSlack:
+			streak, baseline = 1, live
+			clear(seen)
+		default:
+			streak++
+		}
+		if streak > m.Repeats {
+			return fmt.Errorf("%w: no progress after %d interactions with %d live nodes", ErrLikelyDivergent, total, live)
+		}
+		f := n.Fingerprint(root)
+		if seen[f] {
+			return fmt.Errorf("%w: net repeated after %d interactions", ErrLikelyDivergent, total)
+		}
+		seen[f] = true
+	}
+}
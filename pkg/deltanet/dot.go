@@ -0,0 +1,257 @@
+package deltanet
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// TermRole is the part of a lambda term a node was built for.
+type TermRole uint8
+
+const (
+	RoleNone TermRole = iota
+	RoleAbs           // Abstraction fan
+	RoleApp           // Application fan
+	RoleVar           // Variable, or the replicator sharing it
+	RoleLit           // Literal
+)
+
+// TermLayout describes where in the source term a node comes from.
+type TermLayout struct {
+	Role   TermRole
+	Depth  int    // Nesting depth of the term, 0 for the whole term
+	Scope  uint64 // Binder of the innermost abstraction around the term, 0 at top level
+	Binder uint64 // For RoleAbs, an ID naming the abstraction's own scope
+	Name   string // For RoleAbs, the bound variable
+}
+
+// LayoutHint is implemented by node metadata (see SetMeta) that tells
+// WriteDOT which term a node was built for. Nodes copied during reduction
+// inherit their original's hint.
+type LayoutHint interface {
+	TermLayout() TermLayout
+}
+
+// WriteDOT renders the nodes reachable from roots as a Graphviz graph.
+// Nodes are named n0, n1, ... in the breadth-first order used by
+// Fingerprint, so the output does not depend on node IDs and isomorphic
+// nets render identically. Each wire is one edge labelled with the port
+// numbers at both ends. Each agent type has its own shape, principal ports
+// end their edges with a dot, and wires between the principal ports of two
+// agents (active pairs) are drawn bold and red.
+//
+// When nodes carry a LayoutHint the drawing follows the source term: each
+// abstraction is a cluster holding its body, nested as in the term, and
+// edges between hinted nodes run from the shallower to the deeper term, at
+// least as many ranks apart as the terms are nested.
+func (n *Network) WriteDOT(w io.Writer, roots ...Node) error {
+	order, index := n.reachable(roots...)
+	layout := n.dotLayout(order)
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "graph net {")
+	if layout == nil {
+		for i, node := range order {
+			fmt.Fprintf(bw, "\tn%d [%s];\n", i, dotNode(node))
+		}
+	} else {
+		layout.write(bw, order)
+	}
+	for i, node := range order {
+		for port := range node.Ports() {
+			next, nextPort := n.GetLink(node, port)
+			if next == nil {
+				continue
+			}
+			j := index[next.ID()]
+			if j < i || (j == i && nextPort < port) {
+				continue // Drawn from the other end
+			}
+			tail, head, tailPort, headPort := i, j, port, nextPort
+			minlen := -1
+			if layout != nil {
+				a, aok := layout.hints[i]
+				b, bok := layout.hints[j]
+				if aok && bok {
+					if b.Depth < a.Depth {
+						tail, head, tailPort, headPort = j, i, nextPort, port
+						a, b = b, a
+					}
+					minlen = b.Depth - a.Depth
+				}
+			}
+			attrs := fmt.Sprintf("taillabel=%d, headlabel=%d", tailPort, headPort)
+			if minlen >= 0 {
+				attrs += fmt.Sprintf(", minlen=%d", minlen)
+			}
+			tailAgent, headAgent := node.Type() != NodeTypeVar, next.Type() != NodeTypeVar
+			if tail != i {
+				tailAgent, headAgent = headAgent, tailAgent
+			}
+			if ends := dotPrincipal(tailPort == 0 && tailAgent, headPort == 0 && headAgent); ends != "" {
+				attrs += ", " + ends
+			}
+			if port == 0 && nextPort == 0 && node.Type() != NodeTypeVar && next.Type() != NodeTypeVar {
+				attrs += ", style=bold, color=red"
+			}
+			fmt.Fprintf(bw, "\tn%d -- n%d [%s];\n", tail, head, attrs)
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// dotLayout holds the layout hints of the rendered nodes and the clusters
+// they form, keyed by binder.
+type dotLayout struct {
+	hints    map[int]TermLayout  // Node index -> hint
+	members  map[uint64][]int    // Binder -> nodes directly in its cluster, 0 for none
+	children map[uint64][]uint64 // Binder -> nested binders, in order of appearance
+	names    map[uint64]string
+	clusters map[uint64]int // Binder -> cluster number, in order of appearance
+}
+
+// dotLayout collects the hints of order, or returns nil when there are
+// none.
+func (n *Network) dotLayout(order []Node) *dotLayout {
+	l := &dotLayout{
+		hints:    make(map[int]TermLayout),
+		members:  make(map[uint64][]int),
+		children: make(map[uint64][]uint64),
+		names:    make(map[uint64]string),
+		clusters: make(map[uint64]int),
+	}
+	parents := make(map[uint64]uint64)
+	for i, node := range order {
+		meta, ok := n.Meta(node.ID())
+		if !ok {
+			continue
+		}
+		hint, ok := meta.(LayoutHint)
+		if !ok {
+			continue
+		}
+		h := hint.TermLayout()
+		l.hints[i] = h
+		key := h.Scope
+		if h.Role == RoleAbs && h.Binder != 0 {
+			key = h.Binder
+			if _, seen := parents[key]; !seen && key != h.Scope {
+				parents[key] = h.Scope
+				l.names[key] = h.Name
+			}
+		}
+		l.members[key] = append(l.members[key], i)
+		if _, seen := l.clusters[key]; !seen && key != 0 {
+			l.clusters[key] = len(l.clusters)
+		}
+	}
+	if len(l.hints) == 0 {
+		return nil
+	}
+	// Nest clusters in order of appearance. A binder whose abstraction is
+	// not rendered is placed at the top level.
+	binders := make([]uint64, len(l.clusters))
+	for b, c := range l.clusters {
+		binders[c] = b
+	}
+	for _, b := range binders {
+		parent := parents[b]
+		if _, ok := l.clusters[parent]; !ok || l.nested(parent, b, parents) {
+			parent = 0
+		}
+		l.children[parent] = append(l.children[parent], b)
+	}
+	return l
+}
+
+// nested reports whether binder b encloses p, which would make p's cluster
+// a cycle if b were nested in it.
+func (l *dotLayout) nested(p, b uint64, parents map[uint64]uint64) bool {
+	for seen := 0; p != 0 && seen <= len(parents); seen++ {
+		if p == b {
+			return true
+		}
+		p = parents[p]
+	}
+	return false
+}
+
+// write declares the nodes, inside the clusters of their binders.
+func (l *dotLayout) write(w io.Writer, order []Node) {
+	clustered := make(map[int]bool)
+	for b, members := range l.members {
+		for _, i := range members {
+			clustered[i] = b != 0
+		}
+	}
+	for i, node := range order {
+		if !clustered[i] {
+			fmt.Fprintf(w, "\tn%d [%s];\n", i, dotNode(node))
+		}
+	}
+	for _, b := range l.children[0] {
+		l.writeCluster(w, order, b, "\t")
+	}
+}
+
+func (l *dotLayout) writeCluster(w io.Writer, order []Node, binder uint64, indent string) {
+	fmt.Fprintf(w, "%ssubgraph cluster_%d {\n", indent, l.clusters[binder])
+	fmt.Fprintf(w, "%s\tlabel=%s;\n", indent, strconv.Quote("λ"+l.names[binder]))
+	for _, i := range l.members[binder] {
+		fmt.Fprintf(w, "%s\tn%d [%s];\n", indent, i, dotNode(order[i]))
+	}
+	for _, child := range l.children[binder] {
+		l.writeCluster(w, order, child, indent+"\t")
+	}
+	fmt.Fprintf(w, "%s}\n", indent)
+}
+
+// dotShapes gives each agent type its own node shape.
+var dotShapes = map[NodeType]string{
+	NodeTypeFan:        "triangle",
+	NodeTypeReplicator: "invtriangle",
+	NodeTypeEraser:     "circle",
+	NodeTypeVar:        "plaintext",
+	NodeTypeData:       "box",
+	NodeTypePure:       "box, style=rounded",
+	NodeTypeEffect:     "diamond",
+	NodeTypeHandler:    "hexagon",
+}
+
+// dotNode returns the attributes of a node's declaration.
+func dotNode(node Node) string {
+	return fmt.Sprintf("label=%s, shape=%s", strconv.Quote(dotLabel(node)), dotShapes[node.Type()])
+}
+
+// dotPrincipal marks the ends of an edge that are principal ports of an
+// agent with a dot.
+func dotPrincipal(tail, head bool) string {
+	if !tail && !head {
+		return ""
+	}
+	arrow := func(principal bool) string {
+		if principal {
+			return "dot"
+		}
+		return "none"
+	}
+	return fmt.Sprintf("dir=both, arrowtail=%s, arrowhead=%s", arrow(tail), arrow(head))
+}
+
+// dotLabel names a node with the attributes Fingerprint hashes.
+func dotLabel(node Node) string {
+	switch node.Type() {
+	case NodeTypeReplicator:
+		return fmt.Sprintf("Replicator %d %v", node.Level(), node.Deltas())
+	case NodeTypeData:
+		return fmt.Sprintf("Data %v", node.GetValue())
+	case NodeTypePure:
+		return "Pure " + node.GetName()
+	case NodeTypeEffect:
+		return "Effect " + node.GetEffect().Name
+	default:
+		return node.Type().String()
+	}
+}
@@ -0,0 +1,173 @@
+package deltanet
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite golden files in testdata")
+
+// ruleCases builds one active pair per interaction rule, with every
+// auxiliary port wired to a Var so the rule's rewiring is visible. They
+// return the Vars as the roots to render from.
+var ruleCases = []struct {
+	rule  RuleKind
+	build func(net *Network) []Node
+}{
+	{RuleFanFan, func(net *Network) []Node {
+		a, b := net.NewFan(), net.NewFan()
+		net.Link(a, 0, b, 0)
+		return boundary(net, a, 1, 2, b, 1, 2)
+	}},
+	{RuleRepRep, func(net *Network) []Node {
+		a, b := net.NewReplicator(0, []int{0, 1}), net.NewReplicator(0, []int{0, 1})
+		net.Link(a, 0, b, 0)
+		return boundary(net, a, 1, 2, b, 1, 2)
+	}},
+	{RuleRepRepComm, func(net *Network) []Node {
+		a, b := net.NewReplicator(0, []int{0, 0}), net.NewReplicator(1, []int{0, 0})
+		net.Link(a, 0, b, 0)
+		return boundary(net, a, 1, 2, b, 1, 2)
+	}},
+	{RuleFanRep, func(net *Network) []Node {
+		fan, rep := net.NewFan(), net.NewReplicator(0, []int{0, 0})
+		net.Link(fan, 0, rep, 0)
+		return boundary(net, fan, 1, 2, rep, 1, 2)
+	}},
+	{RuleErasure, func(net *Network) []Node {
+		era, fan := net.NewEraser(), net.NewFan()
+		net.Link(era, 0, fan, 0)
+		return boundary(net, fan, 1, 2)
+	}},
+	{RuleRepCopy, func(net *Network) []Node {
+		rep, data := net.NewReplicator(0, []int{0, 0}), net.NewData(7)
+		net.Link(rep, 0, data, 0)
+		return boundary(net, rep, 1, 2)
+	}},
+	{RuleFanNative, func(net *Network) []Node {
+		net.RegisterNative("inc", func(v interface{}) (interface{}, error) {
+			return v.(int) + 1, nil
+		})
+		fan, native := net.NewFan(), net.NewNative("inc")
+		net.Link(fan, 0, native, 0)
+		net.Link(fan, 2, net.NewData(41), 0)
+		return boundary(net, fan, 1)
+	}},
+}
+
+// boundary links a new Var to each listed port. Arguments alternate a node
+// and the ports of it to wire.
+func boundary(net *Network, spec ...interface{}) []Node {
+	var vars []Node
+	var node Node
+	for _, x := range spec {
+		switch v := x.(type) {
+		case Node:
+			node = v
+		case int:
+			root := net.NewVar()
+			net.Link(root, 0, node, v)
+			vars = append(vars, root)
+		}
+	}
+	return vars
+}
+
+// TestRuleGoldenDOT renders each rule's active pair before and after one
+// interaction and compares both with the DOT files in testdata/rules. The
+// rendering is canonical, so a diff means the rule's topology changed. Run
+// with -update to rewrite the files after an intended change.
+func TestRuleGoldenDOT(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	for _, tc := range ruleCases {
+		t.Run(tc.rule.String(), func(t *testing.T) {
+			net := NewNetwork()
+			net.EnableTrace(4)
+			roots := tc.build(net)
+
+			var before, after bytes.Buffer
+			if err := net.WriteDOT(&before, roots...); err != nil {
+				t.Fatal(err)
+			}
+			if got := net.ReduceWithLimit(1); got != 1 {
+				t.Fatalf("expected one interaction, got %d", got)
+			}
+			if events := net.TraceSnapshot(); len(events) != 1 || events[0].Rule != tc.rule {
+				t.Fatalf("expected a single %v interaction, got %v", tc.rule, events)
+			}
+			if err := net.WriteDOT(&after, roots...); err != nil {
+				t.Fatal(err)
+			}
+
+			checkGolden(t, tc.rule.String()+".before.dot", before.Bytes())
+			checkGolden(t, tc.rule.String()+".after.dot", after.Bytes())
+		})
+	}
+}
+
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", "rules", name)
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s differs from golden file:\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+type testHint TermLayout
+
+func (h testHint) TermLayout() TermLayout { return TermLayout(h) }
+
+// TestWriteDOTLayoutHints tests that hinted nodes are clustered by binder
+// and that their edges point from the shallower term to the deeper one.
+func TestWriteDOTLayoutHints(t *testing.T) {
+	net := NewNetwork()
+	out, arg, abs, body := net.NewVar(), net.NewVar(), net.NewFan(), net.NewFan()
+	net.Link(out, 0, abs, 0)
+	net.Link(abs, 1, body, 1)
+	net.Link(abs, 2, body, 0)
+	net.Link(body, 2, arg, 0)
+	net.SetMeta(abs, testHint{Role: RoleAbs, Depth: 0, Binder: 100, Name: "x"})
+	net.SetMeta(body, testHint{Role: RoleApp, Depth: 2, Scope: 100})
+
+	// Rendering from arg reaches the body before the abstraction, so its
+	// edges to it are turned around.
+	var buf bytes.Buffer
+	if err := net.WriteDOT(&buf, arg); err != nil {
+		t.Fatal(err)
+	}
+	want := `graph net {
+	n0 [label="Var", shape=plaintext];
+	n3 [label="Var", shape=plaintext];
+	subgraph cluster_0 {
+		label="λx";
+		n1 [label="Fan", shape=triangle];
+		n2 [label="Fan", shape=triangle];
+	}
+	n0 -- n1 [taillabel=0, headlabel=2];
+	n2 -- n1 [taillabel=2, headlabel=0, minlen=2, dir=both, arrowtail=none, arrowhead=dot];
+	n2 -- n1 [taillabel=1, headlabel=1, minlen=2];
+	n2 -- n3 [taillabel=0, headlabel=0, dir=both, arrowtail=dot, arrowhead=none];
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
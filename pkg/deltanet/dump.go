@@ -0,0 +1,59 @@
+package deltanet
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Dump writes the live nodes in ID order, one per line, with their
+// attributes and the port each of their ports is linked to:
+//
+//	#3 Replicator level=1 deltas=[0 1]  0:#2.1 1:#5.0 2:-
+//	#4 Data 42  0:#3.2
+//
+// A link is written as #ID.port; "-" marks an unlinked port and "*" after
+// a link marks an active pair. Dead nodes that are still registered are
+// listed with "(dead)". The network must not be reducing, or must be
+// paused (see Pause).
+func (n *Network) Dump(w io.Writer) error {
+	nodes := n.snapshotNodes()
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID() < nodes[j].ID() })
+	bw := bufio.NewWriter(w)
+	for _, node := range nodes {
+		n.dumpNode(bw, node)
+	}
+	return bw.Flush()
+}
+
+// dumpNode writes the line of node, as in Dump.
+func (n *Network) dumpNode(w io.Writer, node Node) {
+	fmt.Fprintf(w, "#%d %s", node.ID(), dumpLabel(node))
+	if node.IsDead() {
+		fmt.Fprint(w, " (dead)")
+	}
+	fmt.Fprint(w, " ")
+	for port := range node.Ports() {
+		next, nextPort := n.GetLink(node, port)
+		if next == nil {
+			fmt.Fprintf(w, " %d:-", port)
+			continue
+		}
+		fmt.Fprintf(w, " %d:#%d.%d", port, next.ID(), nextPort)
+		if port == 0 && nextPort == 0 && isActive(node) && isActive(next) {
+			fmt.Fprint(w, "*")
+		}
+	}
+	fmt.Fprintln(w)
+}
+
+// dumpLabel names a node with its attributes, as in Dump.
+func dumpLabel(node Node) string {
+	switch node.Type() {
+	case NodeTypeReplicator:
+		return fmt.Sprintf("Replicator level=%d deltas=%v", node.Level(), node.Deltas())
+	default:
+		return dotLabel(node)
+	}
+}
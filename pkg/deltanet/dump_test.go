@@ -0,0 +1,28 @@
+package deltanet
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	n := NewNetwork()
+	out := n.NewVar()
+	rep := n.NewReplicator(1, []int{0, 1})
+	data := n.NewData(42)
+	n.Link(out, 0, rep, 1)
+	n.Link(rep, 0, data, 0)
+
+	var buf strings.Builder
+	if err := n.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf(`#%[1]d Var  0:#%[2]d.1
+#%[2]d Replicator level=1 deltas=[0 1]  0:#%[3]d.0* 1:#%[1]d.0 2:-
+#%[3]d Data 42  0:#%[2]d.0*
+`, out.ID(), rep.ID(), data.ID())
+	if got := buf.String(); got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -1,5 +1,12 @@
 package deltanet
 
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
 // Effect represents a single algebraic effect to be performed.
 type Effect struct {
 	Name    string      // Effect name: "Print", "Exception", "State.Get", etc.
@@ -20,6 +27,16 @@ func (row EffectRow) Contains(effectName string) bool {
 	return false
 }
 
+// Covers checks if an effect is matched by any name or pattern in the row.
+func (row EffectRow) Covers(effectName string) bool {
+	for _, pattern := range row {
+		if EffectMatches(pattern, effectName) {
+			return true
+		}
+	}
+	return false
+}
+
 // Remove returns a new EffectRow without the specified effect.
 func (row EffectRow) Remove(effectName string) EffectRow {
 	result := make(EffectRow, 0, len(row))
@@ -79,40 +96,202 @@ func (k *Continuation) Resume(value interface{}) (interface{}, error) {
 //   - Choice: call n times with different values
 type EffectHandler func(effect Effect, resume *Continuation) (interface{}, error)
 
+// Effect names are hierarchical, with dot-separated namespaces
+// ("State.Get", "State.Cell.Put"). A handler may be registered for an exact
+// name, for a whole namespace with the pattern "State.*", or for every
+// effect with "*". Lookup prefers the exact name, then the innermost
+// matching namespace, then "*".
+
+// EffectWildcard is the pattern matching every effect.
+const EffectWildcard = "*"
+
+// EffectMatches reports whether an effect name matches a registration
+// pattern: an exact name, a namespace pattern "NS.*" or "*".
+func EffectMatches(pattern, effectName string) bool {
+	if pattern == EffectWildcard || pattern == effectName {
+		return true
+	}
+	ns, ok := strings.CutSuffix(pattern, ".*")
+	return ok && strings.HasPrefix(effectName, ns+".")
+}
+
+// effectPatterns lists the patterns that can match an effect name, most
+// specific first.
+func effectPatterns(effectName string) []string {
+	patterns := []string{effectName}
+	for i := strings.LastIndexByte(effectName, '.'); i > 0; i = strings.LastIndexByte(effectName[:i], '.') {
+		patterns = append(patterns, effectName[:i]+".*")
+	}
+	return append(patterns, EffectWildcard)
+}
+
 // HandlerScope manages a set of effect handlers.
 // Handlers are applied innermost-first during reduction.
 type HandlerScope struct {
-	Handlers map[string]EffectHandler // Effect name -> handler
-	Handled  EffectRow                // Effects this scope handles
+	Handlers     map[string]EffectHandler // Effect name or pattern -> handler
+	Handled      EffectRow                // Effects this scope handles
+	Capabilities map[string]Capability    // Effect name -> required capability
+	Profile      *Profile                 // Sandbox profile (nil = unrestricted)
 }
 
 // NewHandlerScope creates a new handler scope.
 func NewHandlerScope() *HandlerScope {
 	return &HandlerScope{
-		Handlers: make(map[string]EffectHandler),
-		Handled:  make(EffectRow, 0),
+		Handlers:     make(map[string]EffectHandler),
+		Handled:      make(EffectRow, 0),
+		Capabilities: make(map[string]Capability),
 	}
 }
 
 // Register adds a handler for an effect.
+// Handlers registered this way are considered IO-capable.
 func (hs *HandlerScope) Register(effectName string, handler EffectHandler) {
+	hs.RegisterWithCapability(effectName, handler, CapIO)
+}
+
+// RegisterNamespace adds a handler for every effect in a namespace, e.g.
+// "State" handles State.Get and State.Put.
+func (hs *HandlerScope) RegisterNamespace(namespace string, handler EffectHandler) {
+	hs.Register(namespace+".*", handler)
+}
+
+// Forward registers a handler that delegates effects matching pattern to
+// another scope, e.g. to pass a namespace through to an outer handler.
+func (hs *HandlerScope) Forward(pattern string, target *HandlerScope) {
+	hs.Register(pattern, target.Handle)
+}
+
+// RegisterWithCapability adds a handler for an effect or pattern together
+// with the capability it requires.
+func (hs *HandlerScope) RegisterWithCapability(effectName string, handler EffectHandler, capability Capability) {
 	hs.Handlers[effectName] = handler
+	if hs.Capabilities == nil {
+		hs.Capabilities = make(map[string]Capability)
+	}
+	hs.Capabilities[effectName] = capability
 	if !hs.Handled.Contains(effectName) {
 		hs.Handled = append(hs.Handled, effectName)
 	}
 }
 
+// resolve returns the most specific registered pattern matching an effect.
+func (hs *HandlerScope) resolve(effectName string) (string, EffectHandler, bool) {
+	for _, pattern := range effectPatterns(effectName) {
+		if handler, ok := hs.Handlers[pattern]; ok {
+			return pattern, handler, true
+		}
+	}
+	return "", nil, false
+}
+
+// lookup returns the handler for an effect if it is visible under the
+// scope's profile.
+func (hs *HandlerScope) lookup(effectName string) (EffectHandler, bool) {
+	pattern, handler, ok := hs.resolve(effectName)
+	if !ok {
+		return nil, false
+	}
+	if !hs.Profile.Allows(hs.capability(pattern)) {
+		return nil, false
+	}
+	return handler, true
+}
+
+// capability returns the capability required by the handler registered
+// for pattern; handlers without one are treated as IO.
+func (hs *HandlerScope) capability(pattern string) Capability {
+	if capability, ok := hs.Capabilities[pattern]; ok {
+		return capability
+	}
+	return CapIO
+}
+
 // CanHandle checks if this scope handles the given effect.
 func (hs *HandlerScope) CanHandle(effectName string) bool {
-	_, ok := hs.Handlers[effectName]
+	_, ok := hs.lookup(effectName)
 	return ok
 }
 
 // Handle invokes the handler for an effect.
 func (hs *HandlerScope) Handle(effect Effect, resume *Continuation) (interface{}, error) {
-	handler, ok := hs.Handlers[effect.Name]
+	handler, ok := hs.lookup(effect.Name)
 	if !ok {
+		if _, _, registered := hs.resolve(effect.Name); registered {
+			return nil, ErrCapabilityDenied
+		}
 		return nil, nil // Effect not handled by this scope
 	}
 	return handler(effect, resume)
 }
+
+// ErrUnhandledEffect is returned by RunEffects when the handler scope has no
+// handler for an effect left in the net.
+var ErrUnhandledEffect = errors.New("unhandled effect")
+
+// RunEffects is the effect runner: it reduces the net, performs the effects
+// left in it with the handlers of scope and repeats until none remain.
+// Each handler gets a continuation whose first Resume puts a Data node
+// holding the value in the effect's place; later resumes only return the
+// value. A handler that does not resume (an exception) has its result take
+// the effect's place instead. Effects are performed in node ID order.
+func (n *Network) RunEffects(scope *HandlerScope) error {
+	for {
+		if err := n.ReduceAll(); err != nil {
+			return err
+		}
+		var pending []Node
+		n.nodes.each(func(node Node) {
+			if node.Type() == NodeTypeEffect && node.Ports()[0].Wire.Load() != nil {
+				pending = append(pending, node)
+			}
+		})
+		if len(pending) == 0 {
+			return nil
+		}
+		sort.Slice(pending, func(i, j int) bool { return pending[i].ID() < pending[j].ID() })
+		for _, node := range pending {
+			if err := n.performEffect(scope, node); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// performEffect runs the handler for an Effect node and replaces the node
+// with the value it resumes with. The handler must be allowed both by the
+// scope's profile and by the network's.
+func (n *Network) performEffect(scope *HandlerScope, node Node) error {
+	effect := node.GetEffect()
+	var pattern string
+	registered := false
+	if scope != nil {
+		pattern, _, registered = scope.resolve(effect.Name)
+	}
+	if !registered {
+		return fmt.Errorf("%w: %q", ErrUnhandledEffect, effect.Name)
+	}
+	if !scope.CanHandle(effect.Name) || !n.Profile().Allows(scope.capability(pattern)) {
+		return fmt.Errorf("effect %q: %w", effect.Name, ErrCapabilityDenied)
+	}
+	placed := false
+	place := func(value interface{}) {
+		if !placed {
+			placed = true
+			n.splice(n.NewData(value).Ports()[0], node.Ports()[0], nil)
+		}
+	}
+	cont := &Continuation{
+		capturedState: node,
+		resume: func(value interface{}) (interface{}, error) {
+			place(value)
+			return value, nil
+		},
+	}
+	result, err := scope.Handle(*effect, cont)
+	n.emit(Event{Kind: EventEffect, Effect: effect.Name, Err: err})
+	if err != nil {
+		return fmt.Errorf("effect %q: %w", effect.Name, err)
+	}
+	place(result)
+	return nil
+}
@@ -1,6 +1,7 @@
 package deltanet
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -269,3 +270,95 @@ func TestChoiceHandlerMultiShot(t *testing.T) {
 
 	t.Logf("Choice results: %v", results)
 }
+
+// TestHandlerScopeNamespaces tests hierarchical effect names, namespace
+// registration, wildcards and forwarding.
+func TestHandlerScopeNamespaces(t *testing.T) {
+	handlerFor := func(label string) EffectHandler {
+		return func(effect Effect, resume *Continuation) (interface{}, error) {
+			return label + ":" + effect.Name, nil
+		}
+	}
+
+	outer := NewHandlerScope()
+	outer.Register(EffectWildcard, handlerFor("outer"))
+
+	scope := NewHandlerScope()
+	scope.RegisterNamespace("State", handlerFor("state"))
+	scope.Register("State.Cell.*", handlerFor("cell"))
+	scope.Register("State.Put", handlerFor("put"))
+	scope.Forward("Log.*", outer)
+
+	tests := []struct {
+		effect string
+		want   interface{}
+	}{
+		{"State.Get", "state:State.Get"},
+		{"State.Put", "put:State.Put"},
+		{"State.Cell.Get", "cell:State.Cell.Get"},
+		{"Log.Info", "outer:Log.Info"},
+		{"StateX.Get", nil},
+		{"State", nil},
+	}
+	for _, tt := range tests {
+		got, err := scope.Handle(Effect{Name: tt.effect}, &Continuation{})
+		if err != nil || got != tt.want {
+			t.Errorf("%s: got %v (%v), want %v", tt.effect, got, err, tt.want)
+		}
+		if scope.CanHandle(tt.effect) != (tt.want != nil) {
+			t.Errorf("%s: CanHandle = %v", tt.effect, scope.CanHandle(tt.effect))
+		}
+	}
+
+	if !scope.Handled.Covers("State.Anything") || scope.Handled.Covers("Other") {
+		t.Errorf("Handled row %v covers the wrong effects", scope.Handled)
+	}
+}
+
+// TestNamespaceCapability tests that a namespace registration carries its
+// capability to every effect it matches.
+func TestNamespaceCapability(t *testing.T) {
+	scope := NewHandlerScope()
+	scope.RegisterWithCapability("Console.*", func(Effect, *Continuation) (interface{}, error) {
+		return "ok", nil
+	}, CapConsole)
+	scope.Profile = ProfilePure
+	if _, err := scope.Handle(Effect{Name: "Console.Print"}, &Continuation{}); !errors.Is(err, ErrCapabilityDenied) {
+		t.Errorf("Expected ErrCapabilityDenied under pure profile, got %v", err)
+	}
+	scope.Profile = ProfileConsole
+	if got, _ := scope.Handle(Effect{Name: "Console.Print"}, &Continuation{}); got != "ok" {
+		t.Errorf("Expected console handler under console profile, got %v", got)
+	}
+}
+
+// TestRunEffects tests that the effect runner puts the value a handler
+// resumes with, or returns without resuming, in place of the effect.
+func TestRunEffects(t *testing.T) {
+	scope := NewHandlerScope()
+	scope.Register("Ask", func(effect Effect, resume *Continuation) (interface{}, error) {
+		return resume.Resume(effect.Payload.(int) * 2)
+	})
+	scope.Register("Throw", func(effect Effect, resume *Continuation) (interface{}, error) {
+		return "caught", nil
+	})
+
+	for name, want := range map[string]interface{}{"Ask": 42, "Throw": "caught"} {
+		net := NewNetwork()
+		out := net.NewVar()
+		net.Link(out, 0, net.NewIO(&Effect{Name: name, Payload: 21}, EffectRow{name}), 0)
+		if err := net.RunEffects(scope); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		result, _ := net.GetLink(out, 0)
+		if result == nil || result.Type() != NodeTypeData || result.GetValue() != want {
+			t.Errorf("%s: output linked to %v, want Data %v", name, result, want)
+		}
+	}
+
+	net := NewNetwork()
+	net.Link(net.NewVar(), 0, net.NewIO(&Effect{Name: "Missing"}, nil), 0)
+	if err := net.RunEffects(scope); !errors.Is(err, ErrUnhandledEffect) {
+		t.Errorf("Expected ErrUnhandledEffect, got %v", err)
+	}
+}
@@ -0,0 +1,447 @@
+package deltanet
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+// Binary encoding
+//
+// A Snapshot encodes to a compact binary form, so a net can be persisted
+// mid-reduction and resumed later, or translated once and shipped instead
+// of its source. The layout is a magic string and version byte followed by
+// unsigned varints: the header (next ID, phases, Stats), the nodes in ID
+// order with each ID stored as the gap from the previous one, the wires,
+// and the roots. Data values and effect payloads are tagged by type.
+// Node metadata is not encoded.
+
+const (
+	encodingMagic   = "GDNET"
+	encodingVersion = 1
+)
+
+var (
+	// ErrUnserializable is returned when a net holds a node or value that
+	// has no encoded form, e.g. a Handler node or a partial native.
+	ErrUnserializable = errors.New("net cannot be serialized")
+	// ErrEncoding is returned when decoding input that is not an encoded
+	// net of a known version.
+	ErrEncoding = errors.New("invalid encoded net")
+)
+
+// Value tags of encoded Data values and effect payloads.
+const (
+	valueNil byte = iota
+	valueInt
+	valueBigInt
+	valueRat
+	valueFloat
+	valueString
+	valueBool
+)
+
+// Encode writes the whole net in binary form (see Snapshot). The network
+// must not be reducing, or must be paused.
+func (n *Network) Encode(w io.Writer) error {
+	return n.Snapshot().Encode(w)
+}
+
+// Decode replaces the net with one written by Encode, as Restore does.
+func (n *Network) Decode(r io.Reader) error {
+	s, err := DecodeSnapshot(r)
+	if err != nil {
+		return err
+	}
+	n.Restore(s)
+	return nil
+}
+
+// MarshalBinary encodes the snapshot.
+func (s *Snapshot) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := s.Encode(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary replaces the snapshot with the encoded one in data.
+func (s *Snapshot) UnmarshalBinary(data []byte) error {
+	decoded, err := DecodeSnapshot(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	*s = *decoded
+	return nil
+}
+
+// Encode writes the snapshot in binary form.
+func (s *Snapshot) Encode(w io.Writer) error {
+	e := &encoder{w: bufio.NewWriter(w)}
+	e.w.WriteString(encodingMagic)
+	e.w.WriteByte(encodingVersion)
+	e.uint(s.nextID)
+	e.uint(uint64(s.phase))
+	e.uint(uint64(s.maxPhase))
+	for _, c := range statsFields(&s.stats) {
+		e.uint(*c)
+	}
+
+	// Sorted records make the output deterministic and the ID gaps small.
+	nodes := slices.Clone(s.nodes)
+	slices.SortFunc(nodes, func(a, b NodeRecord) int { return cmp.Compare(a.ID, b.ID) })
+	wires := slices.Clone(s.wires)
+	slices.SortFunc(wires, func(a, b wireRecord) int {
+		return cmp.Or(cmp.Compare(a.a, b.a), cmp.Compare(a.aPort, b.aPort))
+	})
+
+	e.uint(uint64(len(nodes)))
+	var prev uint64
+	for _, rec := range nodes {
+		e.uint(rec.ID - prev)
+		prev = rec.ID
+		e.node(rec)
+	}
+	e.uint(uint64(len(wires)))
+	for _, w := range wires {
+		e.uint(w.a)
+		e.uint(uint64(w.aPort))
+		e.uint(w.b)
+		e.uint(uint64(w.bPort))
+		e.uint(w.depth)
+	}
+	e.uint(uint64(len(s.roots)))
+	for _, r := range s.roots {
+		e.uint(r.id)
+		e.uint(uint64(r.port))
+	}
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+// DecodeSnapshot reads a snapshot written by Snapshot.Encode.
+func DecodeSnapshot(r io.Reader) (*Snapshot, error) {
+	d := &decoder{r: bufio.NewReader(r)}
+	magic := make([]byte, len(encodingMagic)+1)
+	if _, err := io.ReadFull(d.r, magic); err != nil || string(magic[:len(encodingMagic)]) != encodingMagic {
+		return nil, fmt.Errorf("%w: missing header", ErrEncoding)
+	}
+	if v := magic[len(encodingMagic)]; v != encodingVersion {
+		return nil, fmt.Errorf("%w: version %d, want %d", ErrEncoding, v, encodingVersion)
+	}
+
+	s := &Snapshot{nextID: d.uint()}
+	if s.nextID > math.MaxUint32 {
+		d.fail("next ID %d out of range", s.nextID)
+	}
+	s.phase, s.maxPhase = d.int(), d.int()
+	for _, c := range statsFields(&s.stats) {
+		*c = d.uint()
+	}
+
+	var prev uint64
+	ports := make(map[uint64]int)
+	for i, count := 0, d.count(); i < count && d.err == nil; i++ {
+		gap := d.uint()
+		if gap == 0 && d.err == nil {
+			d.fail("node IDs out of order")
+		}
+		// Restore numbers new nodes after nextID, so no recorded node may
+		// hold a later ID.
+		if gap > s.nextID-prev && d.err == nil {
+			d.fail("node ID %d+%d above the next ID %d", prev, gap, s.nextID)
+		}
+		prev += gap
+		rec := d.node(prev)
+		s.nodes = append(s.nodes, rec)
+		ports[rec.ID] = rec.arity()
+	}
+	// Restore links whatever the records say, so ends must exist.
+	end := func(id uint64, port int) {
+		if arity, ok := ports[id]; (!ok || port >= arity) && d.err == nil {
+			d.fail("port %d of node %d does not exist", port, id)
+		}
+	}
+	for i, count := 0, d.count(); i < count && d.err == nil; i++ {
+		w := wireRecord{
+			a: d.uint(), aPort: d.int(),
+			b: d.uint(), bPort: d.int(),
+			depth: d.uint(),
+		}
+		end(w.a, w.aPort)
+		end(w.b, w.bPort)
+		s.wires = append(s.wires, w)
+	}
+	for i, count := 0, d.count(); i < count && d.err == nil; i++ {
+		r := rootRecord{id: d.uint(), port: d.int()}
+		end(r.id, r.port)
+		s.roots = append(s.roots, r)
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return s, nil
+}
+
+// arity returns the number of ports of the recorded node.
+func (rec NodeRecord) arity() int {
+	switch rec.Type {
+	case NodeTypeFan:
+		return 3
+	case NodeTypeReplicator:
+		return len(rec.Deltas) + 1
+	default:
+		return 1
+	}
+}
+
+// CheckSerializable returns an error wrapping ErrUnserializable if the
+// recorded node has no serialized form: handler nodes and partially
+// applied natives stand for closures. Values are checked by each form, as
+// they do not all accept the same ones.
+func (rec NodeRecord) CheckSerializable() error {
+	switch {
+	case rec.Type == NodeTypeHandler:
+		return fmt.Errorf("%w: handler node %d", ErrUnserializable, rec.ID)
+	case rec.Type == NodeTypePure && partialNative(rec.Name):
+		return fmt.Errorf("%w: partially applied native %q", ErrUnserializable, rec.Name)
+	}
+	return nil
+}
+
+// statsFields lists the counters of s in encoding order.
+func statsFields(s *Stats) []*uint64 {
+	return []*uint64{
+		&s.TotalReductions, &s.FanAnnihilation, &s.RepAnnihilation,
+		&s.RepCommutation, &s.FanRepCommutation, &s.Erasure, &s.RepDecay,
+		&s.RepMerge, &s.AuxFanRep, &s.DataCopy, &s.NativeCalls,
+		&s.EtaContraction,
+	}
+}
+
+// encoder writes varints, keeping the first error.
+type encoder struct {
+	w   *bufio.Writer
+	buf [binary.MaxVarintLen64]byte
+	err error
+}
+
+func (e *encoder) uint(v uint64) {
+	e.w.Write(binary.AppendUvarint(e.buf[:0], v))
+}
+
+func (e *encoder) sint(v int64) {
+	e.w.Write(binary.AppendVarint(e.buf[:0], v))
+}
+
+func (e *encoder) string(v string) {
+	e.uint(uint64(len(v)))
+	e.w.WriteString(v)
+}
+
+func (e *encoder) fail(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *encoder) node(rec NodeRecord) {
+	if err := rec.CheckSerializable(); err != nil {
+		e.fail(err)
+	}
+	e.w.WriteByte(byte(rec.Type))
+	switch rec.Type {
+	case NodeTypeReplicator:
+		e.sint(int64(rec.Level))
+		e.uint(uint64(len(rec.Deltas)))
+		for _, delta := range rec.Deltas {
+			e.sint(int64(delta))
+		}
+	case NodeTypeData:
+		e.value(rec.Value)
+	case NodeTypePure:
+		e.string(rec.Name)
+	case NodeTypeEffect:
+		e.string(rec.Effect.Name)
+		e.value(rec.Effect.Payload)
+		e.uint(uint64(len(rec.EffectRow)))
+		for _, name := range rec.EffectRow {
+			e.string(name)
+		}
+	}
+}
+
+func (e *encoder) value(v interface{}) {
+	switch x := v.(type) {
+	case nil:
+		e.w.WriteByte(valueNil)
+	case int:
+		e.w.WriteByte(valueInt)
+		e.sint(int64(x))
+	case *big.Int:
+		e.w.WriteByte(valueBigInt)
+		e.string(x.String())
+	case *big.Rat:
+		e.w.WriteByte(valueRat)
+		e.string(x.String())
+	case float64:
+		e.w.WriteByte(valueFloat)
+		e.uint(math.Float64bits(x))
+	case string:
+		e.w.WriteByte(valueString)
+		e.string(x)
+	case bool:
+		e.w.WriteByte(valueBool)
+		if x {
+			e.w.WriteByte(1)
+		} else {
+			e.w.WriteByte(0)
+		}
+	default:
+		e.fail(fmt.Errorf("%w: value %v (%T)", ErrUnserializable, v, v))
+	}
+}
+
+// decoder reads varints, keeping the first error. Once it failed every
+// read returns zero.
+type decoder struct {
+	r   *bufio.Reader
+	err error
+}
+
+func (d *decoder) fail(format string, args ...interface{}) {
+	if d.err == nil {
+		d.err = fmt.Errorf("%w: %s", ErrEncoding, fmt.Sprintf(format, args...))
+	}
+}
+
+func (d *decoder) uint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		d.fail("truncated input")
+	}
+	return v
+}
+
+func (d *decoder) sint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, err := binary.ReadVarint(d.r)
+	if err != nil {
+		d.fail("truncated input")
+	}
+	return v
+}
+
+// int reads a port, phase or other small count.
+func (d *decoder) int() int {
+	v := d.uint()
+	if v > math.MaxInt32 {
+		d.fail("value %d out of range", v)
+		return 0
+	}
+	return int(v)
+}
+
+// count reads a length. Lengths are not trusted for allocation: records
+// are appended as they are read, so a corrupt length fails on truncation.
+func (d *decoder) count() int {
+	v := d.uint()
+	if v > math.MaxInt32 {
+		d.fail("count %d out of range", v)
+		return 0
+	}
+	return int(v)
+}
+
+func (d *decoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	b, err := d.r.ReadByte()
+	if err != nil {
+		d.fail("truncated input")
+	}
+	return b
+}
+
+func (d *decoder) string() string {
+	size := d.count()
+	if d.err != nil {
+		return ""
+	}
+	var sb strings.Builder
+	if _, err := io.CopyN(&sb, d.r, int64(size)); err != nil {
+		d.fail("truncated input")
+	}
+	return sb.String()
+}
+
+func (d *decoder) node(id uint64) NodeRecord {
+	rec := NodeRecord{ID: id, Type: NodeType(d.byte())}
+	switch rec.Type {
+	case NodeTypeFan, NodeTypeEraser, NodeTypeVar:
+	case NodeTypeReplicator:
+		rec.Level = int(d.sint())
+		for i, count := 0, d.count(); i < count && d.err == nil; i++ {
+			rec.Deltas = append(rec.Deltas, int(d.sint()))
+		}
+	case NodeTypeData:
+		rec.Value = d.value()
+	case NodeTypePure:
+		rec.Name = d.string()
+	case NodeTypeEffect:
+		rec.Effect = &Effect{Name: d.string(), Payload: d.value()}
+		for i, count := 0, d.count(); i < count && d.err == nil; i++ {
+			rec.EffectRow = append(rec.EffectRow, d.string())
+		}
+	default:
+		d.fail("node %d has unknown type %d", id, rec.Type)
+	}
+	return rec
+}
+
+func (d *decoder) value() interface{} {
+	switch tag := d.byte(); tag {
+	case valueNil:
+		return nil
+	case valueInt:
+		return int(d.sint())
+	case valueBigInt:
+		s := d.string()
+		if v, ok := new(big.Int).SetString(s, 10); ok {
+			return v
+		}
+		d.fail("bad integer %q", s)
+	case valueRat:
+		s := d.string()
+		if v, ok := new(big.Rat).SetString(s); ok {
+			return v
+		}
+		d.fail("bad rational %q", s)
+	case valueFloat:
+		return math.Float64frombits(d.uint())
+	case valueString:
+		return d.string()
+	case valueBool:
+		return d.byte() != 0
+	default:
+		d.fail("unknown value tag %d", tag)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package deltanet
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// TestEncodeResume tests that a net encoded mid-reduction and decoded into
+// another network continues to the same result.
+func TestEncodeResume(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(1))
+	buildCommutations(n, 10)
+	for _, v := range []interface{}{42, big.NewInt(-7), big.NewRat(1, 3), 2.5, "hi", true, nil} {
+		rep := n.NewReplicator(0, []int{0, 0})
+		n.Link(rep, 0, n.NewData(v), 0)
+		n.Link(rep, 1, n.NewVar(), 0)
+		n.Link(rep, 2, n.NewVar(), 0)
+	}
+	n.ReduceWithLimit(4)
+
+	var buf bytes.Buffer
+	if err := n.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	encoded := buf.Bytes()
+
+	m := NewNetworkWith(WithWorkers(1))
+	if err := m.Decode(bytes.NewReader(encoded)); err != nil {
+		t.Fatal(err)
+	}
+	var again bytes.Buffer
+	if err := m.Encode(&again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), encoded) {
+		t.Errorf("re-encoding a decoded net differs")
+	}
+
+	n.ReduceWithLimit(100)
+	m.ReduceWithLimit(100)
+	if got, want := m.GetStats().Interactions(), n.GetStats().Interactions(); got != want {
+		t.Errorf("resumed stats %+v, want %+v", got, want)
+	}
+	if got, want := m.ActiveNodeCount(), n.ActiveNodeCount(); got != want {
+		t.Errorf("resumed net has %d nodes, want %d", got, want)
+	}
+}
+
+func TestEncodeSubnet(t *testing.T) {
+	n := NewNetwork()
+	out := n.NewVar()
+	fan := n.NewFan()
+	n.Link(out, 0, fan, 0)
+	n.Link(fan, 1, n.NewVar(), 0)
+	n.Link(fan, 2, n.NewData("kept"), 0)
+	n.Link(n.NewVar(), 0, n.NewData("dropped"), 0)
+
+	data, err := n.SnapshotFrom(Root{Node: out}).MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewNetwork()
+	var s Snapshot
+	if err := s.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	m.Restore(&s)
+	if got := m.ActiveNodeCount(); got != 4 {
+		t.Errorf("subnet has %d nodes, want 4", got)
+	}
+	roots := m.Roots()
+	if len(roots) != 1 || roots[0].Node.ID() != out.ID() {
+		t.Fatalf("roots: got %v, want node %d", roots, out.ID())
+	}
+	if next, _ := m.GetLink(roots[0].Node, 0); next == nil || next.Type() != NodeTypeFan {
+		t.Errorf("root is linked to %v, want a Fan", next)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	n := NewNetwork()
+	n.Link(n.NewVar(), 0, n.NewHandler(NewHandlerScope()), 0)
+	if err := n.Encode(&bytes.Buffer{}); !errors.Is(err, ErrUnserializable) {
+		t.Errorf("handler: got %v, want ErrUnserializable", err)
+	}
+
+	n = NewNetwork()
+	n.Link(n.NewVar(), 0, n.NewData(struct{}{}), 0)
+	if err := n.Encode(&bytes.Buffer{}); !errors.Is(err, ErrUnserializable) {
+		t.Errorf("struct value: got %v, want ErrUnserializable", err)
+	}
+
+	n = NewNetwork()
+	n.Link(n.NewVar(), 0, n.NewFan(), 0)
+	var buf bytes.Buffer
+	if err := n.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	valid := buf.Bytes()
+	if valid[6] != 2 {
+		t.Fatalf("expected next ID 2 after the header, got %d", valid[6])
+	}
+	// withNextID replaces the next ID, a one-byte varint after the header.
+	withNextID := func(next ...byte) []byte {
+		return append(append(append([]byte(nil), valid[:6]...), next...), valid[7:]...)
+	}
+	for name, data := range map[string][]byte{
+		"node above next ID":   withNextID(1),
+		"next ID out of range": withNextID(0x80, 0x80, 0x80, 0x80, 0x10), // 1<<32
+		"empty":                nil,
+		"magic":                append([]byte("XXXXX"), valid[5:]...),
+		"version":              append(append([]byte(nil), valid[:5]...), append([]byte{9}, valid[6:]...)...),
+		"truncated":            valid[:len(valid)-2],
+	} {
+		if err := NewNetwork().Decode(bytes.NewReader(data)); !errors.Is(err, ErrEncoding) {
+			t.Errorf("%s: got %v, want ErrEncoding", name, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package deltanet
+
+import (
+	"slices"
+	"sync/atomic"
+)
+
+// ErasureCanonization is an erasure canonicalization run in steps: nodes
+// are marked from the roots with an explicit worklist, a bounded number at
+// a time, and the unmarked ones are then erased. Memory is bounded by the
+// network: marks are a bitset over node IDs and each node enters the
+// worklist at most once, so nets of millions of nodes are canonicalized
+// without deep recursion or per-node maps. The network must not be
+// reduced between the steps of a canonicalization.
+type ErasureCanonization struct {
+	n      *Network
+	marked idSet
+	stack  []Node
+	done   bool
+}
+
+// NewErasureCanonization starts an erasure canonicalization from the
+// registered roots, or from every Var node when none are registered (see
+// ApplyErasureCanonization).
+func (n *Network) NewErasureCanonization() *ErasureCanonization {
+	return n.newErasure(n.canonicalRoots())
+}
+
+func (n *Network) newErasure(roots []Root) *ErasureCanonization {
+	c := &ErasureCanonization{n: n, marked: newIDSet(n.ids.last())}
+	for _, r := range roots {
+		c.push(r.Node)
+	}
+	return c
+}
+
+func (c *ErasureCanonization) push(node Node) {
+	if node == nil || node.IsDead() || c.marked.has(node.ID()) {
+		return
+	}
+	c.marked.add(node.ID())
+	c.stack = append(c.stack, node)
+}
+
+// Mark visits at most budget marked nodes, following their wires, and
+// reports whether marking is complete. A budget of zero or less marks
+// everything.
+func (c *ErasureCanonization) Mark(budget int) bool {
+	for visited := 0; len(c.stack) > 0 && (budget <= 0 || visited < budget); visited++ {
+		node := c.stack[len(c.stack)-1]
+		c.stack = c.stack[:len(c.stack)-1]
+		for _, p := range node.Ports() {
+			w := p.Wire.Load()
+			if w == nil {
+				continue
+			}
+			if other := w.Other(p); other != nil {
+				c.push(other.Node)
+			}
+		}
+	}
+	if len(c.stack) == 0 {
+		c.stack = nil
+		c.done = true
+	}
+	return c.done
+}
+
+// Marked returns the number of nodes marked so far.
+func (c *ErasureCanonization) Marked() int {
+	return c.marked.len()
+}
+
+// Sweep completes the marking and erases every live node left unmarked,
+// returning how many it erased. Each wire of an erased node is handed to a
+// fresh eraser, so the pairs left between erasers reduce away, and the
+// node is marked dead for CollectGarbage to reclaim. Nodes are erased
+// shard by shard in ID order, so the result is deterministic.
+func (c *ErasureCanonization) Sweep() int {
+	c.Mark(0)
+	n := c.n
+	pruned := 0
+	var nodes []Node
+	for i := range n.nodes.shards {
+		// Copy one shard at a time: erasers created below are registered
+		// while the shard is not locked.
+		sh := &n.nodes.shards[i]
+		sh.mu.Lock()
+		nodes = append(nodes[:0], sh.slots...)
+		sh.mu.Unlock()
+		nodes = slices.DeleteFunc(nodes, func(node Node) bool {
+			return node == nil || c.marked.has(node.ID())
+		})
+		slices.SortFunc(nodes, func(a, b Node) int {
+			switch {
+			case a.ID() < b.ID():
+				return -1
+			case a.ID() > b.ID():
+				return 1
+			}
+			return 0
+		})
+		for _, node := range nodes {
+			if node.IsDead() {
+				continue
+			}
+			for _, p := range node.Ports() {
+				if p.Wire.Load() == nil {
+					continue
+				}
+				// Mark the eraser so a later shard does not sweep it.
+				eraser := n.NewEraser()
+				c.marked.add(eraser.ID())
+				n.splice(eraser.Ports()[0], p, nil)
+			}
+			node.SetDead()
+			n.releasePayload(node)
+			pruned++
+		}
+	}
+	atomic.AddUint64(&n.statPruned, uint64(pruned))
+	n.emit(Event{Kind: EventGC, Pruned: pruned})
+	return pruned
+}
+
+// idSet is a bitset of node IDs below a limit.
+type idSet struct {
+	bits  []uint64
+	count int
+}
+
+// newIDSet returns a set for the IDs up to maxID.
+func newIDSet(maxID uint64) idSet {
+	return idSet{bits: make([]uint64, maxID/64+1)}
+}
+
+func (s *idSet) limit() uint64 {
+	return uint64(len(s.bits)) * 64
+}
+
+func (s *idSet) has(id uint64) bool {
+	return id < s.limit() && s.bits[id/64]&(1<<(id%64)) != 0
+}
+
+// add inserts id, growing the set past its limit if needed.
+func (s *idSet) add(id uint64) {
+	for id >= s.limit() {
+		s.bits = append(s.bits, 0)
+	}
+	if !s.has(id) {
+		s.bits[id/64] |= 1 << (id % 64)
+		s.count++
+	}
+}
+
+func (s *idSet) len() int {
+	return s.count
+}
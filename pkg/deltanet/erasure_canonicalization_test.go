@@ -10,9 +10,7 @@ import (
 // from the root are traversed and nodes are marked. All non-marked nodes
 // are then erased."
 func TestErasureCanonizationDisconnectedSubnet(t *testing.T) {
-	t.Skip("ApplyErasureCanonization needs implementation review - currently causes timeout")
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Create the main connected subnet: Root -> Fan -> Var
 	root := n.NewVar()
@@ -21,6 +19,7 @@ func TestErasureCanonizationDisconnectedSubnet(t *testing.T) {
 
 	n.Link(root, 0, fan, 0)
 	n.Link(fan, 1, result, 0)
+	n.SetRoot(root, 0)
 
 	// Create a disconnected subnet that should be erased
 	// This represents what happens when K combinator discards an argument
@@ -63,8 +62,7 @@ func TestErasureCanonizationDisconnectedSubnet(t *testing.T) {
 // from the root."
 // Term: (\x. y) z -> y (where z gets disconnected)
 func TestErasureCanonizationKCombinator(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Build (\x. y) z
 
@@ -116,8 +114,7 @@ func TestErasureCanonizationKCombinator(t *testing.T) {
 // "In order to keep memory usage to a minimum, this step should be applied
 // after every application of an abstraction which doesn't use its bound variable."
 func TestErasureCanonizationAfterEveryK(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Build: (\x. (\y. z) a) b
 	// This creates TWO disconnected subnets (a and b) that should be cleaned up
@@ -221,8 +218,7 @@ func TestErasureCanonizationMarking(t *testing.T) {
 // "this step can be applied at any point during reduction in order to reduce
 // the net size, effectively trading computation (time) for memory (space)"
 func TestErasureCanonizationTradeoff(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Build a term that creates large disconnected subnets
 	// (\x. y) (large_term)
@@ -0,0 +1,59 @@
+package deltanet
+
+import "testing"
+
+// fanChain links depth fans principal to first auxiliary, a path as deep
+// as the net, hanging from root. The last fan's ports end in erasers.
+func fanChain(n *Network, root Node, depth int) {
+	prev, port := root, 0
+	for i := 0; i < depth; i++ {
+		fan := n.NewFan()
+		n.Link(prev, port, fan, 0)
+		n.Link(fan, 2, n.NewEraser(), 0)
+		prev, port = fan, 1
+	}
+	n.Link(prev, port, n.NewVar(), 0)
+}
+
+func TestErasureCanonizationDeepNet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a net of a million nodes")
+	}
+	n := NewNetwork()
+	root := n.NewVar()
+	fanChain(n, root, 500_000)
+	n.SetRoot(root, 0)
+	garbage := n.NewVar()
+	fanChain(n, garbage, 1000)
+
+	n.ApplyErasureCanonization()
+	if got := n.Report().Pruned; got != 2*1000+2 {
+		t.Errorf("pruned %d nodes, want the %d of the garbage chain", got, 2*1000+2)
+	}
+	if target, _ := n.GetLink(root, 0); target == nil || target.Type() != NodeTypeFan {
+		t.Error("rooted chain was pruned")
+	}
+}
+
+func TestErasureCanonizationSteps(t *testing.T) {
+	n := NewNetwork()
+	root := n.NewVar()
+	fanChain(n, root, 100)
+	n.SetRoot(root, 0)
+	fanChain(n, n.NewVar(), 10)
+
+	c := n.NewErasureCanonization()
+	steps := 0
+	for !c.Mark(16) {
+		steps++
+	}
+	if steps < 100*2/16 {
+		t.Errorf("marking finished in %d steps of 16 nodes", steps)
+	}
+	if got, want := c.Marked(), 1+2*100+1; got != want {
+		t.Errorf("marked %d nodes, want %d", got, want)
+	}
+	if got := c.Sweep(); got != 2*10+2 {
+		t.Errorf("swept %d nodes, want %d", got, 2*10+2)
+	}
+}
@@ -0,0 +1,64 @@
+package deltanet
+
+// SetEtaContraction enables the eta rule among the canonical rules (see
+// ApplyCanonicalRules): an abstraction whose body only applies a function
+// to its variable, (x: f x), is contracted to f. Eta-contraction changes
+// the net up to eta-equivalence only, so it is off by default.
+func (n *Network) SetEtaContraction(on bool) {
+	n.eta = on
+}
+
+// WithEtaContraction enables the eta rule (see SetEtaContraction).
+func WithEtaContraction() Option {
+	return func(n *Network) { n.SetEtaContraction(true) }
+}
+
+// reduceEta contracts the eta-redex fan a belongs to, if any, and reports
+// whether it did. An abstraction fan and the application fan of its body
+// face each other on both auxiliary ports, body to result and variable to
+// argument; the rule fuses their remaining ports, joining the parent of
+// the abstraction to the function. The variable must reach the argument
+// directly, as it does once its replicator decayed, so it cannot occur in
+// the function. Which fan is the abstraction does not matter: the pattern
+// and the result are symmetric. Fans are rotated in phase 2, so the ports
+// depend on the phase.
+func (n *Network) reduceEta(a Node) bool {
+	body, variable, outer := 1, 2, 0
+	if n.phase == 2 {
+		body, variable, outer = 0, 1, 2
+	}
+	peer := a.Ports()[body].Peer()
+	if peer == nil || peer.Node == a || peer.Node.Type() != NodeTypeFan || peer.Index != body {
+		return false
+	}
+	b := peer.Node
+	if a.Ports()[variable].Peer() != b.Ports()[variable] {
+		return false
+	}
+	if a.Ports()[outer].Peer() == b.Ports()[outer] {
+		return false
+	}
+
+	if !a.SetDead() {
+		return false
+	}
+	if !b.SetDead() {
+		a.Revive()
+		return false
+	}
+	start := n.clock()
+	depth := n.LinkDepth(a, outer)
+	n.fuse(a.Ports()[outer], b.Ports()[outer], nil)
+	for _, i := range []int{body, variable} {
+		if w := a.Ports()[i].Wire.Load(); w != nil {
+			w.P0.Store(nil)
+			w.P1.Store(nil)
+		}
+		a.Ports()[i].Wire.Store(nil)
+		b.Ports()[i].Wire.Store(nil)
+	}
+	n.statsFor(0).add(statEta)
+	n.recordTrace(RuleEta, a, b, depth, nil)
+	n.ruleDone(RuleEta, start)
+	return true
+}
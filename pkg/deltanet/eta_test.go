@@ -0,0 +1,42 @@
+package deltanet
+
+import "testing"
+
+// etaRedex builds x: f x in phase 1 layout and returns the interface nodes
+// of the result and of f.
+func etaRedex(n *Network) (root, f Node) {
+	root, f = n.NewVar(), n.NewVar()
+	abs, app := n.NewFan(), n.NewFan()
+	n.Link(root, 0, abs, 0)
+	n.Link(abs, 1, app, 1) // Body is the application's result
+	n.Link(abs, 2, app, 2) // Variable is its argument
+	n.Link(app, 0, f, 0)
+	return root, f
+}
+
+func TestEtaContraction(t *testing.T) {
+	n := NewNetworkWith(WithEtaContraction())
+	root, f := etaRedex(n)
+
+	sweep := n.ApplyCanonicalRules()
+	if sweep.Etas != 1 || !sweep.Changed() {
+		t.Fatalf("sweep = %+v, want one eta", sweep)
+	}
+	if !n.IsConnected(root, 0, f, 0) {
+		t.Error("result is not f")
+	}
+	if got := n.Report().Rules[RuleEta.String()]; got != 1 {
+		t.Errorf("report counts %d eta rules", got)
+	}
+}
+
+func TestEtaContractionOff(t *testing.T) {
+	n := NewNetwork()
+	root, f := etaRedex(n)
+	if sweep := n.ApplyCanonicalRules(); sweep.Etas != 0 {
+		t.Errorf("eta applied while disabled: %+v", sweep)
+	}
+	if n.IsConnected(root, 0, f, 0) {
+		t.Error("net contracted while disabled")
+	}
+}
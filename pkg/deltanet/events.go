@@ -0,0 +1,133 @@
+package deltanet
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// EventKind tells what an Event reports.
+type EventKind int
+
+const (
+	EventReduce EventKind = iota // An interaction, see Event.Interaction
+	EventGC                      // A collection, see Event.Collected and Event.Pruned
+	EventPhase                   // A phase change, see Event.Phase
+	EventEffect                  // An effect performed, see Event.Effect
+)
+
+var eventKindNames = [...]string{
+	EventReduce: "reduce",
+	EventGC:     "gc",
+	EventPhase:  "phase",
+	EventEffect: "effect",
+}
+
+func (k EventKind) String() string {
+	if k >= 0 && int(k) < len(eventKindNames) {
+		return eventKindNames[k]
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// Event is a notification delivered by Events. Only the fields of its Kind
+// are set.
+type Event struct {
+	Kind        EventKind
+	Interaction TraceEvent // As OnReduce sees it
+	Collected   int        // Dead nodes removed from the registry
+	Pruned      int        // Nodes erased by erasure canonicalization
+	Phase       int        // Phase entered
+	Effect      string     // Name of the effect performed
+	Err         error      // Error of the effect's handler, if any
+}
+
+// eventBufferSize is the capacity of the Events channel, and
+// reduceEventRoom how much of it interaction events may fill. They come
+// one per interaction; the rest is kept for the rarer kinds.
+const (
+	eventBufferSize = 1024
+	reduceEventRoom = eventBufferSize * 3 / 4
+)
+
+// eventBus delivers Events. Interaction events are sent by reducePair,
+// which runs on the workers or under reductionMu, so they take no lock:
+// closeEvents closes the channel once the workers have exited, holding
+// reductionMu. The other kinds hold mu for reading instead.
+type eventBus struct {
+	once    sync.Once
+	on      atomic.Bool
+	mu      sync.RWMutex
+	ch      chan Event
+	closed  atomic.Bool
+	dropped atomic.Uint64
+}
+
+// Events returns a channel delivering reduction, collection, phase change
+// and effect events, for monitoring a long reduction without polling
+// GetStats. Every call returns the same channel, which Close closes.
+// Events are sent without blocking the reducer: while the buffer is full
+// they are dropped and counted by DroppedEvents. Interaction events are
+// dropped once the buffer is three quarters full, so a flood of them does
+// not crowd out the other kinds. It must be called before reduction
+// starts.
+func (n *Network) Events() <-chan Event {
+	n.events.once.Do(func() {
+		n.events.ch = make(chan Event, eventBufferSize)
+		n.OnReduce(n.emitReduce)
+		n.events.on.Store(true)
+	})
+	return n.events.ch
+}
+
+// DroppedEvents returns the number of events Events dropped because the
+// channel was full.
+func (n *Network) DroppedEvents() uint64 {
+	return n.events.dropped.Load()
+}
+
+// emit sends e to the Events channel, if there is one.
+func (n *Network) emit(e Event) {
+	if !n.events.on.Load() {
+		return
+	}
+	n.events.mu.RLock()
+	defer n.events.mu.RUnlock()
+	n.send(e)
+}
+
+// emitReduce sends an interaction event while there is room for it.
+func (n *Network) emitReduce(e TraceEvent) {
+	if len(n.events.ch) >= reduceEventRoom {
+		n.events.dropped.Add(1)
+		return
+	}
+	n.send(Event{Kind: EventReduce, Interaction: e})
+}
+
+// send sends e without blocking, unless the channel is closed.
+func (n *Network) send(e Event) {
+	if n.events.closed.Load() {
+		return
+	}
+	select {
+	case n.events.ch <- e:
+	default:
+		n.events.dropped.Add(1)
+	}
+}
+
+// closeEvents closes the Events channel. The workers have exited.
+func (n *Network) closeEvents() {
+	if !n.events.on.Load() {
+		return
+	}
+	n.reductionMu.Lock()
+	defer n.reductionMu.Unlock()
+	n.events.mu.Lock()
+	defer n.events.mu.Unlock()
+	if !n.events.closed.Load() {
+		n.events.closed.Store(true)
+		close(n.events.ch)
+	}
+}
@@ -0,0 +1,74 @@
+package deltanet
+
+import "testing"
+
+// TestEvents tests that the Events channel reports interactions, phase
+// changes, collections and effects, and is closed by Close.
+func TestEvents(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(1))
+	events := net.Events()
+	if net.Events() != events {
+		t.Fatalf("Events returned a new channel")
+	}
+	buildCommutations(net, 3)
+	out := net.NewVar()
+	net.Link(out, 0, net.NewIO(&Effect{Name: "Ask"}, EffectRow{"Ask"}), 0)
+	scope := NewHandlerScope()
+	scope.Register("Ask", func(effect Effect, resume *Continuation) (interface{}, error) {
+		return resume.Resume(1)
+	})
+
+	net.ReduceToNormalForm()
+	if err := net.RunEffects(scope); err != nil {
+		t.Fatal(err)
+	}
+	net.CollectGarbage()
+	net.Close()
+
+	counts := make(map[EventKind]int)
+	for e := range events {
+		counts[e.Kind]++
+		switch e.Kind {
+		case EventReduce:
+			if e.Interaction.Rule != RuleFanRep {
+				t.Errorf("got %v, want fan-rep", e.Interaction.Rule)
+			}
+		case EventPhase:
+			if e.Phase != 2 {
+				t.Errorf("entered phase %d, want 2", e.Phase)
+			}
+		case EventEffect:
+			if e.Effect != "Ask" || e.Err != nil {
+				t.Errorf("got effect %q (%v), want Ask", e.Effect, e.Err)
+			}
+		}
+	}
+	if counts[EventReduce] != 3 || counts[EventPhase] != 1 || counts[EventEffect] != 1 || counts[EventGC] == 0 {
+		t.Errorf("got events %v", counts)
+	}
+	if net.DroppedEvents() != 0 {
+		t.Errorf("dropped %d events", net.DroppedEvents())
+	}
+}
+
+// TestEventsRoom tests that a flood of interaction events leaves room in
+// the buffer for the other kinds.
+func TestEventsRoom(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(1))
+	events := net.Events()
+	buildCommutations(net, eventBufferSize)
+	net.ReduceAll()
+	net.CollectGarbage()
+	net.Close()
+
+	counts := make(map[EventKind]int)
+	for e := range events {
+		counts[e.Kind]++
+	}
+	if counts[EventReduce] != reduceEventRoom || counts[EventGC] == 0 {
+		t.Errorf("got events %v, want %d reduce events and a gc one", counts, reduceEventRoom)
+	}
+	if want := uint64(eventBufferSize - reduceEventRoom); net.DroppedEvents() != want {
+		t.Errorf("dropped %d events, want %d", net.DroppedEvents(), want)
+	}
+}
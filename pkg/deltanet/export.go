@@ -0,0 +1,124 @@
+package deltanet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Graph export
+//
+// ExportJSON writes the net as a single JSON object for external tools and
+// web UIs:
+//
+//	{
+//	  "format": 1,
+//	  "nodes": [
+//	    {"id": 1, "type": "Var"},
+//	    {"id": 2, "type": "Replicator", "level": 1, "deltas": [0, 2]},
+//	    {"id": 3, "type": "Data", "value": "42"}
+//	  ],
+//	  "wires": [
+//	    {"from": {"node": 1, "port": 0}, "to": {"node": 2, "port": 1}, "depth": 0},
+//	    {"from": {"node": 2, "port": 0}, "to": {"node": 3, "port": 0}, "depth": 1, "active": true}
+//	  ],
+//	  "roots": [{"node": 1, "port": 0}]
+//	}
+//
+// Nodes are the live nodes in ID order; type is the NodeType name. Level
+// and deltas are set for replicators, value (in fmt %v form) for Data
+// nodes, name for natives and effects. Each wire appears once, from its
+// lower node ID, with the depth used to schedule it; active marks wires
+// between the principal ports of two agents. Roots are the registered
+// roots (see SetRoot).
+
+// GraphFormat is the version of the ExportJSON schema.
+const GraphFormat = 1
+
+// Graph is the JSON form of a net written by ExportJSON.
+type Graph struct {
+	Format int         `json:"format"`
+	Nodes  []GraphNode `json:"nodes"`
+	Wires  []GraphWire `json:"wires"`
+	Roots  []GraphPort `json:"roots,omitempty"`
+}
+
+// GraphNode is a node of an exported net.
+type GraphNode struct {
+	ID     uint64 `json:"id"`
+	Type   string `json:"type"`
+	Level  int    `json:"level,omitempty"`
+	Deltas []int  `json:"deltas,omitempty"`
+	Value  string `json:"value,omitempty"`
+	Name   string `json:"name,omitempty"`
+}
+
+// GraphPort is a port of a node of an exported net.
+type GraphPort struct {
+	Node uint64 `json:"node"`
+	Port int    `json:"port"`
+}
+
+// GraphWire is a wire of an exported net.
+type GraphWire struct {
+	From   GraphPort `json:"from"`
+	To     GraphPort `json:"to"`
+	Depth  uint64    `json:"depth"`
+	Active bool      `json:"active,omitempty"`
+}
+
+// Graph returns the net in the form written by ExportJSON. The network
+// must not be reducing, or must be paused (see Pause).
+func (n *Network) Graph() Graph {
+	s := n.Snapshot()
+	g := Graph{Format: GraphFormat, Nodes: make([]GraphNode, 0, len(s.nodes)), Wires: make([]GraphWire, 0, len(s.wires))}
+	types := make(map[uint64]NodeType, len(s.nodes))
+	for _, rec := range s.nodes {
+		types[rec.ID] = rec.Type
+		node := GraphNode{ID: rec.ID, Type: rec.Type.String(), Name: rec.Name}
+		switch rec.Type {
+		case NodeTypeReplicator:
+			node.Level = rec.Level
+			node.Deltas = rec.Deltas
+		case NodeTypeData:
+			if rec.Value != nil {
+				node.Value = fmt.Sprint(rec.Value)
+			}
+		case NodeTypeEffect:
+			if rec.Effect != nil {
+				node.Name = rec.Effect.Name
+			}
+		}
+		g.Nodes = append(g.Nodes, node)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool { return g.Nodes[i].ID < g.Nodes[j].ID })
+	for _, w := range s.wires {
+		g.Wires = append(g.Wires, GraphWire{
+			From:  GraphPort{Node: w.a, Port: w.aPort},
+			To:    GraphPort{Node: w.b, Port: w.bPort},
+			Depth: w.depth,
+			Active: w.aPort == 0 && w.bPort == 0 &&
+				types[w.a] != NodeTypeVar && types[w.b] != NodeTypeVar,
+		})
+	}
+	sort.Slice(g.Wires, func(i, j int) bool {
+		a, b := g.Wires[i], g.Wires[j]
+		if a.From != b.From {
+			return a.From.Node < b.From.Node || (a.From.Node == b.From.Node && a.From.Port < b.From.Port)
+		}
+		return a.To.Node < b.To.Node || (a.To.Node == b.To.Node && a.To.Port < b.To.Port)
+	})
+	for _, r := range s.roots {
+		g.Roots = append(g.Roots, GraphPort{Node: r.id, Port: r.port})
+	}
+	return g
+}
+
+// ExportJSON writes the net as an indented JSON object in the schema
+// described above. The network must not be reducing, or must be paused.
+func (n *Network) ExportJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(n.Graph())
+}
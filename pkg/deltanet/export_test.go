@@ -0,0 +1,94 @@
+package deltanet
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"reflect"
+	"testing"
+)
+
+func TestExportJSON(t *testing.T) {
+	n := NewNetwork()
+	out := n.NewVar()
+	rep := n.NewReplicator(1, []int{0, 2})
+	data := n.NewData(42)
+	n.Link(out, 0, rep, 1)
+	n.LinkAt(rep, 0, data, 0, 1)
+	n.Link(rep, 2, n.NewEraser(), 0)
+	n.SetRoot(out, 0)
+
+	var buf bytes.Buffer
+	if err := n.ExportJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got Graph
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	want := Graph{
+		Format: GraphFormat,
+		Nodes: []GraphNode{
+			{ID: out.ID(), Type: "Var"},
+			{ID: rep.ID(), Type: "Replicator", Level: 1, Deltas: []int{0, 2}},
+			{ID: data.ID(), Type: "Data", Value: "42"},
+			{ID: data.ID() + 1, Type: "Eraser"},
+		},
+		Wires: []GraphWire{
+			{From: GraphPort{out.ID(), 0}, To: GraphPort{rep.ID(), 1}},
+			{From: GraphPort{rep.ID(), 0}, To: GraphPort{data.ID(), 0}, Depth: 1, Active: true},
+			{From: GraphPort{rep.ID(), 2}, To: GraphPort{data.ID() + 1, 0}},
+		},
+		Roots: []GraphPort{{out.ID(), 0}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("exported\n%+v\nwant\n%+v", got, want)
+	}
+}
+
+func TestWriteGraphML(t *testing.T) {
+	n := NewNetwork()
+	rep := n.NewReplicator(2, []int{1, -1})
+	n.Link(rep, 0, n.NewData("<a & b>"), 0)
+	n.Link(rep, 1, n.NewVar(), 0)
+	n.Link(rep, 2, n.NewVar(), 0)
+
+	var buf bytes.Buffer
+	if err := n.WriteGraphML(&buf); err != nil {
+		t.Fatal(err)
+	}
+	type data struct {
+		Key   string `xml:"key,attr"`
+		Value string `xml:",chardata"`
+	}
+	var doc struct {
+		Keys []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"key"`
+		Nodes []struct {
+			ID   string `xml:"id,attr"`
+			Data []data `xml:"data"`
+		} `xml:"graph>node"`
+		Edges []struct {
+			Source string `xml:"source,attr"`
+			Data   []data `xml:"data"`
+		} `xml:"graph>edge"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid GraphML: %v\n%s", err, buf.String())
+	}
+	if len(doc.Keys) != len(graphMLKeys) || len(doc.Nodes) != 4 || len(doc.Edges) != 3 {
+		t.Fatalf("got %d keys, %d nodes, %d edges; want %d, 4, 3",
+			len(doc.Keys), len(doc.Nodes), len(doc.Edges), len(graphMLKeys))
+	}
+	want := []data{{"type", "Replicator"}, {"level", "2"}, {"deltas", "1 -1"}}
+	if got := doc.Nodes[0].Data; !reflect.DeepEqual(got, want) {
+		t.Errorf("replicator data %v, want %v", got, want)
+	}
+	if got := doc.Nodes[1].Data; len(got) != 2 || got[1] != (data{"value", "<a & b>"}) {
+		t.Errorf("data node %v, want its value unescaped", got)
+	}
+	if got := doc.Edges[0].Data; got[3] != (data{"active", "true"}) {
+		t.Errorf("replicator-data edge %v, want active", got)
+	}
+}
@@ -0,0 +1,61 @@
+package deltanet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrFanOutLimit is wrapped by the *FanOutError reduction returns when a
+// replicator commutation would exceed the fan-out limit (see SetMaxFanOut).
+var ErrFanOutLimit = errors.New("replicator commutation fan-out limit exceeded")
+
+// FanOutError reports the replicator commutation that halted reduction.
+// The pair is left unreduced, so raising or lifting the limit and reducing
+// again continues from there.
+type FanOutError struct {
+	Levels [2]int // Levels of the two replicators
+	Arity  [2]int // Auxiliary ports of the two replicators
+	Wires  int    // Internal wires the commutation would create
+	Limit  int
+}
+
+func (e *FanOutError) Error() string {
+	return fmt.Sprintf("%v: replicators at levels %d and %d with %d and %d auxiliary ports need %d internal wires, limit %d",
+		ErrFanOutLimit, e.Levels[0], e.Levels[1], e.Arity[0], e.Arity[1], e.Wires, e.Limit)
+}
+
+func (e *FanOutError) Unwrap() error {
+	return ErrFanOutLimit
+}
+
+// SetMaxFanOut bounds the internal wires a single replicator commutation
+// may create. Commuting replicators with a and b auxiliary ports creates
+// a+b replicators linked by a×b wires, so a few wide replicators can
+// allocate a large part of the net in one step. Such a pair halts
+// reduction instead (see commutation.go), and reduction reports a
+// *FanOutError describing it. Zero removes the limit. It must be called
+// before reduction starts.
+func (n *Network) SetMaxFanOut(limit int) {
+	n.commute.fanOut = limit
+}
+
+// WithMaxFanOut bounds replicator commutations (see SetMaxFanOut).
+func WithMaxFanOut(limit int) Option {
+	return func(n *Network) { n.SetMaxFanOut(limit) }
+}
+
+// fanOutOver describes the commutation of replicators a and b if it
+// creates more internal wires than limit.
+func fanOutOver(a, b Node, limit int) *FanOutError {
+	arityA, arityB := len(a.Ports())-1, len(b.Ports())-1
+	wires := arityA * arityB
+	if limit == 0 || wires <= limit {
+		return nil
+	}
+	return &FanOutError{
+		Levels: [2]int{a.Level(), b.Level()},
+		Arity:  [2]int{arityA, arityB},
+		Wires:  wires,
+		Limit:  limit,
+	}
+}
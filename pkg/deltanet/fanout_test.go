@@ -0,0 +1,63 @@
+package deltanet
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestMaxFanOut tests that a replicator commutation over the limit halts
+// reduction with its description, and is reduced once the limit is lifted.
+func TestMaxFanOut(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		n := NewNetworkWith(WithWorkers(workers), WithMaxFanOut(10))
+		a := n.NewReplicator(0, []int{0, 0, 0})
+		b := n.NewReplicator(1, []int{0, 0, 0, 0})
+		n.Link(a, 0, b, 0)
+		for port := 1; port < len(a.Ports()); port++ {
+			n.Link(a, port, n.NewVar(), 0)
+		}
+		for port := 1; port < len(b.Ports()); port++ {
+			n.Link(b, port, n.NewVar(), 0)
+		}
+
+		err := n.ReduceWithBudget(context.Background(), Budget{})
+		var fanOut *FanOutError
+		if !errors.As(err, &fanOut) || !errors.Is(err, ErrFanOutLimit) {
+			t.Fatalf("%d workers: expected a *FanOutError, got %v", workers, err)
+		}
+		want := FanOutError{Levels: [2]int{0, 1}, Arity: [2]int{3, 4}, Wires: 12, Limit: 10}
+		if got := *fanOut; got != want {
+			t.Errorf("%d workers: got %+v, want %+v", workers, got, want)
+		}
+		if got := n.GetStats().TotalReductions; got != 0 {
+			t.Errorf("%d workers: expected no interactions, got %d", workers, got)
+		}
+
+		n.SetMaxFanOut(0)
+		if err := n.ReduceWithBudget(context.Background(), Budget{}); err != nil {
+			t.Fatalf("%d workers: resume: %v", workers, err)
+		}
+		if got := n.GetStats().RepCommutation; got != 1 {
+			t.Errorf("%d workers: expected 1 commutation after lifting the limit, got %d", workers, got)
+		}
+	}
+}
+
+// TestMaxFanOutAllowsSmall tests that commutations within the limit reduce.
+func TestMaxFanOutAllowsSmall(t *testing.T) {
+	n := NewNetworkWith(WithMaxFanOut(4))
+	a := n.NewReplicator(0, []int{0, 0})
+	b := n.NewReplicator(1, []int{0, 0})
+	n.Link(a, 0, b, 0)
+	for port := 1; port < 3; port++ {
+		n.Link(a, port, n.NewVar(), 0)
+		n.Link(b, port, n.NewVar(), 0)
+	}
+	if err := n.ReduceWithBudget(context.Background(), Budget{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.GetStats().RepCommutation; got != 1 {
+		t.Errorf("expected 1 commutation, got %d", got)
+	}
+}
@@ -0,0 +1,142 @@
+package deltanet
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"slices"
+)
+
+// Fingerprint is a canonical hash of the structure reachable from a node.
+// It does not depend on node IDs, so isomorphic nets built in different
+// networks, or at different times, have the same fingerprint.
+type Fingerprint [sha256.Size]byte
+
+func (f Fingerprint) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+// Fingerprint hashes the structure reachable from root. Nodes are numbered
+// in breadth-first order, following ports in index order, and each node is
+// hashed with its type, its attributes (replicator level and deltas, data
+// value, native or effect name) and, for every port, the number and port of
+// the node it is connected to. Data values are hashed through their %v
+// form.
+func (n *Network) Fingerprint(root Node) Fingerprint {
+	order, index := n.reachable(root)
+
+	h := sha256.New()
+	var buf [binary.MaxVarintLen64]byte
+	writeInt := func(v int64) {
+		h.Write(buf[:binary.PutVarint(buf[:], v)])
+	}
+	writeString := func(s string) {
+		writeInt(int64(len(s)))
+		h.Write([]byte(s))
+	}
+	for _, node := range order {
+		writeInt(int64(node.Type()))
+		switch node.Type() {
+		case NodeTypeReplicator:
+			writeInt(int64(node.Level()))
+			writeInt(int64(len(node.Deltas())))
+			for _, d := range node.Deltas() {
+				writeInt(int64(d))
+			}
+		case NodeTypeData:
+			writeString(fmt.Sprintf("%T:%v", node.GetValue(), node.GetValue()))
+		case NodeTypePure:
+			writeString(node.GetName())
+		case NodeTypeEffect:
+			writeString(node.GetEffect().Name)
+		}
+		writeInt(int64(len(node.Ports())))
+		for port := range node.Ports() {
+			next, nextPort := n.GetLink(node, port)
+			if next == nil {
+				writeInt(-1)
+				continue
+			}
+			writeInt(int64(index[next.ID()]))
+			writeInt(int64(nextPort))
+		}
+	}
+	var f Fingerprint
+	h.Sum(f[:0])
+	return f
+}
+
+// reachable numbers the nodes reachable from roots in breadth-first order,
+// following ports in index order, and returns them with their numbers by
+// node ID.
+func (n *Network) reachable(roots ...Node) ([]Node, map[uint64]int) {
+	index := make(map[uint64]int)
+	var order []Node
+	visit := func(node Node) {
+		if _, seen := index[node.ID()]; !seen {
+			index[node.ID()] = len(order)
+			order = append(order, node)
+		}
+	}
+	for _, root := range roots {
+		visit(root)
+	}
+	for i := 0; i < len(order); i++ {
+		for port := range order[i].Ports() {
+			if next, _ := n.GetLink(order[i], port); next != nil {
+				visit(next)
+			}
+		}
+	}
+	return order, index
+}
+
+// Isomorphic reports whether the structure reachable from rootA in a is the
+// same as the one reachable from rootB in b up to renaming of node IDs:
+// the same nodes, with the attributes Fingerprint hashes, wired through the
+// same ports. Both nets are numbered in Fingerprint's breadth-first order,
+// which is canonical for a rooted net, so the comparison is exact.
+func Isomorphic(a, b *Network, rootA, rootB Node) bool {
+	orderA, indexA := a.reachable(rootA)
+	orderB, indexB := b.reachable(rootB)
+	if len(orderA) != len(orderB) {
+		return false
+	}
+	for i, x := range orderA {
+		y := orderB[i]
+		if !sameAttributes(x, y) || len(x.Ports()) != len(y.Ports()) {
+			return false
+		}
+		for port := range x.Ports() {
+			nextA, portA := a.GetLink(x, port)
+			nextB, portB := b.GetLink(y, port)
+			if (nextA == nil) != (nextB == nil) {
+				return false
+			}
+			if nextA != nil && (indexA[nextA.ID()] != indexB[nextB.ID()] || portA != portB) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sameAttributes reports whether two nodes have the type and attributes
+// Fingerprint hashes in common.
+func sameAttributes(x, y Node) bool {
+	if x.Type() != y.Type() {
+		return false
+	}
+	switch x.Type() {
+	case NodeTypeReplicator:
+		return x.Level() == y.Level() && slices.Equal(x.Deltas(), y.Deltas())
+	case NodeTypeData:
+		return fmt.Sprintf("%T:%v", x.GetValue(), x.GetValue()) == fmt.Sprintf("%T:%v", y.GetValue(), y.GetValue())
+	case NodeTypePure:
+		return x.GetName() == y.GetName()
+	case NodeTypeEffect:
+		return x.GetEffect().Name == y.GetEffect().Name
+	}
+	return true
+}
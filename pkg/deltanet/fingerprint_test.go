@@ -0,0 +1,61 @@
+package deltanet
+
+import "testing"
+
+// TestFingerprintIgnoresIDs tests that isomorphic nets have the same
+// fingerprint and that attributes and wiring change it, and that
+// Isomorphic agrees.
+func TestFingerprintIgnoresIDs(t *testing.T) {
+	build := func(net *Network, level int, value interface{}) Node {
+		root := net.NewVar()
+		fan := net.NewFan()
+		rep := net.NewReplicator(level, []int{0, 1})
+		net.Link(root, 0, fan, 0)
+		net.Link(fan, 1, rep, 0)
+		net.Link(rep, 1, net.NewData(value), 0)
+		net.Link(rep, 2, net.NewEraser(), 0)
+		net.Link(fan, 2, net.NewVar(), 0)
+		return root
+	}
+
+	a := NewNetwork()
+	rootA := build(a, 1, 7)
+	b := NewNetwork()
+	b.NewFan() // Shift the IDs of the second net
+	rootB := build(b, 1, 7)
+	if a.Fingerprint(rootA) != b.Fingerprint(rootB) {
+		t.Errorf("isomorphic nets have different fingerprints")
+	}
+	if !Isomorphic(a, b, rootA, rootB) {
+		t.Errorf("Isomorphic = false for nets differing only in IDs")
+	}
+
+	for name, root := range map[string]func(*Network) Node{
+		"level": func(net *Network) Node { return build(net, 2, 7) },
+		"value": func(net *Network) Node { return build(net, 1, "7") },
+	} {
+		c := NewNetwork()
+		rootC := root(c)
+		if c.Fingerprint(rootC) == a.Fingerprint(rootA) {
+			t.Errorf("%s: expected a different fingerprint", name)
+		}
+		if Isomorphic(a, c, rootA, rootC) {
+			t.Errorf("%s: Isomorphic = true", name)
+		}
+	}
+
+	// Swapping the fan's auxiliary ports changes the wiring.
+	c := NewNetwork()
+	rootC := build(c, 1, 7)
+	fan, _ := c.GetLink(rootC, 0)
+	n1, p1 := c.GetLink(fan, 1)
+	n2, p2 := c.GetLink(fan, 2)
+	c.Link(fan, 1, n2, p2)
+	c.Link(fan, 2, n1, p1)
+	if c.Fingerprint(rootC) == a.Fingerprint(rootA) {
+		t.Errorf("swapped ports: expected a different fingerprint")
+	}
+	if Isomorphic(a, c, rootA, rootC) {
+		t.Errorf("swapped ports: Isomorphic = true")
+	}
+}
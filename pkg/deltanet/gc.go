@@ -0,0 +1,71 @@
+package deltanet
+
+// Garbage collection policy
+//
+// Interactions mark the nodes they consume dead, and CollectGarbage takes
+// them out of the registry. ReduceWithLimit, which reduces on the calling
+// goroutine, collects every GC interval interactions. The workers of
+// ReduceAll and the reductions built on it collect once dead nodes make up
+// the GC ratio of the registered ones: as for a compaction (see memory.go)
+// the workers halt, the dead nodes are collected while they are idle and
+// reduction resumes. Registries with fewer than gcMinDead dead nodes are
+// left alone, so short reductions never halt for a collection.
+
+const (
+	defaultGCInterval = 10
+	defaultGCRatio    = 0.5
+	gcMinDead         = 4096
+)
+
+// gcPolicy is the state of SetGCInterval and SetGCRatio.
+type gcPolicy struct {
+	interval uint64  // ReduceWithLimit interactions between collections, 0 never
+	ratio    float64 // Dead share of the registry collected by workers, 0 never
+}
+
+// SetGCInterval makes ReduceWithLimit collect dead nodes every k
+// interactions (10 by default). Zero disables the collections.
+func (n *Network) SetGCInterval(k uint64) {
+	n.gc.interval = k
+}
+
+// WithGCInterval sets the collection interval of ReduceWithLimit (see
+// SetGCInterval).
+func WithGCInterval(k uint64) Option {
+	return func(n *Network) { n.SetGCInterval(k) }
+}
+
+// SetGCRatio makes the workers collect dead nodes once they are at least
+// ratio of the registered nodes (0.5 by default), and at least gcMinDead
+// of them. Zero disables the collections. It must be called before
+// reduction starts.
+func (n *Network) SetGCRatio(ratio float64) {
+	n.gc.ratio = max(ratio, 0)
+}
+
+// WithGCRatio sets the dead node ratio at which the workers collect (see
+// SetGCRatio).
+func WithGCRatio(ratio float64) Option {
+	return func(n *Network) { n.SetGCRatio(ratio) }
+}
+
+// collectDue reports whether dead nodes have reached the GC ratio.
+func (n *Network) collectDue() bool {
+	if n.gc.ratio == 0 {
+		return false
+	}
+	dead := n.nodes.dead.Load()
+	return dead >= gcMinDead && float64(dead) >= n.gc.ratio*float64(n.nodes.count.Load())
+}
+
+// collecting reports whether reduction was halted for a collection.
+func (n *Network) collecting() bool {
+	return n.limit.halted.Load() && haltReason(n.limit.reason.Load()) == haltCollect
+}
+
+// collect collects dead nodes and resumes the halted reduction. Workers
+// must be idle.
+func (n *Network) collect() {
+	n.CollectGarbage()
+	n.resume()
+}
@@ -0,0 +1,64 @@
+package deltanet
+
+import "testing"
+
+// buildAnnihilations links n fan pairs whose auxiliary ports are joined,
+// each annihilation leaving two dead nodes and nothing else.
+func buildAnnihilations(net *Network, n int) {
+	for i := 0; i < n; i++ {
+		a, b := net.NewFan(), net.NewFan()
+		net.Link(a, 0, b, 0)
+		net.Link(a, 1, b, 1)
+		net.Link(a, 2, b, 2)
+	}
+}
+
+// TestGCRatio tests that the workers collect dead nodes once they reach
+// the GC ratio, and not when it is disabled or too few nodes are dead.
+func TestGCRatio(t *testing.T) {
+	tests := []struct {
+		name  string
+		pairs int
+		ratio float64
+		gc    bool
+	}{
+		{"default", 2 * gcMinDead, defaultGCRatio, true},
+		{"disabled", 2 * gcMinDead, 0, false},
+		{"small", gcMinDead / 4, defaultGCRatio, false},
+	}
+	for _, tt := range tests {
+		n := NewNetworkWith(WithWorkers(2), WithGCRatio(tt.ratio))
+		buildAnnihilations(n, tt.pairs)
+		n.ReduceAll()
+		stats := n.GetStats()
+		if got := stats.FanAnnihilation; got != uint64(tt.pairs) {
+			t.Errorf("%s: expected %d annihilations, got %d", tt.name, tt.pairs, got)
+		}
+		if collected := stats.Collections > 0; collected != tt.gc {
+			t.Errorf("%s: collected %v, want %v", tt.name, collected, tt.gc)
+		}
+		if got, want := n.NodeCount(), 2*tt.pairs; tt.gc == (got == want) {
+			t.Errorf("%s: %d registered nodes left of %d", tt.name, got, want)
+		}
+		if n.ActiveNodeCount() != 0 {
+			t.Errorf("%s: %d live nodes left", tt.name, n.ActiveNodeCount())
+		}
+	}
+}
+
+// TestGCInterval tests that ReduceWithLimit collects every GC interval
+// interactions, and never with an interval of zero.
+func TestGCInterval(t *testing.T) {
+	for _, interval := range []uint64{0, 10, 25} {
+		n := NewNetworkWith(WithGCInterval(interval))
+		buildAnnihilations(n, 100)
+		n.ReduceWithLimit(100)
+		var want uint64
+		if interval > 0 {
+			want = 100 / interval
+		}
+		if got := n.GetStats().Collections; got != want {
+			t.Errorf("interval %d: expected %d collections, got %d", interval, want, got)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package deltanet
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// graphMLKeys declares the GraphML attributes of nodes and edges, in the
+// order they are written: id, for, attr.name and attr.type.
+var graphMLKeys = [][4]string{
+	{"type", "node", "type", "string"},
+	{"level", "node", "level", "int"},
+	{"deltas", "node", "deltas", "string"},
+	{"value", "node", "value", "string"},
+	{"name", "node", "name", "string"},
+	{"tailport", "edge", "tailport", "int"},
+	{"headport", "edge", "headport", "int"},
+	{"depth", "edge", "depth", "long"},
+	{"active", "edge", "active", "boolean"},
+}
+
+// WriteGraphML writes the net as an undirected GraphML graph, for analysis
+// tools such as Gephi or NetworkX. It holds the nodes and wires of Graph:
+// nodes are named n<ID> and carry type, level, deltas (space separated),
+// value and name; edges run from the lower node ID and carry the port at
+// each end (tailport, headport), depth and active. Attributes that Graph
+// leaves empty are omitted. The network must not be reducing, or must be
+// paused (see Pause).
+func (n *Network) WriteGraphML(w io.Writer) error {
+	g := n.Graph()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, xml.Header+`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">`)
+	for _, k := range graphMLKeys {
+		fmt.Fprintf(bw, "  <key id=%q for=%q attr.name=%q attr.type=%q/>\n", k[0], k[1], k[2], k[3])
+	}
+	fmt.Fprintln(bw, `  <graph id="net" edgedefault="undirected">`)
+	for _, node := range g.Nodes {
+		fmt.Fprintf(bw, "    <node id=\"n%d\">", node.ID)
+		graphMLData(bw, "type", node.Type)
+		if node.Type == NodeTypeReplicator.String() {
+			graphMLData(bw, "level", strconv.Itoa(node.Level))
+			deltas := make([]string, len(node.Deltas))
+			for i, d := range node.Deltas {
+				deltas[i] = strconv.Itoa(d)
+			}
+			graphMLData(bw, "deltas", strings.Join(deltas, " "))
+		}
+		if node.Value != "" {
+			graphMLData(bw, "value", node.Value)
+		}
+		if node.Name != "" {
+			graphMLData(bw, "name", node.Name)
+		}
+		fmt.Fprintln(bw, "</node>")
+	}
+	for i, wire := range g.Wires {
+		fmt.Fprintf(bw, "    <edge id=\"e%d\" source=\"n%d\" target=\"n%d\">", i, wire.From.Node, wire.To.Node)
+		graphMLData(bw, "tailport", strconv.Itoa(wire.From.Port))
+		graphMLData(bw, "headport", strconv.Itoa(wire.To.Port))
+		graphMLData(bw, "depth", strconv.FormatUint(wire.Depth, 10))
+		graphMLData(bw, "active", strconv.FormatBool(wire.Active))
+		fmt.Fprintln(bw, "</edge>")
+	}
+	fmt.Fprintln(bw, "  </graph>")
+	fmt.Fprintln(bw, "</graphml>")
+	return bw.Flush()
+}
+
+func graphMLData(w io.Writer, key, value string) {
+	fmt.Fprintf(w, "<data key=%q>", key)
+	xml.EscapeText(w, []byte(value))
+	fmt.Fprint(w, "</data>")
+}
@@ -0,0 +1,132 @@
+package deltanet
+
+import "sync"
+
+// Verdict is an Interceptor's decision on an interaction.
+type Verdict int
+
+const (
+	// Proceed lets the interaction take place.
+	Proceed Verdict = iota
+	// Postpone holds the pair until the queue drains, then queues it
+	// again with the other postponed pairs, so it waits for every pair
+	// queued meanwhile, including the ones their interactions create. If
+	// no interaction took place since the postponed pairs were last
+	// queued, they are left unreduced like vetoed pairs.
+	Postpone
+	// Veto leaves the pair unreduced for the rest of the reduction call.
+	// The next call (ReduceAll, ReduceToNormalForm, ...) queues it again.
+	Veto
+)
+
+// Interceptor is consulted by the workers before each interaction they
+// pop, with the rule it would apply (see RuleTable), the two nodes and the
+// depth of their wire, and decides whether it takes place. With several
+// workers it is called concurrently. It must not change the net.
+// Interactions made outside the workers (ReduceWithLimit, ReduceAt,
+// Step and the canonical rules) are not intercepted.
+type Interceptor interface {
+	Intercept(rule RuleKind, a, b Node, depth uint64) Verdict
+}
+
+// InterceptorFunc adapts a function to Interceptor.
+type InterceptorFunc func(rule RuleKind, a, b Node, depth uint64) Verdict
+
+func (f InterceptorFunc) Intercept(rule RuleKind, a, b Node, depth uint64) Verdict {
+	return f(rule, a, b, depth)
+}
+
+// reductionHooks holds the callbacks and interceptor that observe and
+// steer reduction without changing reducePair.
+type reductionHooks struct {
+	onReduce    []func(TraceEvent)
+	interceptor Interceptor
+	mu          sync.Mutex
+	postponed   []*Wire
+	progress    uint64 // Interactions when the postponed pairs were last queued
+}
+
+// OnReduce calls fn after every interaction, with its event as traced
+// (see TraceEvent), except that Step, the created nodes and Time are not
+// set. Hooks run on the reducing goroutine, concurrently with several
+// workers, and must not change the net. It must be called before
+// reduction starts.
+func (n *Network) OnReduce(fn func(TraceEvent)) {
+	n.hooks.onReduce = append(n.hooks.onReduce, fn)
+}
+
+// SetInterceptor installs i to veto or postpone interactions, or removes
+// the interceptor when i is nil. It must be called before reduction starts.
+func (n *Network) SetInterceptor(i Interceptor) {
+	n.hooks.interceptor = i
+}
+
+// WithOnReduce adds a reduction hook (see OnReduce).
+func WithOnReduce(fn func(TraceEvent)) Option {
+	return func(n *Network) { n.OnReduce(fn) }
+}
+
+// WithInterceptor installs an interceptor (see SetInterceptor).
+func WithInterceptor(i Interceptor) Option {
+	return func(n *Network) { n.SetInterceptor(i) }
+}
+
+// intercepted asks the interceptor about the pair on w and, unless it
+// proceeds, takes the pair off the worker: vetoed pairs are parked and
+// postponed ones held until the queue drains.
+func (n *Network) intercepted(w *Wire) bool {
+	if n.hooks.interceptor == nil {
+		return false
+	}
+	p0, p1 := w.P0.Load(), w.P1.Load()
+	if p0 == nil || p1 == nil {
+		return false
+	}
+	a, b := p0.Node, p1.Node
+	rule, _, ok := pairRule(a.Type(), b.Type(), n.phase, a.Level() == b.Level())
+	if !ok {
+		return false
+	}
+	switch n.hooks.interceptor.Intercept(rule, a, b, w.depth) {
+	case Postpone:
+		n.hooks.mu.Lock()
+		n.hooks.postponed = append(n.hooks.postponed, w)
+		n.hooks.mu.Unlock()
+		n.hold(w)
+		n.pairs.Done()
+		return true
+	case Veto:
+		n.park(w)
+		return true
+	default:
+		return false
+	}
+}
+
+// requeuePostponed queues the postponed pairs again once the workers are
+// idle, and reports whether it did. When nothing was reduced since they
+// were last queued, postponing them again would never end, so they are
+// parked instead.
+func (n *Network) requeuePostponed() bool {
+	n.hooks.mu.Lock()
+	postponed := n.hooks.postponed
+	n.hooks.postponed = nil
+	progress := n.stat(statOps)
+	stalled := progress == n.hooks.progress
+	n.hooks.progress = progress
+	n.hooks.mu.Unlock()
+	if len(postponed) == 0 {
+		return false
+	}
+	for _, w := range postponed {
+		n.release(w)
+		if stalled {
+			n.pairs.Add(1)
+			n.park(w)
+			continue
+		}
+		n.pairs.Add(1)
+		n.schedule(w, w.depth, nil)
+	}
+	return !stalled
+}
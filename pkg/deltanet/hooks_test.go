@@ -0,0 +1,94 @@
+package deltanet
+
+import "testing"
+
+// fanIDs returns the IDs of the fans of net, in creation order.
+func fanIDs(net *Network) []uint64 {
+	var ids []uint64
+	for _, node := range net.snapshotNodes() {
+		if node.Type() == NodeTypeFan {
+			ids = append(ids, node.ID())
+		}
+	}
+	return ids
+}
+
+// TestOnReduce tests that hooks see every interaction.
+func TestOnReduce(t *testing.T) {
+	var events []TraceEvent
+	net := NewNetworkWith(WithWorkers(1), WithOnReduce(func(e TraceEvent) {
+		events = append(events, e)
+	}))
+	buildCommutations(net, 5)
+	net.ReduceAll()
+	if len(events) != 5 || uint64(len(events)) != net.GetStats().TotalReductions {
+		t.Fatalf("got %d events for %d interactions", len(events), net.GetStats().TotalReductions)
+	}
+	for _, e := range events {
+		if e.Rule != RuleFanRep {
+			t.Errorf("got %v, want fan-rep", e.Rule)
+		}
+	}
+}
+
+// TestInterceptorVeto tests that a vetoed pair is left unreduced until a
+// later reduction lets it through.
+func TestInterceptorVeto(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(1))
+	buildCommutations(net, 3)
+	vetoed := fanIDs(net)[1]
+	net.SetInterceptor(InterceptorFunc(func(rule RuleKind, a, b Node, depth uint64) Verdict {
+		if a.ID() == vetoed || b.ID() == vetoed {
+			return Veto
+		}
+		return Proceed
+	}))
+	net.ReduceAll()
+	if got := net.GetStats().TotalReductions; got != 2 {
+		t.Fatalf("got %d interactions, want 2", got)
+	}
+	if net.NodeByID(vetoed).IsDead() {
+		t.Fatalf("vetoed fan was reduced")
+	}
+
+	net.SetInterceptor(nil)
+	net.ReduceAll()
+	if got := net.GetStats().TotalReductions; got != 3 {
+		t.Errorf("got %d interactions after lifting the veto, want 3", got)
+	}
+}
+
+// TestInterceptorPostpone tests that a postponed pair is reduced after the
+// others, and that postponing forever does not hang.
+func TestInterceptorPostpone(t *testing.T) {
+	var order []uint64
+	net := NewNetworkWith(WithWorkers(1), WithOnReduce(func(e TraceEvent) {
+		order = append(order, e.AID, e.BID)
+	}))
+	buildCommutations(net, 3)
+	later := fanIDs(net)[0]
+	postponed := false
+	net.SetInterceptor(InterceptorFunc(func(rule RuleKind, a, b Node, depth uint64) Verdict {
+		if (a.ID() == later || b.ID() == later) && !postponed {
+			postponed = true
+			return Postpone
+		}
+		return Proceed
+	}))
+	net.ReduceAll()
+	if len(order) != 6 {
+		t.Fatalf("got %d interactions, want 3", len(order)/2)
+	}
+	if last := order[4:]; last[0] != later && last[1] != later {
+		t.Errorf("postponed pair of fan %d reduced before others: %v", later, order)
+	}
+
+	net = NewNetworkWith(WithWorkers(1), WithInterceptor(InterceptorFunc(func(RuleKind, Node, Node, uint64) Verdict {
+		return Postpone
+	})))
+	buildCommutations(net, 2)
+	net.ReduceAll()
+	if got := net.GetStats().TotalReductions; got != 0 {
+		t.Errorf("got %d interactions while postponing everything", got)
+	}
+}
@@ -0,0 +1,130 @@
+package deltanet
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Node IDs
+//
+// Node IDs are 32-bit, numbered from 1; ID widens them to uint64, so maps
+// keyed by ID keep working. By default IDs are handed out in creation order
+// and never reused, so they grow with the number of nodes ever created.
+// With ID recycling, CollectGarbage puts the IDs of the nodes it removes on
+// a free list, together with their metadata, and new nodes take IDs from
+// it first: IDs then stay below the peak number of nodes, which keeps ID
+// bitsets, traces and snapshot encodings dense on long runs.
+//
+// A recycled ID no longer tells when its node was created, so node ID
+// order (e.g. the order RunEffects performs effects in) follows creation
+// order only among nodes that got fresh IDs. While tracing, collected IDs
+// are not recycled and new nodes always get fresh ones, so each ID in a
+// trace names a single node.
+//
+// Reset numbers fresh IDs from 1 again, so a pooled network only runs out
+// of IDs if a single evaluation creates 2³² nodes without recycling.
+
+// idAllocator hands out node IDs.
+type idAllocator struct {
+	next    atomic.Uint32 // Last fresh ID handed out
+	recycle bool
+	spare   atomic.Int32 // Length of free, read without the lock
+	mu      sync.Mutex
+	free    []uint32
+}
+
+// take returns a free ID when reuse is allowed and one is available, and a
+// fresh one otherwise. It panics when the 32-bit IDs run out.
+func (a *idAllocator) take(reuse bool) uint32 {
+	if reuse && a.spare.Load() > 0 {
+		a.mu.Lock()
+		if k := len(a.free); k > 0 {
+			id := a.free[k-1]
+			a.free = a.free[:k-1]
+			a.spare.Store(int32(k - 1))
+			a.mu.Unlock()
+			return id
+		}
+		a.mu.Unlock()
+	}
+	id := a.next.Add(1)
+	if id == 0 {
+		panic("deltanet: node IDs exhausted; enable WithIDRecycling for long reductions")
+	}
+	return id
+}
+
+// release puts ids on the free list.
+func (a *idAllocator) release(ids []uint32) {
+	a.mu.Lock()
+	a.free = append(a.free, ids...)
+	a.spare.Store(int32(len(a.free)))
+	a.mu.Unlock()
+}
+
+// reset empties the free list and numbers fresh IDs from 1 again.
+func (a *idAllocator) reset() {
+	a.mu.Lock()
+	a.free = nil
+	a.spare.Store(0)
+	a.next.Store(0)
+	a.mu.Unlock()
+}
+
+// last returns the last fresh ID handed out.
+func (a *idAllocator) last() uint64 {
+	return uint64(a.next.Load())
+}
+
+// setLast makes id the last fresh ID handed out, so the next fresh ID is
+// id+1.
+func (a *idAllocator) setLast(id uint64) {
+	if id > math.MaxUint32 {
+		panic("deltanet: node ID out of range")
+	}
+	a.next.Store(uint32(id))
+}
+
+// WithIDRecycling enables or disables reusing the IDs of collected nodes
+// (see above). It is disabled by default.
+func WithIDRecycling(on bool) Option {
+	return func(n *Network) { n.ids.recycle = on }
+}
+
+// recycling reports whether new nodes may take collected IDs.
+func (n *Network) recycling() bool {
+	return n.ids.recycle && !(traceCompiled && atomic.LoadUint32(&n.traceOn) != 0)
+}
+
+// recycleIDs frees the IDs of collected nodes and drops their metadata.
+func (n *Network) recycleIDs(ids []uint32) {
+	if len(ids) == 0 {
+		return
+	}
+	if atomic.LoadUint32(&n.metaOn) != 0 {
+		n.metaMu.Lock()
+		for _, id := range ids {
+			delete(n.meta, uint64(id))
+		}
+		n.metaMu.Unlock()
+	}
+	n.ids.release(ids)
+}
+
+// freeGaps frees the IDs up to the last fresh one that no registered node
+// holds, after Restore numbered the nodes of a snapshot.
+func (n *Network) freeGaps() {
+	used := newIDSet(n.ids.last())
+	n.nodes.each(func(node Node) { used.add(node.ID()) })
+	var gaps []uint32
+	for id := uint64(1); id <= n.ids.last(); id++ {
+		if !used.has(id) {
+			gaps = append(gaps, uint32(id))
+		}
+	}
+	// Take the lowest IDs first.
+	slices.Reverse(gaps)
+	n.ids.release(gaps)
+}
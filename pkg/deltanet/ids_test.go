@@ -0,0 +1,115 @@
+package deltanet
+
+import "testing"
+
+// TestIDRecycling tests that collected IDs go to new nodes, with the
+// metadata of the collected nodes dropped, and that they are not reused
+// by default or while tracing.
+func TestIDRecycling(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		opts  []Option
+		reuse bool
+	}{
+		{"default", nil, false},
+		{"recycling", []Option{WithIDRecycling(true)}, true},
+		{"tracing", []Option{WithIDRecycling(true), WithTrace(10)}, !traceCompiled},
+	} {
+		n := NewNetworkWith(append(tt.opts, WithWorkers(1))...)
+		buildAnnihilations(n, 10)
+		n.SetMeta(n.NodeByID(1), "fan")
+		n.ReduceAll()
+		last := n.ids.last()
+		collected := n.CollectGarbage()
+		if collected == 0 {
+			t.Fatalf("%s: nothing collected", tt.name)
+		}
+
+		fresh := 0
+		for i := 0; i < collected; i++ {
+			if n.NewVar().ID() > last {
+				fresh++
+			}
+		}
+		if tt.reuse && fresh != 0 {
+			t.Errorf("%s: %d of %d new nodes got fresh IDs", tt.name, fresh, collected)
+		}
+		if !tt.reuse && fresh != collected {
+			t.Errorf("%s: %d of %d new nodes reused IDs", tt.name, collected-fresh, collected)
+		}
+		if _, ok := n.Meta(1); ok == tt.reuse {
+			t.Errorf("%s: metadata of collected node 1 kept: %v", tt.name, ok)
+		}
+	}
+}
+
+// TestIDRecyclingRestore tests that a restored network reuses the IDs no
+// restored node holds, lowest first.
+func TestIDRecyclingRestore(t *testing.T) {
+	n := NewNetworkWith(WithIDRecycling(true))
+	a, b, c := n.NewVar(), n.NewVar(), n.NewVar()
+	n.Link(a, 0, c, 0)
+	b.SetDead()
+	s := n.Snapshot()
+	n.Restore(s)
+
+	if id := n.NewVar().ID(); id != b.ID() {
+		t.Errorf("expected the ID of the dropped node, %d, got %d", b.ID(), id)
+	}
+	if id := n.NewVar().ID(); id != c.ID()+1 {
+		t.Errorf("expected a fresh ID %d, got %d", c.ID()+1, id)
+	}
+}
+
+// TestResetRestartsIDs tests that Reset numbers fresh IDs from 1 again, so
+// a pooled network does not run out of them.
+func TestResetRestartsIDs(t *testing.T) {
+	n := NewNetwork()
+	for i := 0; i < 5; i++ {
+		n.NewVar()
+	}
+	n.Reset()
+	if id := n.NewVar().ID(); id != 1 {
+		t.Errorf("expected ID 1 after Reset, got %d", id)
+	}
+}
+
+// TestPartialNamesAfterRestore tests that partial natives are named without
+// taking node IDs, so the gaps a restore with ID recycling reissues never
+// name a partial again.
+func TestPartialNamesAfterRestore(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(1), WithIDRecycling(true))
+	registerAdd(n)
+	apply := func(x, y int) Node {
+		inner := n.NewFan()
+		n.Link(inner, 0, n.NewNative("add"), 0)
+		n.Link(inner, 2, n.NewData(x), 0)
+		outer := n.NewFan()
+		n.Link(outer, 0, inner, 1)
+		n.Link(outer, 2, n.NewData(y), 0)
+		output := n.NewVar()
+		n.Link(outer, 1, output, 0)
+		return output
+	}
+	first, second := apply(1, 10).ID(), apply(2, 20).ID()
+	n.ReduceWithLimit(1)
+	if partialEntries(n) != 1 {
+		t.Fatalf("expected one partial native, got %d", partialEntries(n))
+	}
+	// Naming the partial took no node ID, so no gap holds its number.
+	if last, count := n.ids.last(), uint64(n.NodeCount()); last != count {
+		t.Errorf("%d IDs handed out for %d nodes", last, count)
+	}
+	// The restored network reissues the IDs no node holds.
+	n.Restore(n.Snapshot())
+
+	n.ReduceAll()
+	for _, tt := range []struct {
+		output uint64
+		want   int
+	}{{first, 11}, {second, 22}} {
+		if result, _ := n.GetLink(n.NodeByID(tt.output), 0); result == nil || result.GetValue() != tt.want {
+			t.Errorf("expected %d, got %v", tt.want, result)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package deltanet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ErrLevelLimit is wrapped by the *LevelError reduction returns when a
+// replicator commutation would exceed the level limit (see SetMaxLevel).
+var ErrLevelLimit = errors.New("replicator level limit exceeded")
+
+// levelFragmentRadius is how many links away from the pair LevelError's
+// fragment reaches, and levelTraceTail how many trace events it keeps.
+const (
+	levelFragmentRadius = 2
+	levelTraceTail      = 16
+)
+
+// LevelError reports the replicator commutation that halted reduction
+// because it would raise a replicator over the level limit. The pair is
+// left unreduced, so raising or lifting the limit and reducing again
+// continues from there.
+type LevelError struct {
+	IDs    [2]uint64 // The two replicators
+	Levels [2]int    // Their levels
+	Level  int       // Highest level the commutation would create
+	Limit  int
+	Meta   interface{}  // Metadata of the replicator being copied, if any
+	Nodes  string       // The nodes around the pair, as listed by Dump
+	Trace  []TraceEvent // Last recorded interactions, if tracing is on
+}
+
+func (e *LevelError) Error() string {
+	msg := fmt.Sprintf("%v: replicators #%d and #%d at levels %d and %d would create level %d, limit %d",
+		ErrLevelLimit, e.IDs[0], e.IDs[1], e.Levels[0], e.Levels[1], e.Level, e.Limit)
+	if e.Meta != nil {
+		msg = fmt.Sprintf("%v: %s", e.Meta, msg)
+	}
+	return msg
+}
+
+func (e *LevelError) Unwrap() error {
+	return ErrLevelLimit
+}
+
+// WriteText writes the error followed by the net fragment around the pair
+// and the tail of the trace.
+func (e *LevelError) WriteText(w io.Writer) error {
+	ew := &errWriter{w: w}
+	ew.printf("%v\n", e)
+	ew.printf("Nodes within %d links of the pair:\n%s", levelFragmentRadius, e.Nodes)
+	if len(e.Trace) > 0 {
+		ew.printf("Last %d interactions:\n", len(e.Trace))
+		for _, ev := range e.Trace {
+			ew.printf("  #%d %v: %v#%d <-> %v#%d at depth %d\n", ev.Step, ev.Rule, ev.AType, ev.AID, ev.BType, ev.BID, ev.Depth)
+		}
+	}
+	return ew.err
+}
+
+// SetMaxLevel bounds the level of the replicators created by commuting
+// two replicators, which copies the higher one at its level plus a delta
+// of the lower. Terms that keep commuting replicators can drive levels to
+// extreme values, which usually points at a bug in level arithmetic. A
+// commutation over the limit halts reduction instead (see commutation.go),
+// and reduction reports a *LevelError with the nodes around the pair and
+// the tail of the trace. Zero removes the limit. It must be called before
+// reduction starts.
+func (n *Network) SetMaxLevel(limit int) {
+	n.commute.level = limit
+}
+
+// WithMaxLevel bounds replicator levels (see SetMaxLevel).
+func WithMaxLevel(limit int) Option {
+	return func(n *Network) { n.SetMaxLevel(limit) }
+}
+
+// levelOver describes the commutation of replicators a and b if it
+// creates a replicator above level limit.
+func (n *Network) levelOver(a, b Node, limit int) *LevelError {
+	if limit == 0 {
+		return nil
+	}
+	low, high := a, b
+	if low.Level() > high.Level() {
+		low, high = high, low
+	}
+	level := high.Level()
+	for _, delta := range low.Deltas() {
+		level = max(level, high.Level()+delta)
+	}
+	if level <= limit {
+		return nil
+	}
+	e := &LevelError{
+		IDs:    [2]uint64{a.ID(), b.ID()},
+		Levels: [2]int{a.Level(), b.Level()},
+		Level:  level,
+		Limit:  limit,
+	}
+	if meta, ok := n.Meta(high.ID()); ok {
+		e.Meta = meta
+	}
+	return e
+}
+
+// complete fills in the nodes around pair and the tail of the trace. It
+// runs once reduction has stopped, so the net can be walked safely.
+func (e *LevelError) complete(n *Network, pair [2]Node) {
+	if e.Nodes != "" {
+		return
+	}
+	var sb strings.Builder
+	for _, node := range n.around(pair[:], levelFragmentRadius) {
+		n.dumpNode(&sb, node)
+	}
+	e.Nodes = sb.String()
+	if trace := n.TraceSnapshot(); len(trace) > levelTraceTail {
+		e.Trace = trace[len(trace)-levelTraceTail:]
+	} else {
+		e.Trace = trace
+	}
+}
+
+// around returns the nodes at most radius links away from the given ones,
+// in ID order.
+func (n *Network) around(nodes []Node, radius int) []Node {
+	seen := make(map[uint64]bool)
+	var found []Node
+	frontier := nodes
+	for _, node := range nodes {
+		seen[node.ID()] = true
+		found = append(found, node)
+	}
+	for i := 0; i < radius; i++ {
+		var next []Node
+		for _, node := range frontier {
+			for port := range node.Ports() {
+				other, _ := n.GetLink(node, port)
+				if other != nil && !seen[other.ID()] {
+					seen[other.ID()] = true
+					found = append(found, other)
+					next = append(next, other)
+				}
+			}
+		}
+		frontier = next
+	}
+	sort.Slice(found, func(i, j int) bool { return found[i].ID() < found[j].ID() })
+	return found
+}
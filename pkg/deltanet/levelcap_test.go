@@ -0,0 +1,73 @@
+package deltanet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestMaxLevel tests that a replicator commutation over the level limit
+// halts reduction with a diagnostic dump, and is reduced once the limit is
+// lifted.
+func TestMaxLevel(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		n := NewNetworkWith(WithWorkers(workers), WithMaxLevel(4), WithTrace(100))
+		a := n.NewReplicator(0, []int{0, 3})
+		b := n.NewReplicator(2, []int{0})
+		n.Link(a, 0, b, 0)
+		n.Link(a, 1, n.NewVar(), 0)
+		n.Link(a, 2, n.NewVar(), 0)
+		n.Link(b, 1, n.NewVar(), 0)
+
+		err := n.ReduceWithBudget(context.Background(), Budget{})
+		var levelErr *LevelError
+		if !errors.As(err, &levelErr) || !errors.Is(err, ErrLevelLimit) {
+			t.Fatalf("%d workers: expected a *LevelError, got %v", workers, err)
+		}
+		if levelErr.Levels != [2]int{0, 2} && levelErr.Levels != [2]int{2, 0} {
+			t.Errorf("%d workers: expected levels 0 and 2, got %v", workers, levelErr.Levels)
+		}
+		if levelErr.Level != 5 || levelErr.Limit != 4 {
+			t.Errorf("%d workers: expected level 5 over limit 4, got %d over %d", workers, levelErr.Level, levelErr.Limit)
+		}
+		var buf bytes.Buffer
+		if err := levelErr.WriteText(&buf); err != nil {
+			t.Fatal(err)
+		}
+		for _, want := range []string{"Replicator level=0 deltas=[0 3]", "Replicator level=2 deltas=[0]", "Var"} {
+			if !strings.Contains(buf.String(), want) {
+				t.Errorf("%d workers: diagnostic lacks %q:\n%s", workers, want, buf.String())
+			}
+		}
+		if got := n.GetStats().TotalReductions; got != 0 {
+			t.Errorf("%d workers: expected no interactions, got %d", workers, got)
+		}
+
+		n.SetMaxLevel(0)
+		if err := n.ReduceWithBudget(context.Background(), Budget{}); err != nil {
+			t.Fatalf("%d workers: resume: %v", workers, err)
+		}
+		if got := n.GetStats().RepCommutation; got != 1 {
+			t.Errorf("%d workers: expected 1 commutation after lifting the limit, got %d", workers, got)
+		}
+	}
+}
+
+// TestMaxLevelAllowsLow tests that commutations within the limit reduce.
+func TestMaxLevelAllowsLow(t *testing.T) {
+	n := NewNetworkWith(WithMaxLevel(5))
+	a := n.NewReplicator(0, []int{0, 3})
+	b := n.NewReplicator(2, []int{0})
+	n.Link(a, 0, b, 0)
+	n.Link(a, 1, n.NewVar(), 0)
+	n.Link(a, 2, n.NewVar(), 0)
+	n.Link(b, 1, n.NewVar(), 0)
+	if err := n.ReduceWithBudget(context.Background(), Budget{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := n.GetStats().RepCommutation; got != 1 {
+		t.Errorf("expected 1 commutation, got %d", got)
+	}
+}
@@ -0,0 +1,217 @@
+package deltanet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrReductionLimit is returned by ReduceToNormalFormContext when the
+// network reaches its interaction cap (see SetMaxInteractions) before a
+// normal form.
+var ErrReductionLimit = errors.New("reduction limit reached")
+
+// reductionLimit halts the workers once the interaction cap or a limit of
+// the current Budget is reached, or the caller's context is done. A halted
+// worker parks the pairs it pops instead of reducing them, so ReduceAll
+// returns and the net is left as a partial result; parked pairs are queued
+// again on the next reduction.
+type reductionLimit struct {
+	max    uint64 // 0 is unlimited
+	halted atomic.Bool
+	mu     sync.Mutex
+	parked []*Wire
+
+	// Limits of the Budget being enforced, 0 when unlimited. interactions
+	// is a total, like max.
+	interactions atomic.Uint64
+	nodes        atomic.Int64
+	reason       atomic.Int32 // haltReason of the last halt
+
+	// Set once the caller's context is done. Unlike halted it survives
+	// the resumes of compactions and collections, so a cancellation that
+	// races with one still stops the workers.
+	cancelled atomic.Bool
+}
+
+// haltReason records which limit halted reduction.
+type haltReason int32
+
+const (
+	haltNone haltReason = iota
+	haltCap
+	haltInteractions
+	haltNodes
+	haltCompact     // See memory.go
+	haltCommutation // See commutation.go
+	haltInvalid     // See validate.go
+	haltCollect     // See gc.go
+)
+
+// SetMaxInteractions caps the total number of interactions the network
+// performs, counted as in Stats.TotalReductions. Once the cap is reached
+// the workers stop reducing and ReduceAll, ReducePhase1 and
+// ReduceToNormalForm return early with the net partially reduced. Zero
+// removes the cap. It must be called before reduction starts.
+func (n *Network) SetMaxInteractions(limit uint64) {
+	n.limit.max = limit
+}
+
+// WithMaxInteractions caps the interactions of the network (see
+// SetMaxInteractions).
+func WithMaxInteractions(limit uint64) Option {
+	return func(n *Network) { n.SetMaxInteractions(limit) }
+}
+
+// halted reports whether workers must stop reducing.
+func (n *Network) halted() bool {
+	if n.limit.halted.Load() {
+		return true
+	}
+	if n.limit.cancelled.Load() {
+		n.limit.halted.Store(true)
+		return true
+	}
+	reason := haltNone
+	switch ops := n.stat(statOps); {
+	case n.limit.max > 0 && ops >= n.limit.max:
+		reason = haltCap
+	case n.limit.interactions.Load() > 0 && ops >= n.limit.interactions.Load():
+		reason = haltInteractions
+	case n.limit.nodes.Load() > 0 && n.nodes.count.Load() >= n.limit.nodes.Load():
+		reason = haltNodes
+	case n.compactDue():
+		reason = haltCompact
+	case n.collectDue():
+		reason = haltCollect
+	default:
+		return false
+	}
+	n.limit.reason.Store(int32(reason))
+	n.limit.halted.Store(true)
+	return true
+}
+
+// park sets a popped pair aside while reduction is halted.
+func (n *Network) park(w *Wire) {
+	n.limit.mu.Lock()
+	n.limit.parked = append(n.limit.parked, w)
+	n.limit.mu.Unlock()
+	n.hold(w)
+	n.pairs.Done()
+}
+
+// watch halts reduction once ctx is done, until the returned function is
+// called.
+func (n *Network) watch(ctx context.Context) func() {
+	n.limit.cancelled.Store(false)
+	stop := context.AfterFunc(ctx, func() {
+		n.limit.cancelled.Store(true)
+		n.limit.halted.Store(true)
+	})
+	return func() {
+		stop()
+		n.limit.cancelled.Store(false)
+	}
+}
+
+// resume lifts a halt and queues the parked pairs again. With the cap
+// still reached, workers park them again as soon as they are popped.
+func (n *Network) resume() {
+	n.limit.mu.Lock()
+	parked := n.limit.parked
+	n.limit.parked = nil
+	n.limit.halted.Store(false)
+	n.limit.reason.Store(int32(haltNone))
+	n.limit.mu.Unlock()
+	n.commute.mu.Lock()
+	n.commute.err = nil
+	n.commute.pair = [2]Node{}
+	n.commute.mu.Unlock()
+	n.checks.mu.Lock()
+	n.checks.err = nil
+	n.checks.mu.Unlock()
+	for _, w := range parked {
+		n.pairs.Add(1)
+		n.release(w)
+		n.schedule(w, w.depth, nil)
+	}
+}
+
+// ReduceToNormalFormContext runs ReduceToNormalForm until it completes,
+// ctx is done or the interaction cap is reached. In the last two cases it
+// returns ctx.Err() or an error wrapping ErrReductionLimit and leaves the
+// net partially reduced: it can be read back, or reduced further after
+// raising the cap.
+func (n *Network) ReduceToNormalFormContext(ctx context.Context) error {
+	if n.closed.Load() {
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer n.watch(ctx)()
+
+	if n.phase == 2 {
+		// Halted in phase 2, or restored from a snapshot taken there: the
+		// fans are already rotated and must not meet phase 1 rules.
+		n.resume()
+	} else {
+		if err := n.reducePhase1(ctx); err != nil {
+			return err
+		}
+		n.SetPhase(2)
+	}
+
+	// Phase 2
+	n.reduceAll()
+	if err := n.stopped(ctx); err != nil {
+		return err
+	}
+
+	// Final Canonicalization (Decay/Merge)
+	for n.ApplyCanonicalRules().Changed() {
+	}
+	return n.stopped(ctx)
+}
+
+// reduceAllContext runs ReduceAll until it completes or is halted.
+func (n *Network) reduceAllContext(ctx context.Context) error {
+	if n.closed.Load() {
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer n.watch(ctx)()
+	n.resume()
+	n.reduceAll()
+	return n.stopped(ctx)
+}
+
+// stopped returns why reduction was halted, if it was.
+func (n *Network) stopped(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if n.limit.halted.Load() {
+		switch haltReason(n.limit.reason.Load()) {
+		case haltInteractions:
+			return n.budgetError(LimitInteractions)
+		case haltNodes:
+			return n.budgetError(LimitNodes)
+		case haltCommutation:
+			if err := n.commutationError(); err != nil {
+				return err
+			}
+		case haltInvalid:
+			if err := n.validationError(); err != nil {
+				return err
+			}
+		}
+		return fmt.Errorf("%w: %d interactions", ErrReductionLimit, n.stat(statOps))
+	}
+	return nil
+}
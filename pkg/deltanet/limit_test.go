@@ -0,0 +1,47 @@
+package deltanet
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// TestMaxInteractions tests that the cap stops reduction with a partial
+// result that can be resumed after lifting it.
+func TestMaxInteractions(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		n := NewNetworkWith(WithWorkers(workers), WithMaxInteractions(50))
+		buildCommutations(n, 200)
+
+		err := n.ReduceToNormalFormContext(context.Background())
+		if !errors.Is(err, ErrReductionLimit) {
+			t.Fatalf("%d workers: expected ErrReductionLimit, got %v", workers, err)
+		}
+		// Workers already past the check finish their pair.
+		if got := n.GetStats().TotalReductions; got < 50 || got >= 50+uint64(workers)+1 {
+			t.Errorf("%d workers: expected about 50 interactions, got %d", workers, got)
+		}
+
+		n.SetMaxInteractions(0)
+		if err := n.ReduceToNormalFormContext(context.Background()); err != nil {
+			t.Fatalf("%d workers: resume: %v", workers, err)
+		}
+		if got := n.GetStats().FanRepCommutation; got != 200 {
+			t.Errorf("%d workers: expected 200 commutations after resuming, got %d", workers, got)
+		}
+	}
+}
+
+// TestReduceContextCanceled tests that a done context stops reduction.
+func TestReduceContextCanceled(t *testing.T) {
+	n := NewNetwork()
+	buildCommutations(n, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := n.ReduceToNormalFormContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if got := n.GetStats().TotalReductions; got != 0 {
+		t.Errorf("expected no interactions, got %d", got)
+	}
+}
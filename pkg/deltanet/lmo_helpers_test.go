@@ -25,10 +25,8 @@ func newEraserWithFanSink(net *Network) (Node, Node) {
 }
 
 func tracedNet(capacity int) *Network {
-	n := NewNetwork()
-	n.EnableTrace(capacity)
-	n.workers = 1 // Force sequential execution for deterministic order tests
-	return n
+	// Force sequential execution for deterministic order tests
+	return NewNetworkWith(WithTrace(capacity), WithWorkers(1))
 }
 
 func firstTraceEvent(t *testing.T, net *Network) TraceEvent {
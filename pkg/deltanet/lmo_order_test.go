@@ -3,6 +3,9 @@ package deltanet
 import "testing"
 
 func TestLeftmostPrefersRootFanFan(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
 	traceNet := tracedNet(8)
 
 	innerLeft := newFanWithSinks(traceNet)
@@ -23,6 +26,9 @@ func TestLeftmostPrefersRootFanFan(t *testing.T) {
 }
 
 func TestLeftmostPrefersRootFanRep(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
 	traceNet := tracedNet(8)
 
 	innerRep := newReplicatorWithSinks(traceNet, 0, []int{0})
@@ -43,6 +49,9 @@ func TestLeftmostPrefersRootFanRep(t *testing.T) {
 }
 
 func TestLeftmostPrefersRootEraserFan(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
 	traceNet := tracedNet(8)
 
 	// Inner pair: Fan-Fan
@@ -65,6 +74,9 @@ func TestLeftmostPrefersRootEraserFan(t *testing.T) {
 }
 
 func TestLeftmostPrefersRootEraserRep(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
 	traceNet := tracedNet(8)
 
 	// Inner pair: Fan-Fan
@@ -87,6 +99,9 @@ func TestLeftmostPrefersRootEraserRep(t *testing.T) {
 }
 
 func TestLeftmostPrefersRootRepRep(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
 	traceNet := tracedNet(8)
 
 	// Inner pair: Fan-Fan
@@ -109,6 +124,9 @@ func TestLeftmostPrefersRootRepRep(t *testing.T) {
 }
 
 func TestLeftmostPrefersRootRepRepComm(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
 	traceNet := tracedNet(8)
 
 	// Inner pair: Fan-Fan
@@ -180,8 +198,7 @@ func TestDepthIncrement(t *testing.T) {
 // TestLMOConcurrentReduction verifies that multiple workers maintain
 // leftmost-outermost order through depth-based prioritization
 func TestLMOConcurrentReduction(t *testing.T) {
-	net := NewNetwork()
-	net.SetWorkers(4)
+	net := NewNetworkWith(WithWorkers(4))
 
 	// Create outer and inner active pairs at different depths
 	// Outer pair should be reduced first regardless of worker count
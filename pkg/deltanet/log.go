@@ -0,0 +1,31 @@
+package deltanet
+
+import "log/slog"
+
+// discard is the logger of a silenced network.
+var discard = slog.New(slog.DiscardHandler)
+
+// SetLogger sets the logger the network reports diagnostics to: warnings
+// about interactions it cannot perform and errors of native applications,
+// and, at debug level, the steps of code built on the network such as the
+// lambda readback. A nil logger silences them. By default they go to
+// slog.Default(), so its level and output apply.
+func (n *Network) SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = discard
+	}
+	n.logger.Store(l)
+}
+
+// WithLogger sets the network's logger (see SetLogger).
+func WithLogger(l *slog.Logger) Option {
+	return func(n *Network) { n.SetLogger(l) }
+}
+
+// Logger returns the logger the network reports diagnostics to.
+func (n *Network) Logger() *slog.Logger {
+	if l := n.logger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
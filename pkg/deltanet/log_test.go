@@ -0,0 +1,37 @@
+package deltanet
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestLogger tests that diagnostics go to the network's logger, and
+// nowhere once it is silenced.
+func TestLogger(t *testing.T) {
+	apply := func(net *Network) {
+		app := net.NewFan()
+		net.Link(app, 0, net.NewNative("missing"), 0)
+		net.Link(app, 2, net.NewData(1), 0)
+		net.Link(app, 1, net.NewVar(), 0)
+		net.ReduceAll()
+	}
+
+	var out bytes.Buffer
+	apply(NewNetworkWith(WithLogger(slog.New(slog.NewTextHandler(&out, nil)))))
+	if s := out.String(); !strings.Contains(s, "level=ERROR") || !strings.Contains(s, "native=missing") {
+		t.Errorf("expected an error about the missing native, got %q", s)
+	}
+
+	net := NewNetwork()
+	if net.Logger() != slog.Default() {
+		t.Errorf("expected the default logger")
+	}
+	net.SetLogger(nil)
+	if net.Logger().Enabled(context.Background(), slog.LevelError) {
+		t.Errorf("expected a silenced logger")
+	}
+	apply(net)
+}
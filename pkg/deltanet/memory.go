@@ -0,0 +1,53 @@
+package deltanet
+
+import "sync/atomic"
+
+// memoryThreshold triggers compaction of the net during reduction, trading
+// time for space as the paper suggests for erasure canonicalization.
+type memoryThreshold struct {
+	limit       int64        // 0 disables compaction
+	next        atomic.Int64 // Registered nodes at which the next compaction runs
+	compactions atomic.Uint64
+}
+
+// SetMemoryThreshold makes reduction compact the net whenever the number of
+// registered nodes reaches limit: the workers pause, the parts of the net
+// no longer connected to its roots are erased (see ApplyErasureCanonization),
+// dead nodes are collected and reduction resumes. When the live net itself
+// is over the limit, the next compaction waits until it has doubled, so
+// growing nets are not compacted after every interaction. Zero disables
+// compaction. It must be called before reduction starts.
+func (n *Network) SetMemoryThreshold(limit int) {
+	n.memory.limit = int64(limit)
+	n.memory.next.Store(int64(limit))
+}
+
+// WithMemoryThreshold compacts the net during reduction (see
+// SetMemoryThreshold).
+func WithMemoryThreshold(limit int) Option {
+	return func(n *Network) { n.SetMemoryThreshold(limit) }
+}
+
+// compactDue reports whether the net has grown to the next compaction.
+func (n *Network) compactDue() bool {
+	return n.memory.limit > 0 && n.nodes.count.Load() >= n.memory.next.Load()
+}
+
+// compacted reports whether reduction was halted for a compaction.
+func (n *Network) compacted() bool {
+	return n.limit.halted.Load() && haltReason(n.limit.reason.Load()) == haltCompact
+}
+
+// compact erases unreachable parts, collects dead nodes and resumes the
+// halted reduction. Workers must be idle.
+func (n *Network) compact() {
+	n.ApplyErasureCanonization()
+	n.CollectGarbage()
+	n.memory.compactions.Add(1)
+	next := n.memory.limit
+	if live := n.nodes.count.Load(); live*2 > next {
+		next = live * 2
+	}
+	n.memory.next.Store(next)
+	n.resume()
+}
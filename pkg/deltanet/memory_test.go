@@ -0,0 +1,63 @@
+package deltanet
+
+import "testing"
+
+// garbageRing adds size fans wired into a ring with no active pairs and no
+// interface, unreachable from any root.
+func garbageRing(n *Network, size int) {
+	fans := make([]Node, size)
+	for i := range fans {
+		fans[i] = n.NewFan()
+	}
+	for i, fan := range fans {
+		n.Link(fan, 0, fans[(i+1)%size], 1)
+		if i%2 == 0 {
+			n.Link(fan, 2, fans[i+1], 2)
+		}
+	}
+}
+
+func TestMemoryThresholdCompacts(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(1))
+	// (x: x) a
+	root, arg := n.NewVar(), n.NewVar()
+	app, abs := n.NewFan(), n.NewFan()
+	n.Link(app, 1, root, 0)
+	n.Link(app, 2, arg, 0)
+	n.Link(abs, 1, abs, 2)
+	n.SetRoot(root, 0)
+	garbageRing(n, 20)
+	n.SetMemoryThreshold(n.NodeCount())
+	n.Link(app, 0, abs, 0)
+
+	n.ReduceAll()
+	if !n.IsConnected(root, 0, arg, 0) {
+		t.Fatal("result is not a")
+	}
+	r := n.Report()
+	if r.Compactions == 0 {
+		t.Fatal("no compaction ran")
+	}
+	if r.Pruned != 20 {
+		t.Errorf("pruned %d nodes, want the 20 of the ring", r.Pruned)
+	}
+	if live := n.ActiveNodeCount(); live > 4 {
+		t.Errorf("%d nodes live after compaction", live)
+	}
+}
+
+func TestMemoryThresholdDisabled(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(1))
+	root, arg := n.NewVar(), n.NewVar()
+	app, abs := n.NewFan(), n.NewFan()
+	n.Link(app, 1, root, 0)
+	n.Link(app, 2, arg, 0)
+	n.Link(abs, 1, abs, 2)
+	garbageRing(n, 20)
+	n.Link(app, 0, abs, 0)
+
+	n.ReduceAll()
+	if r := n.Report(); r.Compactions != 0 || r.Pruned != 0 {
+		t.Errorf("compacted without a threshold: %+v", r)
+	}
+}
@@ -0,0 +1,67 @@
+package deltanet
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Node metadata is kept in a side table keyed by node ID, so nodes stay
+// small when no metadata is attached. Frontends use it to record where a
+// node came from, e.g. the source span of the lambda term that built it.
+// Nodes created by commutation inherit the metadata of the node they copy,
+// so trace events and errors can be mapped back to the source.
+
+// SetMeta attaches metadata to a node, replacing any previous value.
+func (n *Network) SetMeta(node Node, meta interface{}) {
+	n.metaMu.Lock()
+	if n.meta == nil {
+		n.meta = make(map[uint64]interface{})
+	}
+	n.meta[node.ID()] = meta
+	n.metaMu.Unlock()
+	atomic.StoreUint32(&n.metaOn, 1)
+}
+
+// Meta returns the metadata attached to the node with the given ID.
+func (n *Network) Meta(id uint64) (interface{}, bool) {
+	if atomic.LoadUint32(&n.metaOn) == 0 {
+		return nil, false
+	}
+	n.metaMu.RLock()
+	defer n.metaMu.RUnlock()
+	meta, ok := n.meta[id]
+	return meta, ok
+}
+
+// inheritMeta copies the metadata of original onto a node created from it.
+func (n *Network) inheritMeta(copy, original Node) {
+	if meta, ok := n.Meta(original.ID()); ok {
+		n.metaMu.Lock()
+		n.meta[copy.ID()] = meta
+		n.metaMu.Unlock()
+	}
+}
+
+// NodeError is an error raised while reducing a node that carries metadata.
+// Meta is typically a source location.
+type NodeError struct {
+	NodeID uint64
+	Meta   interface{}
+	Err    error
+}
+
+func (e *NodeError) Error() string {
+	return fmt.Sprintf("%v: %v", e.Meta, e.Err)
+}
+
+func (e *NodeError) Unwrap() error {
+	return e.Err
+}
+
+// locate wraps err in a NodeError when node carries metadata.
+func (n *Network) locate(node Node, err error) error {
+	if meta, ok := n.Meta(node.ID()); ok {
+		return &NodeError{NodeID: node.ID(), Meta: meta, Err: err}
+	}
+	return err
+}
@@ -0,0 +1,60 @@
+package deltanet
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestMetaInheritedByCopies tests that nodes created by commutation carry
+// the metadata of the node they copy, as do the originals reused as copies.
+func TestMetaInheritedByCopies(t *testing.T) {
+	net := tracedNet(16)
+	fan := net.NewFan()
+	rep := net.NewReplicator(0, []int{0, 0})
+	net.SetMeta(fan, "fan-origin")
+	net.SetMeta(rep, "rep-origin")
+	net.Link(fan, 0, rep, 0)
+	for i := 1; i <= 2; i++ {
+		net.Link(fan, i, net.NewVar(), 0)
+		net.Link(rep, i, net.NewVar(), 0)
+	}
+
+	net.ReduceAll()
+
+	counts := make(map[interface{}]int)
+	for _, node := range net.snapshotNodes() {
+		if meta, ok := net.Meta(node.ID()); ok && !node.IsDead() {
+			counts[meta]++
+		}
+	}
+	if counts["fan-origin"] != 2 || counts["rep-origin"] != 2 {
+		t.Errorf("Expected two fan and two replicator copies with metadata, got %v", counts)
+	}
+}
+
+// TestNativeErrorCarriesMeta tests that a failing native application reports
+// the metadata of the application fan.
+func TestNativeErrorCarriesMeta(t *testing.T) {
+	net := NewNetwork()
+	boom := errors.New("boom")
+	net.RegisterNative("fail", func(interface{}) (interface{}, error) { return nil, boom })
+
+	app := net.NewFan()
+	net.SetMeta(app, "3:7")
+	net.Link(app, 0, net.NewNative("fail"), 0)
+	net.Link(app, 2, net.NewData(1), 0)
+	output := net.NewVar()
+	net.Link(app, 1, output, 0)
+
+	net.ReduceAll()
+
+	res, _ := net.GetLink(output, 0)
+	err, _ := res.GetValue().(error)
+	var nodeErr *NodeError
+	if !errors.As(err, &nodeErr) || nodeErr.Meta != "3:7" || !errors.Is(err, boom) {
+		t.Fatalf("Expected NodeError at 3:7 wrapping boom, got %v", res.GetValue())
+	}
+	if err.Error() != "3:7: boom" {
+		t.Errorf("Unexpected message %q", err.Error())
+	}
+}
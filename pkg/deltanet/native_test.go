@@ -1,6 +1,7 @@
 package deltanet
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 )
@@ -204,3 +205,57 @@ func TestConcatLength(t *testing.T) {
 
 	t.Logf("length (concat \"hello\" \"world\") = %v", result)
 }
+
+// TestDataApplied tests that applying a Data node reduces to an error Data
+// node and erases the argument.
+func TestDataApplied(t *testing.T) {
+	net := NewNetwork()
+	app := net.NewFan()
+	net.Link(app, 0, net.NewData(5), 0)
+	// The argument is an abstraction, λx. x.
+	abs := net.NewFan()
+	net.Link(abs, 1, abs, 2)
+	net.Link(app, 2, abs, 0)
+	output := net.NewVar()
+	net.Link(app, 1, output, 0)
+
+	net.ReduceAll()
+
+	result, _ := net.GetLink(output, 0)
+	if result == nil || result.Type() != NodeTypeData {
+		t.Fatalf("expected an error Data node, got %v", result)
+	}
+	err, ok := result.GetValue().(error)
+	if !ok || !errors.Is(err, ErrNotFunction) {
+		t.Fatalf("expected ErrNotFunction, got %v", result.GetValue())
+	}
+	// Erasing the abstraction leaves two erasers facing each other on its
+	// loop, which erase each other too.
+	if s := net.GetStats(); s.NativeCalls != 1 || s.Erasure != 2 {
+		t.Errorf("expected an application and two erasures, got %+v", s)
+	}
+	if live := net.ActiveNodeCount(); live != 2 {
+		t.Errorf("expected the output and the error to remain, got %d live nodes", live)
+	}
+}
+
+// TestDataInPhase2 tests that an abstraction returning Data is left alone
+// after the phase 2 rotation faces its body towards the Data node.
+func TestDataInPhase2(t *testing.T) {
+	net := NewNetwork()
+	abs := net.NewFan()
+	data := net.NewData(5)
+	net.Link(abs, 1, data, 0)
+	net.Link(abs, 2, net.NewEraser(), 0)
+	output := net.NewVar()
+	net.Link(abs, 0, output, 0)
+
+	net.ReduceToNormalForm()
+
+	if node, _ := net.GetLink(data, 0); node != abs {
+		t.Errorf("expected the data to stay the body of the abstraction, got %v", node)
+	}
+	if node, _ := net.GetLink(output, 0); node != abs {
+		t.Errorf("expected the abstraction at the output, got %v", node)
+	}
+}
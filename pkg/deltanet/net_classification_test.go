@@ -9,8 +9,7 @@ import (
 // to a *canonical* Δ S-net."
 // Canonical nets are those directly translated from λ-terms.
 func TestCanonicalNetDefinition(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Build a canonical net: (\x. x) - identity function
 	// This is canonical because it's directly translated from a λ-term
@@ -52,8 +51,10 @@ func TestCanonicalNetDefinition(t *testing.T) {
 // "Δ_S^c ⊆ Δ_S^p ⊂ Δ_S"
 // Proper nets are those reachable from canonical nets through interactions.
 func TestProperNetDefinition(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	n := NewNetworkWith(WithTrace(100))
 
 	// Start with canonical net: (\x. x x) (\y. y)
 	// After one fan-fan annihilation, we get a proper net (still normalizing)
@@ -159,8 +160,10 @@ func TestCanonicalVsProperVsArbitrary(t *testing.T) {
 // "During reduction, fan-out replicators may be produced. In a fan-out replicator,
 // the principal port is a parent port and each auxiliary port is a child port."
 func TestFanOutReplicatorInProperNet(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	n := NewNetworkWith(WithTrace(100))
 
 	// Create a scenario that produces fan-out replicators
 	// Fan-Rep commutation produces both fan-in and fan-out replicators
@@ -205,8 +208,7 @@ func TestFanOutReplicatorInProperNet(t *testing.T) {
 // TestNormalFormIsCanonical tests the key property:
 // "Since all normal Δ-nets are canonical, the Δ-Nets systems are all Church--Rosser confluent."
 func TestNormalFormIsCanonical(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Build complex term that goes through non-canonical proper states
 	// (\x. x) ((\y. y) z)
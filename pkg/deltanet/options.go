@@ -0,0 +1,150 @@
+package deltanet
+
+import (
+	"fmt"
+	"io"
+)
+
+// Strategy selects how Reduce drives a network to its result.
+type Strategy int
+
+const (
+	// StrategyActivePairs reduces active pairs in leftmost-outermost order
+	// until none remain (ReduceAll).
+	StrategyActivePairs Strategy = iota
+	// StrategyNormalForm runs the full strategy of the paper: phase 1 with
+	// canonical rules, then phase 2 (ReduceToNormalForm).
+	StrategyNormalForm
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case StrategyActivePairs:
+		return "active-pairs"
+	case StrategyNormalForm:
+		return "normal-form"
+	default:
+		return fmt.Sprintf("Strategy(%d)", int(s))
+	}
+}
+
+// Option configures a Network built with NewNetworkWith.
+type Option func(*Network)
+
+// NewNetworkWith creates a network and applies the given options in order.
+func NewNetworkWith(opts ...Option) *Network {
+	n := NewNetwork()
+	for _, opt := range opts {
+		opt(n)
+	}
+	return n
+}
+
+// WithWorkers sets the number of reduction workers (see SetWorkers).
+func WithWorkers(w int) Option {
+	return func(n *Network) { n.SetWorkers(w) }
+}
+
+// WithNatives runs each registration function on the network, e.g.
+// natives.Register or natives.RegisterIntegers.
+func WithNatives(register ...func(*Network)) Option {
+	return func(n *Network) {
+		for _, r := range register {
+			r(n)
+		}
+	}
+}
+
+// WithNative registers a single pure native function.
+func WithNative(name string, fn NativeFunc) Option {
+	return func(n *Network) { n.RegisterNative(name, fn) }
+}
+
+// WithHandlers merges the handlers of each scope into the network's root
+// handler scope (see Handlers). Later scopes override earlier ones for the
+// same effect name or pattern.
+func WithHandlers(scopes ...*HandlerScope) Option {
+	return func(n *Network) {
+		if n.handlers == nil {
+			n.handlers = NewHandlerScope()
+			n.handlers.Profile = n.Profile()
+		}
+		for _, scope := range scopes {
+			for _, name := range scope.Handled {
+				capability, known := scope.Capabilities[name]
+				if !known {
+					capability = CapIO
+				}
+				n.handlers.RegisterWithCapability(name, scope.Handlers[name], capability)
+			}
+		}
+	}
+}
+
+// WithParallel enables parallel reduction of same-depth pairs (see
+// SetParallel).
+func WithParallel() Option {
+	return func(n *Network) { n.SetParallel(true) }
+}
+
+// WithStrategy selects the strategy used by Reduce.
+func WithStrategy(s Strategy) Option {
+	return func(n *Network) { n.strategy = s }
+}
+
+// WithTrace enables the trace buffer with the given capacity.
+func WithTrace(capacity int) Option {
+	return func(n *Network) { n.EnableTrace(capacity) }
+}
+
+// WithTraceRing enables the trace ring buffer, keeping the most recent
+// capacity events (see EnableTraceRing).
+func WithTraceRing(capacity int) Option {
+	return func(n *Network) { n.EnableTraceRing(capacity) }
+}
+
+// WithTraceFunc delivers trace events to fn as they happen (see
+// SetTraceFunc).
+func WithTraceFunc(fn func(TraceEvent)) Option {
+	return func(n *Network) { n.SetTraceFunc(fn) }
+}
+
+// WithTraceWriter streams trace events to w as JSON lines (see
+// StreamTrace).
+func WithTraceWriter(w io.Writer) Option {
+	return func(n *Network) { n.StreamTrace(w) }
+}
+
+// WithTraceSampling records only every Nth trace event (see
+// SetTraceSampling).
+func WithTraceSampling(every int) Option {
+	return func(n *Network) { n.SetTraceSampling(every) }
+}
+
+// WithProfile restricts the natives and root handlers visible to the
+// network (see SetProfile).
+func WithProfile(p *Profile) Option {
+	return func(n *Network) { n.SetProfile(p) }
+}
+
+// Handlers returns the root handler scope configured with WithHandlers, or
+// nil when none was configured.
+func (n *Network) Handlers() *HandlerScope {
+	return n.handlers
+}
+
+// Strategy returns the strategy used by Reduce.
+func (n *Network) Strategy() Strategy {
+	return n.strategy
+}
+
+// Reduce reduces the network with its configured strategy and returns the
+// error of ReduceToNormalForm or ReduceAll.
+func (n *Network) Reduce() error {
+	switch n.strategy {
+	case StrategyNormalForm:
+		return n.ReduceToNormalForm()
+	default:
+		return n.ReduceAll()
+	}
+}
@@ -0,0 +1,73 @@
+package deltanet
+
+import "testing"
+
+// TestNewNetworkWith tests that options configure the network at
+// construction time.
+func TestNewNetworkWith(t *testing.T) {
+	io := NewHandlerScope()
+	io.Register("IO.*", func(Effect, *Continuation) (interface{}, error) { return "io", nil })
+	console := NewHandlerScope()
+	console.RegisterWithCapability("Console.Print", func(Effect, *Continuation) (interface{}, error) { return "print", nil }, CapConsole)
+
+	net := NewNetworkWith(
+		WithWorkers(0),
+		WithTrace(8),
+		WithNative("id", func(v interface{}) (interface{}, error) { return v, nil }),
+		WithNatives(func(n *Network) {
+			n.RegisterNative("one", func(interface{}) (interface{}, error) { return 1, nil })
+		}),
+		WithHandlers(io, console),
+		WithProfile(ProfileConsole),
+		WithStrategy(StrategyNormalForm),
+	)
+
+	if net.workers != 1 {
+		t.Errorf("Expected workers clamped to 1, got %d", net.workers)
+	}
+	if net.traceCap != 8 {
+		t.Errorf("Expected trace capacity 8, got %d", net.traceCap)
+	}
+	for _, name := range []string{"id", "one"} {
+		if _, ok := net.GetNative(name); !ok {
+			t.Errorf("Expected native %q to be registered", name)
+		}
+	}
+	if net.Strategy() != StrategyNormalForm {
+		t.Errorf("Expected normal-form strategy, got %v", net.Strategy())
+	}
+
+	handlers := net.Handlers()
+	if handlers == nil || !handlers.CanHandle("Console.Print") {
+		t.Fatal("Expected console handler in root scope")
+	}
+	if handlers.CanHandle("IO.Read") {
+		t.Error("IO handler should be hidden by the console profile")
+	}
+	net.SetProfile(nil)
+	if !handlers.CanHandle("IO.Read") {
+		t.Error("IO handler should be visible without a profile")
+	}
+}
+
+// TestReduceUsesStrategy tests that Reduce runs the configured strategy.
+func TestReduceUsesStrategy(t *testing.T) {
+	for _, strategy := range []Strategy{StrategyActivePairs, StrategyNormalForm} {
+		net := NewNetworkWith(WithStrategy(strategy))
+		// (x: x) applied to a free variable
+		id := net.NewFan()
+		net.Link(id, 1, id, 2)
+		app := net.NewFan()
+		net.Link(app, 0, id, 0)
+		arg := net.NewVar()
+		net.Link(app, 2, arg, 0)
+		output := net.NewVar()
+		net.Link(app, 1, output, 0)
+
+		net.Reduce()
+
+		if res, _ := net.GetLink(output, 0); res != arg {
+			t.Errorf("%v: expected the argument at the output, got %v", strategy, res)
+		}
+	}
+}
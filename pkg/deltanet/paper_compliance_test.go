@@ -1,76 +1,62 @@
-package deltanet
+package deltanet_test
 
 import (
 	"testing"
 	"time"
-)
 
-// TestLMO_ErasureOfDivergingTerm tests that a diverging term (Omega) is erased
-// if it is an argument to a function that ignores it (K combinator),
-// ensuring that the reduction strategy is Leftmost-Outermost (or at least Outermost).
-// Term: (\x. y) ((\z. z z) (\z. z z)) -> y
-func TestLMO_ErasureOfDivergingTerm(t *testing.T) {
-	net := NewNetwork()
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/deltanet/testsupport"
+)
 
+// buildKOmega builds (\x. y) ((\z. z z) (\z. z z)) with its result on a
+// registered root Var, and returns the root and y. The K redex leaves Ω
+// detached from the root, so it is only erased by erasure canonicalization.
+func buildKOmega(net *deltanet.Network) (root, y deltanet.Node) {
 	// Construct (\x. y)
 	// Abs Fan: 0=Result, 1=Body, 2=Var
 	abs := net.NewFan()
-	y := net.NewVar()
+	y = net.NewVar()
 	era := net.NewEraser()
 	net.LinkAt(abs, 1, y, 0, 0)
 	net.LinkAt(abs, 2, era, 0, 0)
 
-	// Construct Omega: (\z. z z) (\z. z z)
-	buildSelfApp := func(depth uint64) Node {
-		// Abs Fan: 0=Result, 1=Body, 2=Var
-		abs := net.NewFan()
-
-		// Body: z z (App)
-		// App Fan: 0=Fun, 1=Result, 2=Arg
-		app := net.NewFan()
-
-		// Abs.1 (Body) -> App.1 (Result)
-		net.LinkAt(abs, 1, app, 1, depth)
-
-		// Var z is shared: Replicator
-		// Rep: 0=Input, 1=Fun, 2=Arg
-		rep := net.NewReplicator(0, []int{0, 0})
-		// Rep.0 -> Abs.2 (Var)
-		net.LinkAt(rep, 0, abs, 2, depth)
-		// Rep.1 -> App.0 (Fun)
-		net.LinkAt(rep, 1, app, 0, depth)
-		// Rep.2 -> App.2 (Arg)
-		net.LinkAt(rep, 2, app, 2, depth)
-
-		return abs
-	}
-
-	omegaFun := buildSelfApp(1)
-	omegaArg := buildSelfApp(2)
-
-	// Omega App: 0=Fun, 1=Result, 2=Arg
-	omegaApp := net.NewFan()
-	// OmegaApp.0 -> OmegaFun.0 (Redex!)
-	// Depth 1
-	net.LinkAt(omegaApp, 0, omegaFun, 0, 1)
-	// OmegaApp.2 -> OmegaArg.0
-	net.LinkAt(omegaApp, 2, omegaArg, 0, 2)
+	// Omega as an argument: one level and depth down
+	omega, omegaPort := testsupport.BuildOmega(net, 1, 1)
 
 	// Main App: (\x. y) Omega
 	// App Fan: 0=Fun, 1=Result, 2=Arg
 	mainApp := net.NewFan()
 	// MainApp.0 -> Abs.0 (Redex!)
-	// Depth 0
+	// Depth 0 - leftmost
 	net.LinkAt(mainApp, 0, abs, 0, 0)
-	// MainApp.2 -> OmegaApp.1 (Arg connects to Result of Omega)
-	net.LinkAt(mainApp, 2, omegaApp, 1, 1)
+	// MainApp.2 -> Omega result
+	net.LinkAt(mainApp, 2, omega, omegaPort, 1)
 
 	// Root interface
-	root := net.NewVar()
-	// MainApp.1 -> Root
+	root = net.NewVar()
 	net.LinkAt(mainApp, 1, root, 0, 0)
+	net.SetRoot(root, 0)
+	return root, y
+}
+
+// TestLMO_ErasureOfDivergingTerm tests that a diverging term (Omega) is erased
+// if it is an argument to a function that ignores it (K combinator),
+// ensuring that the reduction strategy is Leftmost-Outermost.
+// Term: (\x. y) ((\z. z z) (\z. z z)) -> y
+// Omega never reaches a normal form, so the reduction is bounded: the first
+// interaction must be the outermost K redex, which detaches Omega, and
+// erasure canonicalization then removes it.
+func TestLMO_ErasureOfDivergingTerm(t *testing.T) {
+	net := deltanet.NewNetwork()
+	root, y := buildKOmega(net)
+
+	net.ReduceWithLimit(1)
+	if !net.IsConnected(root, 0, y, 0) {
+		t.Fatal("Head normal form not reached after one interaction - LMO not enforced")
+	}
+
+	net.ApplyErasureCanonization()
 
-	// Reduce
 	// Use a channel to detect timeout/hang
 	done := make(chan bool)
 	go func() {
@@ -82,7 +68,7 @@ func TestLMO_ErasureOfDivergingTerm(t *testing.T) {
 	case <-done:
 		// Success
 	case <-time.After(2 * time.Second):
-		t.Fatal("Reduction timed out - likely looping (LMO failure)")
+		t.Fatal("Reduction timed out - Omega was not erased")
 	}
 
 	// Check result: Root should be connected to y
@@ -90,3 +76,66 @@ func TestLMO_ErasureOfDivergingTerm(t *testing.T) {
 		t.Errorf("Did not reduce to y")
 	}
 }
+
+// TestErasureCanonLMORequirement tests:
+// "In order to ensure that no reduction operations are applied in a subnet that is later
+// going to be erased, a sequential leftmost-outermost reduction order needs to be followed."
+func TestErasureCanonLMORequirement(t *testing.T) {
+	if !deltanet.TraceCompiled() {
+		t.Skip("built with notrace")
+	}
+	n := deltanet.NewNetworkWith(deltanet.WithTrace(100))
+
+	// Build: (\x. y) ((\z. z z) (\z. z z))
+	// The argument is Omega (diverging term)
+	// With LMO, the leftmost reduction (main application) happens first,
+	// before any interaction inside the argument that it erases
+	root, y := buildKOmega(n)
+
+	// Omega diverges: reduce a bounded number of steps
+	n.ReduceWithLimit(50)
+
+	trace := n.TraceSnapshot()
+	if len(trace) == 0 {
+		t.Fatal("no interactions traced")
+	}
+	if first := trace[0]; first.Rule != deltanet.RuleFanFan || first.Depth != 0 {
+		t.Errorf("first interaction %v at depth %d, want the root redex at depth 0", first.Rule, first.Depth)
+	}
+	for _, e := range trace[1:] {
+		if e.Depth == 0 {
+			t.Errorf("interaction %d (%v) at depth 0 after the root redex", e.Step, e.Rule)
+		}
+	}
+
+	// Result: root -> y, whatever happens inside Omega
+	if !n.IsConnected(root, 0, y, 0) {
+		t.Errorf("Result should be y")
+	}
+
+	t.Log("LMO requirement: ensures diverging subnets are erased before evaluation")
+}
+
+// TestCompactionErasesDetachedOmega tests that compaction during reduction
+// erases Omega once the K redex detaches it, so reducing to normal form
+// terminates.
+func TestCompactionErasesDetachedOmega(t *testing.T) {
+	net := deltanet.NewNetworkWith(deltanet.WithMemoryThreshold(200))
+	root, y := buildKOmega(net)
+
+	done := make(chan bool)
+	go func() {
+		net.ReduceToNormalForm()
+		done <- true
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Reduction timed out - compaction did not erase Omega")
+	}
+
+	if !net.IsConnected(root, 0, y, 0) {
+		t.Errorf("Did not reduce to y")
+	}
+}
@@ -0,0 +1,65 @@
+package deltanet
+
+import "sync"
+
+// Parallel reduction
+//
+// By default every worker takes reductionMu around reducePair, so pairs are
+// reduced one at a time in leftmost-outermost order and extra workers only
+// help with scheduling. In parallel mode the lock is replaced by a depth
+// gate: any number of workers may reduce pairs of the same depth at once,
+// but a pair at another depth waits until all of them are done. Depth-first
+// priority (and hence the LMO order between levels of the term) is kept;
+// only the order among pairs of one depth is given up.
+//
+// Active pairs at the same depth are disjoint: each node has a single
+// principal port, so two pairs never share a node, and the rewiring
+// primitives (splice, fuse) lock the wires they touch and retry on
+// conflicts. Interaction nets are strongly confluent, so reducing
+// independent pairs in any order, or at the same time, reaches the same
+// normal form with the same number of interactions. The phase 1/phase 2
+// split and the canonical rules, which run between ReduceAll calls, are
+// unaffected.
+
+// depthGate admits reductions of a single depth at a time.
+type depthGate struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	depth  uint64
+	active int
+}
+
+// enter blocks until no reduction at another depth is in progress.
+func (g *depthGate) enter(depth uint64) {
+	g.mu.Lock()
+	if g.cond == nil {
+		g.cond = sync.NewCond(&g.mu)
+	}
+	for g.active > 0 && g.depth != depth {
+		g.cond.Wait()
+	}
+	g.depth = depth
+	g.active++
+	g.mu.Unlock()
+}
+
+// leave ends a reduction started with enter.
+func (g *depthGate) leave() {
+	g.mu.Lock()
+	g.active--
+	if g.active == 0 {
+		g.cond.Broadcast()
+	}
+	g.mu.Unlock()
+}
+
+// SetParallel enables or disables parallel reduction of same-depth pairs.
+// It must be called before reduction starts.
+func (n *Network) SetParallel(on bool) {
+	n.parallel = on
+}
+
+// Parallel reports whether parallel reduction is enabled.
+func (n *Network) Parallel() bool {
+	return n.parallel
+}
@@ -0,0 +1,65 @@
+package deltanet
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestParallelIndependentPairs tests that many independent same-depth pairs
+// all reduce in parallel mode.
+func TestParallelIndependentPairs(t *testing.T) {
+	const pairs = 500
+	n := NewNetworkWith(WithWorkers(8), WithParallel())
+	left := make([]Node, pairs)
+	right := make([]Node, pairs)
+	for i := 0; i < pairs; i++ {
+		a, b := n.NewFan(), n.NewFan()
+		left[i], right[i] = n.NewVar(), n.NewVar()
+		n.Link(a, 0, b, 0)
+		n.Link(a, 1, left[i], 0)
+		n.Link(b, 1, right[i], 0)
+		n.Link(a, 2, n.NewVar(), 0)
+		n.Link(b, 2, n.NewVar(), 0)
+	}
+	n.ReduceAll()
+
+	if got := n.GetStats().FanAnnihilation; got != pairs {
+		t.Errorf("expected %d annihilations, got %d", pairs, got)
+	}
+	for i := 0; i < pairs; i++ {
+		if !n.IsConnected(left[i], 0, right[i], 0) {
+			t.Fatalf("pair %d: outer vars not connected after annihilation", i)
+		}
+	}
+}
+
+// TestDepthGate tests that the gate never admits two depths at once.
+func TestDepthGate(t *testing.T) {
+	var g depthGate
+	var mu sync.Mutex
+	inside := make(map[uint64]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(depth uint64) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				g.enter(depth)
+				mu.Lock()
+				inside[depth]++
+				if len(inside) > 1 {
+					t.Errorf("depths %v reducing at once", inside)
+				}
+				mu.Unlock()
+
+				mu.Lock()
+				if inside[depth]--; inside[depth] == 0 {
+					delete(inside, depth)
+				}
+				mu.Unlock()
+				g.leave()
+			}
+		}(uint64(i % 3))
+	}
+	wg.Wait()
+}
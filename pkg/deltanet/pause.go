@@ -0,0 +1,109 @@
+package deltanet
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Safepoints
+//
+// Pause stops the workers between interactions so that the net can be
+// inspected, snapshotted or stepped mid-reduction, and Resume lets them
+// continue. Each worker holds its own lock around an interaction and checks
+// the paused flag under it. Pause raises the flag and then takes every
+// worker's lock once, so it returns only after the interactions already
+// under way are done. A worker that pops a pair while paused puts it back
+// in the scheduler before waiting, so the queue (see ActivePairs) keeps
+// every active pair.
+
+// safepoint holds the pause state of a network.
+type safepoint struct {
+	mu      sync.Mutex
+	resumed *sync.Cond
+	pauses  int // Pause calls not yet matched by Resume
+	paused  atomic.Bool
+	workers []workerLock
+}
+
+// workerLock is held by a worker while it reduces a pair.
+type workerLock struct {
+	sync.Mutex
+	_ [64]byte // Keep the next worker's lock off this cache line
+}
+
+// setWorkers sizes the worker locks. It must not run during reduction.
+func (s *safepoint) setWorkers(k int) {
+	s.workers = make([]workerLock, k)
+}
+
+// Pause stops reduction at the next interaction boundary and returns once
+// no worker is reducing. ReduceAll and the other reduction methods keep
+// waiting while the network is paused; work done on the calling goroutine
+// (ReduceWithLimit, Step, the canonical rules) is not affected. Calls nest:
+// each Pause needs its own Resume.
+func (n *Network) Pause() {
+	n.pause.mu.Lock()
+	n.pause.pauses++
+	n.pause.paused.Store(true)
+	n.pause.mu.Unlock()
+	for i := range n.pause.workers {
+		n.pause.workers[i].Lock()
+		n.pause.workers[i].Unlock()
+	}
+}
+
+// Resume undoes one Pause, letting the workers continue after the last.
+func (n *Network) Resume() {
+	n.pause.mu.Lock()
+	defer n.pause.mu.Unlock()
+	if n.pause.pauses == 0 {
+		return
+	}
+	n.pause.pauses--
+	if n.pause.pauses == 0 {
+		n.pause.paused.Store(false)
+		n.wakePaused()
+	}
+}
+
+// Paused reports whether the network is paused.
+func (n *Network) Paused() bool {
+	return n.pause.paused.Load()
+}
+
+// enterSafepoint takes worker id's lock before an interaction. It reports
+// false, without the lock, when the network is paused.
+func (n *Network) enterSafepoint(id int) bool {
+	lock := &n.pause.workers[id%len(n.pause.workers)]
+	lock.Lock()
+	if n.pause.paused.Load() {
+		lock.Unlock()
+		return false
+	}
+	return true
+}
+
+// leaveSafepoint releases the lock taken by enterSafepoint.
+func (n *Network) leaveSafepoint(id int) {
+	n.pause.workers[id%len(n.pause.workers)].Unlock()
+}
+
+// awaitResume blocks a worker until the network is resumed or closed.
+func (n *Network) awaitResume() {
+	n.pause.mu.Lock()
+	if n.pause.resumed == nil {
+		n.pause.resumed = sync.NewCond(&n.pause.mu)
+	}
+	for n.pause.pauses > 0 && !n.closed.Load() {
+		n.pause.resumed.Wait()
+	}
+	n.pause.mu.Unlock()
+}
+
+// wakePaused wakes the workers waiting in awaitResume. The caller holds
+// n.pause.mu.
+func (n *Network) wakePaused() {
+	if n.pause.resumed != nil {
+		n.pause.resumed.Broadcast()
+	}
+}
@@ -0,0 +1,82 @@
+package deltanet
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPauseResume tests that a paused net stops interacting, keeps its
+// active pairs queued and reaches the same result once resumed.
+func TestPauseResume(t *testing.T) {
+	const pairs = 20000
+	net := NewNetworkWith(WithWorkers(4), WithParallel())
+	buildCommutations(net, pairs)
+
+	done := make(chan struct{})
+	go func() {
+		net.ReduceAll()
+		close(done)
+	}()
+	net.Pause()
+	if !net.Paused() {
+		t.Fatal("Paused() = false after Pause")
+	}
+
+	before := net.GetStats()
+	time.Sleep(10 * time.Millisecond)
+	if after := net.GetStats(); after != before {
+		t.Errorf("interactions while paused: %d -> %d", before.TotalReductions, after.TotalReductions)
+	}
+	select {
+	case <-done:
+		if before.TotalReductions != pairs {
+			t.Errorf("ReduceAll returned while paused with %d of %d interactions", before.TotalReductions, pairs)
+		}
+	default:
+		if queued := len(net.ActivePairs()); uint64(queued) != pairs-before.TotalReductions {
+			t.Errorf("paused with %d queued pairs, want %d", queued, pairs-before.TotalReductions)
+		}
+	}
+	if net.Snapshot() == nil {
+		t.Error("no snapshot while paused")
+	}
+
+	net.Resume()
+	<-done
+	if got := net.GetStats().FanRepCommutation; got != pairs {
+		t.Errorf("after resume: %d commutations, want %d", got, pairs)
+	}
+}
+
+func TestPauseNests(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(2))
+	net.Pause()
+	net.Pause()
+	net.Resume()
+	if !net.Paused() {
+		t.Error("resumed after one of two pauses")
+	}
+	net.Resume()
+	net.Resume() // Unmatched, ignored
+	if net.Paused() {
+		t.Error("still paused after matching resumes")
+	}
+}
+
+// TestClosePaused tests that Close does not wait for a Resume.
+func TestClosePaused(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(2))
+	buildCommutations(net, 1000)
+	net.Start()
+	net.Pause()
+	closed := make(chan struct{})
+	go func() {
+		net.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close blocked on a paused net")
+	}
+}
@@ -0,0 +1,62 @@
+package deltanet
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// pendingPairs counts the active pairs queued or being reduced. Every
+// schedule adds one and every pair taken off the queue, reduced, dropped
+// or parked removes one, so unlike a WaitGroup the count can be read while
+// workers run. Updates are a single atomic add; only the update that
+// drops the count to zero takes the lock, to wake the waiters.
+type pendingPairs struct {
+	count atomic.Int64
+	mu    sync.Mutex
+	idle  sync.Cond // Signaled when count drops to zero, L is mu
+}
+
+// Add adds delta, which may be negative, to the count.
+func (p *pendingPairs) Add(delta int) {
+	c := p.count.Add(int64(delta))
+	if c < 0 {
+		panic("deltanet: negative pending pair count")
+	}
+	if c == 0 && delta < 0 {
+		p.mu.Lock()
+		p.cond().Broadcast()
+		p.mu.Unlock()
+	}
+}
+
+// Done removes one pair from the count.
+func (p *pendingPairs) Done() {
+	p.Add(-1)
+}
+
+// Wait blocks until the count is zero.
+func (p *pendingPairs) Wait() {
+	if p.count.Load() == 0 {
+		return
+	}
+	p.mu.Lock()
+	for p.count.Load() > 0 {
+		p.cond().Wait()
+	}
+	p.mu.Unlock()
+}
+
+// cond returns the idle condition. p.mu must be held.
+func (p *pendingPairs) cond() *sync.Cond {
+	if p.idle.L == nil {
+		p.idle.L = &p.mu
+	}
+	return &p.idle
+}
+
+// PendingPairs returns the number of active pairs queued or being reduced.
+// Pairs parked by a halt or postponed by an interceptor are not pending
+// until they are queued again. ReduceAll returns once it drops to zero.
+func (n *Network) PendingPairs() int {
+	return int(n.pairs.count.Load())
+}
@@ -0,0 +1,42 @@
+package deltanet
+
+import "testing"
+
+// TestPendingPairs tests that queued pairs are pending until reduced, and
+// that pairs parked by the interaction cap are not.
+func TestPendingPairs(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(2))
+	buildCommutations(n, 50)
+	if got := n.PendingPairs(); got != 50 {
+		t.Errorf("expected 50 pending pairs, got %d", got)
+	}
+	n.ReduceAll()
+	if got := n.PendingPairs(); got != 0 {
+		t.Errorf("expected no pending pairs after ReduceAll, got %d", got)
+	}
+
+	n = NewNetworkWith(WithWorkers(1))
+	buildCommutations(n, 50)
+	n.SetMaxInteractions(10)
+	n.ReduceAll()
+	if got := n.PendingPairs(); got != 0 || n.GetStats().TotalReductions != 10 {
+		t.Errorf("expected the rest parked, got %d pending after %d reductions", got, n.GetStats().TotalReductions)
+	}
+	n.SetMaxInteractions(0)
+	n.ReduceAll()
+	if got := n.GetStats().TotalReductions; got != 50 {
+		t.Errorf("expected parked pairs to resume, got %d reductions", got)
+	}
+}
+
+// TestPendingPairsNegative tests that removing more pairs than were added
+// panics like a WaitGroup.
+func TestPendingPairsNegative(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected a panic")
+		}
+	}()
+	var p pendingPairs
+	p.Done()
+}
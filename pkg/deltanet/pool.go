@@ -0,0 +1,122 @@
+package deltanet
+
+import "sync/atomic"
+
+// Pool keeps networks ready for reuse, so a server or REPL evaluating many
+// terms pays for building a network (registering natives, allocating the
+// scheduler and arena, starting workers) once per pooled network rather
+// than once per term. Networks are reset when they are returned: their
+// nodes, stats and trace are cleared, while natives, handlers, profile,
+// strategy and workers are kept.
+type Pool struct {
+	opts []Option
+	idle chan *Network
+}
+
+// NewPool creates a pool holding up to size idle networks built with opts,
+// and fills it. Include WithStart to keep the workers of pooled networks
+// running.
+func NewPool(size int, opts ...Option) *Pool {
+	if size < 1 {
+		size = 1
+	}
+	p := &Pool{opts: opts, idle: make(chan *Network, size)}
+	for i := 0; i < size; i++ {
+		p.idle <- NewNetworkWith(opts...)
+	}
+	return p
+}
+
+// Get returns an idle network, or builds a new one when none is idle.
+func (p *Pool) Get() *Network {
+	select {
+	case n := <-p.idle:
+		return n
+	default:
+		return NewNetworkWith(p.opts...)
+	}
+}
+
+// Put resets n and returns it to the pool. The network must not be reducing
+// anymore. It is closed and dropped when the pool is full, or when it was
+// already closed.
+func (p *Pool) Put(n *Network) {
+	if n.Closed() {
+		return
+	}
+	n.Reset()
+	select {
+	case p.idle <- n:
+	default:
+		n.Close()
+	}
+}
+
+// WithStart starts the network's workers as soon as it is built (see
+// Start). Networks reduced with ReduceWithLimit should not use it.
+func WithStart() Option {
+	return func(n *Network) { n.Start() }
+}
+
+// Reset clears the net built on n, so one network can evaluate many terms
+// in turn without paying again for its workers and registrations. Nodes,
+// wires, queued pairs, metadata, stats, timing and the trace buffer are
+// cleared, node IDs start from 1 again and the phase returns to 1. Natives registered by the user,
+// handlers, profile, strategy, limits, running workers and the trace
+// capacity are kept; partial natives created by applications are dropped.
+// The network must not be reducing. A closed network stays closed.
+func (n *Network) Reset() {
+	// Pairs left queued by a limited reduction are discarded, and so are
+	// the buckets of the depths they reached.
+	for n.scheduler.TryPop() != nil {
+		n.pairs.Done()
+	}
+	n.scheduler.reset()
+
+	n.limit.mu.Lock()
+	n.limit.parked = nil
+	n.limit.halted.Store(false)
+	n.limit.mu.Unlock()
+
+	n.nodes.reset()
+	n.ids.reset()
+	n.ClearRoots()
+	n.memory.next.Store(n.memory.limit)
+	n.memory.compactions.Store(0)
+	if n.arena != nil {
+		n.arena.reset()
+	}
+
+	n.nativesMu.Lock()
+	for name := range n.natives {
+		if partialNative(name) {
+			delete(n.natives, name)
+			delete(n.nativeCaps, name)
+		}
+	}
+	clear(n.partials)
+	n.nativesMu.Unlock()
+
+	n.metaMu.Lock()
+	n.meta = nil
+	n.metaMu.Unlock()
+	atomic.StoreUint32(&n.metaOn, 0)
+
+	n.resetStats()
+	atomic.StoreUint64(&n.statPruned, 0)
+	atomic.StoreUint64(&n.statCollected, 0)
+	atomic.StoreUint64(&n.statGCs, 0)
+	atomic.StoreInt64(&n.phaseTime[0], 0)
+	atomic.StoreInt64(&n.phaseTime[1], 0)
+	n.sweepsMu.Lock()
+	n.sweeps = CanonicalSweep{}
+	n.sweepsMu.Unlock()
+	n.resetTiming()
+	atomic.StoreUint64(&n.traceIdx, 0)
+	atomic.StoreUint64(&n.traceSeen, 0)
+	n.stream.clearErr()
+	n.hooks.mu.Lock()
+	n.hooks.postponed = nil
+	n.hooks.mu.Unlock()
+	n.phase, n.maxPhase = 1, 1
+}
@@ -0,0 +1,96 @@
+package deltanet
+
+import (
+	"runtime"
+	"testing"
+)
+
+// TestPoolReuse tests that a returned network comes back empty with its
+// natives still registered, and reduces again.
+func TestPoolReuse(t *testing.T) {
+	double := func(v interface{}) (interface{}, error) { return v.(int) * 2, nil }
+	for _, opts := range [][]Option{
+		{WithNative("double", double)},
+		{WithNative("double", double), WithStart()},
+	} {
+		p := NewPool(1, opts...)
+		for round := 0; round < 3; round++ {
+			n := p.Get()
+			if n.NodeCount() != 0 || n.GetStats().TotalReductions != 0 {
+				t.Fatalf("round %d: network not reset: %d nodes, %+v", round, n.NodeCount(), n.GetStats())
+			}
+			app := n.NewFan()
+			n.Link(app, 2, n.NewData(round), 0)
+			out := n.NewVar()
+			n.Link(app, 1, out, 0)
+			n.Link(app, 0, n.NewNative("double"), 0)
+			n.ReduceAll()
+
+			res, _ := n.GetLink(out, 0)
+			if res == nil || res.GetValue() != round*2 {
+				t.Fatalf("round %d: expected %d, got %v", round, round*2, res)
+			}
+			p.Put(n)
+		}
+	}
+}
+
+// TestPoolDropsPartials tests that partial natives created by a reduction
+// do not survive a reset.
+func TestPoolDropsPartials(t *testing.T) {
+	p := NewPool(1, WithNative("const", func(a interface{}) (interface{}, error) {
+		return func(interface{}) (interface{}, error) { return a, nil }, nil
+	}))
+	n := p.Get()
+	app := n.NewFan()
+	n.Link(app, 2, n.NewData(1), 0)
+	n.Link(app, 1, n.NewVar(), 0)
+	n.Link(app, 0, n.NewNative("const"), 0)
+	n.ReduceWithLimit(10)
+	before := len(n.natives)
+	p.Put(n)
+	if after := len(n.natives); after != before-1 {
+		t.Errorf("expected the partial native to be dropped: %d natives before, %d after", before, after)
+	}
+	if _, ok := n.GetNative("const"); !ok {
+		t.Errorf("registered native lost on reset")
+	}
+}
+
+// TestResetBetweenTerms tests that one network evaluates terms in turn,
+// keeping its workers and starting each term from a clean state, even
+// after a reduction stopped by the interaction cap.
+func TestResetBetweenTerms(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(4), WithTrace(16))
+	buildCommutations(n, 20)
+	n.ReduceToNormalForm()
+	want := n.GetStats()
+	goroutines := runtime.NumGoroutine()
+
+	for round := 0; round < 3; round++ {
+		n.Reset()
+		if n.NodeCount() != 0 || n.Phase() != 1 || len(n.TraceSnapshot()) != 0 || n.Report().Canonical.Sweeps != 0 {
+			t.Fatalf("round %d: network not reset: %+v", round, n.Report())
+		}
+		buildCommutations(n, 20)
+		n.ReduceToNormalForm()
+		if got := n.GetStats(); got.Interactions() != want.Interactions() || got.WiresCreated != want.WiresCreated {
+			t.Errorf("round %d: got %+v, want %+v", round, got, want)
+		}
+	}
+	if got := runtime.NumGoroutine(); got != goroutines {
+		t.Errorf("expected workers to be kept: %d goroutines, now %d", goroutines, got)
+	}
+
+	n.SetMaxInteractions(5)
+	n.Reset()
+	buildCommutations(n, 20)
+	n.ReduceToNormalForm()
+	n.SetMaxInteractions(0)
+	n.Reset()
+	buildCommutations(n, 20)
+	n.ReduceToNormalForm()
+	if got := n.GetStats(); got.Interactions() != want.Interactions() || got.WiresCreated != want.WiresCreated {
+		t.Errorf("after a capped reduction: got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,64 @@
+package deltanet
+
+import (
+	"context"
+	"runtime/pprof"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// profileLabels tags goroutines running interaction rules with pprof labels
+// while enabled.
+type profileLabels struct {
+	on   uint32
+	ctxs sync.Map // labelKey -> context.Context carrying the labels
+}
+
+type labelKey struct {
+	rule  RuleKind
+	depth uint64
+}
+
+// SetProfileLabels tags the goroutine running each interaction rule with
+// the pprof labels "rule" (see RuleKind.String) and "depth", so CPU
+// profiles of big reductions attribute time to each rule, e.g. with
+// `go tool pprof -tagfocus rule=fan-rep`. Time outside rule bodies, in the
+// scheduler or idle, is left unlabeled. The goroutine's labels are cleared
+// after each rule, including those a caller of ReduceAt had set. Labeling
+// costs two label switches per interaction, so it is off by default.
+func (n *Network) SetProfileLabels(on bool) {
+	var v uint32
+	if on {
+		v = 1
+	}
+	atomic.StoreUint32(&n.labels.on, v)
+}
+
+// WithProfileLabels enables profile labels (see SetProfileLabels).
+func WithProfileLabels() Option {
+	return func(n *Network) { n.SetProfileLabels(true) }
+}
+
+// labelRule tags the calling goroutine with rule and depth, and reports
+// whether it did, for unlabel.
+func (n *Network) labelRule(rule RuleKind, depth uint64) bool {
+	if atomic.LoadUint32(&n.labels.on) == 0 {
+		return false
+	}
+	key := labelKey{rule, depth}
+	ctx, ok := n.labels.ctxs.Load(key)
+	if !ok {
+		ctx, _ = n.labels.ctxs.LoadOrStore(key, pprof.WithLabels(context.Background(),
+			pprof.Labels("rule", rule.String(), "depth", strconv.FormatUint(depth, 10))))
+	}
+	pprof.SetGoroutineLabels(ctx.(context.Context))
+	return true
+}
+
+// unlabel clears the labels set by labelRule.
+func unlabel(labeled bool) {
+	if labeled {
+		pprof.SetGoroutineLabels(context.Background())
+	}
+}
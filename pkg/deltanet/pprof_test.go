@@ -0,0 +1,33 @@
+package deltanet
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+// TestProfileLabels tests that rule bodies run under the labels of their
+// rule and depth, and only while labels are enabled.
+func TestProfileLabels(t *testing.T) {
+	for _, on := range []bool{true, false} {
+		var profile bytes.Buffer
+		n := NewNetworkWith(WithWorkers(1))
+		n.SetProfileLabels(on)
+		n.RegisterNative("probe", func(v interface{}) (interface{}, error) {
+			pprof.Lookup("goroutine").WriteTo(&profile, 1)
+			return v, nil
+		})
+		app := n.NewFan()
+		n.LinkAt(app, 0, n.NewNative("probe"), 0, 3)
+		n.Link(app, 2, n.NewData(1), 0)
+		n.Link(app, 1, n.NewVar(), 0)
+		n.ReduceAll()
+
+		labeled := strings.Contains(profile.String(), `"rule":"fan-native"`) &&
+			strings.Contains(profile.String(), `"depth":"3"`)
+		if labeled != on {
+			t.Errorf("labels enabled %v, found in profile %v", on, labeled)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package deltanet
+
+import "errors"
+
+// Capability classifies what a native function or effect handler may do
+// when invoked. Capabilities are ordered: a profile allowing CapIO also
+// allows CapConsole and CapPure.
+type Capability int
+
+const (
+	CapPure    Capability = iota // No observable side effects
+	CapConsole                   // May read or write the console
+	CapIO                        // Arbitrary IO (files, network, processes)
+)
+
+func (c Capability) String() string {
+	switch c {
+	case CapPure:
+		return "pure"
+	case CapConsole:
+		return "console"
+	case CapIO:
+		return "io"
+	default:
+		return "unknown"
+	}
+}
+
+// ErrCapabilityDenied is reported when a native or effect handler is
+// registered but hidden by the active Profile.
+var ErrCapabilityDenied = errors.New("capability denied by profile")
+
+// Profile is an allow-list selecting which natives and effect handlers are
+// visible to a given evaluation. A nil *Profile allows everything.
+type Profile struct {
+	Name string
+	Max  Capability // Highest capability visible under this profile
+}
+
+// Predefined sandbox profiles.
+var (
+	ProfilePure    = &Profile{Name: "pure", Max: CapPure}
+	ProfileConsole = &Profile{Name: "console", Max: CapConsole}
+	ProfileFull    = &Profile{Name: "full", Max: CapIO}
+)
+
+// Allows reports whether the profile permits the given capability.
+func (p *Profile) Allows(c Capability) bool {
+	if p == nil {
+		return true
+	}
+	return c <= p.Max
+}
+
+// ProfileByName returns one of the predefined profiles.
+func ProfileByName(name string) (*Profile, bool) {
+	switch name {
+	case ProfilePure.Name:
+		return ProfilePure, true
+	case ProfileConsole.Name:
+		return ProfileConsole, true
+	case ProfileFull.Name:
+		return ProfileFull, true
+	default:
+		return nil, false
+	}
+}
+
+// SetProfile restricts the natives and root handlers visible to this
+// network. Passing nil removes all restrictions.
+func (n *Network) SetProfile(p *Profile) {
+	n.nativesMu.Lock()
+	defer n.nativesMu.Unlock()
+	n.profile = p
+	if n.handlers != nil {
+		n.handlers.Profile = p
+	}
+}
+
+// Profile returns the active sandbox profile (nil means unrestricted).
+func (n *Network) Profile() *Profile {
+	n.nativesMu.RLock()
+	defer n.nativesMu.RUnlock()
+	return n.profile
+}
@@ -0,0 +1,176 @@
+package deltanet
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestProfileHidesNatives tests that natives above the profile's capability
+// are invisible to registry lookups.
+func TestProfileHidesNatives(t *testing.T) {
+	net := NewNetwork()
+	net.RegisterNative("inc", func(v interface{}) (interface{}, error) {
+		return v.(int) + 1, nil
+	})
+	net.RegisterNativeWithCapability("print", func(v interface{}) (interface{}, error) {
+		return v, nil
+	}, CapConsole)
+	net.RegisterNativeWithCapability("readFile", func(v interface{}) (interface{}, error) {
+		return v, nil
+	}, CapIO)
+
+	tests := []struct {
+		profile *Profile
+		visible map[string]bool
+	}{
+		{nil, map[string]bool{"inc": true, "print": true, "readFile": true}},
+		{ProfilePure, map[string]bool{"inc": true, "print": false, "readFile": false}},
+		{ProfileConsole, map[string]bool{"inc": true, "print": true, "readFile": false}},
+		{ProfileFull, map[string]bool{"inc": true, "print": true, "readFile": true}},
+	}
+
+	for _, tt := range tests {
+		net.SetProfile(tt.profile)
+		for name, want := range tt.visible {
+			if _, ok := net.GetNative(name); ok != want {
+				t.Errorf("profile %v: GetNative(%q) visible=%v, want %v", tt.profile, name, ok, want)
+			}
+		}
+	}
+}
+
+// TestProfileDeniedNativeApplication tests that applying a hidden native
+// yields an error Data node instead of running the function.
+func TestProfileDeniedNativeApplication(t *testing.T) {
+	net := NewNetwork()
+	called := false
+	net.RegisterNativeWithCapability("launch", func(v interface{}) (interface{}, error) {
+		called = true
+		return v, nil
+	}, CapIO)
+	net.SetProfile(ProfilePure)
+
+	fan := net.NewFan()
+	net.Link(fan, 0, net.NewNative("launch"), 0)
+	net.Link(fan, 2, net.NewData("missiles"), 0)
+	output := net.NewVar()
+	net.Link(fan, 1, output, 0)
+
+	net.ReduceAll()
+
+	if called {
+		t.Fatal("native hidden by profile was invoked")
+	}
+	resultNode, _ := net.GetLink(output, 0)
+	if resultNode == nil || resultNode.Type() != NodeTypeData {
+		t.Fatalf("Expected error Data node, got %v", resultNode)
+	}
+	err, ok := resultNode.GetValue().(error)
+	if !ok || !errors.Is(err, ErrCapabilityDenied) {
+		t.Errorf("Expected ErrCapabilityDenied, got %v", resultNode.GetValue())
+	}
+}
+
+// TestProfilePartialInheritsCapability tests that curried partials keep the
+// capability of the native that produced them.
+func TestProfilePartialInheritsCapability(t *testing.T) {
+	net := NewNetwork()
+	net.RegisterNativeWithCapability("write", func(path interface{}) (interface{}, error) {
+		return func(data interface{}) (interface{}, error) {
+			return data, nil
+		}, nil
+	}, CapIO)
+
+	fan := net.NewFan()
+	net.Link(fan, 0, net.NewNative("write"), 0)
+	net.Link(fan, 2, net.NewData("/tmp/x"), 0)
+	output := net.NewVar()
+	net.Link(fan, 1, output, 0)
+	net.ReduceAll()
+
+	partial, _ := net.GetLink(output, 0)
+	if partial == nil || partial.Type() != NodeTypePure {
+		t.Fatalf("Expected partial native, got %v", partial)
+	}
+	if c, _ := net.NativeCapability(partial.GetName()); c != CapIO {
+		t.Errorf("Expected partial capability %v, got %v", CapIO, c)
+	}
+}
+
+// TestProfileHandlerScope tests that effect handlers are filtered by profile.
+func TestProfileHandlerScope(t *testing.T) {
+	scope := NewHandlerScope()
+	scope.RegisterWithCapability("Print", func(effect Effect, resume *Continuation) (interface{}, error) {
+		return "printed", nil
+	}, CapConsole)
+	scope.Register("HTTP", func(effect Effect, resume *Continuation) (interface{}, error) {
+		return "fetched", nil
+	})
+
+	scope.Profile = ProfileConsole
+	if !scope.CanHandle("Print") {
+		t.Error("console profile should see Print")
+	}
+	if scope.CanHandle("HTTP") {
+		t.Error("console profile should not see HTTP")
+	}
+	if _, err := scope.Handle(Effect{Name: "HTTP"}, &Continuation{}); !errors.Is(err, ErrCapabilityDenied) {
+		t.Errorf("Expected ErrCapabilityDenied, got %v", err)
+	}
+
+	scope.Profile = ProfilePure
+	if scope.CanHandle("Print") {
+		t.Error("pure profile should not see Print")
+	}
+
+	scope.Profile = nil
+	if res, err := scope.Handle(Effect{Name: "HTTP"}, &Continuation{}); err != nil || res != "fetched" {
+		t.Errorf("unrestricted scope: got (%v, %v)", res, err)
+	}
+}
+
+// TestProfileRunEffects tests that RunEffects checks handlers against the
+// network's profile even when the scope has none of its own.
+func TestProfileRunEffects(t *testing.T) {
+	ran := false
+	scope := NewHandlerScope()
+	scope.Register("HTTP", func(effect Effect, resume *Continuation) (interface{}, error) {
+		ran = true
+		return "fetched", nil
+	})
+	scope.RegisterWithCapability("Ask", func(effect Effect, resume *Continuation) (interface{}, error) {
+		return resume.Resume(42)
+	}, CapPure)
+
+	net := NewNetworkWith(WithProfile(ProfilePure))
+	net.Link(net.NewVar(), 0, net.NewIO(&Effect{Name: "HTTP"}, EffectRow{"HTTP"}), 0)
+	if err := net.RunEffects(scope); !errors.Is(err, ErrCapabilityDenied) {
+		t.Errorf("Expected ErrCapabilityDenied, got %v", err)
+	}
+	if ran {
+		t.Error("handler denied by the network profile ran")
+	}
+
+	net = NewNetworkWith(WithProfile(ProfilePure))
+	out := net.NewVar()
+	net.Link(out, 0, net.NewIO(&Effect{Name: "Ask"}, EffectRow{"Ask"}), 0)
+	if err := net.RunEffects(scope); err != nil {
+		t.Fatalf("pure handler: %v", err)
+	}
+	if result, _ := net.GetLink(out, 0); result == nil || result.GetValue() != 42 {
+		t.Errorf("pure handler: output linked to %v, want Data 42", result)
+	}
+}
+
+// TestProfileByName tests lookup of predefined profiles.
+func TestProfileByName(t *testing.T) {
+	for _, name := range []string{"pure", "console", "full"} {
+		p, ok := ProfileByName(name)
+		if !ok || p.Name != name {
+			t.Errorf("ProfileByName(%q) = %v, %v", name, p, ok)
+		}
+	}
+	if _, ok := ProfileByName("root"); ok {
+		t.Error("unexpected profile for unknown name")
+	}
+}
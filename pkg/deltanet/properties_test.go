@@ -86,6 +86,11 @@ func TestPerfectConfluence(t *testing.T) {
 			stats1.FanAnnihilation, stats2.FanAnnihilation)
 	}
 
+	// And the same normal form
+	if !Isomorphic(net1, net2, output1, output2) {
+		t.Errorf("Perfect confluence violated: the normal forms differ")
+	}
+
 	t.Logf("Perfect confluence verified: both paths used %d reductions", stats1.TotalReductions)
 }
 
@@ -238,14 +243,21 @@ func TestDeterministicReduction(t *testing.T) {
 	// Run reduction multiple times
 	runs := 5
 	var allStats []Stats
+	var firstNet *Network
+	var firstOutput Node
 
 	for i := 0; i < runs; i++ {
 		net, output := buildNet()
 		net.ReduceToNormalForm()
-		stats := net.GetStats()
+		stats := net.GetStats().Interactions()
 		allStats = append(allStats, stats)
 
 		// Also verify result is structurally same
+		if firstNet == nil {
+			firstNet, firstOutput = net, output
+		} else if !Isomorphic(firstNet, net, firstOutput, output) {
+			t.Errorf("Run %d: result differs structurally from run 0", i)
+		}
 		resNode, _ := net.GetLink(output, 0)
 		t.Logf("Run %d: %d reductions, result node type: %v",
 			i+1, stats.TotalReductions, resNode.Type())
@@ -0,0 +1,175 @@
+package deltanet
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// registryShards is the number of independently locked registry shards.
+const registryShards = 16
+
+// nodeRegistry holds the nodes of a network. Nodes are spread over shards
+// by ID, each behind its own lock, so workers creating nodes concurrently
+// during commutations rarely wait on each other. The total and peak sizes
+// are kept in atomics, and so is the number of registered nodes marked
+// dead: nodes know their registry and count themselves in SetDead and
+// Revive, so live nodes are counted without visiting them.
+type nodeRegistry struct {
+	shards [registryShards]registryShard
+	count  atomic.Int64
+	peak   atomic.Int64
+	dead   atomic.Int64
+}
+
+// registryShard holds its nodes in a slice indexed by ID: the node with
+// ID id sits in slot id/registryShards of shard id%registryShards, so
+// lookup and removal are O(1). A removed node leaves its slot empty; with
+// ID recycling (see ids.go) the ID allocator's free list hands the slot to
+// a later node, so the slices stay as large as the peak number of nodes
+// rather than the number of nodes ever created.
+type registryShard struct {
+	mu    sync.Mutex
+	slots []Node
+}
+
+// registered is implemented by every node through BaseNode.
+type registered interface {
+	registryRef() **nodeRegistry
+}
+
+func (b *BaseNode) registryRef() **nodeRegistry { return &b.registry }
+
+// live returns the number of registered nodes not marked dead.
+func (r *nodeRegistry) live() int {
+	return int(r.count.Load() - r.dead.Load())
+}
+
+func (r *nodeRegistry) shard(id uint64) *registryShard {
+	return &r.shards[id%registryShards]
+}
+
+func (r *nodeRegistry) add(node Node) {
+	id := node.ID()
+	sh := r.shard(id)
+	slot := int(id / registryShards)
+	sh.mu.Lock()
+	if slot >= len(sh.slots) {
+		sh.slots = slices.Grow(sh.slots, slot+1-len(sh.slots))[:slot+1]
+	}
+	replaced := sh.slots[slot]
+	sh.slots[slot] = node
+	if s, ok := node.(registered); ok {
+		*s.registryRef() = r
+	}
+	sh.mu.Unlock()
+
+	if replaced != nil {
+		// IDs are unique among registered nodes; a node registered again
+		// under its own ID is not counted twice.
+		return
+	}
+	count := r.count.Add(1)
+	for {
+		peak := r.peak.Load()
+		if count <= peak || r.peak.CompareAndSwap(peak, count) {
+			break
+		}
+	}
+}
+
+// get returns the node registered under id, or nil.
+func (r *nodeRegistry) get(id uint64) Node {
+	sh := r.shard(id)
+	slot := id / registryShards
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if slot >= uint64(len(sh.slots)) {
+		return nil
+	}
+	return sh.slots[slot]
+}
+
+// remove takes node out of the registry, if it is registered.
+func (r *nodeRegistry) remove(node Node) bool {
+	sh := r.shard(node.ID())
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return r.removeLocked(sh, node)
+}
+
+func (r *nodeRegistry) removeLocked(sh *registryShard, node Node) bool {
+	slot := int(node.ID() / registryShards)
+	if slot >= len(sh.slots) || sh.slots[slot] != node {
+		return false
+	}
+	sh.slots[slot] = nil
+	if s, ok := node.(registered); ok {
+		*s.registryRef() = nil
+	}
+	r.count.Add(-1)
+	if node.IsDead() {
+		r.dead.Add(-1)
+	}
+	return true
+}
+
+// collect removes the nodes for which dead returns true, passing each to
+// removed unless it is nil, and returns how many were removed.
+func (r *nodeRegistry) collect(dead func(Node) bool, removed func(Node)) int {
+	collected := 0
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.Lock()
+		for _, node := range sh.slots {
+			if node != nil && dead(node) && r.removeLocked(sh, node) {
+				if removed != nil {
+					removed(node)
+				}
+				collected++
+			}
+		}
+		sh.mu.Unlock()
+	}
+	return collected
+}
+
+// each calls fn for every registered node, shard by shard, holding the
+// shard's lock.
+func (r *nodeRegistry) each(fn func(Node)) {
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.Lock()
+		for _, node := range sh.slots {
+			if node != nil {
+				fn(node)
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// snapshot returns the registered nodes.
+func (r *nodeRegistry) snapshot() []Node {
+	nodes := make([]Node, 0, r.count.Load())
+	r.each(func(node Node) { nodes = append(nodes, node) })
+	return nodes
+}
+
+// reset empties the registry and its peak.
+func (r *nodeRegistry) reset() {
+	for i := range r.shards {
+		sh := &r.shards[i]
+		sh.mu.Lock()
+		for _, node := range sh.slots {
+			if s, ok := node.(registered); ok {
+				*s.registryRef() = nil
+			}
+		}
+		sh.slots = nil
+		sh.mu.Unlock()
+	}
+	r.count.Store(0)
+	r.peak.Store(0)
+	r.dead.Store(0)
+}
@@ -0,0 +1,106 @@
+package deltanet
+
+import "testing"
+
+// TestRegistryReusesSlots tests that, with ID recycling, collected nodes
+// free their slots for later nodes, keeping the registry at its peak size.
+func TestRegistryReusesSlots(t *testing.T) {
+	n := NewNetworkWith(WithIDRecycling(true))
+	var fans []Node
+	for i := 0; i < 10; i++ {
+		fans = append(fans, n.NewFan())
+	}
+	for _, f := range fans[:4] {
+		f.SetDead()
+	}
+	if got := n.CollectGarbage(); got != 4 {
+		t.Fatalf("expected 4 collected, got %d", got)
+	}
+	if got := n.NodeCount(); got != 6 {
+		t.Fatalf("expected 6 nodes, got %d", got)
+	}
+	slots := func() int {
+		total := 0
+		for i := range n.nodes.shards {
+			total += len(n.nodes.shards[i].slots)
+		}
+		return total
+	}
+	before := slots()
+	for i := 0; i < 4; i++ {
+		n.NewEraser()
+	}
+	if got := slots(); got != before {
+		t.Errorf("expected freed slots to be reused, registry grew from %d to %d slots", before, got)
+	}
+	if got, want := n.Report().PeakNodes, 10; got != want {
+		t.Errorf("expected peak %d, got %d", want, got)
+	}
+	if n.nodes.remove(fans[0]) {
+		t.Errorf("removed a node that was already collected")
+	}
+}
+
+// TestNodeByID tests that nodes are found by ID until they are collected.
+func TestNodeByID(t *testing.T) {
+	n := NewNetwork()
+	var nodes []Node
+	for i := 0; i < 3*registryShards; i++ {
+		nodes = append(nodes, n.NewVar())
+	}
+	for _, node := range nodes {
+		if got := n.NodeByID(node.ID()); got != node {
+			t.Fatalf("NodeByID(%d): expected %v, got %v", node.ID(), node, got)
+		}
+	}
+	nodes[5].SetDead()
+	n.CollectGarbage()
+	if got := n.NodeByID(nodes[5].ID()); got != nil {
+		t.Errorf("expected a collected node not to be found, got %v", got)
+	}
+	if got := n.NodeByID(1 << 20); got != nil {
+		t.Errorf("expected an unused ID not to be found, got %v", got)
+	}
+}
+
+// TestActiveNodeCount tests that the live node counter follows deaths,
+// revivals, collection and Reset, and agrees with a scan of the registry
+// after parallel reduction.
+func TestActiveNodeCount(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(4), WithParallel())
+	a, b := n.NewVar(), n.NewVar()
+	a.SetDead()
+	a.SetDead()
+	if got := n.ActiveNodeCount(); got != 1 {
+		t.Errorf("after SetDead: expected 1 live node, got %d", got)
+	}
+	a.Revive()
+	a.Revive()
+	if got := n.ActiveNodeCount(); got != 2 {
+		t.Errorf("after Revive: expected 2 live nodes, got %d", got)
+	}
+	b.SetDead()
+	n.CollectGarbage()
+	b.Revive()
+	if got := n.ActiveNodeCount(); got != 1 {
+		t.Errorf("reviving a collected node: expected 1 live node, got %d", got)
+	}
+
+	buildCommutations(n, 500)
+	n.ReduceAll()
+	scanned := 0
+	n.nodes.each(func(node Node) {
+		if !node.IsDead() {
+			scanned++
+		}
+	})
+	if got := n.ActiveNodeCount(); got != scanned {
+		t.Errorf("after reduction: counted %d live nodes, scanned %d", got, scanned)
+	}
+
+	n.Reset()
+	a.SetDead()
+	if got := n.ActiveNodeCount(); got != 0 {
+		t.Errorf("after Reset: expected no live nodes, got %d", got)
+	}
+}
@@ -0,0 +1,75 @@
+package deltanet
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Releasing payloads
+//
+// Data, native, effect and handler nodes carry payloads that live outside
+// the net: a value, a registered function, a pending effect and its
+// continuation, a handler scope. A dead node stays in the registry until
+// the next garbage collection, so the interactions that consume such a
+// node release its payload at once. Partially applied natives are
+// registered under a name of their own by applyNative; the network counts
+// the live nodes of each and unregisters the function with the last one.
+//
+// Releasing is not part of removeNode: commutations reuse the nodes of the
+// pair they rewrite, and removeNode is called on those too.
+
+// partialNative reports whether name is that of a partially applied native.
+func partialNative(name string) bool {
+	return strings.Contains(name, "$partial$")
+}
+
+// partialName returns a fresh name for a partial application of the native
+// name, itself possibly a partial.
+func (n *Network) partialName(name string) string {
+	base, _, _ := strings.Cut(name, "$partial$")
+	return fmt.Sprintf("%s$partial$%d", base, n.partialSeq.Add(1))
+}
+
+// retainNative counts a new node of a partially applied native.
+func (n *Network) retainNative(name string) {
+	if !partialNative(name) {
+		return
+	}
+	n.nativesMu.Lock()
+	n.partials[name]++
+	n.nativesMu.Unlock()
+}
+
+// releaseNative uncounts a node of a partially applied native and
+// unregisters the function once no node refers to it.
+func (n *Network) releaseNative(name string) {
+	if !partialNative(name) {
+		return
+	}
+	n.nativesMu.Lock()
+	defer n.nativesMu.Unlock()
+	if n.partials[name] > 1 {
+		n.partials[name]--
+		return
+	}
+	delete(n.partials, name)
+	delete(n.natives, name)
+	delete(n.nativeCaps, name)
+}
+
+// releasePayload drops the payload of a node that has been consumed. The
+// effect of an Effect node is kept: it names the node in dumps and
+// diagnostics.
+func (n *Network) releasePayload(node Node) {
+	switch v := node.(type) {
+	case *DataNode:
+		v.value = nil
+	case *NativeNode:
+		n.releaseNative(v.name)
+	case *IONode:
+		v.effectRow = nil
+		v.continuation = nil
+	case *HandlerNode:
+		v.scope = nil
+	}
+}
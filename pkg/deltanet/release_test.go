@@ -0,0 +1,144 @@
+package deltanet
+
+import "testing"
+
+// registerAdd registers a curried addition of ints as "add".
+func registerAdd(n *Network) {
+	n.RegisterNative("add", func(a interface{}) (interface{}, error) {
+		x := a.(int)
+		return func(b interface{}) (interface{}, error) {
+			return x + b.(int), nil
+		}, nil
+	})
+}
+
+// partialEntries counts the partially applied natives in the registry.
+func partialEntries(n *Network) int {
+	n.nativesMu.RLock()
+	defer n.nativesMu.RUnlock()
+	count := 0
+	for name := range n.natives {
+		if partialNative(name) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestEraseLeaves(t *testing.T) {
+	n := NewNetwork()
+	n.RegisterNative("id", func(v interface{}) (interface{}, error) { return v, nil })
+	data := n.NewData("payload")
+	native := n.NewNative("id")
+	io := n.NewIO(&Effect{Name: "print"}, EffectRow{"print"})
+	for _, leaf := range []Node{data, native, io} {
+		n.Link(n.NewEraser(), 0, leaf, 0)
+	}
+
+	n.ReduceAll()
+
+	if s := n.GetStats(); s.Erasure != 3 {
+		t.Errorf("expected 3 erasures, got %d", s.Erasure)
+	}
+	if live := n.ActiveNodeCount(); live != 0 {
+		t.Errorf("expected erasure to end at the leaves, got %d live nodes", live)
+	}
+	if v := data.GetValue(); v != nil {
+		t.Errorf("erased Data kept its value %v", v)
+	}
+	if row := io.GetEffectRow(); row != nil {
+		t.Errorf("erased Effect kept its effect row %v", row)
+	}
+	if _, ok := n.GetNative("id"); !ok {
+		t.Error("erasing a native node unregistered the native")
+	}
+}
+
+func TestEraseHandler(t *testing.T) {
+	n := NewNetwork()
+	handler := n.NewHandler(NewHandlerScope())
+	output := n.NewVar()
+	n.Link(handler, 1, output, 0)
+	n.Link(n.NewEraser(), 0, handler, 0)
+
+	n.ReduceAll()
+
+	if node, _ := n.GetLink(output, 0); node == nil || node.Type() != NodeTypeEraser {
+		t.Errorf("expected erasure to pass on to the result, got %v", node)
+	}
+	if scope := handler.GetHandlerScope(); scope != nil {
+		t.Error("erased handler kept its scope")
+	}
+}
+
+func TestErasePartialNative(t *testing.T) {
+	n := NewNetwork()
+	registerAdd(n)
+	app := n.NewFan()
+	n.Link(app, 0, n.NewNative("add"), 0)
+	n.Link(app, 2, n.NewData(1), 0)
+	n.Link(app, 1, n.NewEraser(), 0)
+
+	n.ReduceAll()
+
+	if got := partialEntries(n); got != 0 {
+		t.Errorf("expected the erased partial to be unregistered, %d remain", got)
+	}
+	if len(n.partials) != 0 {
+		t.Errorf("expected no counted partials, got %v", n.partials)
+	}
+	if _, ok := n.GetNative("add"); !ok {
+		t.Error("the native producing the partial was unregistered")
+	}
+}
+
+// TestSharedPartialNative tests that a partial native stays registered
+// while any of its copies is alive, and is unregistered once all of them
+// are applied.
+func TestSharedPartialNative(t *testing.T) {
+	n := NewNetwork()
+	registerAdd(n)
+	app := n.NewFan()
+	n.Link(app, 0, n.NewNative("add"), 0)
+	n.Link(app, 2, n.NewData(1), 0)
+	rep := n.NewReplicator(0, []int{0, 0})
+	n.Link(app, 1, rep, 0)
+	outputs := make([]Node, 2)
+	for i := range outputs {
+		use := n.NewFan()
+		n.Link(rep, i+1, use, 0)
+		n.Link(use, 2, n.NewData(10*(i+1)), 0)
+		outputs[i] = n.NewVar()
+		n.Link(use, 1, outputs[i], 0)
+	}
+
+	n.ReduceAll()
+
+	for i, output := range outputs {
+		node, _ := n.GetLink(output, 0)
+		if node == nil || node.GetValue() != 10*(i+1)+1 {
+			t.Errorf("use %d: expected %d, got %v", i, 10*(i+1)+1, node)
+		}
+	}
+	if got := partialEntries(n); got != 0 {
+		t.Errorf("expected the applied partials to be unregistered, %d remain", got)
+	}
+}
+
+func TestSweepReleasesPayloads(t *testing.T) {
+	n := NewNetwork()
+	root := n.NewVar()
+	n.Link(root, 0, n.NewData("kept"), 0)
+	n.SetRoot(root, 0)
+	garbage := n.NewData("garbage")
+	n.Link(garbage, 0, n.NewVar(), 0)
+
+	n.ApplyErasureCanonization()
+
+	if !garbage.IsDead() {
+		t.Fatal("unreachable Data was not swept")
+	}
+	if v := garbage.GetValue(); v != nil {
+		t.Errorf("swept Data kept its value %v", v)
+	}
+}
@@ -8,8 +8,7 @@ import (
 // "Replicators start as *unpaired*, and this status is propagated across interactions."
 // Unpaired replicators come from canonical nets (λ-term translation).
 func TestReplicatorStatusUnpaired(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Build canonical net: (\x. x)
 	// Paper: "All replicators in a canonical Δ-net are unpaired fan-ins"
@@ -35,8 +34,10 @@ func TestReplicatorStatusUnpaired(t *testing.T) {
 // "When an unpaired replicator interacts with a fan, the status of both resulting
 // replicators changes to *unknown*."
 func TestReplicatorStatusTransitionToUnknown(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	n := NewNetworkWith(WithTrace(100))
 
 	// Create unpaired replicator from canonical net
 	rep := n.NewReplicator(0, []int{0, 0})
@@ -96,8 +97,7 @@ func TestReplicatorStatusTransitionToUnknown(t *testing.T) {
 // first replicator's level, but no greater than the first replicator's level plus the level
 // delta of the auxiliary port that connects them: 0 ≤ l_B - l_A ≤ d"
 func TestReplicatorStatusMergingConstraint(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Create replicator A: level 3, delta [2] (unpaired)
 	repA := n.NewReplicator(3, []int{2})
@@ -119,7 +119,7 @@ func TestReplicatorStatusMergingConstraint(t *testing.T) {
 	n.Link(repB, 1, rightVar, 0)
 
 	// Apply canonicalization (which includes merge detection)
-	changed := n.ApplyCanonicalRules()
+	changed := n.ApplyCanonicalRules().Changed()
 
 	// Paper: "Under this constraint, no replicator is able to interact with the second
 	// replicator before the first replicator is annihilated. Since the first replicator
@@ -140,8 +140,7 @@ func TestReplicatorStatusMergingConstraint(t *testing.T) {
 // TestReplicatorStatusMergingViolatesConstraint tests that merge does NOT happen when:
 // "0 ≤ l_B - l_A ≤ d" is violated
 func TestReplicatorStatusMergingViolatesConstraint(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Create replicator A: level 3, delta [1] (unpaired)
 	repA := n.NewReplicator(3, []int{1})
@@ -179,8 +178,7 @@ func TestReplicatorStatusMergingViolatesConstraint(t *testing.T) {
 // "While every fan-out is paired with at least one upstream fan-in, the converse is
 // not true: fan-ins may or may not be paired."
 func TestReplicatorStatusPairedFanOut(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Create scenario with fan-out (from commutation)
 	// Paper: "Every commutation between a fan and a replicator (either a fan-in or a fan-out)
@@ -222,8 +220,7 @@ func TestReplicatorStatusPairedFanOut(t *testing.T) {
 // which guarantees that replicator merges happen as early as possible, minimizing the
 // total number of reductions, is a sequential leftmost-outermost order"
 func TestReplicatorStatusOptimalMerging(t *testing.T) {
-	n := NewNetwork()
-	n.EnableTrace(100)
+	n := NewNetworkWith(WithTrace(100))
 
 	// Build term that creates mergeable replicators
 	// (\x. \y. x) a b
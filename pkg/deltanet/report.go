@@ -0,0 +1,189 @@
+package deltanet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Report summarizes a reduction in a single value that can be rendered as
+// text (WriteText) or JSON (WriteJSON). Network.Report fills everything it
+// can observe; Result and Elapsed are left to the caller, which knows how
+// the net was read back and how long reduction took.
+type Report struct {
+	Stats Stats `json:"stats"`
+	// Rules counts interactions per rule name (see RuleKind.String).
+	// Rules that never fired are omitted.
+	Rules map[string]uint64 `json:"rules"`
+	// PeakNodes is the largest number of nodes registered at once, the
+	// net's peak memory. LiveNodes counts the nodes still alive.
+	PeakNodes int `json:"peak_nodes"`
+	LiveNodes int `json:"live_nodes"`
+	// Phases is the highest reduction phase entered: 1, or 2 once aux
+	// fan replication ran (see ReduceToNormalForm).
+	Phases int `json:"phases"`
+	// Pruned counts the nodes removed by Canonicalize and
+	// ApplyErasureCanonization, Collected the dead nodes removed by
+	// CollectGarbage. Compactions counts the times reduction paused to do
+	// both (see SetMemoryThreshold).
+	Pruned      uint64 `json:"pruned"`
+	Collected   uint64 `json:"collected"`
+	Compactions uint64 `json:"compactions,omitempty"`
+	// Canonical sums the sweeps of ApplyCanonicalRules.
+	Canonical CanonicalSweep `json:"canonical"`
+	// Trace summarizes the trace buffer, when tracing is enabled.
+	Trace *TraceSummary `json:"trace,omitempty"`
+	// Timing splits reduction time between scheduling and rules, when
+	// timing is enabled.
+	Timing *Timing `json:"timing,omitempty"`
+
+	Result  string        `json:"result,omitempty"`
+	Elapsed time.Duration `json:"elapsed_ns,omitempty"`
+}
+
+// TraceSummary summarizes recorded trace events.
+type TraceSummary struct {
+	Events   int               `json:"events"`
+	Rules    map[string]uint64 `json:"rules"`
+	MaxDepth uint64            `json:"max_depth"`
+}
+
+// SummarizeTrace counts events per rule and finds their deepest wire.
+func SummarizeTrace(events []TraceEvent) *TraceSummary {
+	s := &TraceSummary{Events: len(events), Rules: make(map[string]uint64)}
+	for _, e := range events {
+		s.Rules[e.Rule.String()]++
+		if e.Depth > s.MaxDepth {
+			s.MaxDepth = e.Depth
+		}
+	}
+	return s
+}
+
+// Report returns a report of the reductions performed so far.
+func (n *Network) Report() Report {
+	stats := n.GetStats()
+	r := Report{
+		Stats:       stats,
+		Rules:       make(map[string]uint64),
+		LiveNodes:   n.ActiveNodeCount(),
+		Phases:      n.maxPhase,
+		Pruned:      atomic.LoadUint64(&n.statPruned),
+		Collected:   atomic.LoadUint64(&n.statCollected),
+		Compactions: n.memory.compactions.Load(),
+	}
+	r.PeakNodes = int(n.nodes.peak.Load())
+	n.sweepsMu.Lock()
+	r.Canonical = n.sweeps
+	n.sweepsMu.Unlock()
+	for rule, count := range map[RuleKind]uint64{
+		RuleFanFan:     stats.FanAnnihilation,
+		RuleRepRep:     stats.RepAnnihilation,
+		RuleRepRepComm: stats.RepCommutation,
+		RuleFanRep:     stats.FanRepCommutation,
+		RuleErasure:    stats.Erasure,
+		RuleRepDecay:   stats.RepDecay,
+		RuleRepMerge:   stats.RepMerge,
+		RuleAuxFanRep:  stats.AuxFanRep,
+		RuleFanNative:  stats.NativeCalls,
+		RuleRepCopy:    stats.DataCopy,
+		RuleEta:        stats.EtaContraction,
+	} {
+		if count > 0 {
+			r.Rules[rule.String()] = count
+		}
+	}
+	if atomic.LoadUint32(&n.traceOn) != 0 {
+		r.Trace = SummarizeTrace(n.TraceSnapshot())
+	}
+	r.Timing = n.Timing()
+	return r
+}
+
+// WriteJSON writes the report as an indented JSON object.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteText writes the report in the format of the godnet CLI. Rates are
+// only shown when Elapsed is set.
+func (r Report) WriteText(w io.Writer) error {
+	ew := &errWriter{w: w}
+	seconds := r.Elapsed.Seconds()
+	rate := func(count uint64) string {
+		if seconds <= 0 {
+			return ""
+		}
+		return fmt.Sprintf(" (%.2f ops/sec)", float64(count)/seconds)
+	}
+
+	if r.Result != "" {
+		ew.printf("Result: %s\n", r.Result)
+	}
+	ew.printf("\nStats:\n")
+	if r.Elapsed > 0 {
+		ew.printf("Time: %v\n", r.Elapsed)
+	}
+	ew.printf("Total Reductions: %d%s\n", r.Stats.TotalReductions, rate(r.Stats.TotalReductions))
+
+	ew.printf("\nBreakdown:\n")
+	for rule := RuleFanFan; int(rule) < len(ruleNames); rule++ {
+		if count := r.Rules[rule.String()]; count > 0 {
+			ew.printf("  %-24s %6d%s\n", rule.String()+":", count, rate(count))
+		}
+	}
+
+	ew.printf("\nNodes: %d peak, %d live\n", r.PeakNodes, r.LiveNodes)
+	ew.printf("Wires: %d created\n", r.Stats.WiresCreated)
+	if s := r.Stats; s.Phase1Time > 0 || s.CanonicalTime > 0 || s.Phase2Time > 0 {
+		ew.printf("Time by phase: %v phase 1, %v canonical, %v phase 2\n",
+			s.Phase1Time, s.CanonicalTime, s.Phase2Time)
+	}
+	ew.printf("Phases: %d\n", r.Phases)
+	if r.Pruned > 0 || r.Collected > 0 {
+		ew.printf("Pruned: %d, collected: %d in %d collections\n", r.Pruned, r.Collected, r.Stats.Collections)
+	}
+	if r.Compactions > 0 {
+		ew.printf("Compactions: %d\n", r.Compactions)
+	}
+	if c := r.Canonical; c.Sweeps > 0 {
+		ew.printf("Canonical: %d sweeps, %d nodes visited, %d decays, %d merges in %v\n",
+			c.Sweeps, c.Visited, c.Decays, c.Merges, c.Elapsed)
+		if c.Etas > 0 {
+			ew.printf("Eta: %d contractions\n", c.Etas)
+		}
+	}
+	if r.Trace != nil {
+		ew.printf("Trace: %d events, max depth %d\n", r.Trace.Events, r.Trace.MaxDepth)
+	}
+	if t := r.Timing; t != nil {
+		ew.printf("\nTiming:\n")
+		ew.printf("  %-24s %v\n", "push:", t.Push)
+		ew.printf("  %-24s %v\n", "pop:", t.Pop)
+		ew.printf("  %-24s %v\n", "idle:", t.Idle)
+		ew.printf("  %-24s %v\n", "lock:", t.Lock)
+		for rule := RuleUnknown; int(rule) < len(ruleNames); rule++ {
+			if d, ok := t.Rules[rule.String()]; ok {
+				ew.printf("  %-24s %v\n", rule.String()+":", d)
+			}
+		}
+	}
+	return ew.err
+}
+
+// errWriter keeps the first write error so a sequence of writes can be
+// checked once.
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err == nil {
+		_, ew.err = fmt.Fprintf(ew.w, format, args...)
+	}
+}
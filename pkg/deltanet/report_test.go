@@ -0,0 +1,62 @@
+package deltanet
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestReport tests the report of a single fan annihilation and its
+// renderings.
+func TestReport(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	n := NewNetworkWith(WithTrace(10))
+	a := n.NewFan()
+	b := n.NewFan()
+	n.Link(a, 0, b, 0)
+	for _, f := range []Node{a, b} {
+		n.Link(f, 1, n.NewVar(), 0)
+		n.Link(f, 2, n.NewVar(), 0)
+	}
+	n.ReduceToNormalForm()
+
+	r := n.Report()
+	if r.Rules["fan-fan"] != 1 || len(r.Rules) != 1 {
+		t.Errorf("rules: got %v", r.Rules)
+	}
+	if r.PeakNodes != 6 || r.LiveNodes != 4 {
+		t.Errorf("nodes: got %d peak, %d live", r.PeakNodes, r.LiveNodes)
+	}
+	if r.Phases != 2 {
+		t.Errorf("phases: got %d", r.Phases)
+	}
+	if r.Trace == nil || r.Trace.Events != 1 || r.Trace.Rules["fan-fan"] != 1 {
+		t.Errorf("trace: got %+v", r.Trace)
+	}
+
+	r.Result = "x"
+	var text bytes.Buffer
+	if err := r.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Result: x", "Total Reductions: 1", "fan-fan:", "Nodes: 6 peak, 4 live"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report lacks %q:\n%s", want, text.String())
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Result != "x" || decoded.Stats != r.Stats || decoded.Rules["fan-fan"] != 1 {
+		t.Errorf("JSON roundtrip: got %+v", decoded)
+	}
+}
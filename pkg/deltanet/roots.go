@@ -0,0 +1,57 @@
+package deltanet
+
+import "slices"
+
+// Root is a registered interface of a network: a port through which the
+// net is observed, such as the output of a translated term. Erasure
+// canonicalization keeps exactly the nodes reachable from the roots.
+type Root struct {
+	Node Node
+	Port int
+}
+
+// SetRoot makes (node, port) the only registered root.
+func (n *Network) SetRoot(node Node, port int) {
+	n.rootsMu.Lock()
+	n.roots = []Root{{Node: node, Port: port}}
+	n.rootsMu.Unlock()
+}
+
+// AddRoot registers (node, port) as another root, for nets with several
+// independent parts that must all be kept.
+func (n *Network) AddRoot(node Node, port int) {
+	n.rootsMu.Lock()
+	n.roots = append(n.roots, Root{Node: node, Port: port})
+	n.rootsMu.Unlock()
+}
+
+// Roots returns the registered roots in registration order.
+func (n *Network) Roots() []Root {
+	n.rootsMu.Lock()
+	defer n.rootsMu.Unlock()
+	return slices.Clone(n.roots)
+}
+
+// ClearRoots unregisters all roots.
+func (n *Network) ClearRoots() {
+	n.rootsMu.Lock()
+	n.roots = nil
+	n.rootsMu.Unlock()
+}
+
+// canonicalRoots returns the registered roots or, when none are
+// registered, every live Var node. The fallback keeps anything attached to
+// an interface, including disconnected garbage that happens to end in a
+// Var.
+func (n *Network) canonicalRoots() []Root {
+	if roots := n.Roots(); len(roots) > 0 {
+		return roots
+	}
+	var roots []Root
+	n.nodes.each(func(node Node) {
+		if node.Type() == NodeTypeVar && !node.IsDead() {
+			roots = append(roots, Root{Node: node})
+		}
+	})
+	return roots
+}
@@ -0,0 +1,81 @@
+package deltanet
+
+import "testing"
+
+// twoParts builds two disconnected fans, each hanging from its own Var.
+func twoParts(n *Network) (a, b Node) {
+	build := func() Node {
+		v := n.NewVar()
+		fan := n.NewFan()
+		n.Link(v, 0, fan, 0)
+		n.Link(fan, 1, n.NewEraser(), 0)
+		n.Link(fan, 2, n.NewEraser(), 0)
+		return v
+	}
+	return build(), build()
+}
+
+func TestErasureKeepsRegisteredRoots(t *testing.T) {
+	n := NewNetwork()
+	a, b := twoParts(n)
+	n.SetRoot(a, 0)
+	n.AddRoot(b, 0)
+
+	n.ApplyErasureCanonization()
+	if got := n.Report().Pruned; got != 0 {
+		t.Fatalf("pruned %d nodes of registered parts", got)
+	}
+	for _, v := range []Node{a, b} {
+		if target, _ := n.GetLink(v, 0); target == nil || target.Type() != NodeTypeFan {
+			t.Errorf("root %d lost its fan", v.ID())
+		}
+	}
+}
+
+func TestErasurePrunesUnregisteredParts(t *testing.T) {
+	n := NewNetwork()
+	a, b := twoParts(n)
+	n.SetRoot(a, 0)
+
+	n.ApplyErasureCanonization()
+	if got := n.Report().Pruned; got != 4 {
+		t.Errorf("pruned %d nodes, want the 4 of the second part", got)
+	}
+	if target, _ := n.GetLink(a, 0); target == nil || target.Type() != NodeTypeFan {
+		t.Error("registered part was pruned")
+	}
+	if target, _ := n.GetLink(b, 0); target != nil {
+		t.Errorf("unregistered root still linked to %v", target.Type())
+	}
+}
+
+func TestSetRootReplacesRoots(t *testing.T) {
+	n := NewNetwork()
+	a, b := twoParts(n)
+	n.AddRoot(a, 0)
+	n.SetRoot(b, 0)
+	if roots := n.Roots(); len(roots) != 1 || roots[0].Node != b {
+		t.Fatalf("roots = %v, want only %d", roots, b.ID())
+	}
+	n.Reset()
+	if roots := n.Roots(); len(roots) != 0 {
+		t.Errorf("Reset kept %d roots", len(roots))
+	}
+}
+
+func TestSnapshotKeepsRoots(t *testing.T) {
+	n := NewNetwork()
+	a, _ := twoParts(n)
+	n.SetRoot(a, 0)
+	s := n.Snapshot()
+	n.ClearRoots()
+
+	n.Restore(s)
+	roots := n.Roots()
+	if len(roots) != 1 || roots[0].Node.ID() != a.ID() {
+		t.Fatalf("restored roots = %v, want %d", roots, a.ID())
+	}
+	if roots[0].Node != n.NodeByID(a.ID()) {
+		t.Error("restored root is not the restored node")
+	}
+}
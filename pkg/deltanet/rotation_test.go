@@ -0,0 +1,113 @@
+package deltanet
+
+import "testing"
+
+// TestRotateFans tests that entering phase 2 rotates every fan once, moving
+// each wire with its depth one port down (1 to 0, 2 to 1, 0 to 2), and
+// reduces the pairs the new principal ports form.
+func TestRotateFans(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(1))
+	fan := net.NewFan()
+	vars := [3]Node{net.NewVar(), net.NewVar(), net.NewVar()}
+	for port, v := range vars {
+		net.LinkAt(fan, port, v, 0, uint64(10+port))
+	}
+	// A fan whose first auxiliary port faces an eraser becomes an active
+	// pair once rotated.
+	erased := net.NewFan()
+	eraser := net.NewEraser()
+	net.LinkAt(erased, 1, eraser, 0, 0)
+	net.LinkAt(erased, 0, net.NewVar(), 0, 0)
+	net.LinkAt(erased, 2, net.NewVar(), 0, 0)
+
+	net.SetPhase(2)
+	for port, from := range []int{1, 2, 0} {
+		if !net.IsConnected(fan, port, vars[from], 0) {
+			next, _ := net.GetLink(fan, port)
+			t.Errorf("port %d: linked to %v, want var %d", port, next, from)
+		}
+		if got, want := net.LinkDepth(fan, port), uint64(10+from); got != want {
+			t.Errorf("port %d: depth %d, want %d", port, got, want)
+		}
+		if idx := fan.Ports()[port].Index; idx != port {
+			t.Errorf("port %d: index %d", port, idx)
+		}
+	}
+
+	// Setting phase 2 again does not rotate again.
+	net.SetPhase(2)
+	if !net.IsConnected(fan, 0, vars[1], 0) {
+		t.Errorf("fans rotated twice")
+	}
+
+	net.ReduceAll()
+	if !erased.IsDead() || net.GetStats().Erasure == 0 {
+		t.Errorf("rotated fan facing an eraser was not erased")
+	}
+}
+
+// TestRotatePendingFanPair tests that fans still facing each other on their
+// principal ports when phase 2 begins annihilate, while the rotated fans of
+// an abstraction whose body is an application do not.
+func TestRotatePendingFanPair(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(1))
+	a, b := net.NewFan(), net.NewFan()
+	net.Link(a, 0, b, 0)
+	vars := [4]Node{net.NewVar(), net.NewVar(), net.NewVar(), net.NewVar()}
+	net.Link(a, 1, vars[0], 0)
+	net.Link(a, 2, vars[1], 0)
+	net.Link(b, 1, vars[2], 0)
+	net.Link(b, 2, vars[3], 0)
+
+	// y: y x, with x and y free: the abstraction's body is the
+	// application's result.
+	abs, app := net.NewFan(), net.NewFan()
+	net.Link(abs, 1, app, 1)
+	net.Link(abs, 0, net.NewVar(), 0)
+	net.Link(abs, 2, net.NewVar(), 0)
+	net.Link(app, 0, net.NewVar(), 0)
+	net.Link(app, 2, net.NewVar(), 0)
+
+	net.SetPhase(2)
+	net.ReduceAll()
+
+	if got := net.GetStats().FanAnnihilation; got != 1 {
+		t.Errorf("expected 1 fan annihilation, got %d", got)
+	}
+	if !a.IsDead() || !b.IsDead() {
+		t.Error("pending fan pair was not annihilated")
+	}
+	if !net.IsConnected(vars[0], 0, vars[2], 0) || !net.IsConnected(vars[1], 0, vars[3], 0) {
+		t.Error("annihilation did not join the auxiliary ports pairwise")
+	}
+	if abs.IsDead() || app.IsDead() || !net.IsConnected(abs, 0, app, 0) {
+		t.Error("rotated abstraction and application interacted")
+	}
+}
+
+// TestRotateSchedulesFormedPairs tests that entering phase 2 queues only
+// the pairs facing each other once every fan is rotated, each once.
+func TestRotateSchedulesFormedPairs(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(1))
+	// a's first auxiliary port faces b's principal port: a turns that port
+	// into its principal one, and b turns it away.
+	a, b := net.NewFan(), net.NewFan()
+	net.Link(a, 1, b, 0)
+	// c and d face each other on their first auxiliary ports, which both
+	// rotate into principal ones.
+	c, d := net.NewFan(), net.NewFan()
+	net.Link(c, 1, d, 1)
+	for _, fan := range []Node{a, b, c, d} {
+		for port := 0; port < 3; port++ {
+			if next, _ := net.GetLink(fan, port); next == nil {
+				net.Link(fan, port, net.NewVar(), 0)
+			}
+		}
+	}
+
+	net.SetPhase(2)
+
+	if got := net.Queued(); got != 1 {
+		t.Errorf("expected 1 queued pair, got %d", got)
+	}
+}
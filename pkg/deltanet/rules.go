@@ -0,0 +1,187 @@
+package deltanet
+
+import (
+	"errors"
+	"fmt"
+)
+
+// The interaction rules reducePair applies are chosen by pairRule from the
+// types of the two nodes, the phase and, for two replicators, whether their
+// levels are equal. RuleTable lists its choices, so the documented rule
+// matrix is the one the workers follow.
+
+// pairRule returns the rule for a pair of principal ports of nodes of types
+// a and b in phase, and the counter it bumps (statOps alone for pairs no
+// rule covers). Replicator pairs annihilate when sameLevel and commute
+// otherwise. It reports false when the pair is not a redex.
+func pairRule(a, b NodeType, phase int, sameLevel bool) (RuleKind, statKind, bool) {
+	if a > b {
+		a, b = b, a
+	}
+	switch {
+	case a == NodeTypeVar || b == NodeTypeVar:
+		// Free ports never interact.
+		return RuleUnknown, statOps, false
+	case a == b && a == NodeTypeFan && phase == 2:
+		// The paper's phase 2 has one rule, aux fan replication: fans
+		// annihilate in phase 1 only, and SetPhase reduces the pairs
+		// still pending before it rotates the fans. Rotation makes the
+		// body of an abstraction and the result of an application their
+		// principal ports, so two rotated fans facing each other are an
+		// abstraction whose body is an application: a normal form, not
+		// a redex.
+		return RuleUnknown, statOps, false
+	case a == b && a == NodeTypeReplicator && sameLevel:
+		return RuleRepRep, statRepAnn, true
+	case a == b && a == NodeTypeReplicator:
+		return RuleRepRepComm, statRepComm, true
+	case a == b && a == NodeTypeFan:
+		return RuleFanFan, statFanAnn, true
+	case a == NodeTypeEraser || b == NodeTypeEraser:
+		// Two erasers annihilate: an erasure with nothing left to erase.
+		return RuleErasure, statErasure, true
+	case b == NodeTypeEffect:
+		// An effect waits for the effect runner (see RunEffects) to put
+		// the value its handler resumes with in its place.
+		return RuleUnknown, statOps, false
+	case a == NodeTypeFan && b == NodeTypeReplicator && phase == 2:
+		return RuleAuxFanRep, statAuxFanRep, true
+	case a == NodeTypeFan && b == NodeTypeReplicator:
+		return RuleFanRep, statFanRepComm, true
+	case a == NodeTypeFan && b == NodeTypeData && phase == 2:
+		// After the rotation a fan faces Data through the body of an
+		// abstraction or the result of an application: a value in place.
+		return RuleUnknown, statOps, false
+	case a == NodeTypeFan && (b == NodeTypePure || b == NodeTypeData):
+		// Data in function position is applied like a native that always
+		// fails (see applyData).
+		return RuleFanNative, statNative, true
+	case a == NodeTypeReplicator && (b == NodeTypeData || b == NodeTypePure):
+		// Data and natives have no auxiliary ports: the replicator
+		// copies them to each of its uses.
+		return RuleRepCopy, statDataCopy, true
+	default:
+		return RuleUnknown, statOps, true
+	}
+}
+
+// first returns a and b with the one of type t first.
+func first(t NodeType, a, b Node) (Node, Node) {
+	if a.Type() != t {
+		return b, a
+	}
+	return a, b
+}
+
+// RuleEntry is a row of the interaction rule table: the rule applied when
+// nodes of types A and B (in either order) meet on their principal ports
+// in Phase.
+type RuleEntry struct {
+	A, B  NodeType
+	Phase int
+	// Levels is "equal" or "different" for replicator pairs, whose rule
+	// depends on it, and empty otherwise.
+	Levels string
+	Rule   RuleKind
+	Stat   string // The Stats field counting the rule
+}
+
+// RuleTable returns the interactions the workers apply, one row per pair
+// of node types, phase and level relation that a rule covers. Pairs that
+// are not redexes or that no rule covers are left out. The canonical rules
+// (RuleRepDecay, RuleRepMerge, RuleEta) act on single nodes and are not
+// listed.
+func RuleTable() []RuleEntry {
+	var table []RuleEntry
+	for phase := 1; phase <= 2; phase++ {
+		for a := NodeTypeFan; a <= NodeTypeHandler; a++ {
+			for b := a; b <= NodeTypeHandler; b++ {
+				levels := []string{""}
+				if a == NodeTypeReplicator && b == NodeTypeReplicator {
+					levels = []string{"equal", "different"}
+				}
+				for _, l := range levels {
+					rule, stat, ok := pairRule(a, b, phase, l != "different")
+					if !ok || rule == RuleUnknown {
+						continue
+					}
+					table = append(table, RuleEntry{A: a, B: b, Phase: phase, Levels: l, Rule: rule, Stat: statNames[stat]})
+				}
+			}
+		}
+	}
+	return table
+}
+
+// ErrRuleNotAllowed is wrapped by the *RuleError reduction returns when a
+// pair needs a rule outside the set allowed by SetRules.
+var ErrRuleNotAllowed = errors.New("interaction rule not allowed")
+
+// RuleError reports the pair whose rule halted reduction. The pair is left
+// unreduced.
+type RuleError struct {
+	Rule  RuleKind
+	Types [2]NodeType // Types of the two nodes
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("%v: %s between %v and %v", ErrRuleNotAllowed, e.Rule, e.Types[0], e.Types[1])
+}
+
+func (e *RuleError) Unwrap() error {
+	return ErrRuleNotAllowed
+}
+
+// SetRules restricts reduction to the given interaction rules, e.g. to
+// check that the nets of a λ-calculus subsystem only need its subset. A
+// pair whose rule is not listed halts reduction (see commutation.go), and
+// reduction reports a *RuleError describing it. Only interactions between
+// principal ports are checked: the canonical rules (decay, merge and eta)
+// are not. An empty list removes the restriction. It must be called before
+// reduction starts.
+func (n *Network) SetRules(rules []RuleKind) {
+	if len(rules) == 0 {
+		n.commute.rules = nil
+		return
+	}
+	allowed := make([]bool, len(ruleNames))
+	for _, r := range rules {
+		if r > RuleUnknown && int(r) < len(allowed) {
+			allowed[r] = true
+		}
+	}
+	n.commute.rules = allowed
+}
+
+// Rules returns the rules reduction is restricted to, or nil when every
+// rule is allowed.
+func (n *Network) Rules() []RuleKind {
+	if n.commute.rules == nil {
+		return nil
+	}
+	var rules []RuleKind
+	for r, ok := range n.commute.rules {
+		if ok {
+			rules = append(rules, RuleKind(r))
+		}
+	}
+	return rules
+}
+
+// WithRules restricts reduction to the given rules (see SetRules).
+func WithRules(rules ...RuleKind) Option {
+	return func(n *Network) { n.SetRules(rules) }
+}
+
+// ruleOver describes the pair of a and b if its rule is not allowed.
+func (n *Network) ruleOver(a, b Node) *RuleError {
+	allowed := n.commute.rules
+	if allowed == nil {
+		return nil
+	}
+	rule, _, ok := pairRule(a.Type(), b.Type(), n.phase, a.Level() == b.Level())
+	if !ok || rule == RuleUnknown || allowed[rule] {
+		return nil
+	}
+	return &RuleError{Rule: rule, Types: [2]NodeType{a.Type(), b.Type()}}
+}
@@ -0,0 +1,145 @@
+package deltanet
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestRuleTable tests the rule matrix against the rules of the paper and
+// that it names real Stats fields.
+func TestRuleTable(t *testing.T) {
+	type key struct {
+		a, b   NodeType
+		phase  int
+		levels string
+	}
+	rows := make(map[key]RuleEntry)
+	for _, e := range RuleTable() {
+		rows[key{e.A, e.B, e.Phase, e.Levels}] = e
+		if _, ok := reflect.TypeOf(Stats{}).FieldByName(e.Stat); !ok {
+			t.Errorf("%v: no Stats field %q", e.Rule, e.Stat)
+		}
+	}
+	tests := []struct {
+		k    key
+		rule RuleKind
+	}{
+		{key{NodeTypeFan, NodeTypeFan, 1, ""}, RuleFanFan},
+		{key{NodeTypeFan, NodeTypeReplicator, 1, ""}, RuleFanRep},
+		{key{NodeTypeFan, NodeTypeReplicator, 2, ""}, RuleAuxFanRep},
+		{key{NodeTypeReplicator, NodeTypeReplicator, 1, "equal"}, RuleRepRep},
+		{key{NodeTypeReplicator, NodeTypeReplicator, 2, "different"}, RuleRepRepComm},
+		{key{NodeTypeFan, NodeTypeEraser, 2, ""}, RuleErasure},
+		{key{NodeTypeEraser, NodeTypeEraser, 1, ""}, RuleErasure},
+		{key{NodeTypeFan, NodeTypePure, 1, ""}, RuleFanNative},
+		{key{NodeTypeFan, NodeTypeData, 1, ""}, RuleFanNative},
+		{key{NodeTypeReplicator, NodeTypeData, 1, ""}, RuleRepCopy},
+	}
+	for _, tt := range tests {
+		if got := rows[tt.k].Rule; got != tt.rule {
+			t.Errorf("%v: got %v, want %v", tt.k, got, tt.rule)
+		}
+	}
+	if e, ok := rows[key{NodeTypeFan, NodeTypeFan, 2, ""}]; ok {
+		t.Errorf("rotated fans interact in phase 2: %+v", e)
+	}
+	if e, ok := rows[key{NodeTypeFan, NodeTypeData, 2, ""}]; ok {
+		t.Errorf("rotated fans interact with data in phase 2: %+v", e)
+	}
+	for _, other := range []NodeType{NodeTypeFan, NodeTypeReplicator, NodeTypeData} {
+		if e, ok := rows[key{other, NodeTypeEffect, 1, ""}]; ok {
+			t.Errorf("an effect interacts before it is performed: %+v", e)
+		}
+	}
+	for _, same := range []NodeType{NodeTypeData, NodeTypePure, NodeTypeEffect, NodeTypeHandler} {
+		if e, ok := rows[key{same, same, 1, ""}]; ok {
+			t.Errorf("two %v nodes interact: %+v", same, e)
+		}
+	}
+	for k := range rows {
+		if k.a == NodeTypeVar || k.b == NodeTypeVar {
+			t.Errorf("free ports interact: %+v", k)
+		}
+	}
+}
+
+// TestSetRules tests that a pair needing a rule outside the allowed set
+// halts reduction with a *RuleError, while allowed rules still apply.
+func TestSetRules(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(4), WithRules(RuleFanFan))
+	defer n.Close()
+	if got := n.Rules(); !reflect.DeepEqual(got, []RuleKind{RuleFanFan}) {
+		t.Errorf("Rules: expected [fan-fan], got %v", got)
+	}
+	// The fans annihilate, wiring the eraser to the third fan.
+	abs, app, fan := n.NewFan(), n.NewFan(), n.NewFan()
+	n.Link(abs, 0, app, 0)
+	n.Link(abs, 1, fan, 0)
+	n.Link(app, 1, n.NewEraser(), 0)
+	n.Link(abs, 2, n.NewVar(), 0)
+	n.Link(app, 2, n.NewVar(), 0)
+	n.Link(fan, 1, n.NewVar(), 0)
+	n.Link(fan, 2, n.NewVar(), 0)
+
+	var ruleErr *RuleError
+	if err := n.ReduceAll(); !errors.As(err, &ruleErr) {
+		t.Fatalf("expected a *RuleError, got %v", err)
+	}
+	if ruleErr.Rule != RuleErasure {
+		t.Errorf("expected erasure to be refused, got %v", ruleErr.Rule)
+	}
+	if got := n.GetStats().FanAnnihilation; got != 1 {
+		t.Errorf("expected the fan pair to annihilate, got %d", got)
+	}
+
+	n.SetRules(nil)
+	if n.Rules() != nil {
+		t.Errorf("Rules: expected nil once lifted, got %v", n.Rules())
+	}
+	if err := n.ReduceAll(); err != nil {
+		t.Fatalf("reduce after lifting the restriction: %v", err)
+	}
+	if n.GetStats().Erasure == 0 {
+		t.Error("erasure not applied after lifting the restriction")
+	}
+}
+
+// TestCommutationReusesNodes tests that commutations rewire the nodes of
+// their pair into the result, creating half the copies, while every
+// interaction leaves a valid net.
+func TestCommutationReusesNodes(t *testing.T) {
+	build := func(n *Network) {
+		buildCommutations(n, 10)
+		a := n.NewReplicator(0, []int{0, 1})
+		b := n.NewReplicator(1, []int{0, 0, 2})
+		n.Link(a, 0, b, 0)
+		for i := 1; i <= 2; i++ {
+			n.Link(a, i, n.NewVar(), 0)
+		}
+		n.Link(b, 1, b, 2) // A loop between the pair's own ports
+		n.Link(b, 3, n.NewVar(), 0)
+	}
+	want := NewNetworkWith(WithWorkers(2), WithParallel())
+	build(want)
+	want.ReduceAll()
+
+	n := NewNetworkWith(WithValidation())
+	build(n)
+	before := n.NodeCount()
+	if err := n.ReduceWithBudget(context.Background(), Budget{}); err != nil {
+		t.Fatalf("reusing nodes left an invalid net: %v", err)
+	}
+	if got, exp := n.GetStats().Interactions(), want.GetStats().Interactions(); got != exp {
+		t.Errorf("stats %+v, expected %+v", got, exp)
+	}
+	// Ten fan-replicator commutations make a fan and a replicator each,
+	// the replicator commutation three of its five copies.
+	if created := n.NodeCount() - before; created != 10*2+3 {
+		t.Errorf("expected 23 new nodes, got %d", created)
+	}
+	if got, exp := n.ActiveNodeCount(), want.ActiveNodeCount(); got != exp {
+		t.Errorf("expected %d live nodes, got %d", exp, got)
+	}
+}
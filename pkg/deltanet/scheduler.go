@@ -1,33 +1,285 @@
 package deltanet
 
-import "sync"
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
 
-const MaxPriority = 64
+// shallowDepths is the number of depths whose buckets are created up front
+// and found by index. Deeper buckets are found by walking the bucket list.
+const shallowDepths = 64
 
+// Scheduler queues active pairs by depth without locks. Each depth has a
+// bucket holding one lock-free FIFO per worker, so workers only contend
+// on the compare-and-swap of the queue they touch. A worker queues the
+// pairs its interactions create on its own queue; pairs pushed from
+// outside are spread round-robin. Pop takes the shallowest depth with
+// queued work (leftmost-outermost priority), serving the worker's own
+// queue first and stealing from the others when it is empty at that depth.
+// Every queue is served from the front, so wires of one depth on one queue
+// keep their push order.
+//
+// Depths are unbounded. The buckets form a list sorted by depth that only
+// grows: the first shallowDepths buckets are linked up front, deeper ones
+// are inserted with a compare-and-swap when a wire of their depth is first
+// pushed, and dropped again by Reset. Each bucket counts its queued wires,
+// and first points at a bucket no deeper than any with queued wires, so
+// Pop walks the list from there to find the shallowest depth. A wire is
+// counted after it is queued and uncounted after it is taken, so a count
+// may briefly trail its queues; the pusher signals the workers only once
+// the wire is counted.
 type Scheduler struct {
-	queues [MaxPriority]chan *Wire
-	signal chan struct{}
-	mu     sync.Mutex // Ensures strict leftmost-outermost order
+	shards  int // Queues per bucket, one per worker
+	shallow [shallowDepths]*depthBucket
+	first   atomic.Pointer[depthBucket]
+	next    atomic.Uint64 // Round-robin queue for Push
+	signal  chan struct{}
+	done    chan struct{} // Closed by Close
+	closing sync.Once
+}
+
+// depthBucket holds the queued wires of one depth.
+type depthBucket struct {
+	depth  uint64
+	queued atomic.Int64
+	queues []atomic.Pointer[wireQueue] // Per worker, created on first push
+	next   atomic.Pointer[depthBucket] // Next deeper bucket
+}
+
+func newDepthBucket(depth uint64, shards int) *depthBucket {
+	return &depthBucket{depth: depth, queues: make([]atomic.Pointer[wireQueue], shards)}
+}
+
+// queue returns the worker's queue, creating it if needed.
+func (b *depthBucket) queue(worker int) *wireQueue {
+	if q := b.queues[worker].Load(); q != nil {
+		return q
+	}
+	b.queues[worker].CompareAndSwap(nil, newWireQueue())
+	return b.queues[worker].Load()
+}
+
+// take removes up to cap(buf) wires, from the worker's own queue if it has
+// any, otherwise from the first other queue that has.
+func (b *depthBucket) take(worker int, buf []*Wire) []*Wire {
+	for i := range b.queues {
+		q := b.queues[(worker+i)%len(b.queues)].Load()
+		if q == nil {
+			continue
+		}
+		n := len(buf)
+		for len(buf) < cap(buf) {
+			w := q.pop()
+			if w == nil {
+				break
+			}
+			buf = append(buf, w)
+		}
+		if len(buf) > n {
+			b.queued.Add(int64(n - len(buf)))
+			return buf
+		}
+	}
+	return buf
+}
+
+// wireQueue is a lock-free FIFO of wires (a Michael-Scott queue). head is
+// a sentinel: the queued wires are those of the nodes after it.
+type wireQueue struct {
+	head atomic.Pointer[queueNode]
+	tail atomic.Pointer[queueNode]
+}
+
+type queueNode struct {
+	wire *Wire
+	next atomic.Pointer[queueNode]
+}
+
+func newWireQueue() *wireQueue {
+	q := &wireQueue{}
+	sentinel := &queueNode{}
+	q.head.Store(sentinel)
+	q.tail.Store(sentinel)
+	return q
+}
+
+func (q *wireQueue) push(w *Wire) {
+	node := &queueNode{wire: w}
+	for {
+		tail := q.tail.Load()
+		if next := tail.next.Load(); next != nil {
+			// Another push linked its node but has not moved the tail yet.
+			q.tail.CompareAndSwap(tail, next)
+			continue
+		}
+		if tail.next.CompareAndSwap(nil, node) {
+			q.tail.CompareAndSwap(tail, node)
+			return
+		}
+	}
+}
+
+func (q *wireQueue) pop() *Wire {
+	for {
+		head := q.head.Load()
+		next := head.next.Load()
+		if next == nil {
+			return nil
+		}
+		if q.head.CompareAndSwap(head, next) {
+			return next.wire
+		}
+	}
+}
+
+// each calls fn with the queued wires in order. Wires pushed or taken
+// meanwhile may be missed.
+func (q *wireQueue) each(fn func(*Wire)) {
+	for node := q.head.Load().next.Load(); node != nil; node = node.next.Load() {
+		fn(node.wire)
+	}
 }
 
 func NewScheduler() *Scheduler {
 	s := &Scheduler{
 		signal: make(chan struct{}, 10000),
+		done:   make(chan struct{}),
 	}
-	for i := range s.queues {
-		s.queues[i] = make(chan *Wire, 1024)
-	}
+	s.setShards(runtime.NumCPU())
 	return s
 }
 
-func (s *Scheduler) Push(w *Wire, depth int) {
-	if depth < 0 {
-		depth = 0
+// setShards redistributes queued wires over k queues per depth, one per
+// worker. It must not run concurrently with Push or Pop: SetWorkers
+// refuses to change the number of workers once they are started.
+func (s *Scheduler) setShards(k int) {
+	if k < 1 {
+		k = 1
+	}
+	var queued []*Wire
+	var depths []uint64
+	for b := s.shallow[0]; b != nil; b = b.next.Load() {
+		for i := range b.queues {
+			if q := b.queues[i].Load(); q != nil {
+				for w := q.pop(); w != nil; w = q.pop() {
+					queued = append(queued, w)
+					depths = append(depths, b.depth)
+				}
+			}
+		}
+	}
+	s.shards = k
+	s.reset()
+	for i, w := range queued {
+		s.pushTo(i%k, w, depths[i])
 	}
-	if depth >= MaxPriority {
-		depth = MaxPriority - 1
+}
+
+// reset drops every bucket, the wires queued in them included, and links
+// fresh shallow ones. It must not run concurrently with Push or Pop.
+func (s *Scheduler) reset() {
+	for d := shallowDepths - 1; d >= 0; d-- {
+		s.shallow[d] = newDepthBucket(uint64(d), s.shards)
+		if d+1 < shallowDepths {
+			s.shallow[d].next.Store(s.shallow[d+1])
+		}
+	}
+	s.first.Store(s.shallow[0])
+}
+
+// bucket returns the bucket of depth, inserting it into the list if needed.
+func (s *Scheduler) bucket(depth uint64) *depthBucket {
+	if depth < shallowDepths {
+		return s.shallow[depth]
 	}
-	s.queues[depth] <- w
+	// Any bucket no deeper than depth is a valid place to start from,
+	// since buckets are never unlinked while the scheduler is in use.
+	prev := s.shallow[shallowDepths-1]
+	if f := s.first.Load(); f.depth > prev.depth && f.depth <= depth {
+		prev = f
+	}
+	for {
+		next := prev.next.Load()
+		switch {
+		case next != nil && next.depth < depth:
+			prev = next
+		case next != nil && next.depth == depth:
+			return next
+		default:
+			b := newDepthBucket(depth, s.shards)
+			b.next.Store(next)
+			if prev.next.CompareAndSwap(next, b) {
+				return b
+			}
+		}
+	}
+}
+
+// lower moves first back to b if it is past it.
+func (s *Scheduler) lower(b *depthBucket) {
+	for {
+		f := s.first.Load()
+		if f.depth <= b.depth || s.first.CompareAndSwap(f, b) {
+			return
+		}
+	}
+}
+
+// firstQueued returns the shallowest bucket with queued wires, moving first
+// past the empty buckets it walks over.
+func (s *Scheduler) firstQueued() *depthBucket {
+	b := s.first.Load()
+	for b.queued.Load() <= 0 {
+		next := b.next.Load()
+		if next == nil {
+			return nil
+		}
+		if !s.first.CompareAndSwap(b, next) {
+			b = s.first.Load()
+			continue
+		}
+		if b.queued.Load() > 0 {
+			// A wire was counted after b was seen empty: its pusher may
+			// have missed the move, so first goes back.
+			s.lower(b)
+			b = s.first.Load()
+			continue
+		}
+		b = next
+	}
+	return b
+}
+
+// nextQueued returns the next bucket deeper than b with queued wires.
+func nextQueued(b *depthBucket) *depthBucket {
+	for b = b.next.Load(); b != nil && b.queued.Load() <= 0; b = b.next.Load() {
+	}
+	return b
+}
+
+// Len returns the number of queued wires.
+func (s *Scheduler) Len() int {
+	var total int64
+	for b := s.shallow[0]; b != nil; b = b.next.Load() {
+		if c := b.queued.Load(); c > 0 {
+			total += c
+		}
+	}
+	return int(total)
+}
+
+// Push queues w at depth on the next queue in round-robin order.
+func (s *Scheduler) Push(w *Wire, depth uint64) {
+	s.pushTo(int(s.next.Add(1)%uint64(s.shards)), w, depth)
+}
+
+// pushTo queues w at depth on the given worker's queue.
+func (s *Scheduler) pushTo(worker int, w *Wire, depth uint64) {
+	b := s.bucket(depth)
+	b.queue(worker % s.shards).push(w)
+	b.queued.Add(1)
+	s.lower(b)
 	select {
 	case s.signal <- struct{}{}:
 	default:
@@ -35,25 +287,81 @@ func (s *Scheduler) Push(w *Wire, depth int) {
 	}
 }
 
-func (s *Scheduler) Pop() *Wire {
+// Pop blocks until a wire is queued and returns the highest priority one,
+// preferring the given worker's queue. After Close it returns nil once no
+// work is queued.
+func (s *Scheduler) Pop(worker int) *Wire {
+	var one [1]*Wire
+	if wires := s.PopBatch(worker, one[:0]); len(wires) > 0 {
+		return wires[0]
+	}
+	return nil
+}
+
+// PopBatch is Pop taking up to cap(buf) wires of the shallowest queued
+// depth at once, appended to buf, which must be empty. The wires come from
+// a single queue, in the order Pop would have returned them. After Close
+// it returns an empty batch once no work is queued.
+func (s *Scheduler) PopBatch(worker int, buf []*Wire) []*Wire {
 	for {
-		// Lock to ensure only one worker pops at a time,
-		// guaranteeing strict leftmost-outermost order
-		s.mu.Lock()
-
-		// Scan for highest priority (lowest depth index)
-		for i := 0; i < MaxPriority; i++ {
-			select {
-			case w := <-s.queues[i]:
-				s.mu.Unlock()
-				return w
-			default:
-				continue
+		if wires := s.takeBatch(worker, buf); len(wires) > 0 {
+			return wires
+		}
+		select {
+		case <-s.signal:
+		case <-s.done:
+			return s.takeBatch(worker, buf)
+		}
+	}
+}
+
+// Close wakes up the workers blocked in Pop so they can exit.
+func (s *Scheduler) Close() {
+	s.closing.Do(func() { close(s.done) })
+}
+
+// TryPop returns the highest priority wire without blocking, or nil when no
+// work is queued.
+func (s *Scheduler) TryPop() *Wire {
+	return s.take(0)
+}
+
+// Queued returns the queued wires in the order TryPop takes them: by depth,
+// then queue by queue in push order. Wires pushed or taken meanwhile may be
+// missed.
+func (s *Scheduler) Queued() []*Wire {
+	var wires []*Wire
+	for b := s.shallow[0]; b != nil; b = b.next.Load() {
+		for i := range b.queues {
+			if q := b.queues[i].Load(); q != nil {
+				q.each(func(w *Wire) { wires = append(wires, w) })
 			}
 		}
+	}
+	return wires
+}
 
-		// No work found, unlock and wait for signal
-		s.mu.Unlock()
-		<-s.signal
+// take removes a wire of the shallowest queued depth, from the worker's
+// own queue if it has one, otherwise stolen from another queue. Either way
+// it is the oldest wire of that depth on its queue.
+func (s *Scheduler) take(worker int) *Wire {
+	var one [1]*Wire
+	if wires := s.takeBatch(worker, one[:0]); len(wires) > 0 {
+		return wires[0]
+	}
+	return nil
+}
+
+// takeBatch is take removing up to cap(buf) wires of the shallowest queued
+// depth from the first queue that has any. A bucket whose count is ahead
+// of its queues, because its last wires are being taken by another worker,
+// is passed over for the next one.
+func (s *Scheduler) takeBatch(worker int, buf []*Wire) []*Wire {
+	home := worker % s.shards
+	for b := s.firstQueued(); b != nil; b = nextQueued(b) {
+		if buf = b.take(home, buf); len(buf) > 0 {
+			return buf
+		}
 	}
+	return buf
 }
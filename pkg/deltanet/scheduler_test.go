@@ -0,0 +1,216 @@
+package deltanet
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestSchedulerDepthPriority tests that shallower wires are popped first
+// whichever shard they were pushed to.
+func TestSchedulerDepthPriority(t *testing.T) {
+	s := NewScheduler()
+	s.setShards(4)
+	wires := make([]*Wire, 8)
+	for i := len(wires) - 1; i >= 0; i-- {
+		wires[i] = &Wire{depth: uint64(i)}
+		s.Push(wires[i], uint64(i))
+	}
+	for i := range wires {
+		// Worker 3 has to steal most of these.
+		if w := s.Pop(3); w != wires[i] {
+			t.Fatalf("pop %d: got depth %d", i, w.depth)
+		}
+	}
+	if w := s.TryPop(); w != nil {
+		t.Fatalf("expected empty scheduler, got depth %d", w.depth)
+	}
+}
+
+// TestSchedulerConcurrentPop tests that every pushed wire is popped exactly
+// once by concurrent workers.
+func TestSchedulerConcurrentPop(t *testing.T) {
+	const workers, perWorker = 8, 1000
+	s := NewScheduler()
+	s.setShards(workers)
+	for i := 0; i < workers*perWorker; i++ {
+		s.Push(&Wire{}, uint64(i%5)*40)
+	}
+	var mu sync.Mutex
+	seen := make(map[*Wire]bool)
+	var wg sync.WaitGroup
+	for id := 0; id < workers; id++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				w := s.Pop(id)
+				mu.Lock()
+				if seen[w] {
+					t.Errorf("wire popped twice")
+				}
+				seen[w] = true
+				mu.Unlock()
+			}
+		}(id)
+	}
+	wg.Wait()
+	if len(seen) != workers*perWorker {
+		t.Errorf("expected %d wires, got %d", workers*perWorker, len(seen))
+	}
+}
+
+// TestSchedulerConcurrentPushPop tests that wires pushed by several
+// goroutines at once, on their own queues and round-robin, at shallow and
+// deep depths, are each popped exactly once by concurrent workers.
+func TestSchedulerConcurrentPushPop(t *testing.T) {
+	const workers, perWorker = 8, 2000
+	s := NewScheduler()
+	s.setShards(workers)
+	var pushers sync.WaitGroup
+	for id := 0; id < workers; id++ {
+		pushers.Add(1)
+		go func(id int) {
+			defer pushers.Done()
+			for i := 0; i < perWorker; i++ {
+				depth := uint64((id*perWorker + i) % 97 * 3)
+				if i%2 == 0 {
+					s.pushTo(id, &Wire{depth: depth}, depth)
+				} else {
+					s.Push(&Wire{depth: depth}, depth)
+				}
+			}
+		}(id)
+	}
+	var mu sync.Mutex
+	seen := make(map[*Wire]bool)
+	var poppers sync.WaitGroup
+	for id := 0; id < workers; id++ {
+		poppers.Add(1)
+		go func(id int) {
+			defer poppers.Done()
+			for i := 0; i < perWorker; i++ {
+				w := s.Pop(id)
+				mu.Lock()
+				if seen[w] {
+					t.Errorf("wire popped twice")
+				}
+				seen[w] = true
+				mu.Unlock()
+			}
+		}(id)
+	}
+	pushers.Wait()
+	poppers.Wait()
+	if len(seen) != workers*perWorker {
+		t.Errorf("expected %d wires, got %d", workers*perWorker, len(seen))
+	}
+	if got := s.Len(); got != 0 {
+		t.Errorf("expected an empty scheduler, got %d wires", got)
+	}
+}
+
+// TestSchedulerDeepDepths tests that depths beyond the atomic counters keep
+// their order instead of being clamped together.
+func TestSchedulerDeepDepths(t *testing.T) {
+	s := NewScheduler()
+	depths := []uint64{5000, 64, 1 << 40, 63, 200, 65}
+	for _, d := range depths {
+		s.Push(&Wire{depth: d}, d)
+	}
+	want := []uint64{63, 64, 65, 200, 5000, 1 << 40}
+	for _, d := range want {
+		w := s.TryPop()
+		if w == nil || w.depth != d {
+			t.Fatalf("expected depth %d, got %v", d, w)
+		}
+	}
+	if w := s.TryPop(); w != nil {
+		t.Fatalf("expected empty scheduler, got depth %d", w.depth)
+	}
+}
+
+// TestSchedulerPopBatch tests that a batch holds wires of the shallowest
+// depth only, in push order, and no more than the buffer's capacity.
+func TestSchedulerPopBatch(t *testing.T) {
+	s := NewScheduler()
+	s.setShards(1)
+	var shallow []*Wire
+	for i := 0; i < 5; i++ {
+		w := &Wire{depth: 2}
+		shallow = append(shallow, w)
+		s.Push(w, 2)
+		s.Push(&Wire{depth: 3}, 3)
+	}
+	batch := s.PopBatch(0, make([]*Wire, 0, 3))
+	if len(batch) != 3 {
+		t.Fatalf("expected a batch of 3, got %d", len(batch))
+	}
+	for i, w := range batch {
+		if w != shallow[i] {
+			t.Errorf("batch[%d]: got depth %d, not the %dth pushed wire", i, w.depth, i)
+		}
+	}
+	batch = s.PopBatch(0, batch[:0])
+	if len(batch) != 2 || batch[0] != shallow[3] || batch[1] != shallow[4] {
+		t.Errorf("expected the last 2 wires of depth 2, got %d wires", len(batch))
+	}
+	if got := s.Len(); got != 5 {
+		t.Errorf("expected 5 wires left, got %d", got)
+	}
+}
+
+// TestSchedulerStealInOrder tests that wires stolen from another shard come
+// in the order they were pushed, like those of the worker's own shard.
+func TestSchedulerStealInOrder(t *testing.T) {
+	s := NewScheduler()
+	s.setShards(2)
+	wires := []*Wire{{depth: 1}, {depth: 1}, {depth: 1}}
+	for _, w := range wires {
+		s.pushTo(1, w, 1)
+	}
+	for i, want := range wires {
+		if w := s.Pop(0); w != want {
+			t.Fatalf("steal %d: got the wrong wire", i)
+		}
+	}
+}
+
+// TestScheduleOnProducerShard tests that the pairs an interaction creates
+// are queued on the shard of the worker that reduced it.
+func TestScheduleOnProducerShard(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(2))
+	eraser, fan, inner := n.NewEraser(), n.NewFan(), n.NewFan()
+	n.Link(eraser, 0, fan, 0)
+	n.Link(fan, 1, inner, 0)
+	n.Link(fan, 2, n.NewVar(), 0)
+	n.Link(inner, 1, n.NewVar(), 0)
+	n.Link(inner, 2, n.NewVar(), 0)
+	for n.scheduler.TryPop() != nil {
+	}
+
+	if _, ok := n.reducePair(eraser.Ports()[0].Wire.Load(), n.statsFor(1)); !ok {
+		t.Fatal("eraser and fan did not interact")
+	}
+	for b := n.scheduler.shallow[0]; b != nil; b = b.next.Load() {
+		if q := b.queues[0].Load(); q != nil && q.pop() != nil {
+			t.Errorf("worker 0's queue got a wire at depth %d", b.depth)
+		}
+	}
+	want := inner.Ports()[0].Wire.Load()
+	if q := n.scheduler.bucket(want.depth).queues[1].Load(); q == nil || q.pop() != want {
+		t.Error("the eraser reaching the inner fan was not queued on worker 1's queue")
+	}
+}
+
+// TestSetWorkersAfterStart tests that the number of workers cannot change
+// while they serve the scheduler's shards.
+func TestSetWorkersAfterStart(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(2), WithStart())
+	defer n.Close()
+	defer func() {
+		if recover() == nil {
+			t.Error("SetWorkers did not panic after Start")
+		}
+	}()
+	n.SetWorkers(4)
+}
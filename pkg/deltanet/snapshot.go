@@ -0,0 +1,268 @@
+package deltanet
+
+import (
+	"maps"
+	"sync/atomic"
+)
+
+// Snapshot is a copy of a network's net taken by Network.Snapshot. It
+// records the live nodes with their IDs and attributes, the wires between
+// them with their depths, the registered roots, node metadata, the phase
+// and the Stats, so Restore can bring the network back to that point any
+// number of times, e.g. to explore alternative reduction continuations from
+// a common state.
+// Partially applied natives the recorded nodes refer to are kept with
+// their closures, as Reset unregisters them. Trace events and timing are
+// not part of a snapshot.
+type Snapshot struct {
+	nodes    []NodeRecord
+	wires    []wireRecord
+	roots    []rootRecord
+	partials map[string]nativeRecord
+	meta     map[uint64]interface{}
+	nextID   uint64
+	phase    int
+	maxPhase int
+	stats    Stats
+}
+
+// NodeRecord is a node as a snapshot records it: its ID, its type and the
+// attributes of that type. Every serialized form of a net is written from
+// these records: the binary encoding (see Encode), the JSON graph (see
+// ExportJSON) and programs (see package program).
+type NodeRecord struct {
+	ID        uint64
+	Type      NodeType
+	Level     int         // Replicators
+	Deltas    []int       // Replicators
+	Value     interface{} // Data
+	Name      string      // Natives
+	Effect    *Effect     // Effects
+	EffectRow EffectRow   // Effects
+	scope     *HandlerScope
+}
+
+// nativeRecord is a registered native with its capability.
+type nativeRecord struct {
+	fn         NativeFunc
+	capability Capability
+}
+
+type rootRecord struct {
+	id   uint64
+	port int
+}
+
+type wireRecord struct {
+	a, b         uint64
+	aPort, bPort int
+	depth        uint64
+}
+
+// Snapshot copies the current net. The network must not be reducing, or
+// must be paused (see Pause).
+func (n *Network) Snapshot() *Snapshot {
+	return n.snapshot(nil, n.Roots())
+}
+
+// SnapshotFrom copies only the part of the net connected to roots, which
+// become the snapshot's registered roots. Restoring it gives a network
+// holding just that subnet.
+func (n *Network) SnapshotFrom(roots ...Root) *Snapshot {
+	reach := n.newErasure(roots)
+	reach.Mark(0)
+	return n.snapshot(func(node Node) bool { return reach.marked.has(node.ID()) }, roots)
+}
+
+// snapshot copies the live nodes accepted by keep (all when nil) and the
+// wires between them, registering roots.
+func (n *Network) snapshot(keep func(Node) bool, roots []Root) *Snapshot {
+	s := &Snapshot{
+		nextID:   n.ids.last(),
+		phase:    n.phase,
+		maxPhase: n.maxPhase,
+		stats:    n.GetStats(),
+	}
+	live := make(map[uint64]bool)
+	nodes := n.snapshotNodes()
+	for _, node := range nodes {
+		if node.IsDead() || (keep != nil && !keep(node)) {
+			continue
+		}
+		live[node.ID()] = true
+		s.nodes = append(s.nodes, RecordNode(node))
+		if node.Type() == NodeTypePure && partialNative(node.GetName()) {
+			s.partials = n.recordNative(s.partials, node.GetName())
+		}
+	}
+	for _, node := range nodes {
+		if !live[node.ID()] {
+			continue
+		}
+		for port := range node.Ports() {
+			next, nextPort := n.GetLink(node, port)
+			if next == nil || !live[next.ID()] {
+				continue
+			}
+			// Record each wire once, from its lower end.
+			if next.ID() < node.ID() || (next.ID() == node.ID() && nextPort < port) {
+				continue
+			}
+			s.wires = append(s.wires, wireRecord{
+				a: node.ID(), aPort: port,
+				b: next.ID(), bPort: nextPort,
+				depth: n.LinkDepth(node, port),
+			})
+		}
+	}
+	for _, r := range roots {
+		if r.Node != nil && live[r.Node.ID()] {
+			s.roots = append(s.roots, rootRecord{id: r.Node.ID(), port: r.Port})
+		}
+	}
+	if atomic.LoadUint32(&n.metaOn) != 0 {
+		n.metaMu.RLock()
+		s.meta = maps.Clone(n.meta)
+		n.metaMu.RUnlock()
+	}
+	return s
+}
+
+// recordNative adds the closure of the registered native name to natives,
+// making the map on first use, and returns it.
+func (n *Network) recordNative(natives map[string]nativeRecord, name string) map[string]nativeRecord {
+	n.nativesMu.RLock()
+	defer n.nativesMu.RUnlock()
+	fn, ok := n.natives[name]
+	if !ok {
+		return natives
+	}
+	if natives == nil {
+		natives = make(map[string]nativeRecord)
+	}
+	natives[name] = nativeRecord{fn: fn, capability: n.nativeCaps[name]}
+	return natives
+}
+
+// Restore replaces the net with the one recorded in s, as Reset followed
+// by rebuilding it. Nodes get back their IDs, so Node values from before
+// must be looked up again with NodeByID. Active pairs are queued again.
+// Natives, handlers and workers are kept as by Reset, and the partially
+// applied natives of the snapshot are registered again. The network must
+// not be reducing.
+func (n *Network) Restore(s *Snapshot) {
+	n.Reset()
+	for name, p := range s.partials {
+		n.RegisterNativeWithCapability(name, p.fn, p.capability)
+	}
+	byID := make(map[uint64]Node, len(s.nodes))
+	for _, rec := range s.nodes {
+		// The constructors number nodes from the last fresh ID, the free
+		// list being empty after Reset.
+		n.ids.setLast(rec.ID - 1)
+		node := n.newNodeFrom(rec)
+		if node == nil {
+			continue
+		}
+		byID[rec.ID] = node
+	}
+	n.ids.setLast(s.nextID)
+	if n.ids.recycle {
+		n.freeGaps()
+	}
+
+	for _, w := range s.wires {
+		a, b := byID[w.a], byID[w.b]
+		if a != nil && b != nil {
+			n.LinkAt(a, w.aPort, b, w.bPort, w.depth)
+		}
+	}
+
+	for _, r := range s.roots {
+		if node := byID[r.id]; node != nil {
+			n.AddRoot(node, r.port)
+		}
+	}
+
+	if s.meta != nil {
+		n.metaMu.Lock()
+		n.meta = maps.Clone(s.meta)
+		n.metaMu.Unlock()
+		atomic.StoreUint32(&n.metaOn, 1)
+	}
+	n.phase, n.maxPhase = s.phase, s.maxPhase
+	stats := n.statsFor(0)
+	for k, v := range map[statKind]uint64{
+		statOps:        s.stats.TotalReductions,
+		statFanAnn:     s.stats.FanAnnihilation,
+		statRepAnn:     s.stats.RepAnnihilation,
+		statRepComm:    s.stats.RepCommutation,
+		statFanRepComm: s.stats.FanRepCommutation,
+		statErasure:    s.stats.Erasure,
+		statRepDecay:   s.stats.RepDecay,
+		statRepMerge:   s.stats.RepMerge,
+		statAuxFanRep:  s.stats.AuxFanRep,
+		statDataCopy:   s.stats.DataCopy,
+		statNative:     s.stats.NativeCalls,
+		statEta:        s.stats.EtaContraction,
+		statWires:      s.stats.WiresCreated,
+	} {
+		stats.counts[k].Store(v)
+	}
+	atomic.StoreUint64(&n.statGCs, s.stats.Collections)
+	atomic.StoreInt64(&n.phaseTime[0], int64(s.stats.Phase1Time))
+	atomic.StoreInt64(&n.phaseTime[1], int64(s.stats.Phase2Time))
+	if peak := int64(s.stats.PeakNodes); peak > n.nodes.peak.Load() {
+		n.nodes.peak.Store(peak)
+	}
+}
+
+// recordNode records the type and attributes of node.
+func RecordNode(node Node) NodeRecord {
+	rec := NodeRecord{ID: node.ID(), Type: node.Type()}
+	switch node.Type() {
+	case NodeTypeReplicator:
+		rec.Level = node.Level()
+		rec.Deltas = append([]int(nil), node.Deltas()...)
+	case NodeTypeData:
+		rec.Value = node.GetValue()
+	case NodeTypePure:
+		rec.Name = node.GetName()
+	case NodeTypeEffect:
+		rec.Effect = node.GetEffect()
+		rec.EffectRow = node.GetEffectRow()
+	case NodeTypeHandler:
+		rec.scope = node.GetHandlerScope()
+	}
+	return rec
+}
+
+// newNodeFrom creates a node with the type and attributes of rec, numbered
+// from the last fresh ID. It returns nil for types it cannot create.
+func (n *Network) newNodeFrom(rec NodeRecord) Node {
+	switch rec.Type {
+	case NodeTypeFan:
+		return n.NewFan()
+	case NodeTypeEraser:
+		return n.NewEraser()
+	case NodeTypeReplicator:
+		return n.NewReplicator(rec.Level, append([]int(nil), rec.Deltas...))
+	case NodeTypeVar:
+		return n.NewVar()
+	case NodeTypeData:
+		return n.NewData(rec.Value)
+	case NodeTypePure:
+		return n.NewNative(rec.Name)
+	case NodeTypeEffect:
+		return n.NewIO(rec.Effect, rec.EffectRow)
+	case NodeTypeHandler:
+		return n.NewHandler(rec.scope)
+	default:
+		return nil
+	}
+}
+
+// NodeByID returns the registered node with the given ID, or nil.
+func (n *Network) NodeByID(id uint64) Node {
+	return n.nodes.get(id)
+}
@@ -0,0 +1,88 @@
+package deltanet
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestSnapshotRestore tests that a restored network continues exactly as
+// the original did from the snapshot, as many times as it is restored.
+func TestSnapshotRestore(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(1))
+	buildCommutations(n, 10)
+	var vars []uint64
+	for _, node := range n.snapshotNodes() {
+		if node.Type() == NodeTypeVar {
+			vars = append(vars, node.ID())
+		}
+	}
+	render := func() string {
+		roots := make([]Node, len(vars))
+		for i, id := range vars {
+			if roots[i] = n.NodeByID(id); roots[i] == nil {
+				t.Fatalf("node %d not found", id)
+			}
+		}
+		var buf bytes.Buffer
+		n.WriteDOT(&buf, roots...)
+		return buf.String()
+	}
+
+	n.ReduceWithLimit(4)
+	snap := n.Snapshot()
+	atSnapshot, statsAtSnapshot := render(), n.GetStats()
+	n.ReduceWithLimit(100)
+	final, finalStats := render(), n.GetStats()
+
+	for round := 0; round < 2; round++ {
+		n.Restore(snap)
+		if got := render(); got != atSnapshot {
+			t.Fatalf("round %d: restored net differs:\n%s\nwant:\n%s", round, got, atSnapshot)
+		}
+		if got := n.GetStats(); got != statsAtSnapshot {
+			t.Errorf("round %d: restored stats %+v, want %+v", round, got, statsAtSnapshot)
+		}
+		n.ReduceWithLimit(100)
+		if got := render(); got != final {
+			t.Errorf("round %d: continuation differs:\n%s\nwant:\n%s", round, got, final)
+		}
+		if got := n.GetStats(); got.Interactions() != finalStats.Interactions() {
+			t.Errorf("round %d: final stats %+v, want %+v", round, got, finalStats)
+		}
+	}
+
+	// New nodes do not reuse restored IDs.
+	if fresh := n.NewVar(); n.NodeByID(fresh.ID()) != fresh {
+		t.Errorf("new node ID %d collides with a restored node", fresh.ID())
+	}
+}
+
+// TestSnapshotRestorePartial tests that a net restored while a curried
+// native is partially applied finishes the application.
+func TestSnapshotRestorePartial(t *testing.T) {
+	n := NewNetworkWith(WithWorkers(1))
+	registerAdd(n)
+	inner := n.NewFan()
+	n.Link(inner, 0, n.NewNative("add"), 0)
+	n.Link(inner, 2, n.NewData(1), 0)
+	outer := n.NewFan()
+	n.Link(outer, 0, inner, 1)
+	n.Link(outer, 2, n.NewData(10), 0)
+	output := n.NewVar()
+	n.Link(outer, 1, output, 0)
+
+	n.ReduceWithLimit(1)
+	if partialEntries(n) != 1 {
+		t.Fatalf("expected a partial native after one interaction, got %d", partialEntries(n))
+	}
+	snap := n.Snapshot()
+
+	for round := 0; round < 2; round++ {
+		n.Restore(snap)
+		n.ReduceWithLimit(100)
+		result, _ := n.GetLink(n.NodeByID(output.ID()), 0)
+		if result == nil || result.GetValue() != 11 {
+			t.Errorf("round %d: expected 11, got %v", round, result)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package deltanet
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Interaction statistics
+//
+// Every interaction bumps a counter, and so does every wire it creates, so
+// a single set of shared atomics would bounce its cache lines between all
+// workers in parallel mode. Each worker counts into its own workerStats
+// instead, and GetStats adds them up. Work done outside the workers
+// (ReduceWithLimit, ReduceAt, the canonical rules and linking) counts
+// against the first set.
+//
+// Loading the counters one by one while workers update them would give a
+// torn view, say an interaction counted in TotalReductions but not yet under
+// its rule. Every update of a set is bracketed by its begin and end
+// sequence numbers, and a reader retries a set until no update started
+// since before it read end (begin == end once it is done), which also holds
+// when several goroutines write the same set.
+
+// statKind indexes the counters of a workerStats.
+type statKind int
+
+const (
+	statOps statKind = iota // Total reductions
+	statFanAnn
+	statRepAnn
+	statRepComm
+	statFanRepComm
+	statErasure
+	statRepDecay
+	statRepMerge
+	statAuxFanRep
+	statDataCopy
+	statNative
+	statEta
+	statWires // Wires created, not an interaction
+	numStats
+)
+
+// statNames names the Stats field of each counter.
+var statNames = [numStats]string{
+	statOps:        "TotalReductions",
+	statFanAnn:     "FanAnnihilation",
+	statRepAnn:     "RepAnnihilation",
+	statRepComm:    "RepCommutation",
+	statFanRepComm: "FanRepCommutation",
+	statErasure:    "Erasure",
+	statRepDecay:   "RepDecay",
+	statRepMerge:   "RepMerge",
+	statAuxFanRep:  "AuxFanRep",
+	statDataCopy:   "DataCopy",
+	statNative:     "NativeCalls",
+	statEta:        "EtaContraction",
+	statWires:      "WiresCreated",
+}
+
+// workerStats holds one worker's counters. They are atomics only so that
+// GetStats can read them while the worker runs. The interactions a worker
+// reduces are handed its counters, which also name the scheduler shard the
+// pairs they create are queued on.
+type workerStats struct {
+	worker int
+	begin  atomic.Uint64 // Updates started
+	end    atomic.Uint64 // Updates finished
+	counts [numStats]atomic.Uint64
+	_      [64]byte // Keep the next worker's counters off these cache lines
+}
+
+func (s *workerStats) add(k statKind) {
+	s.begin.Add(1)
+	s.counts[k].Add(1)
+	s.end.Add(1)
+}
+
+// interaction counts one interaction of rule k.
+func (s *workerStats) interaction(k statKind) {
+	s.begin.Add(1)
+	s.counts[statOps].Add(1)
+	s.counts[k].Add(1)
+	s.end.Add(1)
+}
+
+// load returns a consistent copy of the counters.
+func (s *workerStats) load() [numStats]uint64 {
+	var counts [numStats]uint64
+	for spins := 0; ; spins++ {
+		end := s.end.Load()
+		for k := range counts {
+			counts[k] = s.counts[k].Load()
+		}
+		if s.begin.Load() == end {
+			return counts
+		}
+		if spins > 8 {
+			runtime.Gosched()
+		}
+	}
+}
+
+// StatsSnapshot holds the counters read by SnapshotStats and the Time they
+// were read.
+type StatsSnapshot struct {
+	Stats
+	Time time.Time // Carries a monotonic clock reading for rates
+}
+
+// SnapshotStats returns the counters summed over the workers. Each
+// worker's counters are read consistently, so every interaction counted in
+// TotalReductions is also counted under its rule, but the workers are read
+// one after the other while they run: the totals are not those of any
+// single instant. They are exact once reduction has stopped.
+func (n *Network) SnapshotStats() StatsSnapshot {
+	var totals [numStats]uint64
+	for i := range n.stats {
+		counts := n.stats[i].load()
+		for k, c := range counts {
+			totals[k] += c
+		}
+	}
+	n.sweepsMu.Lock()
+	canonical := n.sweeps.Elapsed
+	n.sweepsMu.Unlock()
+	return StatsSnapshot{
+		Stats: Stats{
+			TotalReductions:   totals[statOps],
+			FanAnnihilation:   totals[statFanAnn],
+			RepAnnihilation:   totals[statRepAnn],
+			RepCommutation:    totals[statRepComm],
+			FanRepCommutation: totals[statFanRepComm],
+			Erasure:           totals[statErasure],
+			RepDecay:          totals[statRepDecay],
+			RepMerge:          totals[statRepMerge],
+			AuxFanRep:         totals[statAuxFanRep],
+			DataCopy:          totals[statDataCopy],
+			NativeCalls:       totals[statNative],
+			EtaContraction:    totals[statEta],
+
+			PeakNodes:     uint64(n.nodes.peak.Load()),
+			WiresCreated:  totals[statWires],
+			Collections:   atomic.LoadUint64(&n.statGCs),
+			Phase1Time:    time.Duration(atomic.LoadInt64(&n.phaseTime[0])),
+			CanonicalTime: canonical,
+			Phase2Time:    time.Duration(atomic.LoadInt64(&n.phaseTime[1])),
+		},
+		Time: time.Now(),
+	}
+}
+
+// Since returns the counts and times added between prev and s and the time
+// elapsed, from which rates follow (see Stats.Sub).
+func (s StatsSnapshot) Since(prev StatsSnapshot) (Stats, time.Duration) {
+	return s.Stats.Sub(prev.Stats), s.Time.Sub(prev.Time)
+}
+
+// Sub returns the counts and times added between prev and s. Counters
+// reset in between give zero rather than wrapping around. PeakNodes is the
+// peak as of s.
+func (s Stats) Sub(prev Stats) Stats {
+	sub := func(a, b uint64) uint64 {
+		if a < b {
+			return 0
+		}
+		return a - b
+	}
+	return Stats{
+		TotalReductions:   sub(s.TotalReductions, prev.TotalReductions),
+		FanAnnihilation:   sub(s.FanAnnihilation, prev.FanAnnihilation),
+		RepAnnihilation:   sub(s.RepAnnihilation, prev.RepAnnihilation),
+		RepCommutation:    sub(s.RepCommutation, prev.RepCommutation),
+		FanRepCommutation: sub(s.FanRepCommutation, prev.FanRepCommutation),
+		Erasure:           sub(s.Erasure, prev.Erasure),
+		RepDecay:          sub(s.RepDecay, prev.RepDecay),
+		RepMerge:          sub(s.RepMerge, prev.RepMerge),
+		AuxFanRep:         sub(s.AuxFanRep, prev.AuxFanRep),
+		DataCopy:          sub(s.DataCopy, prev.DataCopy),
+		NativeCalls:       sub(s.NativeCalls, prev.NativeCalls),
+		EtaContraction:    sub(s.EtaContraction, prev.EtaContraction),
+
+		PeakNodes:     s.PeakNodes,
+		WiresCreated:  sub(s.WiresCreated, prev.WiresCreated),
+		Collections:   sub(s.Collections, prev.Collections),
+		Phase1Time:    time.Duration(sub(uint64(s.Phase1Time), uint64(prev.Phase1Time))),
+		CanonicalTime: time.Duration(sub(uint64(s.CanonicalTime), uint64(prev.CanonicalTime))),
+		Phase2Time:    time.Duration(sub(uint64(s.Phase2Time), uint64(prev.Phase2Time))),
+	}
+}
+
+// StatsSince returns what the network added to its Stats since prev, taken
+// by GetStats, e.g. to measure a single phase, or a single term on a
+// network that evaluates several.
+func (n *Network) StatsSince(prev Stats) Stats {
+	return n.GetStats().Sub(prev)
+}
+
+// Interactions returns s with only the interaction counts, which a
+// reduction repeats exactly, without the memory and time figures, which
+// vary with scheduling and the clock.
+func (s Stats) Interactions() Stats {
+	s.PeakNodes, s.WiresCreated, s.Collections = 0, 0, 0
+	s.Phase1Time, s.CanonicalTime, s.Phase2Time = 0, 0, 0
+	return s
+}
+
+// timePhase adds the time since began to the current phase's total.
+func (n *Network) timePhase(began time.Time) {
+	atomic.AddInt64(&n.phaseTime[min(n.phase, 2)-1], int64(time.Since(began)))
+}
+
+// statsFor returns the counters of worker id.
+func (n *Network) statsFor(id int) *workerStats {
+	return &n.stats[id%len(n.stats)]
+}
+
+// stat adds up counter k over all workers.
+func (n *Network) stat(k statKind) uint64 {
+	var total uint64
+	for i := range n.stats {
+		total += n.stats[i].counts[k].Load()
+	}
+	return total
+}
+
+// newWorkerStats returns the counters of k workers.
+func newWorkerStats(k int) []workerStats {
+	stats := make([]workerStats, k)
+	for i := range stats {
+		stats[i].worker = i
+	}
+	return stats
+}
+
+// setStatWorkers resizes the per-worker counters to k workers, keeping the
+// totals. It must not run during reduction.
+func (n *Network) setStatWorkers(k int) {
+	stats := newWorkerStats(k)
+	for s := statKind(0); s < numStats; s++ {
+		stats[0].counts[s].Store(n.stat(s))
+	}
+	n.stats = stats
+}
+
+// resetStats zeroes every counter.
+func (n *Network) resetStats() {
+	for i := range n.stats {
+		for s := range n.stats[i].counts {
+			n.stats[i].counts[s].Store(0)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package deltanet
+
+import (
+	"testing"
+	"time"
+)
+
+// TestStatsMergeWorkers tests that counters kept by several workers add up
+// to the same Stats as a single worker.
+func TestStatsMergeWorkers(t *testing.T) {
+	single := NewNetworkWith(WithWorkers(1))
+	buildCommutations(single, 200)
+	single.ReduceAll()
+	want := single.GetStats().Interactions()
+	if want.FanRepCommutation != 200 {
+		t.Fatalf("expected 200 commutations, got %d", want.FanRepCommutation)
+	}
+
+	multi := NewNetworkWith(WithWorkers(8), WithParallel())
+	buildCommutations(multi, 200)
+	multi.ReduceAll()
+	if got := multi.GetStats().Interactions(); got != want {
+		t.Errorf("8 workers: got %+v, want %+v", got, want)
+	}
+	used := 0
+	for i := range multi.stats {
+		if multi.stats[i].counts[statOps].Load() > 0 {
+			used++
+		}
+	}
+	if used == 0 {
+		t.Errorf("no worker counted an interaction")
+	}
+}
+
+// TestSnapshotStatsConsistent tests that snapshots taken while workers
+// count never see an interaction without its rule.
+func TestSnapshotStatsConsistent(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(8), WithParallel())
+	buildCommutations(net, 20000)
+
+	done := make(chan struct{})
+	torn := make(chan Stats, 1)
+	go func() {
+		defer close(torn)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if s := net.SnapshotStats().Stats; s.TotalReductions != s.FanRepCommutation {
+				torn <- s
+				return
+			}
+		}
+	}()
+	net.ReduceAll()
+	close(done)
+	if s, ok := <-torn; ok {
+		t.Errorf("torn snapshot: %+v", s)
+	}
+}
+
+func TestStatsSnapshotSince(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(1))
+	buildCommutations(net, 10)
+	net.ReduceAll()
+	first := net.SnapshotStats()
+
+	buildCommutations(net, 5)
+	net.ReduceAll()
+	time.Sleep(time.Millisecond)
+	second := net.SnapshotStats()
+
+	delta, elapsed := second.Since(first)
+	if delta.TotalReductions != 5 || delta.FanRepCommutation != 5 {
+		t.Errorf("delta: got %+v, want 5 commutations", delta)
+	}
+	if elapsed < time.Millisecond {
+		t.Errorf("elapsed: got %v, want at least 1ms", elapsed)
+	}
+	if back, _ := first.Since(second); back.TotalReductions != 0 {
+		t.Errorf("reversed delta: got %d reductions, want 0", back.TotalReductions)
+	}
+}
+
+// TestStatsMemoryAndTime tests the figures kept besides the interaction
+// counts: peak nodes, wires, collections and time by phase.
+func TestStatsMemoryAndTime(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(1))
+	buildCommutations(net, 10)
+	wires := net.GetStats().WiresCreated
+	net.ReduceToNormalForm()
+	net.CollectGarbage()
+
+	s := net.GetStats()
+	if s.PeakNodes != uint64(net.Report().PeakNodes) || s.PeakNodes == 0 {
+		t.Errorf("peak nodes: got %d, report has %d", s.PeakNodes, net.Report().PeakNodes)
+	}
+	// Each commutation of binary nodes creates four wires.
+	if s.WiresCreated != wires+40 {
+		t.Errorf("wires: got %d, want %d", s.WiresCreated, wires+40)
+	}
+	if s.Collections != 1 {
+		t.Errorf("collections: got %d, want 1", s.Collections)
+	}
+	if s.Phase1Time <= 0 || s.CanonicalTime <= 0 || s.Phase2Time <= 0 {
+		t.Errorf("expected time in every phase, got %v, %v, %v", s.Phase1Time, s.CanonicalTime, s.Phase2Time)
+	}
+	if s.Interactions() != (Stats{TotalReductions: 10, FanRepCommutation: 10}) {
+		t.Errorf("interactions: got %+v", s.Interactions())
+	}
+}
+
+// TestStatsSince tests measuring the terms a reused network evaluates in
+// turn without resetting it.
+func TestStatsSince(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(1))
+	buildCommutations(net, 10)
+	net.ReduceAll()
+
+	prev := net.GetStats()
+	buildCommutations(net, 5)
+	net.ReduceAll()
+	delta := net.StatsSince(prev)
+	if delta.Interactions() != (Stats{TotalReductions: 5, FanRepCommutation: 5}) {
+		t.Errorf("delta: got %+v, want 5 commutations", delta.Interactions())
+	}
+	if delta.WiresCreated == 0 || delta.Phase1Time <= 0 {
+		t.Errorf("expected the second term's wires and time, got %+v", delta)
+	}
+
+	net.Reset()
+	if delta := net.StatsSince(prev); delta.TotalReductions != 0 {
+		t.Errorf("after Reset: got %d reductions, want 0", delta.TotalReductions)
+	}
+}
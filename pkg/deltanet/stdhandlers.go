@@ -0,0 +1,72 @@
+package deltanet
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Standard handlers
+//
+// StandardHandlers handles the effects most programs need, so a network
+// can be built ready to run them with WithStandardHandlers:
+//
+//	Console.Print    writes the payload to out and resumes with nil (CapConsole)
+//	Console.Println  the same, followed by a newline (CapConsole)
+//	State.Get        resumes with the value of the state cell (CapPure)
+//	State.Put        stores the payload in the cell and resumes with it (CapPure)
+//	Time.Now         resumes with the Unix time in nanoseconds (CapIO)
+//
+// The state cell starts as nil and belongs to the scope, so networks built
+// with the same scope share it. Payloads are written in fmt %v form.
+
+// StandardHandlers returns a new scope with the standard handlers, writing
+// console output to out.
+func StandardHandlers(out io.Writer) *HandlerScope {
+	scope := NewHandlerScope()
+	var mu sync.Mutex // Serializes console writes and state accesses
+	var state interface{}
+
+	scope.RegisterWithCapability("Console.Print", func(effect Effect, resume *Continuation) (interface{}, error) {
+		mu.Lock()
+		_, err := fmt.Fprint(out, effect.Payload)
+		mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return resume.Resume(nil)
+	}, CapConsole)
+	scope.RegisterWithCapability("Console.Println", func(effect Effect, resume *Continuation) (interface{}, error) {
+		mu.Lock()
+		_, err := fmt.Fprintln(out, effect.Payload)
+		mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+		return resume.Resume(nil)
+	}, CapConsole)
+	scope.RegisterWithCapability("State.Get", func(effect Effect, resume *Continuation) (interface{}, error) {
+		mu.Lock()
+		value := state
+		mu.Unlock()
+		return resume.Resume(value)
+	}, CapPure)
+	scope.RegisterWithCapability("State.Put", func(effect Effect, resume *Continuation) (interface{}, error) {
+		mu.Lock()
+		state = effect.Payload
+		mu.Unlock()
+		return resume.Resume(effect.Payload)
+	}, CapPure)
+	scope.RegisterWithCapability("Time.Now", func(effect Effect, resume *Continuation) (interface{}, error) {
+		return resume.Resume(int(time.Now().UnixNano()))
+	}, CapIO)
+	return scope
+}
+
+// WithStandardHandlers adds the standard handlers, writing console output
+// to out, to the network's root handler scope (see WithHandlers). Handlers
+// given by a later WithHandlers override them.
+func WithStandardHandlers(out io.Writer) Option {
+	return WithHandlers(StandardHandlers(out))
+}
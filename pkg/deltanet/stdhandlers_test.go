@@ -0,0 +1,91 @@
+package deltanet
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// performAll links an Effect node for each effect to an output and runs
+// the effects with the network's root handlers, returning the values that
+// took their places.
+func performAll(t *testing.T, net *Network, effects ...*Effect) ([]interface{}, error) {
+	t.Helper()
+	outputs := make([]Node, len(effects))
+	for i, effect := range effects {
+		outputs[i] = net.NewVar()
+		net.Link(net.NewIO(effect, EffectRow{effect.Name}), 0, outputs[i], 0)
+	}
+	if err := net.RunEffects(net.Handlers()); err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(effects))
+	for i, output := range outputs {
+		node, _ := net.GetLink(output, 0)
+		if node == nil || node.Type() != NodeTypeData {
+			t.Fatalf("effect %d: expected a Data node, got %v", i, node)
+		}
+		values[i] = node.GetValue()
+	}
+	return values, nil
+}
+
+func TestStandardHandlers(t *testing.T) {
+	var out bytes.Buffer
+	net := NewNetworkWith(WithStandardHandlers(&out))
+
+	values, err := performAll(t, net,
+		&Effect{Name: "Console.Print", Payload: "a"},
+		&Effect{Name: "Console.Println", Payload: 1},
+		&Effect{Name: "State.Put", Payload: 42},
+		&Effect{Name: "State.Get"},
+		&Effect{Name: "Time.Now"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out.String(); got != "a1\n" {
+		t.Errorf("expected console output %q, got %q", "a1\n", got)
+	}
+	if values[0] != nil || values[1] != nil {
+		t.Errorf("expected console effects to resume with nil, got %v and %v", values[0], values[1])
+	}
+	if values[2] != 42 || values[3] != 42 {
+		t.Errorf("expected State.Put and State.Get to give 42, got %v and %v", values[2], values[3])
+	}
+	if now, ok := values[4].(int); !ok || now <= 0 {
+		t.Errorf("expected Time.Now to give a positive int, got %v", values[4])
+	}
+}
+
+// TestStandardHandlersProfile tests that the profile hides the standard
+// handlers above its capability.
+func TestStandardHandlersProfile(t *testing.T) {
+	var out bytes.Buffer
+	net := NewNetworkWith(WithStandardHandlers(&out), WithProfile(ProfilePure))
+	values, err := performAll(t, net, &Effect{Name: "State.Put", Payload: "pure"})
+	if err != nil || values[0] != "pure" {
+		t.Errorf("expected State.Put under the pure profile, got %v, %v", values, err)
+	}
+	if _, err := performAll(t, net, &Effect{Name: "Console.Print", Payload: "x"}); !errors.Is(err, ErrCapabilityDenied) {
+		t.Errorf("expected Console.Print denied, got %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("denied effect wrote %q", out.String())
+	}
+}
+
+// TestStandardHandlersOverride tests that later handlers override the
+// standard ones.
+func TestStandardHandlersOverride(t *testing.T) {
+	var out bytes.Buffer
+	quiet := NewHandlerScope()
+	quiet.RegisterWithCapability("Console.Print", func(_ Effect, resume *Continuation) (interface{}, error) {
+		return resume.Resume("quiet")
+	}, CapConsole)
+	net := NewNetworkWith(WithStandardHandlers(&out), WithHandlers(quiet))
+	values, err := performAll(t, net, &Effect{Name: "Console.Print", Payload: "x"})
+	if err != nil || values[0] != "quiet" || out.Len() != 0 {
+		t.Errorf("expected the later handler, got %v, %v, output %q", values, err, out.String())
+	}
+}
@@ -0,0 +1,99 @@
+package deltanet
+
+// PairInfo describes a queued active pair (see ActivePairs).
+type PairInfo struct {
+	A, B  Node
+	Depth uint64   // Depth of the wire between them
+	Rule  RuleKind // Rule they interact by
+	wire  *Wire
+}
+
+// ActivePairs returns the queued active pairs in the order Step reduces
+// them: shallowest first, in leftmost-outermost order. Pairs already
+// consumed by other interactions are left out. The network must not be
+// reducing.
+func (n *Network) ActivePairs() []PairInfo {
+	var pairs []PairInfo
+	for _, w := range n.scheduler.Queued() {
+		if p, ok := n.pairOn(w); ok {
+			pairs = append(pairs, p)
+		}
+	}
+	return pairs
+}
+
+// pairOn describes the redex on w, if it still holds one.
+func (n *Network) pairOn(w *Wire) (PairInfo, bool) {
+	w.mu.Lock()
+	p0, p1 := w.P0.Load(), w.P1.Load()
+	w.mu.Unlock()
+	if p0 == nil || p1 == nil || p0.Index != 0 || p1.Index != 0 {
+		return PairInfo{}, false
+	}
+	a, b := p0.Node, p1.Node
+	if a.IsDead() || b.IsDead() || !isActive(a) || !isActive(b) {
+		return PairInfo{}, false
+	}
+	rule, _, ok := pairRule(a.Type(), b.Type(), n.phase, a.Level() == b.Level())
+	if !ok {
+		return PairInfo{}, false
+	}
+	return PairInfo{A: a, B: b, Depth: w.depth, Rule: rule, wire: w}, true
+}
+
+// Step reduces the next active pair in leftmost-outermost order on the
+// calling goroutine and returns the interaction. It reports false when no
+// active pair remains, or when the next one is a replicator commutation
+// over a limit, which Err then reports. Like ReduceWithLimit, it leaves
+// the rest of the net untouched, so a debugger can inspect the net between
+// steps.
+func (n *Network) Step() (TraceEvent, bool) {
+	if n.closed.Load() {
+		return TraceEvent{}, false
+	}
+	n.resume()
+	for {
+		w := n.tryPop()
+		if w == nil {
+			return TraceEvent{}, false
+		}
+		if n.guard(w) {
+			n.park(w)
+			n.release(w)
+			return TraceEvent{}, false
+		}
+		event, ok := n.reduceWire(w)
+		n.release(w)
+		n.pairs.Done()
+		if ok {
+			return event, true
+		}
+	}
+}
+
+// ReducePair reduces the given pair out of order and returns the
+// interaction, so a debugger can pick any of the ActivePairs. It reports
+// false when the pair is no longer active, or is a replicator commutation
+// over a limit, which Err then reports. The pair's wire stays queued; the
+// scheduler skips it once reduced.
+func (n *Network) ReducePair(p PairInfo) (TraceEvent, bool) {
+	if p.wire == nil || n.closed.Load() {
+		return TraceEvent{}, false
+	}
+	n.resume()
+	if n.guard(p.wire) {
+		return TraceEvent{}, false
+	}
+	return n.reduceWire(p.wire)
+}
+
+// reduceWire reduces w under the reduction lock and numbers the event.
+func (n *Network) reduceWire(w *Wire) (TraceEvent, bool) {
+	n.lockReduction()
+	defer n.reductionMu.Unlock()
+	event, ok := n.reducePair(w, n.statsFor(0))
+	if ok {
+		event.Step = n.stat(statOps) - 1
+	}
+	return event, ok
+}
@@ -0,0 +1,112 @@
+package deltanet
+
+import "testing"
+
+// rootAndInnerPairs queues a fan-fan pair at depth 1 before one at depth 0.
+func rootAndInnerPairs(net *Network) (inner, root [2]Node) {
+	inner = [2]Node{newFanWithSinks(net), newFanWithSinks(net)}
+	net.LinkAt(inner[0], 0, inner[1], 0, 1)
+	root = [2]Node{newFanWithSinks(net), newFanWithSinks(net)}
+	net.Link(root[0], 0, root[1], 0)
+	return inner, root
+}
+
+func TestStepFollowsLeftmostOutermost(t *testing.T) {
+	net := NewNetwork()
+	inner, root := rootAndInnerPairs(net)
+
+	pairs := net.ActivePairs()
+	if len(pairs) != 2 {
+		t.Fatalf("%d active pairs, want 2", len(pairs))
+	}
+	if pairs[0].Depth != 0 || pairs[0].Rule != RuleFanFan {
+		t.Errorf("first pair = depth %d %v, want the root fan-fan", pairs[0].Depth, pairs[0].Rule)
+	}
+
+	for i, want := range [][2]Node{root, inner} {
+		event, ok := net.Step()
+		if !ok {
+			t.Fatalf("step %d found no pair", i)
+		}
+		assertEventMatchesPair(t, event, want[0].ID(), want[1].ID())
+		if event.Step != uint64(i) || event.Rule != RuleFanFan {
+			t.Errorf("step %d = %+v", i, event)
+		}
+	}
+	if event, ok := net.Step(); ok {
+		t.Errorf("step after normal form reduced %+v", event)
+	}
+}
+
+func TestReducePairOutOfOrder(t *testing.T) {
+	net := NewNetwork()
+	inner, root := rootAndInnerPairs(net)
+
+	pairs := net.ActivePairs()
+	event, ok := net.ReducePair(pairs[1])
+	if !ok {
+		t.Fatal("inner pair was not reduced")
+	}
+	assertEventMatchesPair(t, event, inner[0].ID(), inner[1].ID())
+	if _, ok := net.ReducePair(pairs[1]); ok {
+		t.Error("pair reduced twice")
+	}
+
+	pairs = net.ActivePairs()
+	if len(pairs) != 1 || pairs[0].A.ID() != root[0].ID() && pairs[0].B.ID() != root[0].ID() {
+		t.Fatalf("active pairs after picking = %v", pairs)
+	}
+	event, ok = net.Step()
+	if !ok {
+		t.Fatal("root pair was not reduced")
+	}
+	assertEventMatchesPair(t, event, root[0].ID(), root[1].ID())
+	if _, ok := net.Step(); ok {
+		t.Error("stale inner wire was reduced again")
+	}
+}
+
+func TestActivePairsPredictRules(t *testing.T) {
+	net := NewNetwork()
+	rep := newReplicatorWithSinks(net, 1, []int{0, 0})
+	net.Link(newFanWithSinks(net), 0, rep, 0)
+	newEraserWithFanSink(net)
+	net.LinkAt(newReplicatorWithSinks(net, 0, []int{0}), 0, newReplicatorWithSinks(net, 2, []int{0}), 0, 1)
+
+	for steps := 0; ; steps++ {
+		pairs := net.ActivePairs()
+		event, ok := net.Step()
+		if !ok {
+			if len(pairs) != 0 {
+				t.Errorf("%d pairs listed but none reduced", len(pairs))
+			}
+			if steps < 3 {
+				t.Errorf("normal form after %d steps", steps)
+			}
+			return
+		}
+		if len(pairs) == 0 {
+			t.Fatalf("step %d reduced %v with no pair listed", steps, event.Rule)
+		}
+		if pairs[0].Rule != event.Rule {
+			t.Errorf("step %d: listed %v, reduced %v", steps, pairs[0].Rule, event.Rule)
+		}
+		assertEventMatchesPair(t, event, pairs[0].A.ID(), pairs[0].B.ID())
+	}
+}
+
+// TestActivePairsSkipNonRedexes tests that pairs reducePair refuses, such as
+// a fan facing Data after phase 2 rotation, are not listed.
+func TestActivePairsSkipNonRedexes(t *testing.T) {
+	net := NewNetworkWith(WithWorkers(1))
+	net.SetPhase(2)
+	fan := newFanWithSinks(net)
+	net.Link(fan, 0, net.NewData(1), 0)
+
+	if pairs := net.ActivePairs(); len(pairs) != 0 {
+		t.Errorf("listed %v between a fan and Data in phase 2", pairs[0].Rule)
+	}
+	if event, ok := net.Step(); ok {
+		t.Errorf("stepped %v", event.Rule)
+	}
+}
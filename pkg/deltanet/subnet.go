@@ -0,0 +1,171 @@
+package deltanet
+
+// Subnet is a part of a net taken by Extract or Cut, detached from any
+// network: its nodes with their attributes and metadata, the wires between
+// them with their depths, the natives its nodes refer to with their
+// closures, and its boundary, the ports through which it was attached to
+// the rest of the net. Graft places a copy of it in a network, so nets can
+// be built from parts, definitions shared between programs and
+// continuations captured by excision.
+type Subnet struct {
+	nodes    []NodeRecord
+	wires    []wireRecord
+	boundary []rootRecord
+	natives  map[string]nativeRecord
+	meta     map[uint64]interface{}
+}
+
+// NodeCount returns the number of nodes of the subnet.
+func (s *Subnet) NodeCount() int {
+	return len(s.nodes)
+}
+
+// Boundary returns the number of boundary ports, which Graft returns in
+// the order they were given to Extract or Cut.
+func (s *Subnet) Boundary() int {
+	return len(s.boundary)
+}
+
+// Extract copies the subnet delimited by boundary: the live nodes reachable
+// from the boundary nodes without crossing the wires on the boundary ports,
+// which are left out. The net is not changed. The network must not be
+// reducing, or must be paused (see Pause).
+func (n *Network) Extract(boundary ...Root) *Subnet {
+	s, _ := n.extract(boundary)
+	return s
+}
+
+// Cut is Extract that also removes the subnet from the net: its nodes are
+// unlinked and marked dead. It returns, for each boundary port, the port
+// outside the subnet it was linked to, now unlinked, or a zero Root when
+// there was none, so the caller can fill the hole. The network must not be
+// reducing, or must be paused.
+func (n *Network) Cut(boundary ...Root) (*Subnet, []Root) {
+	s, nodes := n.extract(boundary)
+	inside := make(map[uint64]bool, len(nodes))
+	for _, node := range nodes {
+		inside[node.ID()] = true
+	}
+	outside := make([]Root, len(boundary))
+	for i, b := range boundary {
+		if next, port := n.GetLink(b.Node, b.Port); next != nil && !inside[next.ID()] {
+			outside[i] = Root{Node: next, Port: port}
+		}
+	}
+	for _, node := range nodes {
+		for _, p := range node.Ports() {
+			unlink(p)
+		}
+		node.SetDead()
+	}
+	return s, outside
+}
+
+// extract records the subnet delimited by boundary and returns its nodes.
+func (n *Network) extract(boundary []Root) (*Subnet, []Node) {
+	closed := make(map[*Port]bool, len(boundary))
+	seen := make(map[uint64]bool)
+	var nodes, stack []Node
+	for _, b := range boundary {
+		closed[b.Node.Ports()[b.Port]] = true
+		stack = append(stack, b.Node)
+	}
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if node.IsDead() || seen[node.ID()] {
+			continue
+		}
+		seen[node.ID()] = true
+		nodes = append(nodes, node)
+		for port, p := range node.Ports() {
+			if closed[p] {
+				continue
+			}
+			if next, _ := n.GetLink(node, port); next != nil {
+				stack = append(stack, next)
+			}
+		}
+	}
+
+	s := &Subnet{}
+	for _, node := range nodes {
+		s.nodes = append(s.nodes, RecordNode(node))
+		if node.Type() == NodeTypePure {
+			s.natives = n.recordNative(s.natives, node.GetName())
+		}
+		if meta, ok := n.Meta(node.ID()); ok {
+			if s.meta == nil {
+				s.meta = make(map[uint64]interface{})
+			}
+			s.meta[node.ID()] = meta
+		}
+		for port, p := range node.Ports() {
+			next, nextPort := n.GetLink(node, port)
+			if next == nil || closed[p] || closed[next.Ports()[nextPort]] {
+				continue
+			}
+			// Record each wire once, from its lower end.
+			if next.ID() < node.ID() || (next.ID() == node.ID() && nextPort < port) {
+				continue
+			}
+			s.wires = append(s.wires, wireRecord{
+				a: node.ID(), aPort: port,
+				b: next.ID(), bPort: nextPort,
+				depth: n.LinkDepth(node, port),
+			})
+		}
+	}
+	for _, b := range boundary {
+		s.boundary = append(s.boundary, rootRecord{id: b.Node.ID(), port: b.Port})
+	}
+	return s, nodes
+}
+
+// Graft builds a copy of s in the network. Nodes get fresh IDs and keep
+// their levels, deltas, values and metadata, and wires keep their depths;
+// active pairs inside the copy are queued. Natives of s the network does
+// not have are registered with their capabilities, and each partially
+// applied one under a fresh name, as partials are numbered per network. It
+// returns the copy's boundary ports, in the order s was extracted with,
+// for the caller to link. A subnet can be grafted any number of times,
+// into any network. The network must not be reducing.
+func (n *Network) Graft(s *Subnet) []Root {
+	renamed := make(map[string]string)
+	for name, native := range s.natives {
+		if partialNative(name) {
+			renamed[name] = n.partialName(name)
+			name = renamed[name]
+		} else if _, ok := n.NativeCapability(name); ok {
+			continue
+		}
+		n.RegisterNativeWithCapability(name, native.fn, native.capability)
+	}
+	byID := make(map[uint64]Node, len(s.nodes))
+	for _, rec := range s.nodes {
+		if name, ok := renamed[rec.Name]; ok && rec.Type == NodeTypePure {
+			rec.Name = name
+		}
+		node := n.newNodeFrom(rec)
+		if node == nil {
+			continue
+		}
+		byID[rec.ID] = node
+		if meta, ok := s.meta[rec.ID]; ok {
+			n.SetMeta(node, meta)
+		}
+	}
+	for _, w := range s.wires {
+		a, b := byID[w.a], byID[w.b]
+		if a != nil && b != nil {
+			n.LinkAt(a, w.aPort, b, w.bPort, w.depth)
+		}
+	}
+	boundary := make([]Root, len(s.boundary))
+	for i, b := range s.boundary {
+		if node := byID[b.id]; node != nil {
+			boundary[i] = Root{Node: node, Port: b.port}
+		}
+	}
+	return boundary
+}
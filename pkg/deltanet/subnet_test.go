@@ -0,0 +1,108 @@
+package deltanet
+
+import "testing"
+
+// applyTo builds (fun arg) with the result on a fresh Var, which it
+// returns.
+func applyTo(n *Network, fun Root, arg Node) Node {
+	app := n.NewFan()
+	out := n.NewVar()
+	n.Link(app, 0, fun.Node, fun.Port)
+	n.Link(app, 2, arg, 0)
+	n.Link(app, 1, out, 0)
+	return out
+}
+
+// TestExtractGraft tests that an extracted subnet grafted into another
+// network behaves like the original, and that Extract leaves the source
+// untouched.
+func TestExtractGraft(t *testing.T) {
+	src := NewNetwork()
+	id := identity(src)
+	src.SetMeta(id, "identity")
+	before := src.ActiveNodeCount()
+	sub := src.Extract(Root{Node: id, Port: 0})
+	if sub.NodeCount() != 2 || sub.Boundary() != 1 {
+		t.Fatalf("expected 2 nodes and 1 boundary port, got %d and %d", sub.NodeCount(), sub.Boundary())
+	}
+	if got := src.ActiveNodeCount(); got != before {
+		t.Errorf("Extract changed the source net: %d nodes, had %d", got, before)
+	}
+
+	dst := NewNetwork()
+	for _, value := range []int{1, 2} {
+		boundary := dst.Graft(sub)
+		if meta, _ := dst.Meta(boundary[0].Node.ID()); meta != "identity" {
+			t.Errorf("grafted node lost its metadata: %v", meta)
+		}
+		out := applyTo(dst, boundary[0], dst.NewData(value))
+		dst.ReduceAll()
+		if res, _ := dst.GetLink(out, 0); res == nil || res.GetValue() != value {
+			t.Errorf("graft %d: expected Data %d, got %v", value, value, res)
+		}
+	}
+}
+
+// TestCut tests that Cut removes the subnet, returns the ports it leaves
+// open and that grafting it back restores the net.
+func TestCut(t *testing.T) {
+	n := NewNetwork()
+	id := identity(n)
+	out := applyTo(n, Root{Node: id, Port: 0}, n.NewData(7))
+	sub, outside := n.Cut(Root{Node: id, Port: 0})
+	if len(outside) != 1 || outside[0].Node == nil || outside[0].Port != 0 || outside[0].Node.Type() != NodeTypeFan {
+		t.Fatalf("expected the application's principal port outside, got %+v", outside)
+	}
+	if !id.IsDead() {
+		t.Error("cut node is still live")
+	}
+	if next, _ := n.GetLink(outside[0].Node, outside[0].Port); next != nil {
+		t.Errorf("outside port is still linked to %v", next.Type())
+	}
+	if err := n.Validate(); err != nil {
+		t.Fatalf("net after cut: %v", err)
+	}
+
+	boundary := n.Graft(sub)
+	n.Link(boundary[0].Node, boundary[0].Port, outside[0].Node, outside[0].Port)
+	n.ReduceAll()
+	if res, _ := n.GetLink(out, 0); res == nil || res.GetValue() != 7 {
+		t.Errorf("expected Data 7, got %v", res)
+	}
+}
+
+// TestGraftNatives tests that grafting a subnet registers the natives and
+// partially applied natives it refers to in the target network.
+func TestGraftNatives(t *testing.T) {
+	src := NewNetwork()
+	registerAdd(src)
+	out := applyTo(src, Root{Node: src.NewNative("add"), Port: 0}, src.NewData(1))
+	src.ReduceAll()
+	partial, _ := src.GetLink(out, 0)
+	if partial == nil || !partialNative(partial.GetName()) {
+		t.Fatalf("expected a partial native, got %v", partial)
+	}
+	add := src.Extract(Root{Node: src.NewNative("add"), Port: 0})
+	inc := src.Extract(Root{Node: partial, Port: 0})
+
+	dst := NewNetwork()
+	for _, value := range []int{2, 3} {
+		out := applyTo(dst, dst.Graft(inc)[0], dst.NewData(value))
+		dst.ReduceAll()
+		if res, _ := dst.GetLink(out, 0); res == nil || res.GetValue() != value+1 {
+			t.Errorf("inc %d: expected Data %d, got %v", value, value+1, res)
+		}
+	}
+	if got := partialEntries(dst); got != 0 {
+		t.Errorf("expected the applied partials to be unregistered, %d remain", got)
+	}
+
+	out = applyTo(dst, dst.Graft(add)[0], dst.NewData(4))
+	dst.ReduceAll()
+	partial, _ = dst.GetLink(out, 0)
+	sum := applyTo(dst, Root{Node: partial, Port: 0}, dst.NewData(5))
+	dst.ReduceAll()
+	if res, _ := dst.GetLink(sum, 0); res == nil || res.GetValue() != 9 {
+		t.Errorf("expected Data 9, got %v", res)
+	}
+}
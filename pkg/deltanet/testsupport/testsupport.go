@@ -0,0 +1,113 @@
+// Package testsupport builds the nets of well-known λ-terms directly, for
+// tests and benchmarks of the deltanet package and of code built on it.
+// Each builder lays out the net the lambda package would translate the
+// term to, with the same replicator levels and deltas, at a given level
+// and wire depth, and returns the port of the term's result, which the
+// caller links to the rest of the net.
+package testsupport
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// Sources of the terms built here, in the default surface syntax.
+const (
+	OmegaSource = "(x: x x) (x: x x)"
+	YSource     = "f: (x: f (x x)) (x: f (x x))"
+)
+
+// ChurchSource returns the source of the Church numeral n.
+func ChurchSource(n int) string {
+	return fmt.Sprintf("f: x: %sx%s", strings.Repeat("f (", n), strings.Repeat(")", n))
+}
+
+// BuildSelfApp builds δ = λx. x x and returns its abstraction.
+func BuildSelfApp(net *deltanet.Network, level int, depth uint64) (deltanet.Node, int) {
+	abs := net.NewFan()
+	app := net.NewFan()
+	rep := net.NewReplicator(level+1, []int{-1, 0})
+	net.LinkAt(abs, 1, app, 1, depth)
+	net.LinkAt(rep, 0, abs, 2, depth)
+	net.LinkAt(rep, 1, app, 0, depth)
+	net.LinkAt(rep, 2, app, 2, depth+1)
+	return abs, 0
+}
+
+// BuildOmega builds Ω = δ δ, which has no normal form, and returns its
+// application. Its only redex is at depth.
+func BuildOmega(net *deltanet.Network, level int, depth uint64) (deltanet.Node, int) {
+	app := net.NewFan()
+	fun, funPort := BuildSelfApp(net, level, depth)
+	arg, argPort := BuildSelfApp(net, level+1, depth+1)
+	net.LinkAt(app, 0, fun, funPort, depth)
+	net.LinkAt(app, 2, arg, argPort, depth+1)
+	return app, 1
+}
+
+// BuildChurch builds the Church numeral λf. λx. f (… (f x)) with n
+// applications of f and returns its outer abstraction.
+func BuildChurch(net *deltanet.Network, n, level int, depth uint64) (deltanet.Node, int) {
+	fAbs := net.NewFan()
+	xAbs := net.NewFan()
+	net.LinkAt(fAbs, 1, xAbs, 0, depth)
+	// x is used once, n applications deep.
+	xRep := net.NewReplicator(level+1, []int{n - 1})
+	net.LinkAt(xRep, 0, xAbs, 2, depth)
+	if n == 0 {
+		net.LinkAt(fAbs, 2, net.NewEraser(), 0, depth)
+		net.LinkAt(xAbs, 1, xRep, 1, depth)
+		return fAbs, 0
+	}
+
+	// The i-th application of f is i levels and depths down.
+	deltas := make([]int, n)
+	for i := range deltas {
+		deltas[i] = i - 1
+	}
+	fRep := net.NewReplicator(level+1, deltas)
+	net.LinkAt(fRep, 0, fAbs, 2, depth)
+	node, port := xAbs, 1
+	for i := 0; i < n; i++ {
+		app := net.NewFan()
+		at := depth + uint64(i)
+		net.LinkAt(node, port, app, 1, at)
+		net.LinkAt(app, 0, fRep, i+1, at)
+		node, port = app, 2
+	}
+	net.LinkAt(node, port, xRep, 1, depth+uint64(n))
+	return fAbs, 0
+}
+
+// BuildY builds the fixpoint combinator Y = λf. (λx. f (x x)) (λx. f (x x))
+// and returns its abstraction.
+func BuildY(net *deltanet.Network, level int, depth uint64) (deltanet.Node, int) {
+	fAbs := net.NewFan()
+	fRep := net.NewReplicator(level+1, []int{-1, 0})
+	net.LinkAt(fRep, 0, fAbs, 2, depth)
+	app := net.NewFan()
+	net.LinkAt(fAbs, 1, app, 1, depth)
+	fun := buildYHalf(net, fRep, 1, level, depth)
+	arg := buildYHalf(net, fRep, 2, level+1, depth+1)
+	net.LinkAt(app, 0, fun, 0, depth)
+	net.LinkAt(app, 2, arg, 0, depth+1)
+	return fAbs, 0
+}
+
+// buildYHalf builds λx. f (x x), taking f from port fPort of fRep, and
+// returns its abstraction.
+func buildYHalf(net *deltanet.Network, fRep deltanet.Node, fPort, level int, depth uint64) deltanet.Node {
+	abs := net.NewFan()
+	app := net.NewFan()
+	self := net.NewFan()
+	xRep := net.NewReplicator(level+1, []int{0, 1})
+	net.LinkAt(abs, 1, app, 1, depth)
+	net.LinkAt(app, 0, fRep, fPort, depth)
+	net.LinkAt(app, 2, self, 1, depth+1)
+	net.LinkAt(xRep, 0, abs, 2, depth)
+	net.LinkAt(xRep, 1, self, 0, depth+1)
+	net.LinkAt(xRep, 2, self, 2, depth+2)
+	return abs
+}
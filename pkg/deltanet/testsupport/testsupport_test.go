@@ -0,0 +1,60 @@
+package testsupport
+
+import (
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/lambda"
+)
+
+// TestBuildersMatchTranslation tests that each builder lays out the net
+// the lambda package translates the term's source to.
+func TestBuildersMatchTranslation(t *testing.T) {
+	tests := []struct {
+		source string
+		build  func(net *deltanet.Network) (deltanet.Node, int)
+	}{
+		{OmegaSource, func(net *deltanet.Network) (deltanet.Node, int) { return BuildOmega(net, 0, 0) }},
+		{YSource, func(net *deltanet.Network) (deltanet.Node, int) { return BuildY(net, 0, 0) }},
+	}
+	for n := 0; n <= 3; n++ {
+		tests = append(tests, struct {
+			source string
+			build  func(net *deltanet.Network) (deltanet.Node, int)
+		}{ChurchSource(n), func(net *deltanet.Network) (deltanet.Node, int) { return BuildChurch(net, n, 0, 0) }})
+	}
+	for _, tt := range tests {
+		term, err := lambda.Parse(tt.source)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.source, err)
+		}
+		want := deltanet.NewNetwork()
+		translation, err := lambda.NewTranslator(lambda.TranslatorOptions{}).Translate(term, want)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.source, err)
+		}
+
+		got := deltanet.NewNetwork()
+		root, port := tt.build(got)
+		out := got.NewVar()
+		got.Link(root, port, out, 0)
+		if !deltanet.Isomorphic(got, want, out, translation.Output) {
+			t.Errorf("%s: built net differs from the translation", tt.source)
+		}
+	}
+}
+
+// TestChurchReadsBack tests that built numerals read back as themselves.
+func TestChurchReadsBack(t *testing.T) {
+	tr := lambda.NewTranslator(lambda.TranslatorOptions{Church: lambda.DecodeNumerals})
+	for n := 0; n <= 4; n++ {
+		net := deltanet.NewNetwork()
+		root, port := BuildChurch(net, n, 0, 0)
+		out := net.NewVar()
+		net.Link(root, port, out, 0)
+		got := tr.Readback(net, &lambda.Translation{Output: out})
+		if want := (lambda.Numeral{N: n}); got != want {
+			t.Errorf("numeral %d reads back as %s", n, got)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package deltanet
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Timing splits the wall time of reduction between the scheduler, lock
+// waits and the bodies of the interaction rules, to tell whether a workload
+// is bound by scheduling overhead or by the rewriting itself. Times are
+// summed over all workers, so they can exceed the elapsed time.
+type Timing struct {
+	Push time.Duration `json:"push_ns"` // Queuing active pairs
+	Pop  time.Duration `json:"pop_ns"`  // Taking queued pairs
+	Idle time.Duration `json:"idle_ns"` // Workers blocked waiting for pairs
+	Lock time.Duration `json:"lock_ns"` // Waiting for the reduction lock or depth gate
+	// Rules is the time spent in each rule's body, by rule name (see
+	// RuleKind.String). Rules that never fired are omitted.
+	Rules map[string]time.Duration `json:"rules_ns"`
+}
+
+// timingStats accumulates nanoseconds while timing is enabled.
+type timingStats struct {
+	on    uint32
+	used  uint32 // Set once timing was enabled
+	push  int64
+	pop   int64
+	idle  int64
+	lock  int64
+	rules [len(ruleNames)]int64
+}
+
+// EnableTiming starts collecting Timing. It adds two clock reads around
+// every scheduler operation and interaction, so it is off by default.
+func (n *Network) EnableTiming() {
+	atomic.StoreUint32(&n.timing.used, 1)
+	atomic.StoreUint32(&n.timing.on, 1)
+}
+
+// DisableTiming stops collecting Timing. Collected times are kept.
+func (n *Network) DisableTiming() {
+	atomic.StoreUint32(&n.timing.on, 0)
+}
+
+// WithTiming enables timing (see EnableTiming).
+func WithTiming() Option {
+	return func(n *Network) { n.EnableTiming() }
+}
+
+// Timing returns the times collected so far, or nil when timing was never
+// enabled.
+func (n *Network) Timing() *Timing {
+	t := &n.timing
+	if atomic.LoadUint32(&t.used) == 0 {
+		return nil
+	}
+	res := &Timing{
+		Push:  time.Duration(atomic.LoadInt64(&t.push)),
+		Pop:   time.Duration(atomic.LoadInt64(&t.pop)),
+		Idle:  time.Duration(atomic.LoadInt64(&t.idle)),
+		Lock:  time.Duration(atomic.LoadInt64(&t.lock)),
+		Rules: make(map[string]time.Duration),
+	}
+	for rule := range t.rules {
+		if d := atomic.LoadInt64(&t.rules[rule]); d > 0 {
+			res.Rules[RuleKind(rule).String()] = time.Duration(d)
+		}
+	}
+	return res
+}
+
+func (n *Network) timingOn() bool {
+	return atomic.LoadUint32(&n.timing.on) != 0
+}
+
+// clock returns the current time when timing is enabled.
+func (n *Network) clock() time.Time {
+	if n.timingOn() {
+		return time.Now()
+	}
+	return time.Time{}
+}
+
+// elapsed adds the time since start to counter. A zero start means timing
+// was off when the measured operation began.
+func elapsed(counter *int64, start time.Time) {
+	if !start.IsZero() {
+		atomic.AddInt64(counter, int64(time.Since(start)))
+	}
+}
+
+// schedule queues an active pair. Pairs created by an interaction go to the
+// shard of the worker that reduced it (the one whose stats are given), so
+// the worker carries on with the subterm it is in; others, built outside a
+// reduction, are spread over the shards.
+func (n *Network) schedule(w *Wire, depth uint64, stats *workerStats) {
+	n.hold(w)
+	start := n.clock()
+	if stats != nil {
+		n.scheduler.pushTo(stats.worker, w, depth)
+	} else {
+		n.scheduler.Push(w, depth)
+	}
+	elapsed(&n.timing.push, start)
+}
+
+// popBatch takes the next active pairs for a worker, blocking until there
+// is one, appending up to cap(buf) of them to buf. Time spent blocked
+// counts as idle rather than pop time. The pairs are still held: the
+// worker releases each one as it serves it.
+func (n *Network) popBatch(worker int, buf []*Wire) []*Wire {
+	if !n.timingOn() {
+		return n.scheduler.PopBatch(worker, buf)
+	}
+	start := time.Now()
+	buf = n.scheduler.takeBatch(worker, buf)
+	elapsed(&n.timing.pop, start)
+	if len(buf) > 0 {
+		return buf
+	}
+	start = time.Now()
+	buf = n.scheduler.PopBatch(worker, buf)
+	elapsed(&n.timing.idle, start)
+	return buf
+}
+
+// tryPop takes the next active pair without blocking. The pair is still
+// held: the caller releases it once reduced.
+func (n *Network) tryPop() *Wire {
+	start := n.clock()
+	w := n.scheduler.TryPop()
+	elapsed(&n.timing.pop, start)
+	return w
+}
+
+// lockReduction takes the reduction lock.
+func (n *Network) lockReduction() {
+	start := n.clock()
+	n.reductionMu.Lock()
+	elapsed(&n.timing.lock, start)
+}
+
+// enterGate waits for the depth gate in parallel mode.
+func (n *Network) enterGate(depth uint64) {
+	start := n.clock()
+	n.gate.enter(depth)
+	elapsed(&n.timing.lock, start)
+}
+
+// ruleDone adds the time since start to rule.
+func (n *Network) ruleDone(rule RuleKind, start time.Time) {
+	elapsed(&n.timing.rules[rule], start)
+}
+
+// resetTiming clears collected times.
+func (n *Network) resetTiming() {
+	t := &n.timing
+	for _, c := range []*int64{&t.push, &t.pop, &t.idle, &t.lock} {
+		atomic.StoreInt64(c, 0)
+	}
+	for i := range t.rules {
+		atomic.StoreInt64(&t.rules[i], 0)
+	}
+}
@@ -0,0 +1,63 @@
+package deltanet
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTiming tests that enabled timing attributes time to the scheduler
+// and to the rules that fired, and that disabled timing reports nothing.
+func TestTiming(t *testing.T) {
+	n := NewNetworkWith(WithTiming(), WithWorkers(2))
+	buildCommutations(n, 200)
+	n.ReduceAll()
+
+	timing := n.Timing()
+	if timing == nil {
+		t.Fatal("expected timing")
+	}
+	if timing.Push <= 0 || timing.Pop <= 0 {
+		t.Errorf("expected scheduler time, got %+v", timing)
+	}
+	if timing.Rules["fan-rep"] <= 0 || len(timing.Rules) != 1 {
+		t.Errorf("expected fan-rep time only, got %v", timing.Rules)
+	}
+
+	r := n.Report()
+	var text bytes.Buffer
+	r.WriteText(&text)
+	if !strings.Contains(text.String(), "Timing:") {
+		t.Errorf("timing missing from report:\n%s", text.String())
+	}
+
+	if NewNetwork().Timing() != nil {
+		t.Errorf("expected no timing when never enabled")
+	}
+}
+
+// BenchmarkSchedulerVsRules reports how reduction time divides between the
+// scheduler, lock waits and rule bodies.
+func BenchmarkSchedulerVsRules(b *testing.B) {
+	var push, pop, lock, rules time.Duration
+	var ops uint64
+	for i := 0; i < b.N; i++ {
+		n := NewNetworkWith(WithTiming(), WithWorkers(4))
+		buildCommutations(n, 1000)
+		n.ReduceAll()
+		t := n.Timing()
+		push += t.Push
+		pop += t.Pop
+		lock += t.Lock
+		for _, d := range t.Rules {
+			rules += d
+		}
+		ops += n.GetStats().TotalReductions
+	}
+	perOp := func(d time.Duration) float64 { return float64(d) / float64(ops) }
+	b.ReportMetric(perOp(push), "push-ns/interaction")
+	b.ReportMetric(perOp(pop), "pop-ns/interaction")
+	b.ReportMetric(perOp(lock), "lock-ns/interaction")
+	b.ReportMetric(perOp(rules), "rule-ns/interaction")
+}
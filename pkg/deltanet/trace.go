@@ -1,6 +1,10 @@
 package deltanet
 
-import "sync/atomic"
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
 
 type RuleKind int
 
@@ -15,8 +19,42 @@ const (
 	RuleRepMerge
 	RuleAuxFanRep
 	RuleFanNative
+	RuleRepCopy
+	RuleEta // See eta.go
 )
 
+var ruleNames = [...]string{
+	RuleUnknown:    "unknown",
+	RuleFanFan:     "fan-fan",
+	RuleRepRep:     "rep-rep",
+	RuleRepRepComm: "rep-rep-comm",
+	RuleFanRep:     "fan-rep",
+	RuleErasure:    "erasure",
+	RuleRepDecay:   "rep-decay",
+	RuleRepMerge:   "rep-merge",
+	RuleAuxFanRep:  "aux-fan-rep",
+	RuleFanNative:  "fan-native",
+	RuleRepCopy:    "rep-copy",
+	RuleEta:        "eta",
+}
+
+func (r RuleKind) String() string {
+	if r >= 0 && int(r) < len(ruleNames) {
+		return ruleNames[r]
+	}
+	return fmt.Sprintf("RuleKind(%d)", int(r))
+}
+
+// ParseRuleKind returns the rule with the given name (see RuleKind.String).
+func ParseRuleKind(name string) (RuleKind, error) {
+	for r, s := range ruleNames {
+		if s == name {
+			return RuleKind(r), nil
+		}
+	}
+	return RuleUnknown, fmt.Errorf("unknown rule %q", name)
+}
+
 type TraceEvent struct {
 	Step  uint64
 	Rule  RuleKind
@@ -24,55 +62,161 @@ type TraceEvent struct {
 	AID   uint64
 	BType NodeType
 	BID   uint64
+	Depth uint64 // Depth of the reduced wire
+	// New lists the IDs of the nodes the interaction left in the net:
+	// those it created and those of its pair a commutation reused, in the
+	// order the rule made them. For a native returning a NetBuilder it
+	// holds only the node the builder returned.
+	New  []uint64
+	Time int64 // Nanoseconds since tracing was enabled, from the monotonic clock
 }
 
+// TraceCompiled reports whether this build records traces, i.e. it was not
+// built with the notrace tag.
+func TraceCompiled() bool {
+	return traceCompiled
+}
+
+// EnableTrace records the first capacity interactions (or sampled
+// interactions, see SetTraceSampling) into a buffer allocated up front, so
+// recording does not allocate. In builds with the notrace tag nothing is
+// recorded and the trace hooks compile away.
 func (n *Network) EnableTrace(capacity int) {
+	n.enableTrace(capacity, false)
+}
+
+// EnableTraceRing is EnableTrace keeping the most recent capacity
+// interactions instead of the first: once the buffer is full, each event
+// overwrites the oldest one.
+func (n *Network) EnableTraceRing(capacity int) {
+	n.enableTrace(capacity, true)
+}
+
+func (n *Network) enableTrace(capacity int, ring bool) {
 	if capacity <= 0 {
 		capacity = 1
 	}
 	n.traceBuf = make([]TraceEvent, capacity)
 	n.traceCap = uint64(capacity)
+	n.traceRing = ring
+	n.traceStart = time.Now()
 	atomic.StoreUint64(&n.traceIdx, 0)
+	atomic.StoreUint64(&n.traceSeen, 0)
 	atomic.StoreUint32(&n.traceOn, 1)
 }
 
+// SetTraceSampling records only every Nth interaction, starting with the
+// first, so a buffer of a given capacity covers N times longer reductions.
+// The Step of a sampled event is still its position among all traced
+// interactions. Values below 2 record every interaction. It must be called
+// before reduction starts.
+func (n *Network) SetTraceSampling(every int) {
+	n.traceEvery = 0
+	if every > 1 {
+		n.traceEvery = uint64(every)
+	}
+}
+
 func (n *Network) DisableTrace() {
 	atomic.StoreUint32(&n.traceOn, 0)
 }
 
+// TraceSnapshot returns the buffered events, oldest first.
 func (n *Network) TraceSnapshot() []TraceEvent {
-	if atomic.LoadUint32(&n.traceOn) == 0 {
+	if atomic.LoadUint32(&n.traceOn) == 0 || n.traceCap == 0 {
 		return nil
 	}
 	count := atomic.LoadUint64(&n.traceIdx)
-	if count > n.traceCap {
-		count = n.traceCap
+	if count <= n.traceCap {
+		return append([]TraceEvent(nil), n.traceBuf[:count]...)
 	}
-	res := make([]TraceEvent, count)
-	copy(res, n.traceBuf[:count])
-	return res
+	if !n.traceRing {
+		return append([]TraceEvent(nil), n.traceBuf...)
+	}
+	start := count % n.traceCap
+	res := make([]TraceEvent, 0, n.traceCap)
+	res = append(res, n.traceBuf[start:]...)
+	return append(res, n.traceBuf[:start]...)
 }
 
-func (n *Network) recordTrace(rule RuleKind, a, b Node) {
-	if atomic.LoadUint32(&n.traceOn) == 0 || n.traceCap == 0 {
+// createdNodes collects the IDs of the nodes an interaction leaves in the
+// net, for its trace event. The rules add each node as they make it, so
+// the list holds exactly the interaction's nodes whatever other workers
+// create meanwhile. A nil *createdNodes collects nothing.
+type createdNodes struct {
+	ids []uint64
+}
+
+// add records node and returns it.
+func (c *createdNodes) add(node Node) Node {
+	if c != nil {
+		c.ids = append(c.ids, node.ID())
+	}
+	return node
+}
+
+// traceCreated returns a collector for the nodes of an interaction about
+// to take place, or nil when tracing is off.
+func (n *Network) traceCreated() *createdNodes {
+	if !traceCompiled || atomic.LoadUint32(&n.traceOn) == 0 {
+		return nil
+	}
+	return &createdNodes{}
+}
+
+// recordTrace records an interaction if tracing is on, with the nodes
+// made collected. The checks are plain loads until
+// an event is actually kept: a full buffer is detected without touching
+// the shared index, and sampling only counts.
+func (n *Network) recordTrace(rule RuleKind, a, b Node, depth uint64, made *createdNodes) {
+	if !traceCompiled || atomic.LoadUint32(&n.traceOn) == 0 {
 		return
 	}
+	streaming := n.stream.fn != nil
+	if !streaming && !n.traceRing && atomic.LoadUint64(&n.traceIdx) >= n.traceCap {
+		return
+	}
+	var step uint64
+	if every := n.traceEvery; every > 1 {
+		step = atomic.AddUint64(&n.traceSeen, 1) - 1
+		if step%every != 0 {
+			return
+		}
+	}
 	idx := atomic.AddUint64(&n.traceIdx, 1) - 1
-	if idx >= n.traceCap {
+	if !streaming && !n.traceRing && idx >= n.traceCap {
 		return
 	}
+	if n.traceEvery <= 1 {
+		step = idx
+	}
 	var bType NodeType
 	var bID uint64
 	if b != nil {
 		bType = b.Type()
 		bID = b.ID()
 	}
-	n.traceBuf[idx] = TraceEvent{
-		Step:  idx,
+	event := TraceEvent{
+		Step:  step,
 		Rule:  rule,
 		AType: a.Type(),
 		AID:   a.ID(),
 		BType: bType,
 		BID:   bID,
+		Depth: depth,
+		Time:  int64(time.Since(n.traceStart)),
+	}
+	if made != nil && len(made.ids) > 0 {
+		event.New = made.ids
+	}
+	switch {
+	case n.traceCap == 0:
+	case n.traceRing:
+		n.traceBuf[idx%n.traceCap] = event
+	case idx < n.traceCap:
+		n.traceBuf[idx] = event
+	}
+	if streaming {
+		n.stream.deliver(event)
 	}
 }
@@ -0,0 +1,7 @@
+//go:build notrace
+
+package deltanet
+
+// traceCompiled is false in builds with the notrace tag, where recordTrace
+// is a no-op the compiler removes.
+const traceCompiled = false
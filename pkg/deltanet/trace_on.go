@@ -0,0 +1,7 @@
+//go:build !notrace
+
+package deltanet
+
+// traceCompiled is true unless the build uses the notrace tag (see
+// trace_off.go).
+const traceCompiled = true
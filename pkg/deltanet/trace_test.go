@@ -0,0 +1,150 @@
+package deltanet
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTraceSampling(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	net := NewNetworkWith(WithWorkers(1), WithTrace(100), WithTraceSampling(3))
+	buildCommutations(net, 10)
+	net.ReduceAll()
+
+	events := net.TraceSnapshot()
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want 4 of 10", len(events))
+	}
+	for i, e := range events {
+		if e.Step != uint64(3*i) || e.Rule != RuleFanRep {
+			t.Errorf("event %d: got step %d rule %v, want step %d fan-rep", i, e.Step, e.Rule, 3*i)
+		}
+	}
+}
+
+func TestRecordTraceAllocs(t *testing.T) {
+	net := NewNetworkWith(WithTrace(4))
+	a, b := net.NewFan(), net.NewEraser()
+	allocs := testing.AllocsPerRun(100, func() {
+		net.recordTrace(RuleErasure, a, b, 0, nil)
+	})
+	if allocs != 0 {
+		t.Errorf("recordTrace allocates %.1f times per event", allocs)
+	}
+}
+
+func TestTraceRing(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	net := NewNetworkWith(WithWorkers(1), WithTraceRing(4))
+	buildCommutations(net, 10)
+	net.ReduceAll()
+
+	events := net.TraceSnapshot()
+	if len(events) != 4 {
+		t.Fatalf("got %d events, want the last 4 of 10", len(events))
+	}
+	for i, e := range events {
+		if e.Step != uint64(6+i) {
+			t.Errorf("event %d: got step %d, want %d", i, e.Step, 6+i)
+		}
+	}
+}
+
+func TestTraceStream(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	var got []TraceEvent
+	var buf bytes.Buffer
+	net := NewNetworkWith(WithWorkers(1), WithTrace(2), WithTraceFunc(func(e TraceEvent) {
+		got = append(got, e)
+	}))
+	buildCommutations(net, 10)
+	net.ReduceAll()
+	if len(got) != 10 {
+		t.Fatalf("func got %d events, want 10", len(got))
+	}
+	if n := len(net.TraceSnapshot()); n != 2 {
+		t.Errorf("buffer kept %d events, want 2", n)
+	}
+
+	net = NewNetworkWith(WithWorkers(1), WithTraceWriter(&buf))
+	buildCommutations(net, 10)
+	net.ReduceAll()
+	if err := net.TraceStreamErr(); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadTrace(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(read) != 10 {
+		t.Fatalf("writer got %d events, want 10", len(read))
+	}
+	for i, e := range read {
+		if e.Step != uint64(i) || e.Rule != RuleFanRep {
+			t.Errorf("event %d: got step %d rule %v", i, e.Step, e.Rule)
+		}
+	}
+	if net.TraceSnapshot() != nil {
+		t.Errorf("streaming alone should not buffer events")
+	}
+}
+
+// TestTraceCreatedNodes tests that events carry the IDs of the nodes each
+// interaction made, fresh or reused from its pair, even with workers
+// creating nodes concurrently, and monotonic timestamps.
+func TestTraceCreatedNodes(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	for _, opts := range [][]Option{
+		{WithWorkers(1)},
+		{WithWorkers(4), WithParallel()},
+	} {
+		net := NewNetworkWith(append(opts, WithTrace(1000))...)
+		buildCommutations(net, 50)
+		before := net.ids.last()
+		net.ReduceAll()
+
+		events := net.TraceSnapshot()
+		if len(events) != 50 {
+			t.Fatalf("workers %d: got %d events, want 50", net.Workers(), len(events))
+		}
+		made := make(map[uint64]int) // Fresh node ID -> events listing it
+		for i, e := range events {
+			// A fan-replicator commutation makes two fans and two replicators.
+			if len(e.New) != 4 {
+				t.Errorf("workers %d, event %d: made %v, want 4 nodes", net.Workers(), i, e.New)
+			}
+			for _, id := range e.New {
+				switch {
+				case id > before:
+					made[id]++
+				case id != e.AID && id != e.BID:
+					t.Errorf("workers %d, event %d: reused #%d, not of its pair", net.Workers(), i, id)
+				}
+				if node := net.NodeByID(id); node == nil || node.IsDead() {
+					t.Errorf("workers %d, event %d: made #%d, not live", net.Workers(), i, id)
+				}
+			}
+		}
+		for id := before + 1; id <= net.ids.last(); id++ {
+			if made[id] != 1 {
+				t.Errorf("workers %d: node #%d listed by %d events, want 1", net.Workers(), id, made[id])
+			}
+		}
+		if net.Workers() == 1 {
+			for i := 1; i < len(events); i++ {
+				if events[i].Time < events[i-1].Time {
+					t.Errorf("event %d: time %d before %d", i, events[i].Time, events[i-1].Time)
+				}
+			}
+		}
+		net.Close()
+	}
+}
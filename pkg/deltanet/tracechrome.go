@@ -0,0 +1,109 @@
+package deltanet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Traces can also be exported in the trace_event format of Chrome, read by
+// chrome://tracing and Perfetto. Each interaction becomes a complete event
+// one microsecond long at its Step, on the track ("thread") of its depth,
+// with its rule as name and category so rules can be filtered:
+//
+//	{"name":"fan-fan","cat":"fan-fan","ph":"X","ts":0,"dur":1,"pid":1,"tid":0,"args":{...}}
+
+type chromeEvent struct {
+	Name string      `json:"name"`
+	Cat  string      `json:"cat,omitempty"`
+	Ph   string      `json:"ph"`
+	Ts   uint64      `json:"ts"`
+	Dur  uint64      `json:"dur,omitempty"`
+	Pid  int         `json:"pid"`
+	Tid  uint64      `json:"tid"`
+	Args interface{} `json:"args,omitempty"`
+}
+
+type chromeArgs struct {
+	Step  uint64   `json:"step"`
+	AType string   `json:"a"`
+	AID   uint64   `json:"a_id"`
+	BType string   `json:"b,omitempty"`
+	BID   uint64   `json:"b_id,omitempty"`
+	New   []uint64 `json:"new,omitempty"`
+}
+
+// chromePid is the process all tracks belong to.
+const chromePid = 1
+
+// WriteChromeTrace writes events as a Chrome trace_event JSON object, with
+// one track per wire depth, named and sorted by depth.
+func WriteChromeTrace(w io.Writer, events []TraceEvent) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(`{"traceEvents":[`); err != nil {
+		return err
+	}
+	sep := "\n"
+	write := func(e chromeEvent) error {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		bw.WriteString(sep)
+		sep = ",\n"
+		_, err = bw.Write(data)
+		return err
+	}
+
+	if err := write(chromeEvent{
+		Name: "process_name", Ph: "M", Pid: chromePid,
+		Args: map[string]string{"name": "reduction"},
+	}); err != nil {
+		return err
+	}
+	depths := make(map[uint64]bool)
+	for _, e := range events {
+		depths[e.Depth] = true
+	}
+	sorted := make([]uint64, 0, len(depths))
+	for d := range depths {
+		sorted = append(sorted, d)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, d := range sorted {
+		if err := write(chromeEvent{
+			Name: "thread_name", Ph: "M", Pid: chromePid, Tid: d,
+			Args: map[string]string{"name": fmt.Sprintf("depth %d", d)},
+		}); err != nil {
+			return err
+		}
+		if err := write(chromeEvent{
+			Name: "thread_sort_index", Ph: "M", Pid: chromePid, Tid: d,
+			Args: map[string]uint64{"sort_index": d},
+		}); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range events {
+		rec := recordOf(e)
+		if err := write(chromeEvent{
+			Name: rec.Rule,
+			Cat:  rec.Rule,
+			Ph:   "X",
+			Ts:   e.Step,
+			Dur:  1,
+			Pid:  chromePid,
+			Tid:  e.Depth,
+			Args: chromeArgs{Step: e.Step, AType: rec.AType, AID: rec.AID, BType: rec.BType, BID: rec.BID, New: rec.New},
+		}); err != nil {
+			return err
+		}
+	}
+	if _, err := bw.WriteString("\n]}\n"); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,190 @@
+package deltanet
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Traces are serialized as JSON lines, one event per line, so that large
+// traces can be written and queried as streams:
+//
+//	{"step":0,"rule":"fan-rep","a":"Fan","a_id":3,"b":"Replicator","b_id":7,"depth":0,"new":[12,15],"t_ns":1480}
+
+type traceRecord struct {
+	Step  uint64   `json:"step"`
+	Rule  string   `json:"rule"`
+	AType string   `json:"a"`
+	AID   uint64   `json:"a_id"`
+	BType string   `json:"b,omitempty"`
+	BID   uint64   `json:"b_id,omitempty"`
+	Depth uint64   `json:"depth"`
+	New   []uint64 `json:"new,omitempty"`
+	Time  int64    `json:"t_ns,omitempty"`
+}
+
+// WriteTrace writes events as JSON lines.
+func WriteTrace(w io.Writer, events []TraceEvent) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for _, e := range events {
+		if err := enc.Encode(recordOf(e)); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+func recordOf(e TraceEvent) traceRecord {
+	rec := traceRecord{
+		Step:  e.Step,
+		Rule:  e.Rule.String(),
+		AType: e.AType.String(),
+		AID:   e.AID,
+		Depth: e.Depth,
+		New:   e.New,
+		Time:  e.Time,
+	}
+	if e.BID != 0 {
+		rec.BType = e.BType.String()
+		rec.BID = e.BID
+	}
+	return rec
+}
+
+// ScanTrace reads events written by WriteTrace and calls fn for each one
+// until fn returns false.
+func ScanTrace(r io.Reader, fn func(TraceEvent) bool) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec traceRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return fmt.Errorf("trace line %d: %w", line, err)
+		}
+		e, err := rec.event()
+		if err != nil {
+			return fmt.Errorf("trace line %d: %w", line, err)
+		}
+		if !fn(e) {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
+
+// ReadTrace reads all events written by WriteTrace.
+func ReadTrace(r io.Reader) ([]TraceEvent, error) {
+	var events []TraceEvent
+	err := ScanTrace(r, func(e TraceEvent) bool {
+		events = append(events, e)
+		return true
+	})
+	return events, err
+}
+
+func (rec traceRecord) event() (TraceEvent, error) {
+	rule, err := ParseRuleKind(rec.Rule)
+	if err != nil {
+		return TraceEvent{}, err
+	}
+	aType, err := parseNodeType(rec.AType)
+	if err != nil {
+		return TraceEvent{}, err
+	}
+	var bType NodeType
+	if rec.BType != "" {
+		if bType, err = parseNodeType(rec.BType); err != nil {
+			return TraceEvent{}, err
+		}
+	}
+	return TraceEvent{Step: rec.Step, Rule: rule, AType: aType, AID: rec.AID, BType: bType, BID: rec.BID, Depth: rec.Depth, New: rec.New, Time: rec.Time}, nil
+}
+
+func parseNodeType(name string) (NodeType, error) {
+	for t := NodeTypeFan; t <= NodeTypeHandler; t++ {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown node type %q", name)
+}
+
+// TraceQuery selects trace events. Zero fields match everything.
+type TraceQuery struct {
+	Rules    []RuleKind // Any of these rules
+	NodeID   uint64     // Events involving this node
+	MinDepth uint64
+	MaxDepth uint64 // Inclusive; 0 means unbounded
+}
+
+// Match reports whether an event satisfies the query.
+func (q TraceQuery) Match(e TraceEvent) bool {
+	if len(q.Rules) > 0 {
+		found := false
+		for _, r := range q.Rules {
+			if e.Rule == r {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.NodeID != 0 && e.AID != q.NodeID && e.BID != q.NodeID {
+		return false
+	}
+	if e.Depth < q.MinDepth || (q.MaxDepth != 0 && e.Depth > q.MaxDepth) {
+		return false
+	}
+	return true
+}
+
+// Filter returns the events matching the query.
+func (q TraceQuery) Filter(events []TraceEvent) []TraceEvent {
+	var res []TraceEvent
+	for _, e := range events {
+		if q.Match(e) {
+			res = append(res, e)
+		}
+	}
+	return res
+}
+
+// Count returns the number of events matching the query.
+func (q TraceQuery) Count(events []TraceEvent) int {
+	count := 0
+	for _, e := range events {
+		if q.Match(e) {
+			count++
+		}
+	}
+	return count
+}
+
+// First returns the first event matching the query.
+func (q TraceQuery) First(events []TraceEvent) (TraceEvent, bool) {
+	for _, e := range events {
+		if q.Match(e) {
+			return e, true
+		}
+	}
+	return TraceEvent{}, false
+}
+
+// Scan calls fn for each event of a serialized trace that matches the
+// query, until fn returns false.
+func (q TraceQuery) Scan(r io.Reader, fn func(TraceEvent) bool) error {
+	return ScanTrace(r, func(e TraceEvent) bool {
+		if !q.Match(e) {
+			return true
+		}
+		return fn(e)
+	})
+}
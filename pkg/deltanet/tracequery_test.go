@@ -0,0 +1,127 @@
+package deltanet
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestTraceRoundtrip tests that traces written as JSON lines read back
+// unchanged.
+func TestTraceRoundtrip(t *testing.T) {
+	if !traceCompiled {
+		t.Skip("built with notrace")
+	}
+	net := tracedNet(16)
+	a := newFanWithSinks(net)
+	b := newFanWithSinks(net)
+	net.LinkAt(a, 0, b, 0, 3)
+	eras, _ := newEraserWithFanSink(net)
+	net.ReduceAll()
+
+	events := net.TraceSnapshot()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	var buf bytes.Buffer
+	if err := WriteTrace(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	back, err := ReadTrace(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(back) != len(events) {
+		t.Fatalf("read %d events, want %d", len(back), len(events))
+	}
+	for i := range events {
+		if !reflect.DeepEqual(back[i], events[i]) {
+			t.Errorf("event %d: got %+v, want %+v", i, back[i], events[i])
+		}
+	}
+
+	erasures := TraceQuery{Rules: []RuleKind{RuleErasure}}
+	if e, ok := erasures.First(events); !ok || (e.AID != eras.ID() && e.BID != eras.ID()) {
+		t.Errorf("expected erasure involving %d, got %+v", eras.ID(), e)
+	}
+	if n := (TraceQuery{NodeID: a.ID()}).Count(events); n != 1 {
+		t.Errorf("expected 1 event for node %d, got %d", a.ID(), n)
+	}
+	if got := (TraceQuery{MinDepth: 1, MaxDepth: 3}).Filter(events); len(got) != 1 || got[0].Rule != RuleFanFan {
+		t.Errorf("expected the fan-fan event at depth 3, got %+v", got)
+	}
+}
+
+// TestTraceQueryScan tests streaming queries and rejection of malformed
+// lines.
+func TestTraceQueryScan(t *testing.T) {
+	input := `{"step":0,"rule":"fan-fan","a":"Fan","a_id":1,"b":"Fan","b_id":2,"depth":0}
+{"step":1,"rule":"rep-decay","a":"Replicator","a_id":5,"depth":2}
+{"step":2,"rule":"fan-rep","a":"Fan","a_id":6,"b":"Replicator","b_id":5,"depth":1}
+`
+	var steps []uint64
+	q := TraceQuery{NodeID: 5}
+	err := q.Scan(strings.NewReader(input), func(e TraceEvent) bool {
+		steps = append(steps, e.Step)
+		return true
+	})
+	if err != nil || len(steps) != 2 || steps[0] != 1 || steps[1] != 2 {
+		t.Errorf("expected steps [1 2], got %v (%v)", steps, err)
+	}
+
+	for _, bad := range []string{`{"rule":"nope","a":"Fan"}`, `{"rule":"fan-fan","a":"Box"}`, `not json`} {
+		if _, err := ReadTrace(strings.NewReader(bad)); err == nil {
+			t.Errorf("%s: expected error", bad)
+		}
+	}
+	if _, err := ParseRuleKind("fan-native"); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// TestChromeTrace tests that the Chrome export has a track per depth and
+// an event per interaction.
+func TestChromeTrace(t *testing.T) {
+	events := []TraceEvent{
+		{Step: 0, Rule: RuleFanFan, AType: NodeTypeFan, AID: 1, BType: NodeTypeFan, BID: 2, Depth: 3},
+		{Step: 1, Rule: RuleErasure, AType: NodeTypeEraser, AID: 4, BType: NodeTypeFan, BID: 5},
+	}
+	var buf bytes.Buffer
+	if err := WriteChromeTrace(&buf, events); err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		TraceEvents []struct {
+			Name string                 `json:"name"`
+			Cat  string                 `json:"cat"`
+			Ph   string                 `json:"ph"`
+			Ts   uint64                 `json:"ts"`
+			Tid  uint64                 `json:"tid"`
+			Args map[string]interface{} `json:"args"`
+		} `json:"traceEvents"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	tracks := make(map[uint64]string)
+	var complete []string
+	for _, e := range out.TraceEvents {
+		switch {
+		case e.Ph == "M" && e.Name == "thread_name":
+			tracks[e.Tid] = e.Args["name"].(string)
+		case e.Ph == "X":
+			complete = append(complete, e.Cat)
+			if e.Name != e.Cat {
+				t.Errorf("event %s has category %s", e.Name, e.Cat)
+			}
+		}
+	}
+	if tracks[0] != "depth 0" || tracks[3] != "depth 3" || len(tracks) != 2 {
+		t.Errorf("got tracks %v, want depths 0 and 3", tracks)
+	}
+	if strings.Join(complete, " ") != "fan-fan erasure" {
+		t.Errorf("got events %v", complete)
+	}
+}
@@ -0,0 +1,77 @@
+package deltanet
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// traceStream delivers trace events as they happen, besides or instead of
+// the trace buffer.
+type traceStream struct {
+	mu  sync.Mutex
+	fn  func(TraceEvent)
+	err error // First write error of StreamTrace
+}
+
+// SetTraceFunc calls fn with every traced interaction (or sampled
+// interaction, see SetTraceSampling) as it happens, so traces are not
+// bounded by the buffer of EnableTrace, which may be enabled as well or
+// not at all. Calls are serialized, but with several workers events may
+// arrive slightly out of Step order. fn runs on the reducing worker and
+// must not call back into the network. A nil fn stops delivery. It must be
+// called before reduction starts.
+func (n *Network) SetTraceFunc(fn func(TraceEvent)) {
+	n.stream.mu.Lock()
+	n.stream.fn = fn
+	n.stream.err = nil
+	n.stream.mu.Unlock()
+	if fn != nil {
+		if n.traceStart.IsZero() {
+			n.traceStart = time.Now()
+		}
+		atomic.StoreUint32(&n.traceOn, 1)
+	}
+}
+
+// StreamTrace writes every traced interaction to w as it happens, as JSON
+// lines in the format of WriteTrace. Writes are not buffered; wrap w in a
+// bufio.Writer for long runs and flush it after reduction. The first write
+// error stops streaming and is reported by TraceStreamErr. A nil w stops
+// streaming.
+func (n *Network) StreamTrace(w io.Writer) {
+	if w == nil {
+		n.SetTraceFunc(nil)
+		return
+	}
+	enc := json.NewEncoder(w)
+	n.SetTraceFunc(func(e TraceEvent) {
+		// deliver holds the stream lock.
+		if n.stream.err == nil {
+			n.stream.err = enc.Encode(recordOf(e))
+		}
+	})
+}
+
+// TraceStreamErr returns the error that stopped StreamTrace, if any.
+func (n *Network) TraceStreamErr() error {
+	n.stream.mu.Lock()
+	defer n.stream.mu.Unlock()
+	return n.stream.err
+}
+
+func (s *traceStream) deliver(e TraceEvent) {
+	s.mu.Lock()
+	if s.fn != nil {
+		s.fn(e)
+	}
+	s.mu.Unlock()
+}
+
+func (s *traceStream) clearErr() {
+	s.mu.Lock()
+	s.err = nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,211 @@
+package deltanet
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrInvalidNet is wrapped by every problem Validate reports.
+var ErrInvalidNet = errors.New("invalid net")
+
+// nodePorts is the number of ports of each node type; replicators have
+// one per delta besides the principal.
+var nodePorts = map[NodeType]int{
+	NodeTypeFan:     3,
+	NodeTypeEraser:  1,
+	NodeTypeVar:     1,
+	NodeTypeData:    1,
+	NodeTypePure:    1,
+	NodeTypeEffect:  1,
+	NodeTypeHandler: 2,
+}
+
+// Validate checks the structural invariants of the live nodes: each has
+// the ports of its type, each port knows its node and index, every wire
+// on a port holds that port at one end and a live node's port at the
+// other, which holds the same wire, and replicators have a non-negative
+// level and one delta per auxiliary port. It returns nil for a valid net
+// and otherwise every problem found, each wrapping ErrInvalidNet. The
+// network must not be reducing, or must be paused (see Pause).
+func (n *Network) Validate() error {
+	var problems []error
+	n.nodes.each(func(node Node) {
+		if node.IsDead() {
+			return
+		}
+		problem := func(format string, args ...any) {
+			problems = append(problems, fmt.Errorf("%w: #%d %v: %s", ErrInvalidNet, node.ID(), node.Type(), fmt.Sprintf(format, args...)))
+		}
+		ports := node.Ports()
+		want, ok := nodePorts[node.Type()]
+		if node.Type() == NodeTypeReplicator {
+			want, ok = len(node.Deltas())+1, true
+			if node.Level() < 0 {
+				problem("negative level %d", node.Level())
+			}
+		}
+		if !ok {
+			problem("unknown node type")
+		} else if len(ports) != want {
+			problem("%d ports, want %d", len(ports), want)
+		}
+		for i, p := range ports {
+			if p == nil || p.Node != node || p.Index != i {
+				problem("port %d does not belong to the node", i)
+				continue
+			}
+			w := p.Wire.Load()
+			if w == nil {
+				continue
+			}
+			var other *Port
+			switch p {
+			case w.P0.Load():
+				other = w.P1.Load()
+			case w.P1.Load():
+				other = w.P0.Load()
+			default:
+				problem("port %d is on a wire that does not hold it", i)
+				continue
+			}
+			switch {
+			case other == nil:
+				problem("port %d is on a wire with one end", i)
+			case other.Wire.Load() != w:
+				problem("port %d links to #%d.%d, which is on another wire", i, other.Node.ID(), other.Index)
+			case other.Node.IsDead():
+				problem("port %d links to dead node #%d", i, other.Node.ID())
+			}
+		}
+	})
+	return errors.Join(problems...)
+}
+
+// NetClass classifies a net by the paper's definitions. Canonical nets
+// (those translated from λ-terms) are proper, and proper nets (those
+// reachable from canonical ones by interactions) are arbitrary.
+type NetClass int
+
+const (
+	NetInvalid   NetClass = iota // Fails Validate
+	NetArbitrary                 // Valid, but no proper net looks like it
+	NetProper
+	NetCanonical
+)
+
+var netClassNames = [...]string{
+	NetInvalid:   "invalid",
+	NetArbitrary: "arbitrary",
+	NetProper:    "proper",
+	NetCanonical: "canonical",
+}
+
+func (c NetClass) String() string {
+	if c >= 0 && int(c) < len(netClassNames) {
+		return netClassNames[c]
+	}
+	return fmt.Sprintf("NetClass(%d)", int(c))
+}
+
+// Classify returns the class of the net. Whether a net is reachable from a
+// canonical one cannot be decided locally, so a net counts as proper when
+// it has the properties the interaction rules preserve: it is valid and
+// every active pair interacts by a known rule. It counts as canonical when
+// moreover, as the paper puts it, all its replicators are unpaired
+// fan-ins: each replicator's principal port is bound by an abstraction
+// (port 2 of a fan) or is a free variable (a Var), and so is each
+// eraser's. The network must not be reducing, or must be paused.
+func (n *Network) Classify() NetClass {
+	if n.Validate() != nil {
+		return NetInvalid
+	}
+	class := NetCanonical
+	n.nodes.each(func(node Node) {
+		if node.IsDead() || class == NetArbitrary {
+			return
+		}
+		other, port := n.GetLink(node, 0)
+		if other == nil {
+			return
+		}
+		if port == 0 && isActive(node) && isActive(other) {
+			rule, _, redex := pairRule(node.Type(), other.Type(), n.phase, node.Level() == other.Level())
+			if redex && rule == RuleUnknown {
+				class = NetArbitrary
+				return
+			}
+		}
+		switch node.Type() {
+		case NodeTypeReplicator, NodeTypeEraser:
+			binder := other.Type() == NodeTypeFan && port == 2
+			if !binder && other.Type() != NodeTypeVar {
+				class = min(class, NetProper)
+			}
+		}
+	})
+	return class
+}
+
+// SetValidation makes every interaction validate the net once done, to
+// catch corruption at the rule that causes it. The first invalid net
+// halts reduction, and ReduceWithBudget and ReduceToNormalFormContext
+// return a *RuleCheckError. Validation visits the whole net after each
+// interaction and makes parallel mode reduce one pair at a time, so it
+// is meant for debugging. It must be called before reduction starts.
+func (n *Network) SetValidation(on bool) {
+	n.checks.on = on
+}
+
+// WithValidation validates the net after every interaction (see
+// SetValidation).
+func WithValidation() Option {
+	return func(n *Network) { n.SetValidation(true) }
+}
+
+// RuleCheckError reports the interaction after which the net failed
+// validation.
+type RuleCheckError struct {
+	Event TraceEvent // The interaction; Step counts from 0
+	Err   error      // What Validate reported
+}
+
+func (e *RuleCheckError) Error() string {
+	return fmt.Sprintf("after %v interaction %d (#%d %v, #%d %v): %v",
+		e.Event.Rule, e.Event.Step, e.Event.AID, e.Event.AType, e.Event.BID, e.Event.BType, e.Err)
+}
+
+func (e *RuleCheckError) Unwrap() error {
+	return e.Err
+}
+
+// validation is the state of SetValidation.
+type validation struct {
+	on  bool
+	mu  sync.Mutex
+	err *RuleCheckError // First failure since the last resume
+}
+
+// checkRule validates the net after the interaction of event, halting
+// reduction if it is invalid.
+func (n *Network) checkRule(event TraceEvent) {
+	err := n.Validate()
+	if err == nil {
+		return
+	}
+	event.Step = n.stat(statOps) - 1
+	n.checks.mu.Lock()
+	if n.checks.err == nil {
+		n.checks.err = &RuleCheckError{Event: event, Err: err}
+	}
+	n.checks.mu.Unlock()
+	n.limit.reason.Store(int32(haltInvalid))
+	n.limit.halted.Store(true)
+}
+
+// validationError returns the failure that halted reduction.
+func (n *Network) validationError() *RuleCheckError {
+	n.checks.mu.Lock()
+	defer n.checks.mu.Unlock()
+	return n.checks.err
+}
@@ -0,0 +1,111 @@
+package deltanet
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// identity builds λx.x: a fan whose binder is a replicator linked to the
+// body.
+func identity(n *Network) Node {
+	fan := n.NewFan()
+	rep := n.NewReplicator(1, []int{0})
+	n.Link(fan, 2, rep, 0)
+	n.Link(fan, 1, rep, 1)
+	return fan
+}
+
+// identityNet builds λx.x bound to a root Var.
+func identityNet(n *Network) (fan, root Node) {
+	fan = identity(n)
+	root = n.NewVar()
+	n.Link(root, 0, fan, 0)
+	return fan, root
+}
+
+// TestValidate tests that Validate accepts well-formed nets and reports
+// broken wires and links to dead nodes.
+func TestValidate(t *testing.T) {
+	n := NewNetwork()
+	_, root := identityNet(n)
+	if err := n.Validate(); err != nil {
+		t.Fatalf("identity: %v", err)
+	}
+
+	// One-sided link
+	root.Ports()[0].Wire.Store(nil)
+	if err := n.Validate(); !errors.Is(err, ErrInvalidNet) {
+		t.Errorf("one-sided link: expected ErrInvalidNet, got %v", err)
+	}
+
+	n = NewNetwork()
+	_, root = identityNet(n)
+	root.SetDead()
+	if err := n.Validate(); !errors.Is(err, ErrInvalidNet) {
+		t.Errorf("link to a dead node: expected ErrInvalidNet, got %v", err)
+	}
+}
+
+// TestClassify tests the classes of hand-built nets.
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(n *Network)
+		want  NetClass
+	}{
+		{"identity", func(n *Network) { identityNet(n) }, NetCanonical},
+		{"beta redex", func(n *Network) {
+			app := n.NewFan()
+			n.Link(app, 0, identity(n), 0)
+			n.Link(app, 1, n.NewVar(), 0)
+			n.Link(app, 2, identity(n), 0)
+		}, NetCanonical},
+		{"replicator facing a fan", func(n *Network) {
+			rep := n.NewReplicator(0, []int{0, 0})
+			n.Link(rep, 0, identity(n), 0)
+			n.Link(rep, 1, n.NewVar(), 0)
+			n.Link(rep, 2, n.NewVar(), 0)
+		}, NetProper},
+		{"data facing a native", func(n *Network) {
+			n.Link(n.NewNative("f"), 0, n.NewData(1), 0)
+		}, NetArbitrary},
+		{"one-sided link", func(n *Network) {
+			_, root := identityNet(n)
+			root.Ports()[0].Wire.Store(nil)
+		}, NetInvalid},
+	}
+	for _, tt := range tests {
+		n := NewNetwork()
+		tt.build(n)
+		if got := n.Classify(); got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestValidation tests that validation mode lets valid reductions through
+// and halts at the first interaction leaving an invalid net.
+func TestValidation(t *testing.T) {
+	n := NewNetworkWith(WithValidation())
+	buildCommutations(n, 10)
+	if err := n.ReduceWithBudget(context.Background(), Budget{}); err != nil {
+		t.Fatalf("valid net: %v", err)
+	}
+
+	n = NewNetworkWith(WithValidation())
+	buildCommutations(n, 10)
+	_, root := identityNet(n)
+	root.Ports()[0].Wire.Store(nil)
+	err := n.ReduceWithBudget(context.Background(), Budget{})
+	var check *RuleCheckError
+	if !errors.As(err, &check) || !errors.Is(err, ErrInvalidNet) {
+		t.Fatalf("expected a *RuleCheckError, got %v", err)
+	}
+	if check.Event.Step != 0 || check.Event.Rule != RuleFanRep {
+		t.Errorf("expected the first fan-rep interaction, got %+v", check.Event)
+	}
+	if got := n.GetStats().TotalReductions; got != 1 {
+		t.Errorf("expected reduction to halt after 1 interaction, got %d", got)
+	}
+}
@@ -0,0 +1,76 @@
+// Package frontend is a registry of surface syntaxes that parse source text
+// into lambda terms. The godnet CLI selects one with --syntax, so a new
+// syntax only needs to register itself here.
+package frontend
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/vic/godnet/pkg/lambda"
+)
+
+// Frontend parses source text into a lambda term.
+type Frontend interface {
+	Parse(source string) (lambda.Term, error)
+}
+
+// Func adapts a parse function to the Frontend interface.
+type Func func(source string) (lambda.Term, error)
+
+func (f Func) Parse(source string) (lambda.Term, error) {
+	return f(source)
+}
+
+// Default is the name of the frontend used when none is selected: the
+// native lambda syntax of lambda.Parse.
+const Default = "lambda"
+
+var (
+	mu        sync.RWMutex
+	frontends = map[string]Frontend{
+		Default: Func(lambda.Parse),
+	}
+)
+
+// Register makes a frontend available under name, replacing any frontend
+// previously registered with that name.
+func Register(name string, f Frontend) {
+	mu.Lock()
+	defer mu.Unlock()
+	frontends[name] = f
+}
+
+// Lookup returns the frontend registered under name.
+func Lookup(name string) (Frontend, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	f, ok := frontends[name]
+	return f, ok
+}
+
+// Names returns the registered frontend names in sorted order.
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(frontends))
+	for name := range frontends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Parse parses source with the frontend registered under name. An empty
+// name selects Default.
+func Parse(name, source string) (lambda.Term, error) {
+	if name == "" {
+		name = Default
+	}
+	f, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown syntax %q (available: %v)", name, Names())
+	}
+	return f.Parse(source)
+}
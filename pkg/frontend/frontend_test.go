@@ -0,0 +1,40 @@
+package frontend
+
+import (
+	"testing"
+
+	"github.com/vic/godnet/pkg/lambda"
+)
+
+// TestDefaultFrontend tests that the lambda syntax is always available.
+func TestDefaultFrontend(t *testing.T) {
+	term, err := Parse("", "x: x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if term.String() != "(x: x)" {
+		t.Errorf("got %s", term)
+	}
+}
+
+// TestRegister tests that registered frontends are listed and selected by
+// name.
+func TestRegister(t *testing.T) {
+	Register("const", Func(func(string) (lambda.Term, error) {
+		return lambda.Var{Name: "c"}, nil
+	}))
+	term, err := Parse("const", "ignored")
+	if err != nil || term.String() != "c" {
+		t.Errorf("got %v, %v", term, err)
+	}
+	found := false
+	for _, name := range Names() {
+		found = found || name == "const"
+	}
+	if !found {
+		t.Errorf("const missing from %v", Names())
+	}
+	if _, err := Parse("nope", ""); err == nil {
+		t.Errorf("expected error for unknown syntax")
+	}
+}
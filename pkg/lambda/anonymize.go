@@ -0,0 +1,113 @@
+package lambda
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Anonymize returns t with every identifier renamed and every literal
+// replaced by a placeholder, keeping the shape of the term, so a failing
+// input can be shared without revealing the code it came from.
+//
+// Binders are renamed v0, v1, ... in the order they appear, so shadowed
+// names become distinct. Free variables are renamed f0, f1, ...
+// consistently, except those for which keep returns true (e.g. the natives
+// the term calls); keep may be nil. Literals keep their type but not their
+// value: numbers become zero and strings become a run of 'x' of the same
+// length. Source spans are dropped.
+func Anonymize(t Term, keep func(name string) bool) Term {
+	a := &anonymizer{keep: keep, free: make(map[string]string)}
+	return a.term(t, nil)
+}
+
+type anonymizer struct {
+	keep  func(string) bool
+	free  map[string]string
+	bound int
+}
+
+// scope maps source names to their new names, innermost binding last.
+type scope []string
+
+func (a *anonymizer) fresh(prefix string, n *int) string {
+	for {
+		name := fmt.Sprintf("%s%d", prefix, *n)
+		*n++
+		if a.keep == nil || !a.keep(name) {
+			return name
+		}
+	}
+}
+
+func (a *anonymizer) bind(env scope, name string) (scope, string) {
+	renamed := a.fresh("v", &a.bound)
+	return append(env[:len(env):len(env)], name, renamed), renamed
+}
+
+func (a *anonymizer) name(env scope, name string) string {
+	for i := len(env) - 2; i >= 0; i -= 2 {
+		if env[i] == name {
+			return env[i+1]
+		}
+	}
+	if a.keep != nil && a.keep(name) {
+		return name
+	}
+	renamed, ok := a.free[name]
+	if !ok {
+		n := len(a.free)
+		renamed = a.fresh("f", &n)
+		a.free[name] = renamed
+	}
+	return renamed
+}
+
+func (a *anonymizer) term(t Term, env scope) Term {
+	switch v := t.(type) {
+	case Var:
+		return Var{Name: a.name(env, v.Name)}
+	case Lit:
+		return Lit{Value: blankLiteral(v.Value)}
+	case Perform:
+		if v.Payload == nil {
+			return Perform{Name: v.Name}
+		}
+		return Perform{Name: v.Name, Payload: blankLiteral(v.Payload)}
+	case Abs:
+		inner, arg := a.bind(env, v.Arg)
+		return Abs{Arg: arg, Body: a.term(v.Body, inner)}
+	case App:
+		return App{Fun: a.term(v.Fun, env), Arg: a.term(v.Arg, env)}
+	case Let:
+		val := a.term(v.Val, env)
+		inner, name := a.bind(env, v.Name)
+		return Let{Name: name, Val: val, Body: a.term(v.Body, inner)}
+	case LetRec:
+		inner, name := a.bind(env, v.Name)
+		return LetRec{Name: name, Val: a.term(v.Val, inner), Body: a.term(v.Body, inner)}
+	case Pair:
+		return Pair{Fst: a.term(v.Fst, env), Snd: a.term(v.Snd, env)}
+	default:
+		return t
+	}
+}
+
+// blankLiteral returns the zero value of a literal's type; strings keep
+// their length.
+func blankLiteral(v interface{}) interface{} {
+	switch x := v.(type) {
+	case int:
+		return 0
+	case *big.Int:
+		return new(big.Int)
+	case float64:
+		return 0.0
+	case *big.Rat:
+		return new(big.Rat)
+	case string:
+		return strings.Repeat("x", len(x))
+	default:
+		return v
+	}
+}
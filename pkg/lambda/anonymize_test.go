@@ -0,0 +1,38 @@
+package lambda
+
+import "testing"
+
+func TestAnonymize(t *testing.T) {
+	natives := map[string]bool{"concat": true}
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"secret: secret", "(v0: v0)"},
+		{"x: x: x", "(v0: (v1: v1))"},
+		{"x: price x tax", "(v0: ((f0 v0) f1))"},
+		{"a: price (price a)", "(v0: (f0 (f0 v0)))"},
+		{`concat "hunter2" "!"`, `((concat "xxxxxxx") "x")`},
+		{"x: add x 42 1.5 1/3", "(v0: ((((f0 v0) 0) 0.0) 0/1))"},
+	}
+	for _, tt := range tests {
+		got := Anonymize(mustParse(t, tt.input), func(name string) bool { return natives[name] })
+		if got.String() != tt.want {
+			t.Errorf("Anonymize(%q) = %s, want %s", tt.input, got, tt.want)
+		}
+		if !AlphaEqual(got, mustParse(t, got.String())) {
+			t.Errorf("Anonymize(%q) = %s does not parse back", tt.input, got)
+		}
+	}
+}
+
+func TestAnonymizePreservesShape(t *testing.T) {
+	term := mustParse(t, "let twice = f: x: f (f x) in twice (n: mul n n) 3")
+	got := Anonymize(term, nil)
+	if Size(got) != Size(term) {
+		t.Errorf("size changed from %d to %d", Size(term), Size(got))
+	}
+	if !AlphaEqual(Anonymize(got, nil), got) {
+		t.Errorf("anonymizing twice changed the term: %s", got)
+	}
+}
@@ -7,19 +7,97 @@ type Term interface {
 	String() string
 }
 
+// Pos is a position in the source text. Line and Col are 1-based; Col
+// counts bytes.
+type Pos struct {
+	Offset int
+	Line   int
+	Col    int
+}
+
+func (p Pos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
+// Span is the source range a term was parsed from. End is exclusive. Terms
+// built in Go have a zero Span.
+type Span struct {
+	Start Pos
+	End   Pos
+}
+
+// IsZero reports whether the span is unset.
+func (s Span) IsZero() bool {
+	return s == Span{}
+}
+
+func (s Span) String() string {
+	return s.Start.String()
+}
+
 // Var represents a variable usage.
 type Var struct {
 	Name string
+	Span Span
 }
 
 func (v Var) String() string {
 	return v.Name
 }
 
+// Lit represents a literal data value: an int, *big.Int, float64, *big.Rat
+// or string (see ParseLiteral). It translates to a Data node, so it can be
+// passed to native functions.
+type Lit struct {
+	Value interface{}
+	Span  Span
+}
+
+func (l Lit) String() string {
+	return FormatLiteral(l.Value)
+}
+
+// Perform performs the effect Name, optionally with a literal Payload (see
+// Lit). It translates to an Effect node, which the effect runner
+// (deltanet.RunEffects) replaces with the value the handler resumes with.
+// The syntax is !Name, or !Name followed by the payload literal.
+type Perform struct {
+	Name    string
+	Payload interface{}
+	Span    Span
+}
+
+func (p Perform) String() string {
+	if p.Payload == nil {
+		return "!" + p.Name
+	}
+	return fmt.Sprintf("(!%s %s)", p.Name, FormatLiteral(p.Payload))
+}
+
+// Erased marks a position of a readback result whose value was erased, e.g.
+// the unused argument of an affine term.
+type Erased struct{}
+
+func (Erased) String() string {
+	return "<erased>"
+}
+
+// Redex marks a position of a partial readback result (see
+// FromDeltaNetPartial) holding an active pair that has not been reduced. A
+// and B are the IDs of the two nodes of the pair.
+type Redex struct {
+	A, B uint64
+}
+
+func (Redex) String() string {
+	return "⟨redex⟩"
+}
+
 // Abs represents an abstraction (lambda).
 type Abs struct {
 	Arg  string
 	Body Term
+	Span Span
 }
 
 func (a Abs) String() string {
@@ -28,8 +106,9 @@ func (a Abs) String() string {
 
 // App represents an application.
 type App struct {
-	Fun Term
-	Arg Term
+	Fun  Term
+	Arg  Term
+	Span Span
 }
 
 func (a App) String() string {
@@ -42,8 +121,99 @@ type Let struct {
 	Name string
 	Val  Term
 	Body Term
+	Span Span
 }
 
 func (l Let) String() string {
 	return fmt.Sprintf("let %s = %s; %s", l.Name, l.Val, l.Body)
 }
+
+// LetRec represents a recursive binding: Name is in scope in both Val and
+// Body. It is desugared through the Y combinator for translation.
+// letrec x = Val in Body -> let x = Y (\x. Val) in Body
+type LetRec struct {
+	Name string
+	Val  Term
+	Body Term
+	Span Span
+}
+
+func (l LetRec) String() string {
+	return fmt.Sprintf("letrec %s = %s; %s", l.Name, l.Val, l.Body)
+}
+
+// Y is the fixpoint combinator used to desugar LetRec.
+var Y Term = Abs{Arg: "f", Body: App{
+	Fun: Abs{Arg: "x", Body: App{Fun: Var{Name: "f"}, Arg: App{Fun: Var{Name: "x"}, Arg: Var{Name: "x"}}}},
+	Arg: Abs{Arg: "x", Body: App{Fun: Var{Name: "f"}, Arg: App{Fun: Var{Name: "x"}, Arg: Var{Name: "x"}}}},
+}}
+
+// Desugar rewrites the recursive binding into a plain Let over Y.
+func (l LetRec) Desugar() Term {
+	return Let{
+		Name: l.Name,
+		Val:  App{Fun: Y, Arg: Abs{Arg: l.Name, Body: l.Val, Span: l.Span}, Span: l.Span},
+		Body: l.Body,
+		Span: l.Span,
+	}
+}
+
+// Binding is one definition of a recursive group.
+type Binding struct {
+	Name string
+	Val  Term
+}
+
+// MutualLetRec builds letrec for a group of bindings that are all in scope
+// in every value and in body. A single binding is a plain LetRec. A group
+// is encoded as one recursive tuple of all the values, so it still goes
+// through a single Y:
+//
+//	letrec f = F; g = G; B
+//	-> letrec r = (let f = r sel0; g = r sel1 in s: s F G); let f = r sel0; g = r sel1 in B
+//
+// where sel_i = x0: ... xn: x_i. The names r, s and x_i are fresh.
+func MutualLetRec(bindings []Binding, body Term, span Span) Term {
+	if len(bindings) == 1 {
+		return LetRec{Name: bindings[0].Name, Val: bindings[0].Val, Body: body, Span: span}
+	}
+
+	used := make(map[string]bool)
+	collectNames(body, used)
+	for _, b := range bindings {
+		used[b.Name] = true
+		collectNames(b.Val, used)
+	}
+	fresh := func(base string) string {
+		name := base
+		for i := 0; used[name]; i++ {
+			name = fmt.Sprintf("%s%d", base, i)
+		}
+		used[name] = true
+		return name
+	}
+	rec, sel := fresh("_rec"), fresh("_sel")
+	vars := make([]string, len(bindings))
+	for i := range vars {
+		vars[i] = fresh("_x")
+	}
+
+	// project binds every name of the group to its component of rec in t.
+	project := func(t Term) Term {
+		for i := len(bindings) - 1; i >= 0; i-- {
+			var selector Term = Var{Name: vars[i]}
+			for j := len(vars) - 1; j >= 0; j-- {
+				selector = Abs{Arg: vars[j], Body: selector}
+			}
+			t = Let{Name: bindings[i].Name, Val: App{Fun: Var{Name: rec}, Arg: selector}, Body: t, Span: span}
+		}
+		return t
+	}
+
+	var tuple Term = Var{Name: sel}
+	for _, b := range bindings {
+		tuple = App{Fun: tuple, Arg: b.Val}
+	}
+	tuple = Abs{Arg: sel, Body: tuple}
+	return LetRec{Name: rec, Val: project(tuple), Body: project(body), Span: span}
+}
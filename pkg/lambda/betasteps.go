@@ -0,0 +1,70 @@
+package lambda
+
+import (
+	"fmt"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// BetaStep is an interaction of a trace read as a β-step of the source
+// term: the application at App contracts the abstraction at Abs, which
+// binds Var. Steps taken by copies of an abstraction or application made
+// during reduction point to the source term they were copied from.
+type BetaStep struct {
+	Step   uint64 // Interaction number in the trace
+	Depth  uint64 // Depth of the reduced wire
+	Var    string
+	Binder uint64 // Scope ID of the abstraction (see deltanet.TermLayout)
+	Abs    Span
+	App    Span
+}
+
+func (s BetaStep) String() string {
+	return fmt.Sprintf("#%d β %s: abstraction at %v applied at %v", s.Step, s.Var, s.Abs, s.App)
+}
+
+// BetaSteps translates a trace of net into the β-steps of the source term,
+// in trace order. The roles of the nodes are read from the metadata
+// attached by a Translator with LayoutHints set, and their spans require
+// SourceSpans as well. Only fan-fan annihilations between an application
+// and an abstraction are β-steps; the other interactions share, erase or
+// apply natives, and have no counterpart in the source, so they are left
+// out, as are pairs of nodes without layout hints.
+func BetaSteps(net *deltanet.Network, events []deltanet.TraceEvent) []BetaStep {
+	var steps []BetaStep
+	for _, e := range events {
+		if e.Rule != deltanet.RuleFanFan {
+			continue
+		}
+		a, aok := termMeta(net, e.AID)
+		b, bok := termMeta(net, e.BID)
+		if !aok || !bok {
+			continue
+		}
+		if a.Layout.Role == deltanet.RoleApp {
+			a, b = b, a
+		}
+		if a.Layout.Role != deltanet.RoleAbs || b.Layout.Role != deltanet.RoleApp {
+			continue
+		}
+		steps = append(steps, BetaStep{
+			Step:   e.Step,
+			Depth:  e.Depth,
+			Var:    a.Layout.Name,
+			Binder: a.Layout.Binder,
+			Abs:    a.Span,
+			App:    b.Span,
+		})
+	}
+	return steps
+}
+
+// termMeta returns the layout hint attached to a node by a Translator.
+func termMeta(net *deltanet.Network, id uint64) (TermMeta, bool) {
+	meta, ok := net.Meta(id)
+	if !ok {
+		return TermMeta{}, false
+	}
+	m, ok := meta.(TermMeta)
+	return m, ok
+}
@@ -0,0 +1,55 @@
+package lambda
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// TestBetaSteps tests that the fan-fan interactions of a trace are read
+// back as the β-steps of the source term.
+func TestBetaSteps(t *testing.T) {
+	if !deltanet.TraceCompiled() {
+		t.Skip("built with notrace")
+	}
+	input := "(f: x: f (f x)) (y: y) a"
+	net := deltanet.NewNetworkWith(deltanet.WithTrace(1000), deltanet.WithWorkers(1))
+	tr := NewTranslator(TranslatorOptions{SourceSpans: true, LayoutHints: true})
+	if _, err := tr.Translate(mustParse(t, input), net); err != nil {
+		t.Fatal(err)
+	}
+	net.ReduceAll()
+
+	steps := BetaSteps(net, net.TraceSnapshot())
+	var vars []string
+	for _, s := range steps {
+		t.Log(s)
+		vars = append(vars, s.Var)
+		if got := input[s.Abs.Start.Offset:s.Abs.End.Offset]; !strings.HasPrefix(got, s.Var+":") {
+			t.Errorf("%v: abstraction span covers %q", s, got)
+		}
+	}
+	sort.Strings(vars)
+	// f and x are substituted once each, and each copy of y: y once.
+	want := []string{"f", "x", "y", "y"}
+	if len(vars) != len(want) {
+		t.Fatalf("expected β-steps binding %v, got %v", want, vars)
+	}
+	for i := range want {
+		if vars[i] != want[i] {
+			t.Fatalf("expected β-steps binding %v, got %v", want, vars)
+		}
+	}
+
+	// Without layout hints there is nothing to read.
+	net = deltanet.NewNetworkWith(deltanet.WithTrace(1000), deltanet.WithWorkers(1))
+	if _, err := NewTranslator(TranslatorOptions{}).Translate(mustParse(t, input), net); err != nil {
+		t.Fatal(err)
+	}
+	net.ReduceAll()
+	if steps := BetaSteps(net, net.TraceSnapshot()); len(steps) != 0 {
+		t.Errorf("expected no β-steps without layout hints, got %v", steps)
+	}
+}
@@ -0,0 +1,203 @@
+package lambda
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ChurchDecoding selects which Church encodings DecodeChurch recognizes.
+type ChurchDecoding uint8
+
+const (
+	// DecodeNumerals recognizes f: x: f (... (f x)) as a Numeral.
+	DecodeNumerals ChurchDecoding = 1 << iota
+	// DecodeBooleans recognizes t: f: t and t: f: f as a Bool. With
+	// DecodeNumerals also set, t: f: f is read as the numeral 0.
+	DecodeBooleans
+	// DecodePairs recognizes s: s a b, where s is not free in a and b, as
+	// a Pair.
+	DecodePairs
+	// DecodeAll recognizes every supported encoding.
+	DecodeAll = DecodeNumerals | DecodeBooleans | DecodePairs
+)
+
+// Numeral is a decoded Church numeral.
+type Numeral struct {
+	N int
+}
+
+func (n Numeral) String() string {
+	return strconv.Itoa(n.N)
+}
+
+// Desugar returns the Church encoding of the numeral.
+func (n Numeral) Desugar() Term {
+	var body Term = Var{Name: "x"}
+	for i := 0; i < n.N; i++ {
+		body = App{Fun: Var{Name: "f"}, Arg: body}
+	}
+	return Abs{Arg: "f", Body: Abs{Arg: "x", Body: body}}
+}
+
+// Bool is a decoded Church boolean.
+type Bool struct {
+	B bool
+}
+
+func (b Bool) String() string {
+	return strconv.FormatBool(b.B)
+}
+
+// Desugar returns the Church encoding of the boolean.
+func (b Bool) Desugar() Term {
+	result := "f"
+	if b.B {
+		result = "t"
+	}
+	return Abs{Arg: "t", Body: Abs{Arg: "f", Body: Var{Name: result}}}
+}
+
+// Pair is a decoded Church pair.
+type Pair struct {
+	Fst Term
+	Snd Term
+}
+
+func (p Pair) String() string {
+	return fmt.Sprintf("(%s, %s)", p.Fst, p.Snd)
+}
+
+// Desugar returns the Church encoding of the pair. The selector is named
+// so that it does not capture free variables of the components.
+func (p Pair) Desugar() Term {
+	names := make(map[string]bool)
+	collectNames(p.Fst, names)
+	collectNames(p.Snd, names)
+	s := "s"
+	for i := 0; names[s]; i++ {
+		s = fmt.Sprintf("s%d", i)
+	}
+	return Abs{Arg: s, Body: App{Fun: App{Fun: Var{Name: s}, Arg: desugarAll(p.Fst)}, Arg: desugarAll(p.Snd)}}
+}
+
+// desugarAll expands decoded terms nested in t.
+func desugarAll(t Term) Term {
+	switch v := t.(type) {
+	case Abs:
+		return Abs{Arg: v.Arg, Body: desugarAll(v.Body), Span: v.Span}
+	case App:
+		return App{Fun: desugarAll(v.Fun), Arg: desugarAll(v.Arg), Span: v.Span}
+	case Let:
+		return Let{Name: v.Name, Val: desugarAll(v.Val), Body: desugarAll(v.Body), Span: v.Span}
+	case LetRec:
+		return LetRec{Name: v.Name, Val: desugarAll(v.Val), Body: desugarAll(v.Body), Span: v.Span}
+	case Numeral, Bool, Pair:
+		return desugarAll(t.(interface{ Desugar() Term }).Desugar())
+	default:
+		return t
+	}
+}
+
+// DecodeChurch replaces the Church encoded values in t, outermost first,
+// with Numeral, Bool and Pair terms, so results print as 3, true or
+// (a, b). The decoded terms translate back to their encodings.
+func DecodeChurch(t Term, which ChurchDecoding) Term {
+	if which&DecodeNumerals != 0 {
+		if n, ok := churchNumeral(t); ok {
+			return Numeral{N: n}
+		}
+	}
+	if which&DecodeBooleans != 0 {
+		if b, ok := churchBool(t); ok {
+			return Bool{B: b}
+		}
+	}
+	if which&DecodePairs != 0 {
+		if fst, snd, ok := churchPair(t); ok {
+			return Pair{Fst: DecodeChurch(fst, which), Snd: DecodeChurch(snd, which)}
+		}
+	}
+	switch v := t.(type) {
+	case Abs:
+		return Abs{Arg: v.Arg, Body: DecodeChurch(v.Body, which), Span: v.Span}
+	case App:
+		return App{Fun: DecodeChurch(v.Fun, which), Arg: DecodeChurch(v.Arg, which), Span: v.Span}
+	case Let:
+		return Let{Name: v.Name, Val: DecodeChurch(v.Val, which), Body: DecodeChurch(v.Body, which), Span: v.Span}
+	case LetRec:
+		return LetRec{Name: v.Name, Val: DecodeChurch(v.Val, which), Body: DecodeChurch(v.Body, which), Span: v.Span}
+	default:
+		return t
+	}
+}
+
+func churchNumeral(t Term) (int, bool) {
+	f, ok := t.(Abs)
+	if !ok {
+		return 0, false
+	}
+	x, ok := f.Body.(Abs)
+	if !ok || x.Arg == f.Arg {
+		return 0, false
+	}
+	n := 0
+	body := x.Body
+	for {
+		switch v := body.(type) {
+		case Var:
+			return n, v.Name == x.Arg
+		case App:
+			if fn, ok := v.Fun.(Var); !ok || fn.Name != f.Arg {
+				return 0, false
+			}
+			n++
+			body = v.Arg
+		default:
+			return 0, false
+		}
+	}
+}
+
+func churchBool(t Term) (bool, bool) {
+	a, ok := t.(Abs)
+	if !ok {
+		return false, false
+	}
+	b, ok := a.Body.(Abs)
+	if !ok || b.Arg == a.Arg {
+		return false, false
+	}
+	v, ok := b.Body.(Var)
+	switch {
+	case !ok:
+		return false, false
+	case v.Name == a.Arg:
+		return true, true
+	case v.Name == b.Arg:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+func churchPair(t Term) (Term, Term, bool) {
+	s, ok := t.(Abs)
+	if !ok {
+		return nil, nil, false
+	}
+	outer, ok := s.Body.(App)
+	if !ok {
+		return nil, nil, false
+	}
+	inner, ok := outer.Fun.(App)
+	if !ok {
+		return nil, nil, false
+	}
+	if sel, ok := inner.Fun.(Var); !ok || sel.Name != s.Arg {
+		return nil, nil, false
+	}
+	if countUses(inner.Arg, s.Arg) != 0 || countUses(outer.Arg, s.Arg) != 0 {
+		return nil, nil, false
+	}
+	return inner.Arg, outer.Arg, true
+}
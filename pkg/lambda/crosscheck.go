@@ -0,0 +1,91 @@
+package lambda
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// ErrPhaseMismatch is returned by CrossValidate when a term reads back
+// differently with and without phase 2.
+var ErrPhaseMismatch = errors.New("readbacks disagree with and without phase 2")
+
+// CrossCheck is the outcome of CrossValidate.
+type CrossCheck struct {
+	// Phase1 is the readback after phase 1 and the canonical rules only
+	// (see deltanet.Network.ReducePhase1).
+	Phase1 Term
+	// Full is the readback after ReduceToNormalForm, with fans rotated and
+	// aux fan replication applied.
+	Full Term
+	// Compared is false when either readback still contains erased or
+	// unresolved positions, so the results were not compared.
+	Compared bool
+}
+
+// CrossValidate translates term into two networks built by newNet, reduces
+// one with phase 1 only and the other to normal form, and checks that both
+// read back to alpha-equivalent terms. The comparison is skipped when
+// either result is not a proper normal form. It returns an error wrapping
+// ErrPhaseMismatch when the readbacks disagree; the check is returned in
+// every case once translation succeeded.
+func (tr *Translator) CrossValidate(term Term, newNet func() *deltanet.Network) (*CrossCheck, error) {
+	phase1, err := tr.reduceAndRead(term, newNet(), (*deltanet.Network).ReducePhase1)
+	if err != nil {
+		return nil, err
+	}
+	full, err := tr.reduceAndRead(term, newNet(), (*deltanet.Network).ReduceToNormalForm)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &CrossCheck{Phase1: phase1, Full: full}
+	if unresolved(phase1) || unresolved(full) {
+		return check, nil
+	}
+	check.Compared = true
+	if !AlphaEqual(phase1, full) {
+		return check, fmt.Errorf("%w: phase 1 gives %s, normal form gives %s", ErrPhaseMismatch, phase1, full)
+	}
+	return check, nil
+}
+
+func (tr *Translator) reduceAndRead(term Term, net *deltanet.Network, reduce func(*deltanet.Network) error) (Term, error) {
+	t, err := tr.Translate(term, net)
+	if err != nil {
+		return nil, err
+	}
+	// A reduction stopped by a limit is read back as it stands; its
+	// unresolved positions skip the comparison.
+	if err := reduce(net); errors.Is(err, deltanet.ErrClosed) {
+		return nil, err
+	}
+	result, _ := tr.ReadbackChecked(net, t)
+	return result, nil
+}
+
+// unresolved reports whether t contains an erased position, a pending
+// redex or one of the <...> variables readback uses for nodes it cannot
+// interpret.
+func unresolved(t Term) bool {
+	switch v := t.(type) {
+	case Erased, Redex:
+		return true
+	case Var:
+		return strings.HasPrefix(v.Name, "<")
+	case Abs:
+		return unresolved(v.Body)
+	case App:
+		return unresolved(v.Fun) || unresolved(v.Arg)
+	case Let:
+		return unresolved(v.Val) || unresolved(v.Body)
+	case LetRec:
+		return unresolved(v.Val) || unresolved(v.Body)
+	case Pair:
+		return unresolved(v.Fst) || unresolved(v.Snd)
+	default:
+		return false
+	}
+}
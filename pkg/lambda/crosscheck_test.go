@@ -0,0 +1,44 @@
+package lambda
+
+import (
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// TestCrossValidate tests that terms needing sharing and erasure read back
+// the same with and without phase 2.
+func TestCrossValidate(t *testing.T) {
+	inputs := []string{
+		"(x: x) a",
+		"(x: y: x) a b",
+		"(f: x: f (f x)) g c",
+		"(x: x x) (y: y)",
+		"let two = f: x: f (f x); in two two g c",
+		"(x: y: y x) a",
+	}
+	tr := NewTranslator(TranslatorOptions{})
+	for _, input := range inputs {
+		check, err := tr.CrossValidate(mustParse(t, input), deltanet.NewNetwork)
+		if err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+		if !check.Compared {
+			t.Errorf("%s: not compared (phase 1 %s, normal form %s)", input, check.Phase1, check.Full)
+		}
+	}
+}
+
+// TestCrossValidateSkipsUnresolved tests that results with erased positions
+// are not compared.
+func TestCrossValidateSkipsUnresolved(t *testing.T) {
+	if unresolved(mustParse(t, "x: y: x y")) {
+		t.Errorf("normal form reported as unresolved")
+	}
+	for _, term := range []Term{Erased{}, App{Fun: Var{Name: "f"}, Arg: Var{Name: "<free>"}}} {
+		if !unresolved(term) {
+			t.Errorf("%s: expected unresolved", term)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package lambda
+
+// EliminateDeadCode drops the bindings a term can never reach: let and
+// letrec bindings whose name the body does not use, and arguments applied
+// to an abstraction that ignores its variable, such as an entry point given
+// a value it does not need. It works bottom-up, so a definition used only by
+// dropped ones is dropped too, which trims a large prelude down to what the
+// program refers to. A letrec binding that only refers to itself counts as
+// unused.
+func EliminateDeadCode(t Term) Term {
+	switch v := t.(type) {
+	case Abs:
+		return Abs{Arg: v.Arg, Body: EliminateDeadCode(v.Body), Span: v.Span}
+	case App:
+		fun := EliminateDeadCode(v.Fun)
+		if abs, ok := fun.(Abs); ok && countUses(abs.Body, abs.Arg) == 0 {
+			return abs.Body
+		}
+		return App{Fun: fun, Arg: EliminateDeadCode(v.Arg), Span: v.Span}
+	case Let:
+		body := EliminateDeadCode(v.Body)
+		if countUses(body, v.Name) == 0 {
+			return body
+		}
+		return Let{Name: v.Name, Val: EliminateDeadCode(v.Val), Body: body, Span: v.Span}
+	case LetRec:
+		body := EliminateDeadCode(v.Body)
+		if countUses(body, v.Name) == 0 {
+			return body
+		}
+		return LetRec{Name: v.Name, Val: EliminateDeadCode(v.Val), Body: body, Span: v.Span}
+	case Pair:
+		return Pair{Fst: EliminateDeadCode(v.Fst), Snd: EliminateDeadCode(v.Snd)}
+	default:
+		return t
+	}
+}
@@ -0,0 +1,69 @@
+package lambda
+
+import (
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+func TestEliminateDeadCode(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"let id = x: x in y", "y"},
+		{"let id = x: x in id y", "let id = x: x in id y"},
+		// unused is only reached from dead code, so both go.
+		{"let unused = a: a in let dead = unused b in c", "c"},
+		{"(main: main) ((x: y) z)", "(main: main) y"},
+		{"(x: y) (let k = a: a in k)", "y"},
+		{"f: let g = f in f", "(f: f)"},
+	}
+	for _, tt := range tests {
+		got := EliminateDeadCode(mustParse(t, tt.input))
+		if want := mustParse(t, tt.want); !AlphaEqual(got, want) {
+			t.Errorf("EliminateDeadCode(%q) = %s, want %s", tt.input, got, want)
+		}
+	}
+}
+
+func TestEliminateDeadLetRec(t *testing.T) {
+	loop := Abs{Arg: "x", Body: App{Fun: Var{Name: "f"}, Arg: Var{Name: "x"}}}
+	dead := LetRec{Name: "f", Val: loop, Body: Var{Name: "z"}}
+	if got := EliminateDeadCode(dead); !AlphaEqual(got, Var{Name: "z"}) {
+		t.Errorf("self-referencing binding kept: %s", got)
+	}
+	live := LetRec{Name: "f", Val: loop, Body: Var{Name: "f"}}
+	if got := EliminateDeadCode(live); !AlphaEqual(got, live) {
+		t.Errorf("used binding dropped: %s", got)
+	}
+}
+
+func TestDeadCodeShrinksNet(t *testing.T) {
+	source := `
+		let twice = f: x: f (f x) in
+		let thrice = f: x: f (f (f x)) in
+		let compose = f: g: x: f (g x) in
+		let main = n: twice n in
+		main (x: x)`
+	nodes := func(opts TranslatorOptions) (int, string) {
+		net := deltanet.NewNetwork()
+		tr := NewTranslator(opts)
+		translation, err := tr.Translate(mustParse(t, source), net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := net.NodeCount()
+		net.ReduceToNormalForm()
+		return size, tr.Readback(net, translation).String()
+	}
+
+	full, want := nodes(TranslatorOptions{})
+	trimmed, got := nodes(TranslatorOptions{DeadCode: true})
+	if trimmed >= full {
+		t.Errorf("dead code elimination built %d nodes, without it %d", trimmed, full)
+	}
+	if got != want {
+		t.Errorf("result changed from %s to %s", want, got)
+	}
+}
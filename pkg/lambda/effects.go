@@ -0,0 +1,37 @@
+package lambda
+
+import "sort"
+
+// Effects returns the effect row of a term: the names of the effects it
+// performs (see Perform), sorted.
+func Effects(t Term) []string {
+	seen := make(map[string]bool)
+	collectEffects(t, seen)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func collectEffects(t Term, seen map[string]bool) {
+	switch v := t.(type) {
+	case Perform:
+		seen[v.Name] = true
+	case Abs:
+		collectEffects(v.Body, seen)
+	case App:
+		collectEffects(v.Fun, seen)
+		collectEffects(v.Arg, seen)
+	case Let:
+		collectEffects(v.Val, seen)
+		collectEffects(v.Body, seen)
+	case LetRec:
+		collectEffects(v.Val, seen)
+		collectEffects(v.Body, seen)
+	case Pair:
+		collectEffects(v.Fst, seen)
+		collectEffects(v.Snd, seen)
+	}
+}
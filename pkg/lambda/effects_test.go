@@ -0,0 +1,79 @@
+package lambda
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+func TestParsePerform(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"!Ask", "!Ask"},
+		{`!Log "hi"`, `(!Log "hi")`},
+		{"!State.Put 3", "(!State.Put 3)"},
+		{`f (!Log "hi") x`, `((f (!Log "hi")) x)`},
+		{"x: !Ask", "(x: !Ask)"},
+	}
+	for _, tt := range tests {
+		term, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+			continue
+		}
+		if got := term.String(); got != tt.want {
+			t.Errorf("%s: parsed %s, want %s", tt.input, got, tt.want)
+		}
+		back, err := Parse(term.String())
+		if err != nil || !AlphaEqual(back, term) {
+			t.Errorf("%s: reparse gave %v (%v)", tt.input, back, err)
+		}
+	}
+}
+
+func TestEffects(t *testing.T) {
+	term, err := Parse(`let ask = !Ask in (x: y: x) (!Log "a") (!State.Get) ask`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := Effects(term), []string{"Ask", "Log", "State.Get"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Effects = %v, want %v", got, want)
+	}
+	if got := Effects(Abs{Arg: "x", Body: Var{Name: "x"}}); len(got) != 0 {
+		t.Errorf("pure term performs %v", got)
+	}
+}
+
+// TestRunPerform tests that a translated effect is performed by the effect
+// runner and read back as the value the handler resumes with.
+func TestRunPerform(t *testing.T) {
+	term, err := Parse(`(x: y: x) (!Log "hello") 7`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logged []interface{}
+	scope := deltanet.NewHandlerScope()
+	scope.Register("Log", func(eff deltanet.Effect, k *deltanet.Continuation) (interface{}, error) {
+		logged = append(logged, eff.Payload)
+		return k.Resume(len(eff.Payload.(string)))
+	})
+
+	net := deltanet.NewNetwork()
+	tr := NewTranslator(TranslatorOptions{})
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := net.RunEffects(scope); err != nil {
+		t.Fatal(err)
+	}
+	if got := tr.Readback(net, translation).String(); got != "5" {
+		t.Errorf("result %s, want 5", got)
+	}
+	if !reflect.DeepEqual(logged, []interface{}{"hello"}) {
+		t.Errorf("logged %v, want [hello]", logged)
+	}
+}
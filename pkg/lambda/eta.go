@@ -0,0 +1,25 @@
+package lambda
+
+// EtaReduce removes eta-redexes bottom-up: (x: f x) becomes f when x does
+// not occur free in f. Reducing bottom-up also collapses nested wrappers,
+// e.g. (x: y: f x y) becomes f.
+func EtaReduce(t Term) Term {
+	switch v := t.(type) {
+	case Abs:
+		body := EtaReduce(v.Body)
+		if app, ok := body.(App); ok {
+			if arg, ok := app.Arg.(Var); ok && arg.Name == v.Arg && countUses(app.Fun, v.Arg) == 0 {
+				return app.Fun
+			}
+		}
+		return Abs{Arg: v.Arg, Body: body, Span: v.Span}
+	case App:
+		return App{Fun: EtaReduce(v.Fun), Arg: EtaReduce(v.Arg), Span: v.Span}
+	case Let:
+		return Let{Name: v.Name, Val: EtaReduce(v.Val), Body: EtaReduce(v.Body), Span: v.Span}
+	case LetRec:
+		return LetRec{Name: v.Name, Val: EtaReduce(v.Val), Body: EtaReduce(v.Body), Span: v.Span}
+	default:
+		return t
+	}
+}
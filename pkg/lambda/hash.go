@@ -0,0 +1,378 @@
+package lambda
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/fnv"
+)
+
+// Hash returns a structural hash of a term that is invariant under
+// alpha-renaming. Bound variables are hashed by their de Bruijn index,
+// free variables by name and literals by their literal syntax. Let
+// bindings, numerals, booleans and pairs hash like their desugared form, so
+// alpha-equivalent terms (see AlphaEqual) always hash alike. The hash is
+// FNV-1a over a fixed encoding of the term, so it is stable across runs
+// and can be stored, e.g. as a cache or corpus key.
+func Hash(t Term) uint64 {
+	h := fnv.New64a()
+	hashTerm(h, t, nil)
+	return h.Sum64()
+}
+
+// Digest returns a SHA-256 digest of the encoding Hash uses, for stores
+// where 64-bit collisions are not acceptable.
+func Digest(t Term) [sha256.Size]byte {
+	h := sha256.New()
+	hashTerm(h, t, nil)
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+func hashTerm(h hash.Hash, t Term, env []string) {
+	var buf [binary.MaxVarintLen64]byte
+	switch v := t.(type) {
+	case Var:
+		if idx, ok := deBruijn(env, v.Name); ok {
+			h.Write([]byte{'V'})
+			h.Write(buf[:binary.PutUvarint(buf[:], uint64(idx))])
+			return
+		}
+		h.Write([]byte{'F'})
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(v.Name)))])
+		h.Write([]byte(v.Name))
+	case Abs:
+		h.Write([]byte{'L'})
+		hashTerm(h, v.Body, append(env, v.Arg))
+	case App:
+		h.Write([]byte{'A'})
+		hashTerm(h, v.Fun, env)
+		hashTerm(h, v.Arg, env)
+	case Let:
+		hashTerm(h, App{Fun: Abs{Arg: v.Name, Body: v.Body}, Arg: v.Val}, env)
+	case LetRec:
+		hashTerm(h, v.Desugar(), env)
+	case Numeral, Bool, Pair:
+		hashTerm(h, desugarAll(v), env)
+	case Lit:
+		text := v.String()
+		h.Write([]byte{'D'})
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(text)))])
+		h.Write([]byte(text))
+	case Perform:
+		text := v.String()
+		h.Write([]byte{'P'})
+		h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(text)))])
+		h.Write([]byte(text))
+	case Erased:
+		h.Write([]byte{'E'})
+	default:
+		h.Write([]byte{'?'})
+		h.Write([]byte(fmt.Sprintf("%T:%v", t, t)))
+	}
+}
+
+// deBruijn returns the de Bruijn index of name in env (innermost binder is 0).
+func deBruijn(env []string, name string) (int, bool) {
+	for i := len(env) - 1; i >= 0; i-- {
+		if env[i] == name {
+			return len(env) - 1 - i, true
+		}
+	}
+	return 0, false
+}
+
+// AlphaEqual reports whether two terms are equal up to renaming of bound
+// variables.
+func AlphaEqual(a, b Term) bool {
+	return alphaEqual(a, b, nil, nil)
+}
+
+func alphaEqual(a, b Term, envA, envB []string) bool {
+	if l, ok := a.(Let); ok {
+		a = App{Fun: Abs{Arg: l.Name, Body: l.Body}, Arg: l.Val}
+	}
+	if l, ok := b.(Let); ok {
+		b = App{Fun: Abs{Arg: l.Name, Body: l.Body}, Arg: l.Val}
+	}
+	if l, ok := a.(LetRec); ok {
+		return alphaEqual(l.Desugar(), b, envA, envB)
+	}
+	if l, ok := b.(LetRec); ok {
+		return alphaEqual(a, l.Desugar(), envA, envB)
+	}
+	switch a.(type) {
+	case Numeral, Bool, Pair:
+		return alphaEqual(desugarAll(a), b, envA, envB)
+	}
+	switch b.(type) {
+	case Numeral, Bool, Pair:
+		return alphaEqual(a, desugarAll(b), envA, envB)
+	}
+	switch x := a.(type) {
+	case Var:
+		y, ok := b.(Var)
+		if !ok {
+			return false
+		}
+		ix, boundX := deBruijn(envA, x.Name)
+		iy, boundY := deBruijn(envB, y.Name)
+		if boundX || boundY {
+			return boundX && boundY && ix == iy
+		}
+		return x.Name == y.Name
+	case Abs:
+		y, ok := b.(Abs)
+		if !ok {
+			return false
+		}
+		return alphaEqual(x.Body, y.Body, append(envA, x.Arg), append(envB, y.Arg))
+	case App:
+		y, ok := b.(App)
+		if !ok {
+			return false
+		}
+		return alphaEqual(x.Fun, y.Fun, envA, envB) && alphaEqual(x.Arg, y.Arg, envA, envB)
+	default:
+		return fmt.Sprintf("%T:%v", a, a) == fmt.Sprintf("%T:%v", b, b)
+	}
+}
+
+// Interner maps alpha-equivalent terms to a single canonical instance, so
+// identical subterms share the same representation.
+type Interner struct {
+	buckets map[uint64][]Term
+	hits    int
+}
+
+// NewInterner creates an empty interning table.
+func NewInterner() *Interner {
+	return &Interner{buckets: make(map[uint64][]Term)}
+}
+
+// Intern returns the canonical instance of t. Subterms are interned first,
+// so the returned term shares every repeated subterm. Variables are leaves
+// and are returned unchanged.
+func (in *Interner) Intern(t Term) Term {
+	switch v := t.(type) {
+	case Var:
+		return t
+	case Abs:
+		t = Abs{Arg: v.Arg, Body: in.Intern(v.Body)}
+	case App:
+		t = App{Fun: in.Intern(v.Fun), Arg: in.Intern(v.Arg)}
+	case Let:
+		t = Let{Name: v.Name, Val: in.Intern(v.Val), Body: in.Intern(v.Body)}
+	case LetRec:
+		t = LetRec{Name: v.Name, Val: in.Intern(v.Val), Body: in.Intern(v.Body)}
+	}
+	key := Hash(t)
+	for _, existing := range in.buckets[key] {
+		if AlphaEqual(existing, t) {
+			in.hits++
+			return existing
+		}
+	}
+	in.buckets[key] = append(in.buckets[key], t)
+	return t
+}
+
+// Len returns the number of distinct terms in the table.
+func (in *Interner) Len() int {
+	count := 0
+	for _, bucket := range in.buckets {
+		count += len(bucket)
+	}
+	return count
+}
+
+// Hits returns how many Intern calls were answered by an existing entry.
+func (in *Interner) Hits() int {
+	return in.hits
+}
+
+// Size returns the number of Var, Abs and App nodes in a term.
+func Size(t Term) int {
+	switch v := t.(type) {
+	case Abs:
+		return 1 + Size(v.Body)
+	case App:
+		return 1 + Size(v.Fun) + Size(v.Arg)
+	case Let:
+		return 2 + Size(v.Val) + Size(v.Body)
+	case LetRec:
+		return 2 + Size(v.Val) + Size(v.Body)
+	default:
+		return 1
+	}
+}
+
+// ShareCommonSubterms lifts repeated subterms of at least minSize nodes into
+// let bindings, so ToDeltaNet shares them with a replicator instead of
+// building each copy. Only subterms that do not mention enclosing binders
+// are lifted. The result is beta-equivalent to t.
+func ShareCommonSubterms(t Term, minSize int) Term {
+	used := make(map[string]bool)
+	collectNames(t, used)
+	fresh := 0
+	nextName := func() string {
+		for {
+			name := fmt.Sprintf("_s%d", fresh)
+			fresh++
+			if !used[name] {
+				used[name] = true
+				return name
+			}
+		}
+	}
+
+	for {
+		best, count := mostSharedSubterm(t, minSize)
+		if count < 2 {
+			return t
+		}
+		name := nextName()
+		t = Let{Name: name, Val: best, Body: replaceSubterm(t, best, Var{Name: name}, nil)}
+	}
+}
+
+type sharedCandidate struct {
+	term  Term
+	count int
+}
+
+// mostSharedSubterm returns the liftable repeated subterm that saves the most
+// nodes, together with its occurrence count.
+func mostSharedSubterm(t Term, minSize int) (Term, int) {
+	buckets := make(map[uint64][]*sharedCandidate)
+	var walk func(Term, []string)
+	walk = func(t Term, env []string) {
+		switch v := t.(type) {
+		case Abs:
+			walk(v.Body, append(env, v.Arg))
+		case App:
+			walk(v.Fun, env)
+			walk(v.Arg, env)
+		case Let:
+			walk(v.Val, env)
+			walk(v.Body, append(env, v.Name))
+			return
+		case LetRec:
+			inner := append(env, v.Name)
+			walk(v.Val, inner)
+			walk(v.Body, inner)
+			return
+		default:
+			return
+		}
+		if Size(t) < minSize || mentionsAny(t, env) {
+			return
+		}
+		key := Hash(t)
+		for _, c := range buckets[key] {
+			if AlphaEqual(c.term, t) {
+				c.count++
+				return
+			}
+		}
+		buckets[key] = append(buckets[key], &sharedCandidate{term: t, count: 1})
+	}
+	walk(t, nil)
+
+	var best *sharedCandidate
+	bestSaving := 0
+	for _, bucket := range buckets {
+		for _, c := range bucket {
+			if c.count < 2 {
+				continue
+			}
+			saving := (c.count - 1) * Size(c.term)
+			if saving > bestSaving || (saving == bestSaving && best != nil && c.term.String() < best.term.String()) {
+				best, bestSaving = c, saving
+			}
+		}
+	}
+	if best == nil {
+		return nil, 0
+	}
+	return best.term, best.count
+}
+
+// mentionsAny reports whether t has a free occurrence of any name in env.
+func mentionsAny(t Term, env []string) bool {
+	if len(env) == 0 {
+		return false
+	}
+	free := make(map[string]bool)
+	freeVars(t, nil, free)
+	for _, name := range env {
+		if free[name] {
+			return true
+		}
+	}
+	return false
+}
+
+func freeVars(t Term, bound []string, acc map[string]bool) {
+	switch v := t.(type) {
+	case Var:
+		if _, ok := deBruijn(bound, v.Name); !ok {
+			acc[v.Name] = true
+		}
+	case Abs:
+		freeVars(v.Body, append(bound, v.Arg), acc)
+	case App:
+		freeVars(v.Fun, bound, acc)
+		freeVars(v.Arg, bound, acc)
+	case Let:
+		freeVars(v.Val, bound, acc)
+		freeVars(v.Body, append(bound, v.Name), acc)
+	case LetRec:
+		inner := append(bound, v.Name)
+		freeVars(v.Val, inner, acc)
+		freeVars(v.Body, inner, acc)
+	}
+}
+
+func collectNames(t Term, acc map[string]bool) {
+	switch v := t.(type) {
+	case Var:
+		acc[v.Name] = true
+	case Abs:
+		acc[v.Arg] = true
+		collectNames(v.Body, acc)
+	case App:
+		collectNames(v.Fun, acc)
+		collectNames(v.Arg, acc)
+	case Let:
+		acc[v.Name] = true
+		collectNames(v.Val, acc)
+		collectNames(v.Body, acc)
+	case LetRec:
+		acc[v.Name] = true
+		collectNames(v.Val, acc)
+		collectNames(v.Body, acc)
+	}
+}
+
+// replaceSubterm replaces every liftable occurrence of target with repl.
+func replaceSubterm(t, target, repl Term, env []string) Term {
+	if !mentionsAny(t, env) && AlphaEqual(t, target) {
+		return repl
+	}
+	switch v := t.(type) {
+	case Abs:
+		return Abs{Arg: v.Arg, Body: replaceSubterm(v.Body, target, repl, append(env, v.Arg))}
+	case App:
+		return App{Fun: replaceSubterm(v.Fun, target, repl, env), Arg: replaceSubterm(v.Arg, target, repl, env)}
+	case Let:
+		return Let{Name: v.Name, Val: replaceSubterm(v.Val, target, repl, env), Body: replaceSubterm(v.Body, target, repl, append(env, v.Name))}
+	case LetRec:
+		inner := append(env, v.Name)
+		return LetRec{Name: v.Name, Val: replaceSubterm(v.Val, target, repl, inner), Body: replaceSubterm(v.Body, target, repl, inner)}
+	default:
+		return t
+	}
+}
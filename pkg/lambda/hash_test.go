@@ -0,0 +1,187 @@
+package lambda
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+func mustParse(t *testing.T, input string) Term {
+	t.Helper()
+	term, err := Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error for %q: %v", input, err)
+	}
+	return term
+}
+
+func TestHashAlphaInvariant(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		equal bool
+	}{
+		{"x: x", "y: y", true},
+		{"x: y: x", "a: b: a", true},
+		{"x: y: x", "x: y: y", false},
+		{"x: a", "y: a", true},
+		{"x: a", "x: b", false},
+		{"x: x", "x", false},
+		{"(x: x) y", "let z = y; in z", true},
+		{"f: x: f (f x)", "g: y: g (g y)", true},
+		{"x: 1/3", "y: 2/6", true},
+		{"x: 1", `x: "1"`, false},
+	}
+	for _, tt := range tests {
+		a := mustParse(t, tt.a)
+		b := mustParse(t, tt.b)
+		if got := Hash(a) == Hash(b); got != tt.equal {
+			t.Errorf("Hash(%s) == Hash(%s): got %v, want %v", tt.a, tt.b, got, tt.equal)
+		}
+		if got := AlphaEqual(a, b); got != tt.equal {
+			t.Errorf("AlphaEqual(%s, %s): got %v, want %v", tt.a, tt.b, got, tt.equal)
+		}
+	}
+}
+
+// TestHashDecoded tests that decoded terms hash like their encodings.
+func TestHashDecoded(t *testing.T) {
+	tests := []struct {
+		decoded Term
+		source  string
+	}{
+		{Numeral{N: 2}, "f: x: f (f x)"},
+		{Bool{B: false}, "t: f: f"},
+		{Abs{Arg: "a", Body: Pair{Fst: Var{Name: "a"}, Snd: Var{Name: "b"}}}, "x: s: s x b"},
+	}
+	for _, tt := range tests {
+		encoded := mustParse(t, tt.source)
+		if Hash(tt.decoded) != Hash(encoded) || Digest(tt.decoded) != Digest(encoded) {
+			t.Errorf("%s and %s hash differently", tt.decoded, tt.source)
+		}
+		if !AlphaEqual(tt.decoded, encoded) {
+			t.Errorf("AlphaEqual(%s, %s) = false", tt.decoded, tt.source)
+		}
+	}
+}
+
+func TestInternerSharesSubterms(t *testing.T) {
+	in := NewInterner()
+	term := mustParse(t, "(x: x) ((a: a) (b: b))")
+	interned := in.Intern(term).(App)
+
+	arg := interned.Arg.(App)
+	// All three identity functions collapse to one instance
+	if in.Len() != 3 {
+		t.Errorf("Expected 3 distinct terms (id, id id, whole), got %d", in.Len())
+	}
+	if in.Hits() != 2 {
+		t.Errorf("Expected 2 interning hits, got %d", in.Hits())
+	}
+	if interned.Fun != arg.Fun || arg.Fun != arg.Arg {
+		t.Errorf("Expected identical identity instances, got %v %v %v", interned.Fun, arg.Fun, arg.Arg)
+	}
+}
+
+func TestShareCommonSubterms(t *testing.T) {
+	input := "f (g: g (x: y: x) (x: y: x)) (h: h (a: b: a))"
+	term := mustParse(t, input)
+	shared := ShareCommonSubterms(term, 3)
+
+	let, ok := shared.(Let)
+	if !ok {
+		t.Fatalf("Expected a let binding, got %v", shared)
+	}
+	if !AlphaEqual(let.Val, mustParse(t, "x: y: x")) {
+		t.Errorf("Expected K to be shared, got %v", let.Val)
+	}
+	if _, ok := let.Body.(Let); ok {
+		t.Errorf("Expected a single lifted binding, got %v", shared)
+	}
+
+	plain := deltanet.NewNetwork()
+	ToDeltaNet(term, plain)
+	compact := deltanet.NewNetwork()
+	ToDeltaNet(shared, compact)
+	if compact.NodeCount() >= plain.NodeCount() {
+		t.Errorf("Expected sharing to shrink the net: %d >= %d nodes", compact.NodeCount(), plain.NodeCount())
+	}
+	t.Logf("%s\n  => %s (%d -> %d nodes)", input, shared, plain.NodeCount(), compact.NodeCount())
+}
+
+func TestShareCommonSubtermsRespectsBinders(t *testing.T) {
+	// (y: x y) occurs twice but mentions the enclosing binder x, so lifting
+	// it out of the abstraction would change its meaning.
+	term := mustParse(t, "x: (y: x y) (y: x y)")
+	shared := ShareCommonSubterms(term, 2)
+	if _, ok := shared.(Let); ok {
+		t.Errorf("Subterms mentioning an enclosing binder must not be lifted: %v", shared)
+	}
+	if !AlphaEqual(shared, term) {
+		t.Errorf("Term changed unexpectedly: %v", shared)
+	}
+}
+
+// TestFingerprintCorpus tests that the translations of the generated test
+// corpus have pairwise distinct fingerprints unless their terms are
+// alpha-equivalent, and that fingerprints are stable across translations.
+// Free variable names are kept outside the net, so terms are compared with
+// their free variables renamed in order of appearance.
+func TestFingerprintCorpus(t *testing.T) {
+	files, err := filepath.Glob("../../cmd/gentests/*/*/input.nix")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no corpus files found (%v)", err)
+	}
+	seen := make(map[deltanet.Fingerprint]Term)
+	for _, file := range files {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		term := renameFreeVars(mustParse(t, string(source)))
+		fingerprint := func() deltanet.Fingerprint {
+			net := deltanet.NewNetwork()
+			translation, err := NewTranslator(TranslatorOptions{}).Translate(term, net)
+			if err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+			return net.Fingerprint(translation.Output)
+		}
+		f := fingerprint()
+		if fingerprint() != f {
+			t.Errorf("%s: fingerprint is not stable", file)
+		}
+		if other, ok := seen[f]; ok && !AlphaEqual(other, term) {
+			t.Errorf("%s: fingerprint collides with %s", file, other)
+		}
+		seen[f] = term
+	}
+}
+
+// renameFreeVars renames the free variables of t to <free-0>, <free-1>, ...
+// in order of first appearance.
+func renameFreeVars(t Term) Term {
+	names := make(map[string]string)
+	var walk func(Term, []string) Term
+	walk = func(t Term, bound []string) Term {
+		switch v := t.(type) {
+		case Var:
+			if _, ok := deBruijn(bound, v.Name); ok {
+				return v
+			}
+			if _, ok := names[v.Name]; !ok {
+				names[v.Name] = fmt.Sprintf("<free-%d>", len(names))
+			}
+			return Var{Name: names[v.Name]}
+		case Abs:
+			return Abs{Arg: v.Arg, Body: walk(v.Body, append(bound, v.Arg))}
+		case App:
+			return App{Fun: walk(v.Fun, bound), Arg: walk(v.Arg, bound)}
+		default:
+			return t
+		}
+	}
+	return walk(t, nil)
+}
@@ -0,0 +1,135 @@
+package lambda
+
+import "fmt"
+
+// Inline substitutes let bindings, and the redexes (x: body) val the parser
+// desugars them to, into their bodies where that makes the net smaller
+// without duplicating work: bindings used exactly once, and bindings of
+// closed abstractions (combinators) of at most maxSize nodes, which are
+// copied to every use. Recursive bindings are kept. Substitution
+// renames binders that would capture free variables of the inlined value,
+// so the result is beta-equivalent to t.
+func Inline(t Term, maxSize int) Term {
+	used := make(map[string]bool)
+	collectNames(t, used)
+	in := &inliner{maxSize: maxSize, used: used}
+	return in.term(t)
+}
+
+type inliner struct {
+	maxSize int
+	used    map[string]bool // Every name in the term, for fresh binders
+	fresh   int
+}
+
+func (in *inliner) term(t Term) Term {
+	switch v := t.(type) {
+	case Abs:
+		return Abs{Arg: v.Arg, Body: in.term(v.Body), Span: v.Span}
+	case App:
+		fun, arg := in.term(v.Fun), in.term(v.Arg)
+		// The parser desugars let into this redex.
+		if abs, ok := fun.(Abs); ok && in.inlinable(arg, countUses(abs.Body, abs.Arg)) {
+			return in.substitute(abs.Body, abs.Arg, arg)
+		}
+		return App{Fun: fun, Arg: arg, Span: v.Span}
+	case Let:
+		val, body := in.term(v.Val), in.term(v.Body)
+		if in.inlinable(val, countUses(body, v.Name)) {
+			return in.substitute(body, v.Name, val)
+		}
+		return Let{Name: v.Name, Val: val, Body: body, Span: v.Span}
+	case LetRec:
+		return LetRec{Name: v.Name, Val: in.term(v.Val), Body: in.term(v.Body), Span: v.Span}
+	case Pair:
+		return Pair{Fst: in.term(v.Fst), Snd: in.term(v.Snd)}
+	default:
+		return t
+	}
+}
+
+// inlinable reports whether a value used uses times may be substituted.
+// Unused bindings are left to EliminateDeadCode.
+func (in *inliner) inlinable(val Term, uses int) bool {
+	if uses == 1 {
+		return true
+	}
+	if uses == 0 {
+		return false
+	}
+	if _, ok := val.(Abs); !ok || Size(val) > in.maxSize {
+		return false
+	}
+	free := make(map[string]bool)
+	freeVars(val, nil, free)
+	return len(free) == 0
+}
+
+// substitute replaces the free occurrences of name in t with val.
+func (in *inliner) substitute(t Term, name string, val Term) Term {
+	free := make(map[string]bool)
+	freeVars(val, nil, free)
+	return in.subst(t, name, val, free)
+}
+
+func (in *inliner) subst(t Term, name string, val Term, free map[string]bool) Term {
+	switch v := t.(type) {
+	case Var:
+		if v.Name == name {
+			return val
+		}
+		return v
+	case Abs:
+		if v.Arg == name {
+			return v
+		}
+		arg, body := in.avoid(v.Arg, v.Body, name, free)
+		return Abs{Arg: arg, Body: in.subst(body, name, val, free), Span: v.Span}
+	case App:
+		return App{Fun: in.subst(v.Fun, name, val, free), Arg: in.subst(v.Arg, name, val, free), Span: v.Span}
+	case Let:
+		letVal := in.subst(v.Val, name, val, free)
+		if v.Name == name {
+			return Let{Name: v.Name, Val: letVal, Body: v.Body, Span: v.Span}
+		}
+		bound, body := in.avoid(v.Name, v.Body, name, free)
+		return Let{Name: bound, Val: letVal, Body: in.subst(body, name, val, free), Span: v.Span}
+	case LetRec:
+		if v.Name == name {
+			return v
+		}
+		bound, rec, body := v.Name, v.Val, v.Body
+		if free[bound] && countUses(v.Desugar(), name) > 0 {
+			bound = in.freshName(v.Name)
+			renamed := map[string]bool{bound: true}
+			rec = in.subst(rec, v.Name, Var{Name: bound}, renamed)
+			body = in.subst(body, v.Name, Var{Name: bound}, renamed)
+		}
+		return LetRec{Name: bound, Val: in.subst(rec, name, val, free), Body: in.subst(body, name, val, free), Span: v.Span}
+	case Pair:
+		return Pair{Fst: in.subst(v.Fst, name, val, free), Snd: in.subst(v.Snd, name, val, free)}
+	default:
+		return t
+	}
+}
+
+// avoid renames binder in scope when substituting for name there would let
+// it capture a free variable of the value.
+func (in *inliner) avoid(binder string, scope Term, name string, free map[string]bool) (string, Term) {
+	if !free[binder] || countUses(scope, name) == 0 {
+		return binder, scope
+	}
+	renamed := in.freshName(binder)
+	return renamed, in.subst(scope, binder, Var{Name: renamed}, map[string]bool{renamed: true})
+}
+
+func (in *inliner) freshName(base string) string {
+	for {
+		name := fmt.Sprintf("%s_%d", base, in.fresh)
+		in.fresh++
+		if !in.used[name] {
+			in.used[name] = true
+			return name
+		}
+	}
+}
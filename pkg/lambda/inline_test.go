@@ -0,0 +1,69 @@
+package lambda
+
+import (
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+func TestInline(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// Single use, whatever the value.
+		{"let x = f a in g x", "g (f a)"},
+		// Small combinators are copied to every use.
+		{"let id = x: x in id (id a)", "(x: x) ((x: x) a)"},
+		// Shared values with free variables stay shared.
+		{"let v = f a in g v v", "let v = f a in g v v"},
+		{"let k = x: y in k (k a)", "let k = x: y in k (k a)"},
+		// Too large to copy.
+		{"let s = x: y: z: x z (y z) in s s", "let s = x: y: z: x z (y z) in s s"},
+		// Unused bindings are left alone.
+		{"let x = a in b", "let x = a in b"},
+		// Inlining y into (a: ...) must not capture a.
+		{"let y = a in (a: y)", "(a_0: a)"},
+		{"let y = f a in let a = b in a a y", "let a_0 = b in a_0 a_0 (f a)"},
+	}
+	for _, tt := range tests {
+		got := Inline(mustParse(t, tt.input), 4)
+		if want := mustParse(t, tt.want); !AlphaEqual(got, want) {
+			t.Errorf("Inline(%q) = %s, want %s", tt.input, got, want)
+		}
+	}
+}
+
+// inlineCorpus are programs whose results must not change under Inline.
+var inlineCorpus = []string{
+	"let id = x: x in id id a",
+	"let twice = f: x: f (f x) in let inc = n: f: x: f (n f x) in twice inc (f: x: x) g z",
+	"let k = x: y: x in let v = k a in v b",
+	"let pair = x: y: s: s x y in let fst = p: p (x: y: x) in fst (pair a b)",
+	"let s = x: y: z: x z (y z) in let k = x: y: x in s k k a",
+	"let y = a in (a: y) b",
+}
+
+func TestInlinePreservesResults(t *testing.T) {
+	for _, source := range inlineCorpus {
+		var results [2]string
+		var interactions [2]uint64
+		for i, size := range []int{0, 8} {
+			net := deltanet.NewNetwork()
+			tr := NewTranslator(TranslatorOptions{Inline: size})
+			translation, err := tr.Translate(mustParse(t, source), net)
+			if err != nil {
+				t.Fatal(err)
+			}
+			net.ReduceToNormalForm()
+			results[i] = tr.Readback(net, translation).String()
+			interactions[i] = net.GetStats().TotalReductions
+		}
+		if !AlphaEqual(mustParse(t, results[0]), mustParse(t, results[1])) {
+			t.Errorf("%q: result changed from %s to %s", source, results[0], results[1])
+		}
+		if interactions[1] > interactions[0] {
+			t.Errorf("%q: inlining raised interactions from %d to %d", source, interactions[0], interactions[1])
+		}
+	}
+}
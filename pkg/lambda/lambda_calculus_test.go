@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/deltanet/testsupport"
 )
 
 // TestIdentityFunction tests the simplest lambda term: (λx. x)
@@ -15,8 +16,7 @@ func TestIdentityFunction(t *testing.T) {
 	// Paper: "The only interaction rule in Δ L-Nets is fan annihilation,
 	// which expresses β-reduction."
 
-	n := deltanet.NewNetwork()
-	n.EnableTrace(100)
+	n := deltanet.NewNetworkWith(deltanet.WithTrace(100))
 
 	// Parse (λx. x)
 	term, err := Parse("(x: x)")
@@ -54,8 +54,7 @@ func TestKCombinator(t *testing.T) {
 	// its bound variable to an argument which only uses globally-free variables
 	// produces a subnet which is disjointed from the root."
 
-	n := deltanet.NewNetwork()
-	n.EnableTrace(100)
+	n := deltanet.NewNetworkWith(deltanet.WithTrace(100))
 
 	// Parse K combinator applied twice: (((λx. λy. x) a) b) → a
 	term, err := Parse("(((x: (y: x)) a) b)")
@@ -99,8 +98,7 @@ func TestSCombinator(t *testing.T) {
 	// through and out of the fan's two auxiliary ports, resulting in two exact
 	// copies of the replicator."
 
-	n := deltanet.NewNetwork()
-	n.EnableTrace(100)
+	n := deltanet.NewNetworkWith(deltanet.WithTrace(100))
 
 	// Parse S combinator with arguments: S K K e
 	// Where S = λx.λy.λz.(x z)(y z), K = λa.λb.a
@@ -140,8 +138,7 @@ func TestSharing(t *testing.T) {
 	// a single agent type which allows any number of auxiliary ports, called
 	// a *replicator*."
 
-	n := deltanet.NewNetwork()
-	n.EnableTrace(100)
+	n := deltanet.NewNetworkWith(deltanet.WithTrace(100))
 
 	// Parse (λf. f (f x)) (λy. y)
 	// The argument (λy. y) is shared between two applications
@@ -383,8 +380,7 @@ func TestLetBinding(t *testing.T) {
 // operation is applied which is rendered unnecessary later, and no reduction
 // operation which is necessary is applied more than once."
 func TestComplexSharing(t *testing.T) {
-	n := deltanet.NewNetwork()
-	n.EnableTrace(100)
+	n := deltanet.NewNetworkWith(deltanet.WithTrace(100))
 
 	// Complex term with nested sharing
 	// (λf. (f (f (λx. x)))) (λg. (g (λy. y)))
@@ -423,8 +419,7 @@ func TestNestedApplications(t *testing.T) {
 	// happen as early as possible, minimizing the total number of reductions,
 	// is a sequential leftmost-outermost order"
 
-	n := deltanet.NewNetwork()
-	n.EnableTrace(100)
+	n := deltanet.NewNetworkWith(deltanet.WithTrace(100))
 
 	// Nested applications: (((f a) b) c)
 	term, err := Parse("((((f: f) (a: a)) (b: b)) c)")
@@ -510,8 +505,7 @@ func TestMixedFeatures(t *testing.T) {
 	// Paper: "the Δ-Nets core interaction system decomposes perfectly into
 	// three overlapping subsystems, each analogous to a substructure λ-calculus."
 
-	n := deltanet.NewNetwork()
-	n.EnableTrace(100)
+	n := deltanet.NewNetworkWith(deltanet.WithTrace(100))
 
 	// Mixed term: (λf. λx. f (f x)) (λy. λz. y) a b
 	// Combines: sharing (f used twice), erasure (z unused), free vars (a, b)
@@ -614,8 +608,7 @@ func TestOptimalityExample(t *testing.T) {
 	// Paper: "The Delta-Nets algorithm solves the longstanding enigma of
 	// optimal λ-calculi reduction with groundbreaking clarity."
 
-	n := deltanet.NewNetwork()
-	n.EnableTrace(100)
+	n := deltanet.NewNetworkWith(deltanet.WithTrace(100))
 
 	// Complex term from paper demonstrating optimality
 	// ((λg. g (g λx. x)) (λh. (λf. f (f λz. z)) (λw. h (w λy. y))))
@@ -681,3 +674,35 @@ func normalizeForComparison(t Term) string {
 	}
 	return fmt.Sprintf("%s", walk(t))
 }
+
+// BenchmarkChurchArithmetic measures the reduction of Church arithmetic,
+// which creates and discards wires at every interaction, with and without
+// reusing them (see deltanet.WithWirePool) and slab allocation.
+func BenchmarkChurchArithmetic(b *testing.B) {
+	terms := []struct{ name, source string }{
+		// 100 successors of zero: about 20000 interactions.
+		{"succ", "(n: n (m: f: x: f (m f x)) (f: x: x)) (" + testsupport.ChurchSource(100) + ")"},
+		{"mul", "(m: n: f: m (n f)) (" + testsupport.ChurchSource(100) + ") (" + testsupport.ChurchSource(100) + ")"},
+	}
+	for _, tt := range terms {
+		term, err := Parse(tt.source)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, arena := range []bool{true, false} {
+			for _, pool := range []bool{false, true} {
+				b.Run(fmt.Sprintf("%s/arena=%v/pool=%v", tt.name, arena, pool), func(b *testing.B) {
+					b.ReportAllocs()
+					for i := 0; i < b.N; i++ {
+						net := deltanet.NewNetworkWith(deltanet.WithWorkers(1), deltanet.WithArena(arena), deltanet.WithWirePool(pool))
+						if _, err := NewTranslator(TranslatorOptions{}).Translate(term, net); err != nil {
+							b.Fatal(err)
+						}
+						net.ReduceToNormalForm()
+						net.Close()
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package lambda
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+func TestParseLetRec(t *testing.T) {
+	single, ok := mustParse(t, "letrec f = x: f x in f").(LetRec)
+	if !ok || single.Name != "f" {
+		t.Fatalf("single binding: got %s, want letrec f", single)
+	}
+
+	// _rec is taken, so the group tuple gets another name.
+	group, ok := mustParse(t, "letrec f = g; g = _rec; in f").(LetRec)
+	if !ok {
+		t.Fatalf("group: got %T, want LetRec", group)
+	}
+	if group.Name == "f" || group.Name == "g" || group.Name == "_rec" {
+		t.Errorf("group: tuple bound to %q, which is used by the term", group.Name)
+	}
+	if uses := countUses(group.Body, group.Name); uses != 2 {
+		t.Errorf("group body: %d projections of %s, want 2", uses, group.Name)
+	}
+}
+
+// TestMutualRecursion evaluates even/odd defined in terms of each other.
+// The Y unfolding left behind once a branch is chosen never stops on its
+// own, so the net is compacted to drop it.
+func TestMutualRecursion(t *testing.T) {
+	const prelude = `let
+  true = t: f: t;
+  false = t: f: f;
+  isZero = n: n (x: false) true;
+  pred = n: f: x: n (g: h: h (g f)) (u: x) (u: u);
+in letrec
+  even = n: isZero n true (odd (pred n));
+  odd = n: isZero n false (even (pred n));
+in `
+	for n := 0; n <= 4; n++ {
+		for _, fn := range []string{"even", "odd"} {
+			input := fmt.Sprintf("%s%s (%s)", prelude, fn, Numeral{N: n}.Desugar())
+			net := deltanet.NewNetworkWith(deltanet.WithMemoryThreshold(5000))
+			tr := NewTranslator(TranslatorOptions{})
+			translation, err := tr.Translate(mustParse(t, input), net)
+			if err != nil {
+				t.Fatalf("%s %d: %v", fn, n, err)
+			}
+			net.ReduceAll()
+
+			got := tr.Readback(net, translation)
+			want := Bool{B: (n%2 == 0) == (fn == "even")}.Desugar()
+			if !AlphaEqual(got, want) {
+				t.Errorf("%s %d = %s, want %s", fn, n, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package lambda
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// ParseLiteral parses the surface syntax of a numeric literal:
+//
+//	42       int (*big.Int when it does not fit)
+//	1.5      float64, also 2e10 and 1.5e-3
+//	1/3      *big.Rat, kept in lowest terms
+//
+// A leading '-' is accepted so that printed negative values parse back.
+func ParseLiteral(s string) (interface{}, error) {
+	switch {
+	case strings.Contains(s, "/"):
+		num, den, _ := strings.Cut(s, "/")
+		if !isIntLiteral(num) || !isIntLiteral(den) || strings.HasPrefix(den, "-") {
+			return nil, fmt.Errorf("invalid rational literal %q", s)
+		}
+		r, ok := new(big.Rat).SetString(s)
+		if !ok {
+			return nil, fmt.Errorf("invalid rational literal %q", s)
+		}
+		return r, nil
+	case strings.ContainsAny(s, ".eE"):
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil || strings.ContainsAny(s, "xXpP_") || strings.HasSuffix(s, ".") || strings.HasPrefix(strings.TrimPrefix(s, "-"), ".") {
+			return nil, fmt.Errorf("invalid float literal %q", s)
+		}
+		return f, nil
+	default:
+		if !isIntLiteral(s) {
+			return nil, fmt.Errorf("invalid integer literal %q", s)
+		}
+		if i, err := strconv.Atoi(s); err == nil {
+			return i, nil
+		}
+		b, _ := new(big.Int).SetString(s, 10)
+		return b, nil
+	}
+}
+
+func isIntLiteral(s string) bool {
+	s = strings.TrimPrefix(s, "-")
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// FormatLiteral prints a Data value in the surface syntax accepted by
+// ParseLiteral. Floats always carry a '.' or exponent and rationals always
+// carry a '/', so the printed form parses back to the same type.
+func FormatLiteral(v interface{}) string {
+	switch x := v.(type) {
+	case int:
+		return strconv.Itoa(x)
+	case *big.Int:
+		return x.String()
+	case float64:
+		s := strconv.FormatFloat(x, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eIN") {
+			s += ".0"
+		}
+		return s
+	case *big.Rat:
+		return x.String()
+	case string:
+		return strconv.Quote(x)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
@@ -0,0 +1,53 @@
+package lambda
+
+import (
+	"math/big"
+	"testing"
+)
+
+// TestLiteralRoundtrip tests that printed literals parse back to the same
+// value and type.
+func TestLiteralRoundtrip(t *testing.T) {
+	huge, _ := new(big.Int).SetString("100000000000000000000", 10)
+	tests := []struct {
+		input string
+		value interface{}
+		print string
+	}{
+		{"42", 42, "42"},
+		{"-7", -7, "-7"},
+		{"100000000000000000000", huge, "100000000000000000000"},
+		{"1.5", 1.5, "1.5"},
+		{"2.0", 2.0, "2.0"},
+		{"1e3", 1000.0, "1000.0"},
+		{"1.5e-3", 0.0015, "0.0015"},
+		{"2/6", big.NewRat(1, 3), "1/3"},
+		{"4/2", big.NewRat(2, 1), "2/1"},
+	}
+	for _, tt := range tests {
+		v, err := ParseLiteral(tt.input)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.input, err)
+			continue
+		}
+		if FormatLiteral(v) != tt.print {
+			t.Errorf("%s: printed %s, want %s", tt.input, FormatLiteral(v), tt.print)
+		}
+		if FormatLiteral(v) != FormatLiteral(tt.value) {
+			t.Errorf("%s: parsed %T %v, want %T %v", tt.input, v, v, tt.value, tt.value)
+		}
+		back, err := ParseLiteral(FormatLiteral(v))
+		if err != nil || FormatLiteral(back) != FormatLiteral(v) {
+			t.Errorf("%s: reparse gave %v (%v)", tt.input, back, err)
+		}
+	}
+}
+
+// TestLiteralErrors tests rejection of malformed literals.
+func TestLiteralErrors(t *testing.T) {
+	for _, input := range []string{"", "-", "1.", ".5", "1/", "1/-2", "1/0", "0x10", "1_000", "abc"} {
+		if v, err := ParseLiteral(input); err == nil {
+			t.Errorf("%q: expected error, got %v", input, v)
+		}
+	}
+}
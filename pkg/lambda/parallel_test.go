@@ -0,0 +1,38 @@
+package lambda
+
+import (
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// TestParallelConfluence tests that parallel reduction reaches the same
+// result, with the same number of interactions, as sequential reduction.
+func TestParallelConfluence(t *testing.T) {
+	inputs := []string{
+		"(f: x: f (f x)) (f: x: f (f x))",
+		"(m: n: f: x: m f (n f x)) (f: x: f (f x)) (f: x: f (f (f x)))",
+		"(m: n: f: m (n f)) (f: x: f (f x)) (f: x: f (f (f x)))",
+		"(x: y: x) (a b) ((z: z z) c)",
+		"(n: n (x: x) y) ((m: f: x: m f (m f x)) (f: x: f (f x)))",
+	}
+	reduce := func(input string, opts ...deltanet.Option) (string, uint64) {
+		net := deltanet.NewNetworkWith(opts...)
+		tr := NewTranslator(TranslatorOptions{})
+		translation, err := tr.Translate(mustParse(t, input), net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		net.ReduceAll()
+		return tr.Readback(net, translation).String(), net.GetStats().TotalReductions
+	}
+	for _, input := range inputs {
+		want, wantOps := reduce(input, deltanet.WithWorkers(1))
+		for i := 0; i < 20; i++ {
+			got, ops := reduce(input, deltanet.WithWorkers(8), deltanet.WithParallel())
+			if got != want || ops != wantOps {
+				t.Fatalf("%s: parallel run %d got %s in %d interactions, sequential %s in %d", input, i, got, ops, want, wantOps)
+			}
+		}
+	}
+}
@@ -2,6 +2,8 @@ package lambda
 
 import (
 	"fmt"
+	"sort"
+	"strconv"
 	"unicode"
 )
 
@@ -16,28 +18,47 @@ const (
 	TokenLParen
 	TokenRParen
 	TokenLet
+	TokenLetRec
 	TokenIn
+	TokenNumber
+	TokenString
+	TokenPerform // !Name, Literal holding the effect name
 )
 
 type Token struct {
 	Type    TokenType
 	Literal string
+	Start   int // Byte offset of the first character
+	End     int // Byte offset after the last character
 }
 
 type Parser struct {
 	input   string
 	pos     int
 	current Token
+	prevEnd int   // End offset of the last consumed token
+	lines   []int // Byte offsets at which each line starts
 }
 
 func NewParser(input string) *Parser {
-	p := &Parser{input: input}
+	p := &Parser{input: input, lines: []int{0}}
+	for i := 0; i < len(input); i++ {
+		if input[i] == '\n' {
+			p.lines = append(p.lines, i+1)
+		}
+	}
 	p.next()
 	return p
 }
 
 func (p *Parser) next() {
+	p.prevEnd = p.current.End
 	p.skipWhitespace()
+	start := p.pos
+	defer func() {
+		p.current.Start = start
+		p.current.End = p.pos
+	}()
 	if p.pos >= len(p.input) {
 		p.current = Token{Type: TokenEOF}
 		return
@@ -46,18 +67,61 @@ func (p *Parser) next() {
 	ch := p.input[p.pos]
 	switch {
 	case isLetter(ch):
-		start := p.pos
 		for p.pos < len(p.input) && (isLetter(p.input[p.pos]) || isDigit(p.input[p.pos])) {
 			p.pos++
 		}
 		lit := p.input[start:p.pos]
 		if lit == "let" {
 			p.current = Token{Type: TokenLet, Literal: lit}
+		} else if lit == "letrec" {
+			p.current = Token{Type: TokenLetRec, Literal: lit}
 		} else if lit == "in" {
 			p.current = Token{Type: TokenIn, Literal: lit}
 		} else {
 			p.current = Token{Type: TokenIdent, Literal: lit}
 		}
+	case isDigit(ch) || (ch == '-' && p.pos+1 < len(p.input) && isDigit(p.input[p.pos+1])):
+		p.pos++
+		p.scanDigits()
+		if p.pos+1 < len(p.input) && p.input[p.pos] == '.' && isDigit(p.input[p.pos+1]) {
+			p.pos++
+			p.scanDigits()
+		}
+		if p.pos < len(p.input) && (p.input[p.pos] == 'e' || p.input[p.pos] == 'E') {
+			exp := p.pos + 1
+			if exp < len(p.input) && (p.input[exp] == '+' || p.input[exp] == '-') {
+				exp++
+			}
+			if exp < len(p.input) && isDigit(p.input[exp]) {
+				p.pos = exp
+				p.scanDigits()
+			}
+		}
+		if p.pos+1 < len(p.input) && p.input[p.pos] == '/' && isDigit(p.input[p.pos+1]) {
+			p.pos++
+			p.scanDigits()
+		}
+		p.current = Token{Type: TokenNumber, Literal: p.input[start:p.pos]}
+	case ch == '"':
+		p.pos++
+		for p.pos < len(p.input) && p.input[p.pos] != '"' {
+			if p.input[p.pos] == '\\' {
+				p.pos++
+			}
+			p.pos++
+		}
+		if p.pos < len(p.input) {
+			p.pos++ // closing quote
+		}
+		p.current = Token{Type: TokenString, Literal: p.input[start:p.pos]}
+	case ch == '!' && p.pos+1 < len(p.input) && isLetter(p.input[p.pos+1]):
+		// Effect names are dot-separated identifiers, e.g. !State.Get
+		p.pos++
+		for p.pos < len(p.input) && (isLetter(p.input[p.pos]) || isDigit(p.input[p.pos]) ||
+			(p.input[p.pos] == '.' && p.pos+1 < len(p.input) && isLetter(p.input[p.pos+1]))) {
+			p.pos++
+		}
+		p.current = Token{Type: TokenPerform, Literal: p.input[start+1 : p.pos]}
 	case ch == ':':
 		p.current = Token{Type: TokenColon, Literal: ":"}
 		p.pos++
@@ -81,6 +145,12 @@ func (p *Parser) next() {
 	}
 }
 
+func (p *Parser) scanDigits() {
+	for p.pos < len(p.input) && isDigit(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
 func (p *Parser) skipWhitespace() {
 	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
 		p.pos++
@@ -99,9 +169,9 @@ func (p *Parser) Parse() (Term, error) {
 	return p.parseTerm()
 }
 
-// Term ::= Abs | Let | App
+// Term ::= Abs | Let | LetRec | App
 func (p *Parser) parseTerm() (Term, error) {
-	if p.current.Type == TokenLet {
+	if p.current.Type == TokenLet || p.current.Type == TokenLetRec {
 		return p.parseLet()
 	}
 
@@ -128,6 +198,7 @@ func (p *Parser) parseTerm() (Term, error) {
 		// Lookahead
 		savePos := p.pos
 		saveTok := p.current
+		savePrev := p.prevEnd
 
 		// Peek next
 		p.next()
@@ -139,18 +210,20 @@ func (p *Parser) parseTerm() (Term, error) {
 			if err != nil {
 				return nil, err
 			}
-			return Abs{Arg: arg, Body: body}, nil
+			return Abs{Arg: arg, Body: body, Span: p.spanFrom(saveTok.Start)}, nil
 		}
 
 		// Not an abstraction, backtrack
 		p.pos = savePos
 		p.current = saveTok
+		p.prevEnd = savePrev
 	}
 
 	return p.parseApp()
 }
 
 func (p *Parser) parseApp() (Term, error) {
+	start := p.current.Start
 	left, err := p.parseAtom()
 	if err != nil {
 		return nil, err
@@ -170,6 +243,7 @@ func (p *Parser) parseApp() (Term, error) {
 			// Check for colon
 			savePos := p.pos
 			saveTok := p.current
+			savePrev := p.prevEnd
 			p.next()
 			if p.current.Type == TokenColon {
 				// It's an abstraction `arg: body`
@@ -180,7 +254,8 @@ func (p *Parser) parseApp() (Term, error) {
 				if err != nil {
 					return nil, err
 				}
-				left = App{Fun: left, Arg: Abs{Arg: argName, Body: body}}
+				abs := Abs{Arg: argName, Body: body, Span: p.spanFrom(saveTok.Start)}
+				left = App{Fun: left, Arg: abs, Span: p.spanFrom(start)}
 				// After parsing an abstraction (which consumes everything to the right),
 				// we are done with this application chain?
 				// Yes, because `x y: z a` -> `x (y: z a)`.
@@ -189,6 +264,7 @@ func (p *Parser) parseApp() (Term, error) {
 			// Backtrack
 			p.pos = savePos
 			p.current = saveTok
+			p.prevEnd = savePrev
 		}
 
 		right, err := p.parseAtom()
@@ -196,7 +272,7 @@ func (p *Parser) parseApp() (Term, error) {
 			// If we can't parse an atom, maybe we are done
 			break
 		}
-		left = App{Fun: left, Arg: right}
+		left = App{Fun: left, Arg: right, Span: p.spanFrom(start)}
 	}
 
 	return left, nil
@@ -205,9 +281,27 @@ func (p *Parser) parseApp() (Term, error) {
 func (p *Parser) parseAtom() (Term, error) {
 	switch p.current.Type {
 	case TokenIdent:
-		name := p.current.Literal
+		tok := p.current
 		p.next()
-		return Var{Name: name}, nil
+		return Var{Name: tok.Literal, Span: p.spanFrom(tok.Start)}, nil
+	case TokenNumber:
+		tok := p.current
+		value, err := ParseLiteral(tok.Literal)
+		if err != nil {
+			return nil, err
+		}
+		p.next()
+		return Lit{Value: value, Span: p.spanFrom(tok.Start)}, nil
+	case TokenString:
+		tok := p.current
+		value, err := strconv.Unquote(tok.Literal)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string literal %s", tok.Literal)
+		}
+		p.next()
+		return Lit{Value: value, Span: p.spanFrom(tok.Start)}, nil
+	case TokenPerform:
+		return p.parsePerform()
 	case TokenLParen:
 		p.next()
 		term, err := p.parseTerm()
@@ -224,15 +318,33 @@ func (p *Parser) parseAtom() (Term, error) {
 	}
 }
 
+// parsePerform parses an effect, !Name, and the literal right after it as
+// its payload, if there is one.
+func (p *Parser) parsePerform() (Term, error) {
+	tok := p.current
+	p.next()
+	perform := Perform{Name: tok.Literal}
+	switch p.current.Type {
+	case TokenNumber, TokenString:
+		payload, err := p.parseAtom()
+		if err != nil {
+			return nil, err
+		}
+		perform.Payload = payload.(Lit).Value
+	}
+	perform.Span = p.spanFrom(tok.Start)
+	return perform, nil
+}
+
+// parseLet parses let and letrec. The bindings of a letrec are all in
+// scope in every value and in the body (see MutualLetRec).
 func (p *Parser) parseLet() (Term, error) {
-	p.next() // consume 'let'
+	start := p.current.Start
+	rec := p.current.Type == TokenLetRec
+	p.next() // consume 'let' or 'letrec'
 
 	// Parse bindings: x = M; y = N; ...
-	type binding struct {
-		name string
-		val  Term
-	}
-	var bindings []binding
+	var bindings []Binding
 
 	for {
 		if p.current.Type != TokenIdent {
@@ -251,7 +363,7 @@ func (p *Parser) parseLet() (Term, error) {
 			return nil, err
 		}
 
-		bindings = append(bindings, binding{name, val})
+		bindings = append(bindings, Binding{Name: name, Val: val})
 
 		if p.current.Type == TokenSemicolon {
 			p.next()
@@ -274,20 +386,38 @@ func (p *Parser) parseLet() (Term, error) {
 		return nil, err
 	}
 
+	span := p.spanFrom(start)
+	if rec {
+		return MutualLetRec(bindings, body, span), nil
+	}
+
 	// Desugar: let x=M; y=N in B -> (\x. (\y. B) N) M
 	// We iterate backwards
 	term := body
 	for i := len(bindings) - 1; i >= 0; i-- {
 		b := bindings[i]
 		term = App{
-			Fun: Abs{Arg: b.name, Body: term},
-			Arg: b.val,
+			Fun:  Abs{Arg: b.Name, Body: term, Span: span},
+			Arg:  b.Val,
+			Span: span,
 		}
 	}
 
 	return term, nil
 }
 
+// spanFrom returns the span from offset start to the end of the last
+// consumed token.
+func (p *Parser) spanFrom(start int) Span {
+	return Span{Start: p.position(start), End: p.position(p.prevEnd)}
+}
+
+// position converts a byte offset into a line and column.
+func (p *Parser) position(offset int) Pos {
+	line := sort.Search(len(p.lines), func(i int) bool { return p.lines[i] > offset })
+	return Pos{Offset: offset, Line: line, Col: offset - p.lines[line-1] + 1}
+}
+
 // Parse parses a lambda term from a string.
 func Parse(input string) (Term, error) {
 	p := NewParser(input)
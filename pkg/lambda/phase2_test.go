@@ -0,0 +1,180 @@
+package lambda
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// phase2Cases is how many generated terms TestPhase2Differential checks,
+// and phase2MaxInteractions bounds each reduction.
+const (
+	phase2Cases           = 40
+	phase2MaxInteractions = 100000
+)
+
+// genTerm generates a random term of about size nodes whose variables are
+// bound in scope or are the free variable c.
+func genTerm(r *rand.Rand, scope []string, size int) Term {
+	if size <= 1 || (len(scope) > 0 && r.Intn(4) == 0) {
+		if len(scope) == 0 {
+			return Var{Name: "c"}
+		}
+		return Var{Name: scope[r.Intn(len(scope))]}
+	}
+	if r.Intn(2) == 0 {
+		name := fmt.Sprintf("v%d", len(scope))
+		return Abs{Arg: name, Body: genTerm(r, append(scope[:len(scope):len(scope)], name), size-1)}
+	}
+	k := 1 + r.Intn(size-1)
+	return App{Fun: genTerm(r, scope, k), Arg: genTerm(r, scope, size-k)}
+}
+
+// normalize computes the normal form of t by leftmost-outermost
+// substitution, the reference the nets are checked against. It gives up
+// after fuel β-steps.
+func normalize(t Term, fuel int) (Term, bool) {
+	used := make(map[string]bool)
+	collectNames(t, used)
+	in := &inliner{used: used}
+	var norm func(Term) (Term, bool)
+	norm = func(t Term) (Term, bool) {
+		switch v := t.(type) {
+		case Abs:
+			body, ok := norm(v.Body)
+			return Abs{Arg: v.Arg, Body: body}, ok
+		case App:
+			if abs, ok := v.Fun.(Abs); ok {
+				if fuel--; fuel < 0 {
+					return t, false
+				}
+				return norm(in.substitute(abs.Body, abs.Arg, v.Arg))
+			}
+			fun, ok := norm(v.Fun)
+			if !ok {
+				return t, false
+			}
+			if _, isAbs := fun.(Abs); isAbs {
+				return norm(App{Fun: fun, Arg: v.Arg})
+			}
+			arg, ok := norm(v.Arg)
+			return App{Fun: fun, Arg: arg}, ok
+		default:
+			return t, true
+		}
+	}
+	return norm(t)
+}
+
+// expandLets substitutes the let bindings readback emits for shared
+// subterms, so results compare with the reference normal form.
+func expandLets(t Term) Term {
+	used := make(map[string]bool)
+	collectNames(t, used)
+	in := &inliner{used: used}
+	var expand func(Term) Term
+	expand = func(t Term) Term {
+		switch v := t.(type) {
+		case Let:
+			return expand(in.substitute(v.Body, v.Name, v.Val))
+		case Abs:
+			return Abs{Arg: v.Arg, Body: expand(v.Body)}
+		case App:
+			return App{Fun: expand(v.Fun), Arg: expand(v.Arg)}
+		default:
+			return t
+		}
+	}
+	return expand(t)
+}
+
+// phase2Terms generates terms with a normal form whose reduction replicates
+// auxiliary fans in phase 2, with their normal forms and interaction counts.
+func phase2Terms(t *testing.T) (terms, normal []Term, ops []uint64) {
+	r := rand.New(rand.NewSource(1))
+	tr := NewTranslator(TranslatorOptions{})
+	for tries := 0; len(terms) < phase2Cases && tries < 5000; tries++ {
+		term := genTerm(r, nil, 4+r.Intn(10))
+		want, ok := normalize(term, 200)
+		if !ok {
+			continue
+		}
+		net := deltanet.NewNetworkWith(deltanet.WithMaxInteractions(phase2MaxInteractions))
+		if _, err := tr.Translate(term, net); err != nil {
+			t.Fatalf("%s: %v", term, err)
+		}
+		if err := net.ReduceToNormalFormContext(t.Context()); err != nil {
+			// Diverging arguments that are erased in the end may still be
+			// reduced for a while; those are not what this suite covers.
+			continue
+		}
+		if stats := net.GetStats(); stats.AuxFanRep > 0 {
+			terms = append(terms, term)
+			normal = append(normal, want)
+			ops = append(ops, stats.TotalReductions)
+		}
+	}
+	if len(terms) < phase2Cases {
+		t.Fatalf("generated only %d terms needing aux fan replication", len(terms))
+	}
+	return terms, normal, ops
+}
+
+// TestPhase2Differential tests that terms whose normal forms need aux fan
+// replication read back as their reference normal forms, both when reduced
+// at once and when halted in phase 2, snapshotted and continued from a
+// restored copy.
+func TestPhase2Differential(t *testing.T) {
+	terms, normal, ops := phase2Terms(t)
+	tr := NewTranslator(TranslatorOptions{})
+	for i, term := range terms {
+		net := deltanet.NewNetwork()
+		translation, err := tr.Translate(term, net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		net.ReduceToNormalForm()
+		if got := expandLets(tr.Readback(net, translation)); !AlphaEqual(got, normal[i]) {
+			t.Errorf("%s: got %s, want %s", term, got, normal[i])
+			continue
+		}
+
+		// Halt at each interaction of phase 2 in turn.
+		for limit := uint64(1); limit < ops[i]; limit++ {
+			net := deltanet.NewNetworkWith(deltanet.WithMaxInteractions(limit))
+			translation, err := tr.Translate(term, net)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = net.ReduceToNormalFormContext(t.Context())
+			if !errors.Is(err, deltanet.ErrReductionLimit) {
+				t.Fatalf("%s: halted at %d with %v", term, limit, err)
+			}
+			if net.Phase() != 2 {
+				continue
+			}
+			snap := net.Snapshot()
+
+			restored := deltanet.NewNetwork()
+			restored.Restore(snap)
+			again := &Translation{Output: restored.NodeByID(translation.Output.ID()), VarNames: translation.VarNames}
+			if err := restored.ReduceToNormalFormContext(t.Context()); err != nil {
+				t.Fatalf("%s: restored at %d: %v", term, limit, err)
+			}
+			if got := expandLets(tr.Readback(restored, again)); !AlphaEqual(got, normal[i]) {
+				t.Errorf("%s: restored at interaction %d: got %s, want %s", term, limit, got, normal[i])
+			}
+
+			net.SetMaxInteractions(0)
+			if err := net.ReduceToNormalFormContext(t.Context()); err != nil {
+				t.Fatalf("%s: resumed at %d: %v", term, limit, err)
+			}
+			if got := expandLets(tr.Readback(net, translation)); !AlphaEqual(got, normal[i]) {
+				t.Errorf("%s: resumed at interaction %d: got %s, want %s", term, limit, got, normal[i])
+			}
+		}
+	}
+}
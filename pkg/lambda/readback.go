@@ -0,0 +1,573 @@
+package lambda
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// repFrame records the aux port through which readback entered a replicator.
+// When a replicator of the same level is later entered at its principal port,
+// readback leaves through the matching aux port, selecting the right copy of
+// a shared structure. Frames form a persistent stack so that sibling branches
+// (App function and argument) each see the context of their parent. The
+// reader hash-conses frames, so equal stacks are the same pointer.
+type repFrame struct {
+	level int
+	port  int
+	next  *repFrame
+}
+
+// frameKey identifies a frame by its contents.
+type frameKey struct {
+	level, port int
+	next        *repFrame
+}
+
+// frame returns the frame for level and port on top of next, the same
+// pointer for the same arguments.
+func (r *reader) frame(level, port int, next *repFrame) *repFrame {
+	if r.mu != nil {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+	}
+	key := frameKey{level, port, next}
+	f, ok := r.frames[key]
+	if !ok {
+		f = &repFrame{level: level, port: port, next: next}
+		r.frames[key] = f
+	}
+	return f
+}
+
+// pop removes the most recent frame of f with the given level.
+func (r *reader) pop(f *repFrame, level int) (int, *repFrame, bool) {
+	if f == nil {
+		return 0, nil, false
+	}
+	if f.level == level {
+		return f.port, f.next, true
+	}
+	port, rest, ok := r.pop(f.next, level)
+	if !ok {
+		return 0, f, false
+	}
+	return port, r.frame(f.level, f.port, rest), true
+}
+
+func (f *repFrame) String() string {
+	var sb strings.Builder
+	for ; f != nil; f = f.next {
+		fmt.Fprintf(&sb, "%d.%d/", f.level, f.port)
+	}
+	return sb.String()
+}
+
+// sharedRead is a subterm reached through a replicator. Uses are replaced by
+// a placeholder variable until the readback decides whether to inline it or
+// bind it with a let.
+type sharedRead struct {
+	placeholder string
+	rep         uint64
+	value       Term
+}
+
+// cycle is assigned to a position whose readback revisits itself.
+type cycle struct {
+	name string
+}
+
+// position is a port entered by readback with a replicator stack.
+type position struct {
+	node  uint64
+	port  int
+	stack *repFrame
+}
+
+type reader struct {
+	net      *deltanet.Network
+	varNames map[uint64]string
+	bindings map[uint64]string // Key: Node ID of the binder (Fan), Value: Name
+	visiting map[position]*cycle
+	frames   map[frameKey]*repFrame // See frame
+	mu       *sync.Mutex            // Guards frames when goroutines share the reader
+	shares   []*sharedRead
+	nameGen  int
+	free     map[string]bool // Names of free variables, never generated
+	binders  map[string]bool // Names handed out by nextName
+	// Lazy readback: active pairs met while reading are reduced in place,
+	// spending at most maxSteps interactions in total.
+	steps    uint64
+	maxSteps uint64
+	onPath   map[uint64]int // Nodes whose readback is in progress
+	// Partial readback: nodes in an active pair are read as Redex.
+	partial bool
+	// The network's logger when it logs debug messages, otherwise nil.
+	log *slog.Logger
+}
+
+func newReader(net *deltanet.Network, varNames map[uint64]string) *reader {
+	r := &reader{
+		net:      net,
+		varNames: varNames,
+		bindings: make(map[uint64]string),
+		visiting: make(map[position]*cycle),
+		frames:   make(map[frameKey]*repFrame),
+		onPath:   make(map[uint64]int),
+		free:     make(map[string]bool, len(varNames)),
+		binders:  make(map[string]bool),
+	}
+	for _, name := range varNames {
+		r.free[name] = true
+	}
+	if l := net.Logger(); l.Enabled(context.Background(), slog.LevelDebug) {
+		r.log = l
+	}
+	return r
+}
+
+// FromDeltaNet reconstructs a lambda term from the network.
+// varNames maps Var node IDs to their original variable names.
+//
+// Subterms shared by replicators are read once and bound with a let when
+// they are used more than once; positions that reach themselves again are
+// bound with a letrec.
+//
+// Deprecated: use Translator.Readback, which reads from the output node
+// attached by Translate.
+func FromDeltaNet(net *deltanet.Network, rootNode deltanet.Node, rootPort int, varNames map[uint64]string) Term {
+	r := newReader(net, varNames)
+	term := r.readTerm(rootNode, rootPort, nil)
+	return r.introduceLets(term)
+}
+
+// FromDeltaNetPartial is FromDeltaNet for nets that are not fully reduced.
+// A node whose principal port faces another's is read as a Redex marker
+// instead of being descended into, so an intermediate net reads back as
+// quickly as a normal form and shows where reduction is still pending.
+func FromDeltaNetPartial(net *deltanet.Network, rootNode deltanet.Node, rootPort int, varNames map[uint64]string) Term {
+	r := newReader(net, varNames)
+	r.partial = true
+	term := r.readTerm(rootNode, rootPort, nil)
+	return r.introduceLets(term)
+}
+
+// readbackLazy reads the term connected to (node, port). Whenever the
+// readback reaches a node whose principal port is part of an active pair,
+// that pair is reduced first, so a net left partially reduced (e.g. by
+// ReduceWithLimit) reads back as far as maxSteps interactions allow.
+func readbackLazy(net *deltanet.Network, node deltanet.Node, port int, varNames map[uint64]string, maxSteps uint64) Term {
+	r := newReader(net, varNames)
+	r.maxSteps = maxSteps
+	term := r.readLink(node, port, nil)
+	return r.introduceLets(term)
+}
+
+// nextName returns a fresh binder name. Names of free variables are
+// skipped so that no binder captures them.
+func (r *reader) nextName() string {
+	for {
+		name := fmt.Sprintf("x%d", r.nameGen)
+		r.nameGen++
+		if !r.free[name] {
+			r.binders[name] = true
+			return name
+		}
+	}
+}
+
+// logicalPort undoes the Phase 2 fan rotation.
+// Phys 0 -> Log 1
+// Phys 1 -> Log 2
+// Phys 2 -> Log 0
+func (r *reader) logicalPort(node deltanet.Node, port int) int {
+	if r.net.Phase() != 2 || node.Type() != deltanet.NodeTypeFan {
+		return port
+	}
+	return (port + 1) % 3
+}
+
+// physicalPort maps a logical fan port back to the physical port.
+func (r *reader) physicalPort(logical int) int {
+	if r.net.Phase() != 2 {
+		return logical
+	}
+	return (logical + 2) % 3
+}
+
+func (r *reader) readTerm(node deltanet.Node, port int, stack *repFrame) Term {
+	if node == nil {
+		return Var{Name: "<nil>"}
+	}
+	if r.partial {
+		if peer := r.redexPeer(node); peer != nil {
+			return Redex{A: node.ID(), B: peer.ID()}
+		}
+	}
+
+	key := position{node.ID(), port, stack}
+	if c, ok := r.visiting[key]; ok {
+		if c.name == "" {
+			c.name = r.nextName()
+		}
+		if r.log != nil {
+			r.log.Debug("readback: revisit", "id", node.ID(), "port", port, "stack", stack.String(), "letrec", c.name)
+		}
+		return Var{Name: c.name}
+	}
+	c := &cycle{}
+	r.visiting[key] = c
+	defer delete(r.visiting, key)
+	r.onPath[node.ID()]++
+	defer func() { r.onPath[node.ID()]-- }()
+
+	if r.log != nil {
+		r.log.Debug("readback: read", "type", node.Type(), "id", node.ID(), "port", port, "phase", r.net.Phase(), "stack", stack.String())
+	}
+
+	term := r.readNode(node, port, stack)
+	if c.name != "" {
+		return LetRec{Name: c.name, Val: term, Body: Var{Name: c.name}}
+	}
+	return term
+}
+
+func (r *reader) readNode(node deltanet.Node, port int, stack *repFrame) Term {
+	switch node.Type() {
+	case deltanet.NodeTypeFan:
+		switch r.logicalPort(node, port) {
+		case 0:
+			// Entering at the output of an abstraction.
+			name := r.nextName()
+			old, shadowed := r.bindings[node.ID()]
+			r.bindings[node.ID()] = name
+			body := r.readLink(node, r.physicalPort(1), stack)
+			if shadowed {
+				r.bindings[node.ID()] = old
+			} else {
+				delete(r.bindings, node.ID())
+			}
+			return Abs{Arg: name, Body: body}
+
+		case 1:
+			// Entering at the result of an application.
+			fun := r.readLink(node, r.physicalPort(0), stack)
+			arg := r.readLink(node, r.physicalPort(2), stack)
+			return App{Fun: fun, Arg: arg}
+
+		default:
+			// Entering at the variable port of an abstraction.
+			if name, ok := r.bindings[node.ID()]; ok {
+				return Var{Name: name}
+			}
+			return Var{Name: "<binding>"}
+		}
+
+	case deltanet.NodeTypeReplicator:
+		if r.log != nil {
+			r.log.Debug("readback: replicator", "id", node.ID(), "deltas", node.Deltas(), "level", node.Level(), "port", port)
+		}
+		if port > 0 {
+			// A use of a shared value: continue towards its source.
+			value := r.readLink(node, 0, r.frame(node.Level(), port, stack))
+			if !r.isShareable(node, value) {
+				return value
+			}
+			share := &sharedRead{placeholder: fmt.Sprintf("<share-%d>", len(r.shares)), rep: node.ID(), value: value}
+			r.shares = append(r.shares, share)
+			return Var{Name: share.placeholder}
+		}
+
+		// Entered at the principal port: leave through the copy selected
+		// when the path entered a replicator of the same level.
+		aux, rest, ok := r.pop(stack, node.Level())
+		if !ok || aux >= len(node.Ports()) {
+			aux = r.firstConnectedAux(node)
+			rest = stack
+			if r.log != nil {
+				r.log.Debug("readback: replicator without matching frame", "id", node.ID(), "aux", aux)
+			}
+		}
+		if aux < 0 {
+			return Erased{}
+		}
+		return r.readLink(node, aux, rest)
+
+	case deltanet.NodeTypeVar:
+		// Free variable: names are recovered from the varNames table
+		// produced by ToDeltaNet, since Var nodes carry no label.
+		if name, ok := r.varNames[node.ID()]; ok {
+			return Var{Name: name}
+		}
+		if r.log != nil {
+			r.log.Debug("readback: free var", "id", node.ID())
+		}
+		return Var{Name: "<free>"}
+
+	case deltanet.NodeTypeData:
+		return Lit{Value: node.GetValue()}
+
+	case deltanet.NodeTypeEraser:
+		return Erased{}
+
+	default:
+		return Var{Name: fmt.Sprintf("<? %v>", node.Type())}
+	}
+}
+
+func (r *reader) readLink(node deltanet.Node, port int, stack *repFrame) Term {
+	p := node.Ports()[port]
+	next := p.Peer()
+	for next != nil && r.settle(next.Node) {
+		// The net was rewritten: follow the link again.
+		next = p.Peer()
+	}
+	if next == nil {
+		return r.readTerm(nil, -1, stack)
+	}
+	return r.readTerm(next.Node, next.Index, stack)
+}
+
+// settle is the lazy readback step. It follows the principal chain that
+// starts at node (the node's principal port, then the principal port of the
+// node it leads to through an auxiliary port, and so on) and reduces the
+// first active pair found. Nodes whose readback is in progress are never
+// reduced. It reports whether an interaction took place.
+func (r *reader) settle(node deltanet.Node) bool {
+	seen := make(map[uint64]bool)
+	for r.steps < r.maxSteps && !seen[node.ID()] && r.onPath[node.ID()] == 0 {
+		seen[node.ID()] = true
+		peer := node.Ports()[0].Peer()
+		if peer == nil || r.onPath[peer.Node.ID()] > 0 {
+			return false
+		}
+		if peer.Index != 0 {
+			node = peer.Node
+			continue
+		}
+		if !r.net.ReduceAt(node) {
+			return false
+		}
+		r.steps++
+		if r.log != nil {
+			r.log.Debug("readback: settled", "id", node.ID(), "steps", r.steps, "max", r.maxSteps)
+		}
+		return true
+	}
+	return false
+}
+
+// redexPeer returns the node node forms an active pair with, or nil. Free
+// variables never interact, and neither do fans after the phase 2 rotation.
+func (r *reader) redexPeer(node deltanet.Node) deltanet.Node {
+	p := node.Ports()[0].Peer()
+	if p == nil || p.Index != 0 {
+		return nil
+	}
+	peer := p.Node
+	if node.Type() == deltanet.NodeTypeVar || peer.Type() == deltanet.NodeTypeVar {
+		return nil
+	}
+	if r.net.Phase() == 2 && node.Type() == deltanet.NodeTypeFan && peer.Type() == deltanet.NodeTypeFan {
+		return nil
+	}
+	return peer
+}
+
+// isShareable reports whether a value read through a replicator is worth
+// binding: only compound terms of replicators with several copies qualify.
+func (r *reader) isShareable(rep deltanet.Node, value Term) bool {
+	switch value.(type) {
+	case Abs, App, LetRec:
+	default:
+		return false
+	}
+	copies := 0
+	for i := 1; i < len(rep.Ports()); i++ {
+		if rep.Ports()[i].Wire.Load() != nil {
+			copies++
+		}
+	}
+	return copies > 1
+}
+
+func (r *reader) firstConnectedAux(rep deltanet.Node) int {
+	for i := 1; i < len(rep.Ports()); i++ {
+		if rep.Ports()[i].Wire.Load() != nil {
+			return i
+		}
+	}
+	return -1
+}
+
+// pendingLet is a shared value bound by name, waiting to be placed.
+type pendingLet struct {
+	name  string
+	value Term
+	refs  map[string]bool // Binder names the value depends on
+}
+
+// introduceLets replaces share placeholders: values used once are inlined,
+// alpha-equivalent values used more than once become let bindings placed
+// directly inside the innermost binder they depend on.
+func (r *reader) introduceLets(term Term) Term {
+	if len(r.shares) == 0 {
+		return term
+	}
+
+	// Cluster reads of the same replicator by alpha-equivalence.
+	inline := make(map[string]Term)
+	bound := make(map[string]string)
+	var lets []*pendingLet
+	byRep := make(map[uint64][]*sharedRead)
+	for _, s := range r.shares {
+		byRep[s.rep] = append(byRep[s.rep], s)
+	}
+	clustered := make(map[string]bool)
+	for _, s := range r.shares {
+		if clustered[s.placeholder] {
+			continue
+		}
+		var cluster []*sharedRead
+		for _, other := range byRep[s.rep] {
+			if !clustered[other.placeholder] && AlphaEqual(s.value, other.value) {
+				cluster = append(cluster, other)
+				clustered[other.placeholder] = true
+			}
+		}
+		if len(cluster) == 1 {
+			inline[s.placeholder] = s.value
+			continue
+		}
+		l := &pendingLet{name: r.nextName(), value: s.value}
+		lets = append(lets, l)
+		for _, member := range cluster {
+			bound[member.placeholder] = l.name
+		}
+	}
+
+	var resolve func(Term) Term
+	resolve = func(t Term) Term {
+		switch v := t.(type) {
+		case Var:
+			if name, ok := bound[v.Name]; ok {
+				return Var{Name: name}
+			}
+			if value, ok := inline[v.Name]; ok {
+				return resolve(value)
+			}
+			return v
+		case Abs:
+			return Abs{Arg: v.Arg, Body: resolve(v.Body)}
+		case App:
+			return App{Fun: resolve(v.Fun), Arg: resolve(v.Arg)}
+		case LetRec:
+			return LetRec{Name: v.Name, Val: resolve(v.Val), Body: resolve(v.Body)}
+		default:
+			return t
+		}
+	}
+	term = resolve(term)
+	letsByName := make(map[string]*pendingLet)
+	for _, l := range lets {
+		l.value = resolve(l.value)
+		letsByName[l.name] = l
+	}
+
+	// A let depends on the binders its value mentions, directly or
+	// through other lets.
+	for _, l := range lets {
+		l.refs = make(map[string]bool)
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, l := range lets {
+			free := make(map[string]bool)
+			freeVars(l.value, nil, free)
+			for name := range free {
+				deps := map[string]bool{name: true}
+				if other, ok := letsByName[name]; ok {
+					deps = other.refs
+				}
+				for dep := range deps {
+					if r.binders[dep] && letsByName[dep] == nil && !l.refs[dep] {
+						l.refs[dep] = true
+						changed = true
+					}
+				}
+			}
+		}
+	}
+
+	placed := make(map[string]bool)
+	var place func(Term, map[string]bool) Term
+	var wrap func(Term, map[string]bool, string) Term
+	wrap = func(body Term, scope map[string]bool, binder string) Term {
+		var here []*pendingLet
+		for _, l := range lets {
+			if placed[l.name] || (binder != "" && !l.refs[binder]) {
+				continue
+			}
+			inScope := true
+			for ref := range l.refs {
+				if !scope[ref] {
+					inScope = false
+					break
+				}
+			}
+			if inScope {
+				placed[l.name] = true
+				here = append(here, l)
+			}
+		}
+		for i := len(here) - 1; i >= 0; i-- {
+			body = Let{Name: here[i].name, Val: place(here[i].value, scope), Body: body}
+		}
+		return body
+	}
+	place = func(t Term, scope map[string]bool) Term {
+		switch v := t.(type) {
+		case Abs:
+			inner := make(map[string]bool, len(scope)+1)
+			for k := range scope {
+				inner[k] = true
+			}
+			inner[v.Arg] = true
+			return Abs{Arg: v.Arg, Body: wrap(place(v.Body, inner), inner, v.Arg)}
+		case App:
+			return App{Fun: place(v.Fun, scope), Arg: place(v.Arg, scope)}
+		case LetRec:
+			return LetRec{Name: v.Name, Val: place(v.Val, scope), Body: place(v.Body, scope)}
+		default:
+			return t
+		}
+	}
+	term = wrap(place(term, nil), nil, "")
+
+	// Lets whose binders could not be located are inlined at their uses.
+	var leftovers func(Term) Term
+	leftovers = func(t Term) Term {
+		switch v := t.(type) {
+		case Var:
+			if l, ok := letsByName[v.Name]; ok && !placed[v.Name] {
+				return leftovers(l.value)
+			}
+			return v
+		case Abs:
+			return Abs{Arg: v.Arg, Body: leftovers(v.Body)}
+		case App:
+			return App{Fun: leftovers(v.Fun), Arg: leftovers(v.Arg)}
+		case Let:
+			return Let{Name: v.Name, Val: leftovers(v.Val), Body: leftovers(v.Body)}
+		case LetRec:
+			return LetRec{Name: v.Name, Val: leftovers(v.Val), Body: leftovers(v.Body)}
+		default:
+			return t
+		}
+	}
+	return leftovers(term)
+}
@@ -0,0 +1,409 @@
+package lambda
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// Parallel readback
+//
+// FromDeltaNetParallel walks the net with an explicit task stack instead of
+// recursion, so results millions of nodes deep do not grow the goroutine
+// stack, and hands the argument branch of an application to another
+// goroutine whenever a worker is free. Each task writes its term into a slot
+// owned by its parent, and finishing tasks assemble the parent once the
+// slots are filled.
+//
+// Names cannot be handed out in reading order while branches are read
+// concurrently, so binders and letrecs get temporary names, and a final
+// sequential pass renames them, and orders the shared reads, exactly as
+// FromDeltaNet would have. introduceLets then places the lets as usual, and
+// the result is the same Term.
+
+// tempPrefix marks temporary names; it cannot occur in parsed names.
+const tempPrefix = "\x00"
+
+// parallelGrain is the number of positions a goroutine reads before it may
+// hand a branch to another goroutine, so tiny subterms are read in place.
+const parallelGrain = 256
+
+// maxScopeDepth bounds the frozen scopes on a path, which lookups walk.
+const maxScopeDepth = 32
+
+// pscope holds the positions being read and the binder names of a path.
+// Scopes a goroutine hands to another are frozen: they are only read until
+// the branch is joined, and new entries go to a fresh scope on top.
+type pscope struct {
+	visiting map[position]*cycle
+	bindings map[uint64]string
+	parent   *pscope
+	depth    int
+}
+
+func newPScope(parent *pscope) *pscope {
+	s := &pscope{
+		visiting: make(map[position]*cycle),
+		bindings: make(map[uint64]string),
+		parent:   parent,
+	}
+	if parent != nil {
+		s.depth = parent.depth + 1
+	}
+	return s
+}
+
+func (s *pscope) cycle(key position) *cycle {
+	for ; s != nil; s = s.parent {
+		if c, ok := s.visiting[key]; ok {
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *pscope) binding(id uint64) (string, bool) {
+	for ; s != nil; s = s.parent {
+		if name, ok := s.bindings[id]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// rbOp is the kind of a readback task.
+type rbOp uint8
+
+const (
+	opLink  rbOp = iota // Read the term linked to (node, port)
+	opPos               // Leave a position, wrapping it in a letrec if revisited
+	opAbs               // Build an abstraction from its body
+	opApp               // Join the argument branch and build an application
+	opShare             // Decide whether a read through a replicator is shared
+)
+
+type rbTask struct {
+	op    rbOp
+	node  deltanet.Node
+	port  int
+	stack *repFrame
+	dst   *Term
+	parts *[2]Term
+
+	key   position        // opPos
+	cycle *cycle          // opPos
+	name  string          // opAbs: binder name
+	old   string          // opAbs: shadowed binder name
+	had   bool            // opAbs: whether a name was shadowed
+	wait  *sync.WaitGroup // opApp: argument branch, nil when read in place
+	scope *pscope         // opApp: scope frozen when the branch was handed out
+}
+
+type parallelReader struct {
+	*reader
+	tokens chan struct{} // One per goroutine that may be started
+	grain  int
+	temps  atomic.Uint64
+	mu     sync.Mutex // Guards cycle names and shares
+}
+
+// FromDeltaNetParallel is FromDeltaNet reading independent subterms with up
+// to workers goroutines; zero uses GOMAXPROCS. The net must not change
+// while it is read. It returns the same term as FromDeltaNet.
+func FromDeltaNetParallel(net *deltanet.Network, rootNode deltanet.Node, rootPort int, varNames map[uint64]string, workers int) Term {
+	return newParallelReader(net, varNames, workers, parallelGrain).read(rootNode, rootPort)
+}
+
+func newParallelReader(net *deltanet.Network, varNames map[uint64]string, workers, grain int) *parallelReader {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	r := newReader(net, varNames)
+	r.mu = new(sync.Mutex)
+	return &parallelReader{
+		reader: r,
+		tokens: make(chan struct{}, workers-1),
+		grain:  grain,
+	}
+}
+
+func (pr *parallelReader) read(node deltanet.Node, port int) Term {
+	var term Term
+	w := &rbWorker{pr: pr, scope: newPScope(nil)}
+	if node == nil {
+		term = Var{Name: "<nil>"}
+	} else {
+		w.enter(node, port, nil, &term)
+		w.drain()
+	}
+	return pr.introduceLets(pr.rename(term))
+}
+
+func (pr *parallelReader) tempName() string {
+	return fmt.Sprintf("%s%d", tempPrefix, pr.temps.Add(1))
+}
+
+// cycleName names c on its first revisit.
+func (pr *parallelReader) cycleName(c *cycle) string {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	if c.name == "" {
+		c.name = pr.tempName()
+	}
+	return c.name
+}
+
+// rbWorker reads one branch on one goroutine.
+type rbWorker struct {
+	pr    *parallelReader
+	scope *pscope
+	tasks []rbTask
+	reads int // Positions read since the last branch was handed out
+}
+
+// drain runs the pending tasks.
+func (w *rbWorker) drain() {
+	for len(w.tasks) > 0 {
+		t := w.tasks[len(w.tasks)-1]
+		w.tasks = w.tasks[:len(w.tasks)-1]
+		w.step(t)
+	}
+}
+
+func (w *rbWorker) push(t rbTask) {
+	w.tasks = append(w.tasks, t)
+}
+
+func (w *rbWorker) step(t rbTask) {
+	r := w.pr.reader
+	switch t.op {
+	case opLink:
+		next := t.node.Ports()[t.port].Peer()
+		if next == nil {
+			*t.dst = Var{Name: "<nil>"}
+			return
+		}
+		w.enter(next.Node, next.Index, t.stack, t.dst)
+
+	case opPos:
+		delete(w.scope.visiting, t.key)
+		if t.cycle.name != "" {
+			*t.dst = LetRec{Name: t.cycle.name, Val: *t.dst, Body: Var{Name: t.cycle.name}}
+		}
+
+	case opAbs:
+		if t.had {
+			w.scope.bindings[t.node.ID()] = t.old
+		} else {
+			delete(w.scope.bindings, t.node.ID())
+		}
+		*t.dst = Abs{Arg: t.name, Body: t.parts[0]}
+
+	case opApp:
+		if t.wait != nil {
+			t.wait.Wait()
+			w.scope = t.scope
+		}
+		*t.dst = App{Fun: t.parts[0], Arg: t.parts[1]}
+
+	case opShare:
+		value := t.parts[0]
+		if !r.isShareable(t.node, value) {
+			*t.dst = value
+			return
+		}
+		w.pr.mu.Lock()
+		share := &sharedRead{placeholder: fmt.Sprintf("<share-%d>", len(r.shares)), rep: t.node.ID(), value: value}
+		r.shares = append(r.shares, share)
+		w.pr.mu.Unlock()
+		*t.dst = Var{Name: share.placeholder}
+	}
+}
+
+// enter is readTerm: it reads the term at (node, port) into dst, pushing
+// the tasks that finish it.
+func (w *rbWorker) enter(node deltanet.Node, port int, stack *repFrame, dst *Term) {
+	key := position{node.ID(), port, stack}
+	if c := w.scope.cycle(key); c != nil {
+		*dst = Var{Name: w.pr.cycleName(c)}
+		return
+	}
+	c := &cycle{}
+	w.scope.visiting[key] = c
+	w.push(rbTask{op: opPos, key: key, cycle: c, dst: dst})
+	w.reads++
+	w.readNode(node, port, stack, dst)
+}
+
+// readNode is reader.readNode with the recursive reads pushed as tasks.
+func (w *rbWorker) readNode(node deltanet.Node, port int, stack *repFrame, dst *Term) {
+	r := w.pr.reader
+	switch node.Type() {
+	case deltanet.NodeTypeFan:
+		switch r.logicalPort(node, port) {
+		case 0:
+			name := w.pr.tempName()
+			old, had := w.scope.bindings[node.ID()]
+			w.scope.bindings[node.ID()] = name
+			parts := new([2]Term)
+			w.push(rbTask{op: opAbs, node: node, name: name, old: old, had: had, parts: parts, dst: dst})
+			w.push(rbTask{op: opLink, node: node, port: r.physicalPort(1), stack: stack, dst: &parts[0]})
+
+		case 1:
+			parts := new([2]Term)
+			arg := rbTask{op: opLink, node: node, port: r.physicalPort(2), stack: stack, dst: &parts[1]}
+			app := rbTask{op: opApp, parts: parts, dst: dst}
+			if w.handOut(arg, &app) {
+				w.push(app)
+			} else {
+				w.push(app)
+				w.push(arg)
+			}
+			w.push(rbTask{op: opLink, node: node, port: r.physicalPort(0), stack: stack, dst: &parts[0]})
+
+		default:
+			if name, ok := w.scope.binding(node.ID()); ok {
+				*dst = Var{Name: name}
+			} else {
+				*dst = Var{Name: "<binding>"}
+			}
+		}
+
+	case deltanet.NodeTypeReplicator:
+		if port > 0 {
+			parts := new([2]Term)
+			w.push(rbTask{op: opShare, node: node, parts: parts, dst: dst})
+			w.push(rbTask{op: opLink, node: node, port: 0, stack: r.frame(node.Level(), port, stack), dst: &parts[0]})
+			return
+		}
+		aux, rest, ok := r.pop(stack, node.Level())
+		if !ok || aux >= len(node.Ports()) {
+			aux = r.firstConnectedAux(node)
+			rest = stack
+		}
+		if aux < 0 {
+			*dst = Erased{}
+			return
+		}
+		w.push(rbTask{op: opLink, node: node, port: aux, stack: rest, dst: dst})
+
+	default:
+		*dst = r.readNode(node, port, stack)
+	}
+}
+
+// handOut starts a goroutine reading task when a worker is free, recording
+// in app what joining it takes. The current scope is frozen for the new
+// goroutine, and this one continues on a fresh scope above it.
+func (w *rbWorker) handOut(task rbTask, app *rbTask) bool {
+	if w.reads < w.pr.grain || w.scope.depth >= maxScopeDepth {
+		return false
+	}
+	select {
+	case w.pr.tokens <- struct{}{}:
+	default:
+		return false
+	}
+	w.reads = 0
+	frozen := w.scope
+	w.scope = newPScope(frozen)
+	app.scope = frozen
+	app.wait = new(sync.WaitGroup)
+	app.wait.Add(1)
+	child := &rbWorker{pr: w.pr, scope: newPScope(frozen)}
+	go func() {
+		defer func() {
+			<-w.pr.tokens
+			app.wait.Done()
+		}()
+		child.push(task)
+		child.drain()
+	}()
+	return true
+}
+
+// rename replaces the temporary names of term with the names FromDeltaNet
+// gives, visiting binders, revisits and shared reads in its reading order,
+// and orders the shared reads the same way.
+func (pr *parallelReader) rename(term Term) Term {
+	byPlaceholder := make(map[string]*sharedRead, len(pr.shares))
+	for _, s := range pr.shares {
+		byPlaceholder[s.placeholder] = s
+	}
+	shares := make([]*sharedRead, 0, len(pr.shares))
+	names := make(map[string]string)
+	name := func(temp string) string {
+		n, ok := names[temp]
+		if !ok {
+			n = pr.nextName()
+			names[temp] = n
+		}
+		return n
+	}
+
+	type renameTask struct {
+		term  Term
+		dst   *Term
+		parts *[2]Term
+		share *sharedRead
+		done  bool // Build term from parts
+	}
+	var result Term
+	tasks := []renameTask{{term: term, dst: &result}}
+	for len(tasks) > 0 {
+		t := tasks[len(tasks)-1]
+		tasks = tasks[:len(tasks)-1]
+		if t.done {
+			switch v := t.term.(type) {
+			case Abs:
+				*t.dst = Abs{Arg: v.Arg, Body: t.parts[0]}
+			case App:
+				*t.dst = App{Fun: t.parts[0], Arg: t.parts[1]}
+			case LetRec:
+				n := name(v.Name)
+				*t.dst = LetRec{Name: n, Val: t.parts[0], Body: t.parts[1]}
+			case Var:
+				t.share.value = t.parts[0]
+				shares = append(shares, t.share)
+			}
+			continue
+		}
+		parts := new([2]Term)
+		switch v := t.term.(type) {
+		case Var:
+			if s, ok := byPlaceholder[v.Name]; ok {
+				*t.dst = v
+				tasks = append(tasks,
+					renameTask{term: v, parts: parts, share: s, done: true},
+					renameTask{term: s.value, dst: &parts[0]})
+			} else if strings.HasPrefix(v.Name, tempPrefix) {
+				*t.dst = Var{Name: name(v.Name)}
+			} else {
+				*t.dst = v
+			}
+		case Abs:
+			n := name(v.Arg)
+			tasks = append(tasks,
+				renameTask{term: Abs{Arg: n}, dst: t.dst, parts: parts, done: true},
+				renameTask{term: v.Body, dst: &parts[0]})
+		case App:
+			tasks = append(tasks,
+				renameTask{term: v, dst: t.dst, parts: parts, done: true},
+				renameTask{term: v.Arg, dst: &parts[1]},
+				renameTask{term: v.Fun, dst: &parts[0]})
+		case LetRec:
+			tasks = append(tasks,
+				renameTask{term: v, dst: t.dst, parts: parts, done: true},
+				renameTask{term: v.Body, dst: &parts[1]},
+				renameTask{term: v.Val, dst: &parts[0]})
+		default:
+			*t.dst = t.term
+		}
+	}
+	pr.shares = shares
+	return result
+}
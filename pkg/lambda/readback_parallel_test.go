@@ -0,0 +1,77 @@
+package lambda
+
+import (
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/deltanet/testsupport"
+)
+
+// TestParallelReadback tests that parallel readback returns the same term
+// as sequential readback, with branches handed out as often as possible.
+func TestParallelReadback(t *testing.T) {
+	inputs := []string{
+		"(f: x: f (f x)) (" + testsupport.ChurchSource(32) + ")",
+		"(m: n: f: x: m f (n f x)) (f: x: f (f x)) (f: x: f (f (f x)))",
+		"(x: f x x) (a b)",
+		"g: (x: f x x) (g a)",
+		"a: (x: x x) (y: a y)",
+		"(s: x: s (s x)) (a: b: a b)",
+		"x: y: (x (y x)) (y (x y))",
+		"(x: y: x) (a b) ((z: z z) c)",
+		"(n: n (x: x) y) ((m: f: x: m f (m f x)) (f: x: f (f x)))",
+	}
+	for _, input := range inputs {
+		term := mustParse(t, input)
+		net := deltanet.NewNetwork()
+		root, port, varNames := ToDeltaNet(term, net)
+		out := net.NewVar()
+		net.Link(root, port, out, 0)
+		net.ReduceAll()
+		node, nodePort := net.GetLink(out, 0)
+		want := FromDeltaNet(net, node, nodePort, varNames)
+		for _, workers := range []int{1, 4} {
+			for i := 0; i < 10; i++ {
+				got := newParallelReader(net, varNames, workers, 0).read(node, nodePort)
+				if got.String() != want.String() {
+					t.Fatalf("%s with %d workers: expected %s, got %s", input, workers, want, got)
+				}
+			}
+		}
+	}
+}
+
+// TestParallelReadbackCycle tests that a cyclic net reads back as a letrec.
+func TestParallelReadbackCycle(t *testing.T) {
+	net := deltanet.NewNetwork()
+	app := net.NewFan()
+	f := net.NewVar()
+	net.Link(app, 0, f, 0)
+	net.Link(app, 2, app, 1)
+
+	res := FromDeltaNetParallel(net, app, 1, map[uint64]string{f.ID(): "f"}, 4)
+	expected := "letrec x0 = (f x0); x0"
+	if res.String() != expected {
+		t.Errorf("expected %s, got %s", expected, res)
+	}
+}
+
+// BenchmarkParallelReadback is BenchmarkReadback with parallel readback.
+func BenchmarkParallelReadback(b *testing.B) {
+	term, err := Parse("(f: x: f (f x)) (" + testsupport.ChurchSource(32) + ")")
+	if err != nil {
+		b.Fatal(err)
+	}
+	tr := NewTranslator(TranslatorOptions{})
+	net := deltanet.NewNetwork()
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		b.Fatal(err)
+	}
+	net.ReduceToNormalForm()
+	node, port := net.GetLink(translation.Output, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		FromDeltaNetParallel(net, node, port, translation.VarNames, 0)
+	}
+}
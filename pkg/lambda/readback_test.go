@@ -0,0 +1,190 @@
+package lambda
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/deltanet/testsupport"
+)
+
+// readAfterReduceAll parses input, reduces it with ReduceAll and reads the
+// result back.
+func readAfterReduceAll(t *testing.T, input string) Term {
+	term := mustParse(t, input)
+	net := deltanet.NewNetwork()
+	root, port, varNames := ToDeltaNet(term, net)
+	out := net.NewVar()
+	net.Link(root, port, out, 0)
+	net.ReduceAll()
+	node, nodePort := net.GetLink(out, 0)
+	return FromDeltaNet(net, node, nodePort, varNames)
+}
+
+// TestReadbackThroughReplicators tests that readback follows replicators
+// back to the shared structure instead of stopping at them.
+func TestReadbackThroughReplicators(t *testing.T) {
+	inputs := []string{
+		"(s: x: s (s x)) (a: b: a b)",
+		"a: (x: x x) (y: a y)",
+	}
+	for _, input := range inputs {
+		res := readAfterReduceAll(t, input)
+		t.Logf("%s => %s", input, res)
+		if strings.Contains(res.String(), "<") {
+			t.Errorf("%s: readback left an unresolved node: %s", input, res)
+		}
+	}
+}
+
+// TestReadbackEmitsLet tests that a subterm shared by a replicator is
+// reported once with a let binding, inside the binder it depends on.
+func TestReadbackEmitsLet(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(x: f x x) (a b)", "let x0 = (a b); ((f x0) x0)"},
+		{"g: (x: f x x) (g a)", "(x0: let x1 = (x0 a); ((f x1) x1))"},
+	}
+	for _, tt := range tests {
+		res := readAfterReduceAll(t, tt.input)
+		if res.String() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.expected, res)
+		}
+	}
+}
+
+// TestReadbackAvoidsFreeNames tests that generated binder names skip the
+// names of free variables, so no binder captures one.
+func TestReadbackAvoidsFreeNames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"y: x0", "(x1: x0)"},
+		{"(a: b: a) x0", "(x1: x0)"},
+		{"f: f x1 x0", "(x2: ((x2 x1) x0))"},
+	}
+	for _, tt := range tests {
+		term := mustParse(t, tt.input)
+		net := deltanet.NewNetwork()
+		root, port, varNames := ToDeltaNet(term, net)
+		out := net.NewVar()
+		net.Link(root, port, out, 0)
+		net.ReduceToNormalForm()
+		node, nodePort := net.GetLink(out, 0)
+		res := FromDeltaNet(net, node, nodePort, varNames)
+		if res.String() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.expected, res)
+		}
+	}
+
+	// Let names are generated too, and a let still goes inside the
+	// binder its value depends on.
+	lets := []struct {
+		input    string
+		expected string
+	}{
+		{"(x: f x x) (x0 b)", "let x1 = (x0 b); ((f x1) x1)"},
+		{"g: (x: f x x) (g x0)", "(x1: let x2 = (x1 x0); ((f x2) x2))"},
+	}
+	for _, tt := range lets {
+		res := readAfterReduceAll(t, tt.input)
+		if res.String() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.expected, res)
+		}
+	}
+}
+
+// TestReadbackEmitsLetRec tests that a cyclic net is read back as letrec.
+func TestReadbackEmitsLetRec(t *testing.T) {
+	net := deltanet.NewNetwork()
+	// An application whose argument is its own result: x = f x.
+	app := net.NewFan()
+	f := net.NewVar()
+	net.Link(app, 0, f, 0)
+	net.Link(app, 2, app, 1)
+
+	res := FromDeltaNet(net, app, 1, map[uint64]string{f.ID(): "f"})
+	expected := "letrec x0 = (f x0); x0"
+	if res.String() != expected {
+		t.Errorf("expected %s, got %s", expected, res)
+	}
+}
+
+// TestLazyReadback tests that readback reduces the pairs it meets on demand.
+func TestLazyReadback(t *testing.T) {
+	tests := []struct {
+		input    string
+		limit    uint64 // ReduceWithLimit steps before readback
+		steps    uint64 // ReadbackSteps
+		expected string
+	}{
+		{"(x: x) a", 0, 10, "a"},
+		{"(f: x: f (f x)) (y: y) a", 1, 100, "a"},
+		// The argument never normalizes, but readback never visits it.
+		{"(x: y: y) ((z: z z) (z: z z))", 0, 10, "(x0: x0)"},
+		// Out of budget: the remaining redex is reported as is.
+		{"(x: x) ((y: y) a)", 0, 1, "((x0: x0) a)"},
+	}
+	for _, tt := range tests {
+		net := deltanet.NewNetwork()
+		tr := NewTranslator(TranslatorOptions{ReadbackSteps: tt.steps})
+		translation, err := tr.Translate(mustParse(t, tt.input), net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		net.ReduceWithLimit(tt.limit)
+		if res := tr.Readback(net, translation); res.String() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.expected, res)
+		}
+	}
+}
+
+// TestPartialReadback tests that partial readback marks pending redexes
+// instead of reading through them.
+func TestPartialReadback(t *testing.T) {
+	tests := []struct {
+		input    string
+		limit    uint64 // ReduceWithLimit steps before readback
+		expected string
+	}{
+		{"(x: x) a", 0, "⟨redex⟩"},
+		{"(x: x) a", 1, "a"},
+		{"f ((x: x) a)", 0, "(f ⟨redex⟩)"},
+		{"y: (x: y x) (z: z)", 0, "(x0: ⟨redex⟩)"},
+	}
+	for _, tt := range tests {
+		net := deltanet.NewNetwork()
+		tr := NewTranslator(TranslatorOptions{})
+		translation, err := tr.Translate(mustParse(t, tt.input), net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		net.ReduceWithLimit(tt.limit)
+		if res := tr.ReadbackPartial(net, translation); res.String() != tt.expected {
+			t.Errorf("%s after %d steps: expected %s, got %s", tt.input, tt.limit, tt.expected, res)
+		}
+	}
+}
+
+// BenchmarkReadback measures reading back a large normal form, the Church
+// numeral 1024 computed as two applied to 32.
+func BenchmarkReadback(b *testing.B) {
+	term, err := Parse("(f: x: f (f x)) (" + testsupport.ChurchSource(32) + ")")
+	if err != nil {
+		b.Fatal(err)
+	}
+	tr := NewTranslator(TranslatorOptions{})
+	net := deltanet.NewNetwork()
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		b.Fatal(err)
+	}
+	net.ReduceToNormalForm()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Readback(net, translation)
+	}
+}
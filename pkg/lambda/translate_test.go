@@ -1,8 +1,10 @@
 package lambda
 
 import (
+	"bytes"
 	"github.com/vic/godnet/pkg/deltanet"
-	"os"
+	"log/slog"
+	"strings"
 	"testing"
 )
 
@@ -38,10 +40,43 @@ func TestRoundtripNestedApp(t *testing.T) {
 func TestRoundtripFreeVar(t *testing.T) {
 	orig := Var{Name: "a"}
 	res := roundtrip(t, orig)
-	// free variables lose name (deltanet doesn't store names) but structure should be Var
-	if _, ok := res.(Var); !ok {
+	v, ok := res.(Var)
+	if !ok {
 		t.Fatalf("FreeVar roundtrip: expected Var, got %T: %#v", res, res)
 	}
+	if v.Name != "a" {
+		t.Errorf("FreeVar roundtrip: expected name a, got %q", v.Name)
+	}
+}
+
+func TestFreeVarNamesAfterReduction(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x y", "(x y)"},
+		{"(z: z) a", "a"},
+		{"(f: x: f x) g b", "(g b)"},
+		{"x", "x"},
+	}
+	for _, tt := range tests {
+		term, err := Parse(tt.input)
+		if err != nil {
+			t.Fatalf("Parse error: %v", err)
+		}
+		net := deltanet.NewNetwork()
+		root, port, varNames := ToDeltaNet(term, net)
+		output := net.NewVar()
+		net.Link(root, port, output, 0)
+		// Canonical rules decay the free-variable replicators, so the
+		// readback reaches the Var nodes directly.
+		net.ReduceToNormalForm()
+		resNode, resPort := net.GetLink(output, 0)
+		res := FromDeltaNet(net, resNode, resPort, varNames)
+		if res.String() != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.input, tt.expected, res)
+		}
+	}
 }
 
 func TestRoundtripSharedVar(t *testing.T) {
@@ -55,10 +90,16 @@ func TestRoundtripSharedVar(t *testing.T) {
 }
 
 func TestTranslatorDiagnostics(t *testing.T) {
-	// This ensures DELTA_DEBUG can be toggled without breaking behavior.
-	old := os.Getenv("DELTA_DEBUG")
-	defer os.Setenv("DELTA_DEBUG", old)
-	os.Setenv("DELTA_DEBUG", "1")
+	// This ensures debug logging can be enabled without breaking behavior.
+	var out bytes.Buffer
+	net := deltanet.NewNetworkWith(deltanet.WithLogger(slog.New(slog.NewTextHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))))
 	orig := Abs{Arg: "x", Body: Var{Name: "x"}}
-	_ = roundtrip(t, orig)
+	rootNode, rootPort, varNames := ToDeltaNet(orig, net)
+	net.ReduceAll()
+	if _, ok := FromDeltaNet(net, rootNode, rootPort, varNames).(Abs); !ok {
+		t.Fatalf("expected Abs")
+	}
+	if !strings.Contains(out.String(), "readback: read") {
+		t.Errorf("expected readback debug messages, got %q", out.String())
+	}
 }
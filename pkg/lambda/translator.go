@@ -0,0 +1,391 @@
+package lambda
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// ErrSubsystem is returned by Translate when a term uses its variables in a
+// way the selected subsystem does not allow.
+var ErrSubsystem = errors.New("term is outside the translation subsystem")
+
+// ErrErased is returned by ReadbackChecked in ErasedError mode when the
+// result contains an erased position.
+var ErrErased = errors.New("readback reached an erased position")
+
+// ErasedMode selects how readback represents erased positions.
+type ErasedMode int
+
+const (
+	// ErasedKeep leaves Erased terms in the result, printed as <erased>, or
+	// as a variable named ErasedPlaceholder when one is set.
+	ErasedKeep ErasedMode = iota
+	// ErasedFresh replaces each erased position with a fresh variable whose
+	// name occurs nowhere else in the result.
+	ErasedFresh
+	// ErasedError makes ReadbackChecked fail with ErrErased.
+	ErasedError
+)
+
+// Subsystem selects the fragment of the lambda calculus a translation
+// targets, following the Δ-nets subsystems. Every subsystem is reduced by
+// the same reducer; Translate only restricts the rules it may apply.
+type Subsystem int
+
+const (
+	// SubsystemFull (Δ_K) places no restriction on variables. Every
+	// variable is shared through a replicator.
+	SubsystemFull Subsystem = iota
+	// SubsystemLinear (Δ_L) requires every bound variable to be used
+	// exactly once. Variables are wired directly to their binders.
+	SubsystemLinear
+	// SubsystemAffine (Δ_A) allows bound variables to be used at most once.
+	// Variables are wired directly; unused binders are erased.
+	SubsystemAffine
+	// SubsystemRelevant (Δ_I) requires every bound variable to be used at
+	// least once. Variables are shared through replicators.
+	SubsystemRelevant
+)
+
+func (s Subsystem) String() string {
+	switch s {
+	case SubsystemFull:
+		return "full"
+	case SubsystemLinear:
+		return "linear"
+	case SubsystemAffine:
+		return "affine"
+	case SubsystemRelevant:
+		return "relevant"
+	default:
+		return fmt.Sprintf("Subsystem(%d)", int(s))
+	}
+}
+
+// Rules returns the interaction rules needed to reduce the nets of the
+// subsystem, canonicalization rules included. Linear and affine nets only
+// annihilate fans and erase; relevant nets share through replicators and
+// need every rule, like full ones. Natives may be applied in every
+// subsystem, and Data applied as a function erases its argument (see
+// deltanet.ErrNotFunction), so even linear nets erase.
+func (s Subsystem) Rules() []deltanet.RuleKind {
+	switch s {
+	case SubsystemLinear, SubsystemAffine:
+		return []deltanet.RuleKind{deltanet.RuleFanFan, deltanet.RuleErasure, deltanet.RuleFanNative}
+	default:
+		return []deltanet.RuleKind{
+			deltanet.RuleFanFan, deltanet.RuleRepRep, deltanet.RuleRepRepComm,
+			deltanet.RuleFanRep, deltanet.RuleErasure, deltanet.RuleRepDecay,
+			deltanet.RuleRepMerge, deltanet.RuleAuxFanRep, deltanet.RuleFanNative,
+			deltanet.RuleRepCopy,
+		}
+	}
+}
+
+// TranslatorOptions configures a Translator. The zero value translates the
+// full calculus at level 0 and preserves free variable names.
+type TranslatorOptions struct {
+	// DiscardNames reads free variables back as <free> instead of their
+	// source names.
+	DiscardNames bool
+	// BaseLevel is the level at which the root term is built. Each
+	// application argument is built one level deeper than its application.
+	// Use it to build a term that will be grafted at a nested position.
+	BaseLevel int
+	// Subsystem restricts the accepted terms and selects their encoding.
+	Subsystem Subsystem
+	// DeadCode applies EliminateDeadCode before translation, so bindings
+	// the term never reaches are not built into the net.
+	DeadCode bool
+	// Inline applies Inline before translation, after dead code
+	// elimination, copying combinators of up to this many nodes to their
+	// uses. Zero disables inlining.
+	Inline int
+	// SourceSpans attaches the Span of each parsed term to the nodes built
+	// for it (see deltanet.Network.SetMeta), so traces and native errors
+	// can point back to the source.
+	SourceSpans bool
+	// LayoutHints attaches to each node built for an abstraction,
+	// application, variable or literal its role, nesting depth and
+	// enclosing abstraction, so deltanet.Network.WriteDOT draws the net
+	// in the shape of the term (see TermMeta).
+	LayoutHints bool
+	// ReadbackSteps enables lazy readback: active pairs met while reading
+	// the result are reduced on demand, using at most this many
+	// interactions. Zero reads the net as it is.
+	ReadbackSteps uint64
+	// ReadbackWorkers reads the result with FromDeltaNetParallel using this
+	// many goroutines when greater than one. It is ignored by lazy readback.
+	ReadbackWorkers int
+	// WHNF makes Readback reduce the result to weak head normal form first
+	// (see deltanet.Network.ReduceToWHNF): only the outermost abstraction
+	// or head application is evaluated, and the subterms are read back as
+	// they are.
+	WHNF bool
+	// Eta applies EtaReduce to readback results, so (x: f x) reads back
+	// as f. To contract such redexes in the net itself, enable the eta
+	// rule of the network (see deltanet.Network.SetEtaContraction).
+	Eta bool
+	// Church decodes Church encoded values in readback results (see
+	// DecodeChurch). Decoding happens before eta-reduction.
+	Church ChurchDecoding
+	// Erased selects how erased positions are read back.
+	Erased ErasedMode
+	// ErasedPlaceholder is the variable name used for erased positions in
+	// ErasedKeep mode.
+	ErasedPlaceholder string
+}
+
+// Translator converts terms to nets and reads reduced nets back to terms.
+type Translator struct {
+	opts TranslatorOptions
+}
+
+// NewTranslator creates a Translator with the given options.
+func NewTranslator(opts TranslatorOptions) *Translator {
+	return &Translator{opts: opts}
+}
+
+// Options returns the translator's options.
+func (tr *Translator) Options() TranslatorOptions {
+	return tr.opts
+}
+
+// Translation is a term placed in a network. Its root is linked to the
+// Output interface node, so the result can be read back after reduction
+// whatever the root has been rewritten to.
+type Translation struct {
+	Output   deltanet.Node
+	VarNames map[uint64]string // Key: Var node ID, Value: free variable name
+}
+
+// Translate builds term in net and attaches it to a fresh output node,
+// which is registered as a root of net (see deltanet.Network.AddRoot).
+// Translating into an empty network restricts its reduction to the rules of
+// the subsystem (see deltanet.Network.SetRules), so a pair outside them
+// halts reduction with a *deltanet.RuleError; each further translation
+// widens the restriction with the rules of its own subsystem.
+func (tr *Translator) Translate(term Term, net *deltanet.Network) (*Translation, error) {
+	if tr.opts.DeadCode {
+		term = EliminateDeadCode(term)
+	}
+	if tr.opts.Inline > 0 {
+		term = Inline(term, tr.opts.Inline)
+	}
+	if err := checkSubsystem(term, tr.opts.Subsystem); err != nil {
+		return nil, err
+	}
+	restrictRules(net, tr.opts.Subsystem)
+	b := &builder{
+		net:      net,
+		vars:     make(map[string]*varInfo),
+		varNames: make(map[uint64]string),
+		direct:   tr.opts.Subsystem == SubsystemLinear || tr.opts.Subsystem == SubsystemAffine,
+		spans:    tr.opts.SourceSpans,
+		hints:    tr.opts.LayoutHints,
+	}
+	root, port := b.build(term, tr.opts.BaseLevel, 0)
+	output := net.NewVar()
+	net.Link(root, port, output, 0)
+	net.AddRoot(output, 0)
+	return &Translation{Output: output, VarNames: b.varNames}, nil
+}
+
+// restrictRules adds the rules of s to those net is restricted to, or
+// restricts an empty net to them.
+func restrictRules(net *deltanet.Network, s Subsystem) {
+	rules := net.Rules()
+	if rules == nil && net.NodeCount() > 0 {
+		return
+	}
+	net.SetRules(append(rules, s.Rules()...))
+}
+
+// Report returns the network's reduction report with the readback of the
+// translation as its Result. The readback runs first, so the reductions a
+// lazy or WHNF readback performs are included.
+func (tr *Translator) Report(net *deltanet.Network, t *Translation) deltanet.Report {
+	result := tr.Readback(net, t)
+	report := net.Report()
+	report.Result = result.String()
+	return report
+}
+
+// Readback reconstructs the term currently connected to the translation's
+// output. With WHNF set, the head of the result is reduced first; with
+// ReadbackSteps set, the parts of the net the readback visits are reduced on
+// demand.
+func (tr *Translator) Readback(net *deltanet.Network, t *Translation) Term {
+	result, _ := tr.ReadbackChecked(net, t)
+	return result
+}
+
+// ReadbackPartial reads back the term connected to the translation's output
+// as it stands, showing the active pairs it meets as Redex markers (see
+// FromDeltaNetPartial). Nothing is reduced, so it suits displaying the
+// progress of a reduction.
+func (tr *Translator) ReadbackPartial(net *deltanet.Network, t *Translation) Term {
+	names := t.VarNames
+	if tr.opts.DiscardNames {
+		names = nil
+	}
+	node, port := net.GetLink(t.Output, 0)
+	return FromDeltaNetPartial(net, node, port, names)
+}
+
+// ReadbackChecked is Readback that reports erased positions as ErrErased
+// in ErasedError mode, and the interaction cap of net stopping WHNF
+// reduction (see deltanet.Network.ReduceToWHNFContext). The partial result
+// is returned along with the error.
+func (tr *Translator) ReadbackChecked(net *deltanet.Network, t *Translation) (Term, error) {
+	names := t.VarNames
+	if tr.opts.DiscardNames {
+		names = nil
+	}
+	var whnfErr error
+	if tr.opts.WHNF {
+		_, whnfErr = net.ReduceToWHNFContext(context.Background(), t.Output)
+	}
+	var result Term
+	if tr.opts.ReadbackSteps > 0 {
+		result = readbackLazy(net, t.Output, 0, names, tr.opts.ReadbackSteps)
+	} else {
+		node, port := net.GetLink(t.Output, 0)
+		if tr.opts.ReadbackWorkers > 1 {
+			result = FromDeltaNetParallel(net, node, port, names, tr.opts.ReadbackWorkers)
+		} else {
+			result = FromDeltaNet(net, node, port, names)
+		}
+	}
+	if tr.opts.Church != 0 {
+		result = DecodeChurch(result, tr.opts.Church)
+	}
+	if tr.opts.Eta {
+		result = EtaReduce(result)
+	}
+	result, err := tr.resolveErased(result)
+	return result, errors.Join(whnfErr, err)
+}
+
+// resolveErased applies the ErasedMode to the Erased terms of a result.
+func (tr *Translator) resolveErased(result Term) (Term, error) {
+	switch tr.opts.Erased {
+	case ErasedFresh:
+		used := make(map[string]bool)
+		collectNames(result, used)
+		next := 0
+		return replaceErased(result, func() Term {
+			name := fmt.Sprintf("_e%d", next)
+			for next++; used[name]; next++ {
+				name = fmt.Sprintf("_e%d", next)
+			}
+			return Var{Name: name}
+		}), nil
+	case ErasedError:
+		erased := false
+		replaceErased(result, func() Term {
+			erased = true
+			return Erased{}
+		})
+		if erased {
+			return result, ErrErased
+		}
+		return result, nil
+	default:
+		if tr.opts.ErasedPlaceholder == "" {
+			return result, nil
+		}
+		return replaceErased(result, func() Term { return Var{Name: tr.opts.ErasedPlaceholder} }), nil
+	}
+}
+
+// replaceErased replaces each Erased term in t with a term from fresh.
+func replaceErased(t Term, fresh func() Term) Term {
+	switch v := t.(type) {
+	case Erased:
+		return fresh()
+	case Abs:
+		return Abs{Arg: v.Arg, Body: replaceErased(v.Body, fresh), Span: v.Span}
+	case App:
+		return App{Fun: replaceErased(v.Fun, fresh), Arg: replaceErased(v.Arg, fresh), Span: v.Span}
+	case Let:
+		return Let{Name: v.Name, Val: replaceErased(v.Val, fresh), Body: replaceErased(v.Body, fresh), Span: v.Span}
+	case LetRec:
+		return LetRec{Name: v.Name, Val: replaceErased(v.Val, fresh), Body: replaceErased(v.Body, fresh), Span: v.Span}
+	case Pair:
+		return Pair{Fst: replaceErased(v.Fst, fresh), Snd: replaceErased(v.Snd, fresh)}
+	default:
+		return t
+	}
+}
+
+// checkSubsystem verifies that every bound variable of term is used as
+// often as the subsystem requires. Let and LetRec are checked in their
+// desugared form, so a letrec is only accepted by the full and relevant
+// subsystems.
+func checkSubsystem(term Term, sub Subsystem) error {
+	if sub == SubsystemFull {
+		return nil
+	}
+	var check func(Term) error
+	check = func(t Term) error {
+		switch v := t.(type) {
+		case Abs:
+			uses := countUses(v.Body, v.Arg)
+			switch {
+			case sub == SubsystemLinear && uses != 1,
+				sub == SubsystemAffine && uses > 1,
+				sub == SubsystemRelevant && uses == 0:
+				err := fmt.Errorf("%w: %s: variable %q used %d times", ErrSubsystem, sub, v.Arg, uses)
+				if !v.Span.IsZero() {
+					err = fmt.Errorf("%v: %w", v.Span, err)
+				}
+				return err
+			}
+			return check(v.Body)
+		case App:
+			if err := check(v.Fun); err != nil {
+				return err
+			}
+			return check(v.Arg)
+		case Let:
+			return check(App{Fun: Abs{Arg: v.Name, Body: v.Body}, Arg: v.Val})
+		case LetRec:
+			return check(v.Desugar())
+		case Numeral, Bool, Pair:
+			return check(desugarAll(v))
+		default:
+			return nil
+		}
+	}
+	return check(term)
+}
+
+// countUses counts the free occurrences of name in t.
+func countUses(t Term, name string) int {
+	switch v := t.(type) {
+	case Var:
+		if v.Name == name {
+			return 1
+		}
+		return 0
+	case Abs:
+		if v.Arg == name {
+			return 0
+		}
+		return countUses(v.Body, name)
+	case App:
+		return countUses(v.Fun, name) + countUses(v.Arg, name)
+	case Let:
+		return countUses(App{Fun: Abs{Arg: v.Name, Body: v.Body}, Arg: v.Val}, name)
+	case LetRec:
+		return countUses(v.Desugar(), name)
+	case Pair:
+		return countUses(v.Fst, name) + countUses(v.Snd, name)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,530 @@
+package lambda
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+func translateAndReduce(t *testing.T, opts TranslatorOptions, input string) Term {
+	tr := NewTranslator(opts)
+	net := deltanet.NewNetwork()
+	translation, err := tr.Translate(mustParse(t, input), net)
+	if err != nil {
+		t.Fatalf("%s: %v", input, err)
+	}
+	net.ReduceAll()
+	return tr.Readback(net, translation)
+}
+
+// TestTranslatorSubsystems tests that each subsystem accepts its terms and
+// that the direct (replicator-free) encodings reduce correctly.
+func TestTranslatorSubsystems(t *testing.T) {
+	tests := []struct {
+		sub      Subsystem
+		input    string
+		expected string
+	}{
+		{SubsystemFull, "(x: x x) y", "(y y)"},
+		{SubsystemLinear, "(f: x: f x) g a", "(g a)"},
+		{SubsystemLinear, "(x: y: y x) a", "(x0: (x0 a))"},
+		{SubsystemAffine, "(x: y: x) a b", "a"},
+		{SubsystemRelevant, "(x: x x) y", "(y y)"},
+	}
+	for _, tt := range tests {
+		res := translateAndReduce(t, TranslatorOptions{Subsystem: tt.sub}, tt.input)
+		if res.String() != tt.expected {
+			t.Errorf("%s %s: expected %s, got %s", tt.sub, tt.input, tt.expected, res)
+		}
+	}
+}
+
+// TestTranslatorRejectsOutsideSubsystem tests subsystem validation.
+func TestTranslatorRejectsOutsideSubsystem(t *testing.T) {
+	tests := []struct {
+		sub   Subsystem
+		input string
+	}{
+		{SubsystemLinear, "x: x x"},
+		{SubsystemLinear, "x: y: x"},
+		{SubsystemAffine, "x: x x"},
+		{SubsystemAffine, "let f = y: y in f f"},
+		{SubsystemRelevant, "x: y: x"},
+	}
+	for _, tt := range tests {
+		net := deltanet.NewNetwork()
+		_, err := NewTranslator(TranslatorOptions{Subsystem: tt.sub}).Translate(mustParse(t, tt.input), net)
+		if !errors.Is(err, ErrSubsystem) {
+			t.Errorf("%s %s: expected ErrSubsystem, got %v", tt.sub, tt.input, err)
+		}
+	}
+
+	// letrec goes through Y, which duplicates its argument.
+	rec := LetRec{Name: "f", Val: Abs{Arg: "y", Body: Var{Name: "y"}}, Body: Var{Name: "f"}}
+	if _, err := NewTranslator(TranslatorOptions{Subsystem: SubsystemAffine}).Translate(rec, deltanet.NewNetwork()); !errors.Is(err, ErrSubsystem) {
+		t.Errorf("affine letrec: expected ErrSubsystem, got %v", err)
+	}
+}
+
+// TestSubsystemRules tests that reducing a subsystem's nets only uses the
+// rules the subsystem declares.
+func TestSubsystemRules(t *testing.T) {
+	if !deltanet.TraceCompiled() {
+		t.Skip("built with notrace")
+	}
+	tests := []struct {
+		sub   Subsystem
+		input string
+	}{
+		{SubsystemLinear, "(f: x: f x) (y: y) a"},
+		{SubsystemLinear, "(f: x: f x) 1 (y: y)"},
+		{SubsystemAffine, "(x: y: x) a (z: z)"},
+		{SubsystemRelevant, "(f: x: f (f x)) (y: y) a"},
+	}
+	for _, tt := range tests {
+		allowed := make(map[deltanet.RuleKind]bool)
+		for _, r := range tt.sub.Rules() {
+			allowed[r] = true
+		}
+		net := deltanet.NewNetworkWith(deltanet.WithTrace(1000))
+		if _, err := NewTranslator(TranslatorOptions{Subsystem: tt.sub}).Translate(mustParse(t, tt.input), net); err != nil {
+			t.Fatalf("%s %s: %v", tt.sub, tt.input, err)
+		}
+		if err := net.ReduceAll(); err != nil {
+			t.Errorf("%s %s: %v", tt.sub, tt.input, err)
+		}
+		events := net.TraceSnapshot()
+		if len(events) == 0 {
+			t.Errorf("%s %s: no interactions traced", tt.sub, tt.input)
+		}
+		for _, ev := range events {
+			if !allowed[ev.Rule] {
+				t.Errorf("%s %s: used rule %s", tt.sub, tt.input, ev.Rule)
+			}
+		}
+	}
+}
+
+// TestSubsystemRestrictsRules tests that a net translated in a subsystem
+// refuses the rules outside it, and that translating a term of a wider
+// subsystem into it lifts the restriction.
+func TestSubsystemRestrictsRules(t *testing.T) {
+	net := deltanet.NewNetwork()
+	linear := NewTranslator(TranslatorOptions{Subsystem: SubsystemLinear})
+	if _, err := linear.Translate(mustParse(t, "(f: x: f x) (y: y) a"), net); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(net.Rules()); got != len(SubsystemLinear.Rules()) {
+		t.Errorf("expected %d allowed rules, got %v", len(SubsystemLinear.Rules()), net.Rules())
+	}
+	a, b := net.NewReplicator(0, []int{0}), net.NewReplicator(1, []int{0})
+	net.Link(a, 0, b, 0)
+	net.Link(a, 1, net.NewVar(), 0)
+	net.Link(b, 1, net.NewVar(), 0)
+	var ruleErr *deltanet.RuleError
+	if err := net.ReduceAll(); !errors.As(err, &ruleErr) || ruleErr.Rule != deltanet.RuleRepRepComm {
+		t.Fatalf("expected rep-rep-comm to be refused, got %v", err)
+	}
+
+	full := NewTranslator(TranslatorOptions{})
+	if _, err := full.Translate(mustParse(t, "(x: x x) (y: y)"), net); err != nil {
+		t.Fatal(err)
+	}
+	if err := net.ReduceAll(); err != nil {
+		t.Errorf("reduce after a full translation: %v", err)
+	}
+}
+
+// TestTranslatorNames tests name preservation and BaseLevel.
+func TestTranslatorNames(t *testing.T) {
+	if res := translateAndReduce(t, TranslatorOptions{}, "(z: z) a"); res.String() != "a" {
+		t.Errorf("expected a, got %s", res)
+	}
+	if res := translateAndReduce(t, TranslatorOptions{DiscardNames: true}, "(z: z) a"); res.String() != "<free>" {
+		t.Errorf("expected <free>, got %s", res)
+	}
+	if res := translateAndReduce(t, TranslatorOptions{BaseLevel: 3}, "(f: x: f (f x)) (y: y)"); res.String() != "(x0: x0)" {
+		t.Errorf("BaseLevel 3: expected (x0: x0), got %s", res)
+	}
+}
+
+// TestParserSpans tests the source spans recorded by the parser.
+func TestParserSpans(t *testing.T) {
+	term := mustParse(t, "f\n  (x: x) y")
+	app, ok := term.(App)
+	if !ok {
+		t.Fatalf("expected App, got %T", term)
+	}
+	if app.Span.Start.String() != "1:1" || app.Span.End.Offset != len("f\n  (x: x) y") {
+		t.Errorf("outer App span %+v", app.Span)
+	}
+	abs := app.Fun.(App).Arg.(Abs)
+	if abs.Span.Start.String() != "2:4" || abs.Span.End.String() != "2:8" {
+		t.Errorf("Abs span %v-%v, want 2:4-2:8", abs.Span.Start, abs.Span.End)
+	}
+	if y := app.Arg.(Var); y.Span.Start.String() != "2:10" {
+		t.Errorf("Var y at %v, want 2:10", y.Span)
+	}
+}
+
+// TestTranslatorSourceSpans tests that spans reach the net as metadata and
+// that subsystem errors point at the offending binder.
+func TestTranslatorSourceSpans(t *testing.T) {
+	net := deltanet.NewNetwork()
+	tr := NewTranslator(TranslatorOptions{SourceSpans: true})
+	translation, err := tr.Translate(mustParse(t, "(x: x) y"), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ := net.GetLink(translation.Output, 0)
+	if span, ok := net.Meta(root.ID()); !ok || span.(Span).Start.String() != "1:1" {
+		t.Errorf("expected root span at 1:1, got %v", span)
+	}
+
+	_, err = NewTranslator(TranslatorOptions{Subsystem: SubsystemLinear}).Translate(mustParse(t, "a: b: a"), deltanet.NewNetwork())
+	if err == nil || err.Error()[:4] != "1:4:" {
+		t.Errorf("expected error located at 1:4, got %v", err)
+	}
+}
+
+// TestTranslatorLayoutHints tests that layout hints record each node's
+// role, depth and abstraction, and that WriteDOT nests the abstractions.
+func TestTranslatorLayoutHints(t *testing.T) {
+	net := deltanet.NewNetwork()
+	tr := NewTranslator(TranslatorOptions{LayoutHints: true, SourceSpans: true})
+	translation, err := tr.Translate(mustParse(t, "(x: y: x) z"), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, _ := net.GetLink(translation.Output, 0)
+	outer, _ := net.GetLink(app, 0)
+	inner, _ := net.GetLink(outer, 1)
+	layout := func(node deltanet.Node) deltanet.TermLayout {
+		meta, ok := net.Meta(node.ID())
+		if !ok {
+			t.Fatalf("node %d has no metadata", node.ID())
+		}
+		return meta.(TermMeta).Layout
+	}
+	if got := layout(app); got.Role != deltanet.RoleApp || got.Depth != 0 || got.Scope != 0 {
+		t.Errorf("application: got %+v", got)
+	}
+	if got := layout(outer); got.Role != deltanet.RoleAbs || got.Depth != 1 || got.Name != "x" {
+		t.Errorf("outer abstraction: got %+v", got)
+	}
+	if got := layout(inner); got.Role != deltanet.RoleAbs || got.Depth != 2 || got.Scope != layout(outer).Binder {
+		t.Errorf("inner abstraction: got %+v, want scope %d", got, layout(outer).Binder)
+	}
+	if meta, _ := net.Meta(app.ID()); fmt.Sprint(meta) != "1:1" {
+		t.Errorf("span: got %v", meta)
+	}
+
+	var buf bytes.Buffer
+	if err := net.WriteDOT(&buf, translation.Output); err != nil {
+		t.Fatal(err)
+	}
+	dot := buf.String()
+	x, y := strings.Index(dot, `label="λx"`), strings.Index(dot, `label="λy"`)
+	if x < 0 || y < x || strings.Count(dot[x:y], "subgraph") != 1 {
+		t.Errorf("expected cluster λy nested in λx:\n%s", dot)
+	}
+}
+
+// TestTranslatorWHNF tests that WHNF readback evaluates only the head of the
+// result, so terms without a normal form can still be inspected.
+func TestTranslatorWHNF(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(x: y: x) a ((z: z z) (z: z z))", "a"},
+		{"(x: x) (y: (z: z) y)", "(x0: ((x1: x1) x0))"},
+		{"x: (y: y y) (y: y y)", "(x0: ((x1: (x1 x1)) (x2: (x2 x2))))"},
+		{"(f: x: f (f x)) (y: y) a", "a"},
+	}
+	for _, tt := range tests {
+		net := deltanet.NewNetwork()
+		tr := NewTranslator(TranslatorOptions{WHNF: true})
+		translation, err := tr.Translate(mustParse(t, tt.input), net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tr.Readback(net, translation); got.String() != tt.expected {
+			t.Errorf("%s: got %s, want %s", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestTranslatorWHNFDiverges tests that reducing a term without a WHNF
+// stops at the interaction cap or when the context is done.
+func TestTranslatorWHNFDiverges(t *testing.T) {
+	const omega = "(x: x x) (x: x x)"
+	net := deltanet.NewNetworkWith(deltanet.WithMaxInteractions(1000))
+	tr := NewTranslator(TranslatorOptions{WHNF: true})
+	translation, err := tr.Translate(mustParse(t, omega), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tr.ReadbackChecked(net, translation); !errors.Is(err, deltanet.ErrReductionLimit) {
+		t.Errorf("capped: expected ErrReductionLimit, got %v", err)
+	}
+
+	net = deltanet.NewNetwork()
+	translation, err = tr.Translate(mustParse(t, omega), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	count, err := net.ReduceToWHNFContext(ctx, translation.Output)
+	if !errors.Is(err, context.DeadlineExceeded) || count == 0 {
+		t.Errorf("timed out: expected DeadlineExceeded after some interactions, got %v after %d", err, count)
+	}
+}
+
+// TestEtaReduce tests eta-reduction of terms and of readback results.
+func TestEtaReduce(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x: f x", "f"},
+		{"x: y: f x y", "f"},
+		{"x: x x", "(x: (x x))"},
+		{"x: f x x", "(x: ((f x) x))"},
+		{"g (x: f x)", "(g f)"},
+		{"(x: f x) (y: g y)", "(f g)"},
+	}
+	for _, tt := range tests {
+		if got := EtaReduce(mustParse(t, tt.input)); got.String() != tt.expected {
+			t.Errorf("%s: got %s, want %s", tt.input, got, tt.expected)
+		}
+	}
+
+	net := deltanet.NewNetwork()
+	tr := NewTranslator(TranslatorOptions{Eta: true})
+	translation, err := tr.Translate(mustParse(t, "(h: x: h x) f"), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	net.ReduceAll()
+	if got := tr.Readback(net, translation); got.String() != "f" {
+		t.Errorf("expected f, got %s", got)
+	}
+}
+
+// TestReduceMonitored tests that Ω-like terms are flagged as divergent while
+// normalizing terms reduce to completion.
+func TestReduceMonitored(t *testing.T) {
+	monitor := deltanet.DivergenceMonitor{Window: 200}
+	for _, input := range []string{"(x: x x) (x: x x)", "x: (y: y y) (y: y y)"} {
+		net := deltanet.NewNetwork()
+		translation, err := NewTranslator(TranslatorOptions{}).Translate(mustParse(t, input), net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := net.ReduceMonitored(translation.Output, monitor); !errors.Is(err, deltanet.ErrLikelyDivergent) {
+			t.Errorf("%s: expected ErrLikelyDivergent, got %v", input, err)
+		}
+	}
+
+	net := deltanet.NewNetwork()
+	tr := NewTranslator(TranslatorOptions{})
+	translation, err := tr.Translate(mustParse(t, "(n: f: x: n f (n f x)) (f: x: f (f (f x)))"), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := net.ReduceMonitored(translation.Output, monitor); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if got := tr.Readback(net, translation); got.String() != "(x0: (x1: (x0 (x0 (x0 (x0 (x0 (x0 x1))))))))" {
+		t.Errorf("got %s", got)
+	}
+}
+
+// TestChurchDecoding tests decoding of Church numerals, booleans and pairs
+// in readback results.
+func TestChurchDecoding(t *testing.T) {
+	tests := []struct {
+		input    string
+		which    ChurchDecoding
+		expected string
+	}{
+		// pow 2 3 = 3 2
+		{"(b: e: e b) (f: x: f (f x)) (f: x: f (f (f x)))", DecodeAll, "8"},
+		{"f: x: x", DecodeAll, "0"},
+		{"f: x: x", DecodeBooleans, "false"},
+		{"t: f: t", DecodeAll, "true"},
+		{"(a: b: s: s a b) (f: x: f x) (t: f: t)", DecodeAll, "(1, true)"},
+		{"(a: b: s: s a b) y z", DecodePairs, "(y, z)"},
+		{"s: s s z", DecodePairs, "(x0: ((x0 x0) z))"},
+		{"g (f: x: f (f x))", DecodeNumerals, "(g 2)"},
+	}
+	for _, tt := range tests {
+		if got := translateAndReduce(t, TranslatorOptions{Church: tt.which}, tt.input); got.String() != tt.expected {
+			t.Errorf("%s: got %s, want %s", tt.input, got, tt.expected)
+		}
+	}
+
+	// Decoded values translate back to their encodings.
+	net := deltanet.NewNetwork()
+	tr := NewTranslator(TranslatorOptions{Church: DecodeAll})
+	term := App{Fun: Abs{Arg: "p", Body: App{Fun: Var{Name: "p"}, Arg: Bool{B: false}}}, Arg: Pair{Fst: Numeral{N: 2}, Snd: Var{Name: "y"}}}
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	net.ReduceAll()
+	if got := tr.Readback(net, translation); got.String() != "y" {
+		t.Errorf("expected y, got %s", got)
+	}
+}
+
+// TestLiterals tests that literals parse to Lit terms, translate to Data
+// nodes and read back as their printed value.
+func TestLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"42", "42"},
+		{"(x: x) -7", "-7"},
+		{"(x: y: y) 1 2.5", "2.5"},
+		{"(x: y: x) 2/4 z", "1/2"},
+		{`(x: x) "hi\n"`, `"hi\n"`},
+		{"f 100000000000000000000", "(f 100000000000000000000)"},
+		{"p: p 1 1e3", "(x0: ((x0 1) 1000.0))"},
+	}
+	for _, tt := range tests {
+		if got := translateAndReduce(t, TranslatorOptions{}, tt.input); got.String() != tt.expected {
+			t.Errorf("%s: got %s, want %s", tt.input, got, tt.expected)
+		}
+	}
+
+	lit, ok := mustParse(t, "x 3").(App).Arg.(Lit)
+	if !ok || lit.Value != 3 || lit.Span.Start.String() != "1:3" {
+		t.Errorf("expected Lit 3 at 1:3, got %#v", lit)
+	}
+	for _, input := range []string{`"unterminated`, "f 1/0"} {
+		if term, err := Parse(input); err == nil {
+			if _, isLit := term.(Lit); isLit {
+				t.Errorf("%s: expected error, got %v", input, term)
+			}
+		}
+	}
+}
+
+// TestErasedModes tests the representations of erased positions.
+func TestErasedModes(t *testing.T) {
+	read := func(opts TranslatorOptions) (Term, error) {
+		// f _e0 y with y replaced by an eraser.
+		opts.Subsystem = SubsystemLinear
+		tr := NewTranslator(opts)
+		net := deltanet.NewNetwork()
+		translation, err := tr.Translate(mustParse(t, "f _e0 y"), net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		app, _ := net.GetLink(translation.Output, 0)
+		net.Link(app, 2, net.NewEraser(), 0)
+		return tr.ReadbackChecked(net, translation)
+	}
+
+	got, err := read(TranslatorOptions{})
+	if err != nil || got.String() != "((f _e0) <erased>)" {
+		t.Errorf("keep: got %v (%v)", got, err)
+	}
+	if _, ok := got.(App).Arg.(Erased); !ok {
+		t.Errorf("keep: expected an Erased term, got %T", got.(App).Arg)
+	}
+	if got, _ := read(TranslatorOptions{ErasedPlaceholder: "_"}); got.String() != "((f _e0) _)" {
+		t.Errorf("placeholder: got %v", got)
+	}
+	if got, _ := read(TranslatorOptions{Erased: ErasedFresh}); got.String() != "((f _e0) _e1)" {
+		t.Errorf("fresh: got %v", got)
+	}
+	if _, err := read(TranslatorOptions{Erased: ErasedError}); !errors.Is(err, ErrErased) {
+		t.Errorf("error: expected ErrErased, got %v", err)
+	}
+	tr := NewTranslator(TranslatorOptions{Erased: ErasedError})
+	net := deltanet.NewNetwork()
+	translation, err := tr.Translate(mustParse(t, "(x: y: x) a b"), net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	net.ReduceAll()
+	if got, err := tr.ReadbackChecked(net, translation); err != nil || got.String() != "a" {
+		t.Errorf("error: expected a without error, got %v (%v)", got, err)
+	}
+}
+
+// TestDivergingTermHitsLimit tests that the interaction cap stops the
+// reduction of Ω instead of looping forever.
+func TestDivergingTermHitsLimit(t *testing.T) {
+	net := deltanet.NewNetworkWith(deltanet.WithMaxInteractions(10000))
+	tr := NewTranslator(TranslatorOptions{})
+	if _, err := tr.Translate(mustParse(t, "(x: x x) (x: x x)"), net); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := net.ReduceToNormalFormContext(ctx); !errors.Is(err, deltanet.ErrReductionLimit) {
+		t.Fatalf("expected ErrReductionLimit, got %v", err)
+	}
+}
+
+// TestDivergingTermBudget tests that a wall time budget turns a diverging
+// reduction into a BudgetError.
+func TestDivergingTermBudget(t *testing.T) {
+	net := deltanet.NewNetwork()
+	tr := NewTranslator(TranslatorOptions{})
+	if _, err := tr.Translate(mustParse(t, "(x: x x) (x: x x)"), net); err != nil {
+		t.Fatal(err)
+	}
+	err := net.ReduceWithBudget(context.Background(), deltanet.Budget{Timeout: 50 * time.Millisecond})
+	var be *deltanet.BudgetError
+	if !errors.As(err, &be) || be.Limit != deltanet.LimitTime {
+		t.Fatalf("expected the time limit, got %v", err)
+	}
+	if be.Stats.TotalReductions == 0 {
+		t.Errorf("expected partial stats, got %+v", be.Stats)
+	}
+	net.Close()
+}
+
+func TestEtaContractionRule(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"x: f x", "f"},
+		{"y: x: y x", "y: y"},
+		{"(h: x: h x) f", "f"},
+		{"x: (y: y) f x", "f"},
+		{"g: x: g x x", "g: x: g x x"},
+		{"f: x: f (f x)", "f: x: f (f x)"},
+	}
+	for _, tt := range tests {
+		for name, reduce := range map[string]func(*deltanet.Network) error{
+			"phase1":      (*deltanet.Network).ReducePhase1,
+			"normal form": (*deltanet.Network).ReduceToNormalForm,
+		} {
+			net := deltanet.NewNetworkWith(deltanet.WithEtaContraction())
+			tr := NewTranslator(TranslatorOptions{})
+			translation, err := tr.Translate(mustParse(t, tt.input), net)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reduce(net)
+			if got := tr.Readback(net, translation); !AlphaEqual(got, mustParse(t, tt.want)) {
+				t.Errorf("%s: %q reads back as %s, want %s", name, tt.input, got, tt.want)
+			}
+		}
+	}
+}
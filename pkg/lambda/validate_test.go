@@ -0,0 +1,40 @@
+package lambda
+
+import (
+	"context"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// TestTranslationCanonical tests that translated terms are canonical nets
+// and that every interaction reducing them leaves a valid net.
+func TestTranslationCanonical(t *testing.T) {
+	inputs := []string{
+		"x: x",
+		"f: x: f (f x)",
+		"x: y: x",
+		"(f: x: f (f x)) (f: x: f (f x))",
+		"(x: y: x) (a b) ((z: z z) c)",
+		"(n: n (x: x) y) ((m: f: x: m f (m f x)) (f: x: f (f x)))",
+		"(m: n: f: x: m f (n f x)) (f: x: f (f x)) (f: x: f (f (f x)))",
+	}
+	for _, input := range inputs {
+		net := deltanet.NewNetworkWith(deltanet.WithValidation())
+		tr := NewTranslator(TranslatorOptions{})
+		_, err := tr.Translate(mustParse(t, input), net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if class := net.Classify(); class != deltanet.NetCanonical {
+			t.Errorf("%s: translated to a %v net", input, class)
+		}
+		if err := net.ReduceToNormalFormContext(context.Background()); err != nil {
+			t.Errorf("%s: %v", input, err)
+			continue
+		}
+		if class := net.Classify(); class < deltanet.NetProper {
+			t.Errorf("%s: normal form is %v", input, class)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Package metrics exports the counters of running networks for
+// monitoring: interactions per rule, live and peak nodes, queued active
+// pairs, collections and, with timing enabled (see
+// deltanet.Network.EnableTiming), the time workers spend idle, scheduling
+// and in rules, from which worker utilization follows. A Collector serves
+// them in the Prometheus text exposition format and publishes them as an
+// expvar variable, without depending on a Prometheus client library.
+package metrics
+
+import (
+	"bufio"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// Collector reads the metrics of a set of named networks when scraped.
+type Collector struct {
+	mu   sync.Mutex
+	nets map[string]*deltanet.Network
+}
+
+func NewCollector() *Collector {
+	return &Collector{nets: make(map[string]*deltanet.Network)}
+}
+
+// Register adds n under name, the value of its net label, replacing the
+// network registered under that name, if any.
+func (c *Collector) Register(name string, n *deltanet.Network) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nets[name] = n
+}
+
+// Unregister removes the network registered under name.
+func (c *Collector) Unregister(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.nets, name)
+}
+
+// snapshot is what a scrape reads from one network.
+type snapshot struct {
+	Name    string          `json:"-"`
+	Report  deltanet.Report `json:"report"`
+	Nodes   int             `json:"registered_nodes"`
+	Queued  int             `json:"queued_pairs"`
+	Pending int             `json:"pending_pairs"`
+	Workers int             `json:"workers"`
+}
+
+// snapshots reads the registered networks, in name order.
+func (c *Collector) snapshots() []snapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make([]snapshot, 0, len(c.nets))
+	for name, n := range c.nets {
+		res = append(res, snapshot{
+			Name:    name,
+			Report:  n.Report(),
+			Nodes:   n.NodeCount(),
+			Queued:  n.Queued(),
+			Pending: n.PendingPairs(),
+			Workers: n.Workers(),
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+// family is a Prometheus metric with its samples.
+type family struct {
+	name, help, typ string
+	samples         []sample
+}
+
+type sample struct {
+	labels string
+	value  float64
+}
+
+func (f *family) add(net string, value float64, labels ...string) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "net=%q", net)
+	for i := 0; i+1 < len(labels); i += 2 {
+		fmt.Fprintf(&sb, ",%s=%q", labels[i], labels[i+1])
+	}
+	f.samples = append(f.samples, sample{labels: sb.String(), value: value})
+}
+
+// families lays out the metrics of snaps.
+func families(snaps []snapshot) []*family {
+	reductions := &family{name: "godnet_reductions_total", typ: "counter", help: "Interactions performed."}
+	interactions := &family{name: "godnet_interactions_total", typ: "counter", help: "Interactions performed, by rule."}
+	live := &family{name: "godnet_nodes_live", typ: "gauge", help: "Nodes alive in the net."}
+	registered := &family{name: "godnet_nodes_registered", typ: "gauge", help: "Nodes in the registry, including dead ones not yet collected."}
+	peak := &family{name: "godnet_nodes_peak", typ: "gauge", help: "Largest number of nodes registered at once."}
+	queued := &family{name: "godnet_queued_pairs", typ: "gauge", help: "Active pairs waiting in the scheduler."}
+	pending := &family{name: "godnet_pending_pairs", typ: "gauge", help: "Active pairs queued or being reduced."}
+	workers := &family{name: "godnet_workers", typ: "gauge", help: "Reduction workers."}
+	collected := &family{name: "godnet_collected_nodes_total", typ: "counter", help: "Dead nodes removed by garbage collection."}
+	pruned := &family{name: "godnet_pruned_nodes_total", typ: "counter", help: "Nodes removed by erasure canonicalization."}
+	compactions := &family{name: "godnet_compactions_total", typ: "counter", help: "Pauses to compact the net over its memory threshold."}
+	idle := &family{name: "godnet_worker_idle_seconds_total", typ: "counter", help: "Time workers waited for active pairs, summed over workers (with timing)."}
+	sched := &family{name: "godnet_scheduler_seconds_total", typ: "counter", help: "Time spent queuing, taking and waiting for locks, summed over workers (with timing)."}
+	rules := &family{name: "godnet_rule_seconds_total", typ: "counter", help: "Time spent in rule bodies, by rule (with timing)."}
+
+	for _, s := range snaps {
+		r := s.Report
+		reductions.add(s.Name, float64(r.Stats.TotalReductions))
+		names := make([]string, 0, len(r.Rules))
+		for rule := range r.Rules {
+			names = append(names, rule)
+		}
+		sort.Strings(names)
+		for _, rule := range names {
+			interactions.add(s.Name, float64(r.Rules[rule]), "rule", rule)
+		}
+		live.add(s.Name, float64(r.LiveNodes))
+		registered.add(s.Name, float64(s.Nodes))
+		peak.add(s.Name, float64(r.PeakNodes))
+		queued.add(s.Name, float64(s.Queued))
+		pending.add(s.Name, float64(s.Pending))
+		workers.add(s.Name, float64(s.Workers))
+		collected.add(s.Name, float64(r.Collected))
+		pruned.add(s.Name, float64(r.Pruned))
+		compactions.add(s.Name, float64(r.Compactions))
+		if t := r.Timing; t != nil {
+			idle.add(s.Name, t.Idle.Seconds())
+			sched.add(s.Name, t.Push.Seconds(), "op", "push")
+			sched.add(s.Name, t.Pop.Seconds(), "op", "pop")
+			sched.add(s.Name, t.Lock.Seconds(), "op", "lock")
+			names = names[:0]
+			for rule := range t.Rules {
+				names = append(names, rule)
+			}
+			sort.Strings(names)
+			for _, rule := range names {
+				rules.add(s.Name, t.Rules[rule].Seconds(), "rule", rule)
+			}
+		}
+	}
+	return []*family{reductions, interactions, live, registered, peak, queued, pending, workers,
+		collected, pruned, compactions, idle, sched, rules}
+}
+
+// WritePrometheus writes the metrics of the registered networks in the
+// Prometheus text exposition format, each sample labeled with its
+// network's name.
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range families(c.snapshots()) {
+		if len(f.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.typ)
+		for _, s := range f.samples {
+			fmt.Fprintf(bw, "%s{%s} %g\n", f.name, s.labels, s.value)
+		}
+	}
+	return bw.Flush()
+}
+
+// ServeHTTP serves the metrics for Prometheus to scrape.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.WritePrometheus(w)
+}
+
+// Publish publishes the metrics as the expvar variable name, a JSON object
+// holding, for each registered network, its report (see deltanet.Report),
+// registered nodes, queued pairs and workers. Like expvar.Publish it panics
+// if name is already in use.
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		vars := make(map[string]snapshot)
+		for _, s := range c.snapshots() {
+			vars[s.Name] = s
+		}
+		return vars
+	}))
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"expvar"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/deltanet/testsupport"
+)
+
+// reduced returns a network that reduced Ω for a while.
+func reduced(opts ...deltanet.Option) *deltanet.Network {
+	n := deltanet.NewNetworkWith(opts...)
+	omega, port := testsupport.BuildOmega(n, 0, 0)
+	root := n.NewVar()
+	n.LinkAt(omega, port, root, 0, 0)
+	n.SetRoot(root, 0)
+	n.ReduceWithLimit(50)
+	return n
+}
+
+// TestWritePrometheus tests that the exposition lists each metric family
+// once, with a sample per network.
+func TestWritePrometheus(t *testing.T) {
+	c := NewCollector()
+	c.Register("a", reduced(deltanet.WithTiming()))
+	c.Register("b", reduced())
+
+	var buf bytes.Buffer
+	if err := c.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE godnet_reductions_total counter\n",
+		`godnet_reductions_total{net="a"} 50`,
+		`godnet_reductions_total{net="b"} 50`,
+		`godnet_nodes_live{net="b"} `,
+		`godnet_queued_pairs{net="a"} `,
+		`godnet_pending_pairs{net="a"} `,
+		`godnet_workers{net="a"} `,
+		`godnet_scheduler_seconds_total{net="a",op="pop"} `,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+	if strings.Count(out, "# TYPE godnet_reductions_total") != 1 {
+		t.Errorf("family repeated:\n%s", out)
+	}
+	if strings.Contains(out, `godnet_worker_idle_seconds_total{net="b"}`) {
+		t.Errorf("timing reported for a network without it:\n%s", out)
+	}
+
+	c.Unregister("a")
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if body := rec.Body.String(); strings.Contains(body, `net="a"`) || !strings.Contains(body, `net="b"`) {
+		t.Errorf("unexpected networks served:\n%s", body)
+	}
+}
+
+// TestPublish tests that the expvar variable holds each network's report.
+func TestPublish(t *testing.T) {
+	c := NewCollector()
+	c.Register("a", reduced())
+	c.Publish("godnet_test")
+
+	var vars map[string]struct {
+		Report  deltanet.Report `json:"report"`
+		Workers int             `json:"workers"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("godnet_test").String()), &vars); err != nil {
+		t.Fatal(err)
+	}
+	if got := vars["a"].Report.Stats.TotalReductions; got != 50 {
+		t.Errorf("expected 50 reductions, got %d", got)
+	}
+	if vars["a"].Workers < 1 {
+		t.Errorf("expected workers, got %d", vars["a"].Workers)
+	}
+}
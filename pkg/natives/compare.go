@@ -0,0 +1,111 @@
+package natives
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/lambda"
+)
+
+// RegisterComparisons installs the eq and lt natives. Both return Church
+// booleans, so their result can select between two branches directly:
+//
+//	eq a b then else
+func RegisterComparisons(net *deltanet.Network) {
+	net.RegisterNative("eq", binary(Eq))
+	net.RegisterNative("lt", binary(Lt))
+}
+
+// ChurchBool is a native result delivered as a Church boolean
+// (true = t: f: t, false = t: f: f) instead of a Data node.
+type ChurchBool bool
+
+// Build implements deltanet.NetBuilder. Each binder is used at most once,
+// so the abstractions are wired linearly without replicators.
+func (b ChurchBool) Build(net *deltanet.Network) (deltanet.Node, int) {
+	outer := net.NewFan()
+	inner := net.NewFan()
+	net.Link(outer, 1, inner, 0)
+	if b {
+		net.Link(outer, 2, inner, 1)
+		net.Link(inner, 2, net.NewEraser(), 0)
+	} else {
+		net.Link(outer, 2, net.NewEraser(), 0)
+		net.Link(inner, 2, inner, 1)
+	}
+	return outer, 0
+}
+
+// Compare orders two Data values. Numbers compare by value across int,
+// *big.Int, *big.Rat and float64; strings compare lexicographically. ok is
+// false when the values have no order, including NaN.
+func Compare(a, b interface{}) (c int, ok bool) {
+	if x, isStr := a.(string); isStr {
+		y, isStr := b.(string)
+		if !isStr {
+			return 0, false
+		}
+		return strings.Compare(x, y), true
+	}
+	if isFloat(a) || isFloat(b) {
+		x, okA := ToFloat(a)
+		y, okB := ToFloat(b)
+		if !okA || !okB || math.IsNaN(x) || math.IsNaN(y) {
+			return 0, false
+		}
+		switch {
+		case x < y:
+			return -1, true
+		case x > y:
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+	x, okA := ToRat(a)
+	y, okB := ToRat(b)
+	if !okA || !okB {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
+
+func isFloat(v interface{}) bool {
+	switch v.(type) {
+	case float64, float32:
+		return true
+	default:
+		return false
+	}
+}
+
+// Equal reports whether two Data values are equal. Ordered values use
+// Compare, so 1, 1/1 and 1.0 are equal; lambda terms (normal forms carried
+// as Data) are compared up to alpha-renaming.
+func Equal(a, b interface{}) bool {
+	if c, ok := Compare(a, b); ok {
+		return c == 0
+	}
+	if x, isTerm := a.(lambda.Term); isTerm {
+		y, isTerm := b.(lambda.Term)
+		return isTerm && lambda.AlphaEqual(x, y)
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Eq returns the Church boolean a == b.
+func Eq(a, b interface{}) (interface{}, error) {
+	return ChurchBool(Equal(a, b)), nil
+}
+
+// Lt returns the Church boolean a < b.
+func Lt(a, b interface{}) (interface{}, error) {
+	c, ok := Compare(a, b)
+	if !ok {
+		return nil, fmt.Errorf("lt: cannot order %T and %T", a, b)
+	}
+	return ChurchBool(c < 0), nil
+}
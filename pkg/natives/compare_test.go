@@ -0,0 +1,90 @@
+package natives
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/lambda"
+)
+
+// TestCompare tests ordering across numeric representations and strings.
+func TestCompare(t *testing.T) {
+	huge := new(big.Int).Lsh(big.NewInt(1), 100)
+	tests := []struct {
+		a, b interface{}
+		want int
+		ok   bool
+	}{
+		{1, 2, -1, true},
+		{huge, math.MaxInt, 1, true},
+		{big.NewRat(1, 2), 0.5, 0, true},
+		{big.NewRat(1, 3), big.NewRat(1, 2), -1, true},
+		{"abc", "abd", -1, true},
+		{"1", 1, 0, false},
+		{math.NaN(), 1.0, 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := Compare(tt.a, tt.b)
+		if ok != tt.ok || (ok && got != tt.want) {
+			t.Errorf("Compare(%v, %v) = %d, %v; want %d, %v", tt.a, tt.b, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// TestEqualTerms tests alpha-equivalence of lambda terms carried as Data.
+func TestEqualTerms(t *testing.T) {
+	a, _ := lambda.Parse("x: y: x")
+	b, _ := lambda.Parse("p: q: p")
+	c, _ := lambda.Parse("p: q: q")
+	if !Equal(a, b) {
+		t.Errorf("expected %s == %s", a, b)
+	}
+	if Equal(a, c) {
+		t.Errorf("expected %s != %s", a, c)
+	}
+	if !Equal(1, big.NewRat(2, 2)) || Equal(1, "1") {
+		t.Error("numeric equality should not cross into strings")
+	}
+}
+
+// TestComparisonSelectsBranch tests that eq/lt results reduce as Church
+// booleans when applied to two branches.
+func TestComparisonSelectsBranch(t *testing.T) {
+	tests := []struct {
+		native string
+		a, b   interface{}
+		want   string
+	}{
+		{"eq", 3, 3, "then"},
+		{"eq", 3, 4, "else"},
+		{"lt", 1, big.NewRat(3, 2), "then"},
+		{"lt", 2.5, 2, "else"},
+	}
+	for _, tt := range tests {
+		net := deltanet.NewNetwork()
+		RegisterComparisons(net)
+
+		// native a b then else
+		term := net.NewNative(tt.native)
+		port := 0
+		args := []deltanet.Node{net.NewData(tt.a), net.NewData(tt.b), net.NewVar(), net.NewVar()}
+		for _, arg := range args {
+			app := net.NewFan()
+			net.Link(app, 0, term, port)
+			net.Link(app, 2, arg, 0)
+			term, port = app, 1
+		}
+		output := net.NewVar()
+		net.Link(term, port, output, 0)
+
+		net.ReduceAll()
+
+		res, _ := net.GetLink(output, 0)
+		got := map[deltanet.Node]string{args[2]: "then", args[3]: "else"}[res]
+		if got != tt.want {
+			t.Errorf("%s %v %v: selected %q, want %q", tt.native, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
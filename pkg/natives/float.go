@@ -0,0 +1,135 @@
+package natives
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// RegisterFloats installs float64 arithmetic natives. Integer and rational
+// arguments are converted to float64, so mixed arithmetic needs no explicit
+// conversion.
+func RegisterFloats(net *deltanet.Network) {
+	net.RegisterNative("fadd", binary(FAdd))
+	net.RegisterNative("fsub", binary(FSub))
+	net.RegisterNative("fmul", binary(FMul))
+	net.RegisterNative("fdiv", binary(FDiv))
+	net.RegisterNative("fneg", FNeg)
+	net.RegisterNative("sqrt", Sqrt)
+	net.RegisterNative("floor", Floor)
+	net.RegisterNative("tofloat", ToFloatNative)
+}
+
+// ToFloat converts a numeric Data value to float64.
+func ToFloat(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case float32:
+		return float64(x), true
+	case *big.Rat:
+		if x == nil {
+			return 0, false
+		}
+		f, _ := x.Float64()
+		return f, true
+	default:
+		b, ok := ToBigInt(v)
+		if !ok {
+			return 0, false
+		}
+		f, _ := new(big.Float).SetInt(b).Float64()
+		return f, true
+	}
+}
+
+func floats(op string, a, b interface{}) (float64, float64, error) {
+	x, ok := ToFloat(a)
+	if !ok {
+		return 0, 0, fmt.Errorf("%s: first arg must be a number, got %T", op, a)
+	}
+	y, ok := ToFloat(b)
+	if !ok {
+		return 0, 0, fmt.Errorf("%s: second arg must be a number, got %T", op, b)
+	}
+	return x, y, nil
+}
+
+// FAdd returns a + b as float64.
+func FAdd(a, b interface{}) (interface{}, error) {
+	x, y, err := floats("fadd", a, b)
+	if err != nil {
+		return nil, err
+	}
+	return x + y, nil
+}
+
+// FSub returns a - b as float64.
+func FSub(a, b interface{}) (interface{}, error) {
+	x, y, err := floats("fsub", a, b)
+	if err != nil {
+		return nil, err
+	}
+	return x - y, nil
+}
+
+// FMul returns a * b as float64.
+func FMul(a, b interface{}) (interface{}, error) {
+	x, y, err := floats("fmul", a, b)
+	if err != nil {
+		return nil, err
+	}
+	return x * y, nil
+}
+
+// FDiv returns a / b as float64. Division by zero follows IEEE 754 and
+// yields ±Inf or NaN rather than an error.
+func FDiv(a, b interface{}) (interface{}, error) {
+	x, y, err := floats("fdiv", a, b)
+	if err != nil {
+		return nil, err
+	}
+	return x / y, nil
+}
+
+// FNeg returns -a as float64.
+func FNeg(a interface{}) (interface{}, error) {
+	x, ok := ToFloat(a)
+	if !ok {
+		return nil, fmt.Errorf("fneg: arg must be a number, got %T", a)
+	}
+	return -x, nil
+}
+
+// Sqrt returns the square root of a.
+func Sqrt(a interface{}) (interface{}, error) {
+	x, ok := ToFloat(a)
+	if !ok {
+		return nil, fmt.Errorf("sqrt: arg must be a number, got %T", a)
+	}
+	return math.Sqrt(x), nil
+}
+
+// Floor returns the greatest integer not above a, as an integer value.
+func Floor(a interface{}) (interface{}, error) {
+	x, ok := ToFloat(a)
+	if !ok {
+		return nil, fmt.Errorf("floor: arg must be a number, got %T", a)
+	}
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return nil, fmt.Errorf("floor: %v has no integer value", x)
+	}
+	b, _ := big.NewFloat(math.Floor(x)).Int(nil)
+	return NormalizeInt(b), nil
+}
+
+// ToFloatNative converts a number to float64.
+func ToFloatNative(a interface{}) (interface{}, error) {
+	x, ok := ToFloat(a)
+	if !ok {
+		return nil, fmt.Errorf("tofloat: arg must be a number, got %T", a)
+	}
+	return x, nil
+}
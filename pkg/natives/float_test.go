@@ -0,0 +1,60 @@
+package natives
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// TestFloatArithmetic tests float natives, including mixed argument types.
+func TestFloatArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(a, b interface{}) (interface{}, error)
+		a, b interface{}
+		want float64
+	}{
+		{"fadd", FAdd, 1.5, 2.25, 3.75},
+		{"fsub mixed int", FSub, 1, 0.5, 0.5},
+		{"fmul rat", FMul, big.NewRat(1, 2), 3.0, 1.5},
+		{"fdiv ints", FDiv, 1, 4, 0.25},
+		{"fdiv zero", FDiv, 1.0, 0, math.Inf(1)},
+	}
+	for _, tt := range tests {
+		got, err := tt.fn(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if _, err := FAdd("1", 2.0); err == nil {
+		t.Error("FAdd with string should fail")
+	}
+	if got, _ := Floor(-1.5); got != -2 {
+		t.Errorf("Floor(-1.5) = %v, want -2", got)
+	}
+}
+
+// TestFloatNativeReduction tests sqrt through a Fan-Native interaction.
+func TestFloatNativeReduction(t *testing.T) {
+	net := deltanet.NewNetwork()
+	RegisterFloats(net)
+
+	app := net.NewFan()
+	net.Link(app, 0, net.NewNative("sqrt"), 0)
+	net.Link(app, 2, net.NewData(2.25), 0)
+	output := net.NewVar()
+	net.Link(app, 1, output, 0)
+
+	net.ReduceAll()
+
+	res, _ := net.GetLink(output, 0)
+	if res == nil || res.GetValue() != 1.5 {
+		t.Errorf("Expected 1.5, got %v", res)
+	}
+}
@@ -0,0 +1,164 @@
+package natives
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// ErrDivisionByZero is returned by div and mod when the divisor is zero.
+var ErrDivisionByZero = errors.New("division by zero")
+
+// RegisterIntegers installs integer arithmetic natives. Results are plain
+// ints while they fit and are promoted to *big.Int on overflow, so values
+// never silently wrap.
+func RegisterIntegers(net *deltanet.Network) {
+	net.RegisterNative("add", binary(Add))
+	net.RegisterNative("sub", binary(Sub))
+	net.RegisterNative("mul", binary(Mul))
+	net.RegisterNative("div", binary(Div))
+	net.RegisterNative("mod", binary(Mod))
+	net.RegisterNative("neg", Neg)
+	net.RegisterNative("succ", Succ)
+}
+
+// ToBigInt converts an integer Data value to a new *big.Int.
+func ToBigInt(v interface{}) (*big.Int, bool) {
+	switch x := v.(type) {
+	case int:
+		return big.NewInt(int64(x)), true
+	case int64:
+		return big.NewInt(x), true
+	case int32:
+		return big.NewInt(int64(x)), true
+	case *big.Int:
+		if x == nil {
+			return nil, false
+		}
+		return new(big.Int).Set(x), true
+	default:
+		return nil, false
+	}
+}
+
+// NormalizeInt demotes a *big.Int to int when it fits, so small results stay
+// cheap to compare and print.
+func NormalizeInt(b *big.Int) interface{} {
+	if b.IsInt64() {
+		v := b.Int64()
+		if v >= math.MinInt && v <= math.MaxInt {
+			return int(v)
+		}
+	}
+	return b
+}
+
+func smallInts(a, b interface{}) (int, int, bool) {
+	x, ok := a.(int)
+	if !ok {
+		return 0, 0, false
+	}
+	y, ok := b.(int)
+	return x, y, ok
+}
+
+func bigInts(op string, a, b interface{}) (*big.Int, *big.Int, error) {
+	x, ok := ToBigInt(a)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: first arg must be an integer, got %T", op, a)
+	}
+	y, ok := ToBigInt(b)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: second arg must be an integer, got %T", op, b)
+	}
+	return x, y, nil
+}
+
+// Add returns a + b, promoting to *big.Int on overflow.
+func Add(a, b interface{}) (interface{}, error) {
+	if x, y, ok := smallInts(a, b); ok {
+		s := x + y
+		if (x >= 0) != (y >= 0) || (s >= 0) == (x >= 0) {
+			return s, nil
+		}
+	}
+	x, y, err := bigInts("add", a, b)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeInt(x.Add(x, y)), nil
+}
+
+// Sub returns a - b, promoting to *big.Int on overflow.
+func Sub(a, b interface{}) (interface{}, error) {
+	if x, y, ok := smallInts(a, b); ok {
+		d := x - y
+		if (x >= 0) == (y >= 0) || (d >= 0) == (x >= 0) {
+			return d, nil
+		}
+	}
+	x, y, err := bigInts("sub", a, b)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeInt(x.Sub(x, y)), nil
+}
+
+// Mul returns a * b, promoting to *big.Int on overflow.
+func Mul(a, b interface{}) (interface{}, error) {
+	if x, y, ok := smallInts(a, b); ok {
+		if x == 0 || y == 0 {
+			return 0, nil
+		}
+		p := x * y
+		if p/y == x && !(x == -1 && y == math.MinInt) && !(y == -1 && x == math.MinInt) {
+			return p, nil
+		}
+	}
+	x, y, err := bigInts("mul", a, b)
+	if err != nil {
+		return nil, err
+	}
+	return NormalizeInt(x.Mul(x, y)), nil
+}
+
+// Div returns a / b truncated towards zero.
+func Div(a, b interface{}) (interface{}, error) {
+	x, y, err := bigInts("div", a, b)
+	if err != nil {
+		return nil, err
+	}
+	if y.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	return NormalizeInt(x.Quo(x, y)), nil
+}
+
+// Mod returns the remainder of a / b with the sign of a.
+func Mod(a, b interface{}) (interface{}, error) {
+	x, y, err := bigInts("mod", a, b)
+	if err != nil {
+		return nil, err
+	}
+	if y.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	return NormalizeInt(x.Rem(x, y)), nil
+}
+
+// Neg returns -a.
+func Neg(a interface{}) (interface{}, error) {
+	x, ok := ToBigInt(a)
+	if !ok {
+		return nil, fmt.Errorf("neg: arg must be an integer, got %T", a)
+	}
+	return NormalizeInt(x.Neg(x)), nil
+}
+
+// Succ returns a + 1. Applying a Church numeral to succ and 0 decodes it.
+func Succ(a interface{}) (interface{}, error) {
+	return Add(a, 1)
+}
@@ -0,0 +1,125 @@
+package natives
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+func bigFromString(t *testing.T, s string) *big.Int {
+	t.Helper()
+	b, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		t.Fatalf("bad big.Int literal %q", s)
+	}
+	return b
+}
+
+func sameInt(got interface{}, want interface{}) bool {
+	g, ok1 := ToBigInt(got)
+	w, ok2 := ToBigInt(want)
+	return ok1 && ok2 && g.Cmp(w) == 0
+}
+
+// TestIntegerPromotion tests that arithmetic promotes on overflow and
+// demotes back to int when the result fits.
+func TestIntegerPromotion(t *testing.T) {
+	maxPlusOne := new(big.Int).Add(big.NewInt(math.MaxInt64), big.NewInt(1))
+
+	tests := []struct {
+		name string
+		fn   func(a, b interface{}) (interface{}, error)
+		a, b interface{}
+		want interface{}
+		big  bool
+	}{
+		{"add small", Add, 2, 3, 5, false},
+		{"add overflow", Add, math.MaxInt, 1, maxPlusOne, true},
+		{"add negative overflow", Add, math.MinInt, -1, new(big.Int).Sub(big.NewInt(math.MinInt64), big.NewInt(1)), true},
+		{"sub overflow", Sub, math.MinInt, 1, new(big.Int).Sub(big.NewInt(math.MinInt64), big.NewInt(1)), true},
+		{"mul overflow", Mul, math.MaxInt, 2, new(big.Int).Mul(big.NewInt(math.MaxInt64), big.NewInt(2)), true},
+		{"mul minint", Mul, math.MinInt, -1, new(big.Int).Neg(big.NewInt(math.MinInt64)), true},
+		{"demote", Sub, maxPlusOne, 1, math.MaxInt, false},
+		{"div", Div, 7, 2, 3, false},
+		{"mod sign", Mod, -7, 2, -1, false},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.fn(tt.a, tt.b)
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+			continue
+		}
+		if !sameInt(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+		if _, isBig := got.(*big.Int); isBig != tt.big {
+			t.Errorf("%s: got %T, big=%v expected", tt.name, got, tt.big)
+		}
+	}
+}
+
+// TestIntegerErrors tests type and division errors.
+func TestIntegerErrors(t *testing.T) {
+	if _, err := Div(1, 0); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Div by zero: got %v", err)
+	}
+	if _, err := Mod(1, big.NewInt(0)); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("Mod by zero: got %v", err)
+	}
+	if _, err := Add("1", 2); err == nil {
+		t.Error("Add with string should fail")
+	}
+}
+
+// TestFactorialDoesNotWrap computes 25! through the registered natives.
+func TestFactorialDoesNotWrap(t *testing.T) {
+	net := deltanet.NewNetwork()
+	RegisterIntegers(net)
+	mul, _ := net.GetNative("mul")
+
+	var acc interface{} = 1
+	for i := 2; i <= 25; i++ {
+		partial, err := mul(acc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		acc, err = partial.(func(interface{}) (interface{}, error))(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := bigFromString(t, "15511210043330985984000000")
+	if !sameInt(acc, want) {
+		t.Errorf("25! = %v, want %v", acc, want)
+	}
+}
+
+// TestIntegerNativeReduction tests arithmetic through Fan-Native interactions.
+func TestIntegerNativeReduction(t *testing.T) {
+	net := deltanet.NewNetwork()
+	RegisterIntegers(net)
+
+	// add MaxInt 1
+	inner := net.NewFan()
+	net.Link(inner, 0, net.NewNative("add"), 0)
+	net.Link(inner, 2, net.NewData(math.MaxInt), 0)
+	outer := net.NewFan()
+	net.Link(outer, 0, inner, 1)
+	net.Link(outer, 2, net.NewData(1), 0)
+	output := net.NewVar()
+	net.Link(outer, 1, output, 0)
+
+	net.ReduceAll()
+
+	res, _ := net.GetLink(output, 0)
+	if res == nil || res.Type() != deltanet.NodeTypeData {
+		t.Fatalf("Expected Data result, got %v", res)
+	}
+	if b, ok := res.GetValue().(*big.Int); !ok || b.Cmp(new(big.Int).Add(big.NewInt(math.MaxInt64), big.NewInt(1))) != 0 {
+		t.Errorf("Expected promoted big.Int, got %T %v", res.GetValue(), res.GetValue())
+	}
+}
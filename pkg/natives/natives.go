@@ -0,0 +1,23 @@
+// Package natives provides a standard library of native functions that can
+// be registered on a deltanet.Network.
+package natives
+
+import "github.com/vic/godnet/pkg/deltanet"
+
+// binary adapts a two-argument Go function to the curried NativeFunc shape
+// expected by deltanet: the first application returns a partial native.
+func binary(fn func(a, b interface{}) (interface{}, error)) deltanet.NativeFunc {
+	return func(a interface{}) (interface{}, error) {
+		return func(b interface{}) (interface{}, error) {
+			return fn(a, b)
+		}, nil
+	}
+}
+
+// Register installs every native in this package on the network.
+func Register(net *deltanet.Network) {
+	RegisterIntegers(net)
+	RegisterFloats(net)
+	RegisterRationals(net)
+	RegisterComparisons(net)
+}
@@ -0,0 +1,121 @@
+package natives
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/vic/godnet/pkg/deltanet"
+)
+
+// RegisterRationals installs exact rational arithmetic natives backed by
+// *big.Rat. Integer arguments are accepted as rationals with denominator 1.
+func RegisterRationals(net *deltanet.Network) {
+	net.RegisterNative("radd", binary(RAdd))
+	net.RegisterNative("rsub", binary(RSub))
+	net.RegisterNative("rmul", binary(RMul))
+	net.RegisterNative("rdiv", binary(RDiv))
+	net.RegisterNative("rneg", RNeg)
+	net.RegisterNative("rat", binary(Rat))
+	net.RegisterNative("numer", Numer)
+	net.RegisterNative("denom", Denom)
+}
+
+// ToRat converts an integer or rational Data value to a new *big.Rat.
+// Floats are rejected, since they would silently bring rounding error into
+// exact arithmetic.
+func ToRat(v interface{}) (*big.Rat, bool) {
+	if x, ok := v.(*big.Rat); ok {
+		if x == nil {
+			return nil, false
+		}
+		return new(big.Rat).Set(x), true
+	}
+	b, ok := ToBigInt(v)
+	if !ok {
+		return nil, false
+	}
+	return new(big.Rat).SetInt(b), true
+}
+
+func rats(op string, a, b interface{}) (*big.Rat, *big.Rat, error) {
+	x, ok := ToRat(a)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: first arg must be an integer or rational, got %T", op, a)
+	}
+	y, ok := ToRat(b)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s: second arg must be an integer or rational, got %T", op, b)
+	}
+	return x, y, nil
+}
+
+// RAdd returns a + b exactly.
+func RAdd(a, b interface{}) (interface{}, error) {
+	x, y, err := rats("radd", a, b)
+	if err != nil {
+		return nil, err
+	}
+	return x.Add(x, y), nil
+}
+
+// RSub returns a - b exactly.
+func RSub(a, b interface{}) (interface{}, error) {
+	x, y, err := rats("rsub", a, b)
+	if err != nil {
+		return nil, err
+	}
+	return x.Sub(x, y), nil
+}
+
+// RMul returns a * b exactly.
+func RMul(a, b interface{}) (interface{}, error) {
+	x, y, err := rats("rmul", a, b)
+	if err != nil {
+		return nil, err
+	}
+	return x.Mul(x, y), nil
+}
+
+// RDiv returns a / b exactly.
+func RDiv(a, b interface{}) (interface{}, error) {
+	x, y, err := rats("rdiv", a, b)
+	if err != nil {
+		return nil, err
+	}
+	if y.Sign() == 0 {
+		return nil, ErrDivisionByZero
+	}
+	return x.Quo(x, y), nil
+}
+
+// RNeg returns -a exactly.
+func RNeg(a interface{}) (interface{}, error) {
+	x, ok := ToRat(a)
+	if !ok {
+		return nil, fmt.Errorf("rneg: arg must be an integer or rational, got %T", a)
+	}
+	return x.Neg(x), nil
+}
+
+// Rat builds the rational a/b, e.g. rat 1 3 is 1/3.
+func Rat(a, b interface{}) (interface{}, error) {
+	return RDiv(a, b)
+}
+
+// Numer returns the numerator of a in lowest terms.
+func Numer(a interface{}) (interface{}, error) {
+	x, ok := ToRat(a)
+	if !ok {
+		return nil, fmt.Errorf("numer: arg must be an integer or rational, got %T", a)
+	}
+	return NormalizeInt(new(big.Int).Set(x.Num())), nil
+}
+
+// Denom returns the (positive) denominator of a in lowest terms.
+func Denom(a interface{}) (interface{}, error) {
+	x, ok := ToRat(a)
+	if !ok {
+		return nil, fmt.Errorf("denom: arg must be an integer or rational, got %T", a)
+	}
+	return NormalizeInt(new(big.Int).Set(x.Denom())), nil
+}
@@ -0,0 +1,39 @@
+package natives
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// TestRationalArithmetic tests that rational natives stay exact.
+func TestRationalArithmetic(t *testing.T) {
+	third, err := Rat(1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := interface{}(0)
+	for i := 0; i < 3; i++ {
+		if sum, err = RAdd(sum, third); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if sum.(*big.Rat).Cmp(big.NewRat(1, 1)) != 0 {
+		t.Errorf("1/3 + 1/3 + 1/3 = %v, want 1", sum)
+	}
+
+	q, _ := RDiv(big.NewRat(3, 4), 6)
+	if n, _ := Numer(q); n != 1 {
+		t.Errorf("numer(3/4 / 6) = %v, want 1", n)
+	}
+	if d, _ := Denom(q); d != 8 {
+		t.Errorf("denom(3/4 / 6) = %v, want 8", d)
+	}
+
+	if _, err := RDiv(1, big.NewRat(0, 1)); !errors.Is(err, ErrDivisionByZero) {
+		t.Errorf("RDiv by zero: got %v", err)
+	}
+	if _, err := RAdd(0.5, 1); err == nil {
+		t.Error("RAdd with float should fail")
+	}
+}
@@ -0,0 +1,382 @@
+// Package program defines the Program artifact: a translated net together
+// with the natives and effects it needs and a hash of the source it was
+// built from. Programs are self-describing, so a consumer can check that a
+// network provides everything a program requires before running it.
+package program
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/lambda"
+)
+
+// Format is the version of the serialized program layout.
+const Format = 1
+
+var (
+	// ErrFormat is returned when a program uses an unknown layout version
+	// or is structurally invalid.
+	ErrFormat = errors.New("invalid program")
+	// ErrMissingNative is returned by Validate when the network does not
+	// provide a native the program requires.
+	ErrMissingNative = errors.New("program requires an unregistered native")
+	// ErrMissingEffect is returned by Validate when no root handler of the
+	// network handles an effect the program requires.
+	ErrMissingEffect = errors.New("program requires an unhandled effect")
+	// ErrSourceMismatch is returned by CheckSource when the source does not
+	// match the program's source hash.
+	ErrSourceMismatch = errors.New("program was built from a different source")
+	// ErrUnserializable is returned when a net holds a node or value that
+	// has no serialized form, e.g. a Handler node or a partial native.
+	ErrUnserializable = deltanet.ErrUnserializable
+)
+
+// Program is a serialized net with its manifest.
+type Program struct {
+	Format     int               `json:"format"`
+	SourceHash string            `json:"source_hash,omitempty"`
+	Natives    []string          `json:"natives,omitempty"` // Required natives, sorted
+	Effects    []string          `json:"effects,omitempty"` // Required effects, sorted
+	Meta       map[string]string `json:"meta,omitempty"`    // Free-form, e.g. "source": file name
+	Nodes      []NodeRecord      `json:"nodes"`
+	Wires      []WireRecord      `json:"wires"`
+	Output     int               `json:"output"`              // Index of the output Var node
+	FreeVars   map[int]string    `json:"free_vars,omitempty"` // Var node index -> free variable name
+}
+
+// NodeRecord is a serialized node. Only the fields meaningful for Type are
+// set.
+type NodeRecord struct {
+	Type   string   `json:"type"` // deltanet.NodeType name
+	Level  int      `json:"level,omitempty"`
+	Deltas []int    `json:"deltas,omitempty"`
+	Name   string   `json:"name,omitempty"`  // Native or effect name
+	Value  string   `json:"value,omitempty"` // Data value or effect payload, in literal syntax
+	Row    []string `json:"row,omitempty"`   // Effect row
+}
+
+// WireRecord is a serialized wire: node index and port of both ends, and
+// the wire depth used to schedule its reduction.
+type WireRecord struct {
+	From  [2]int `json:"from"`
+	To    [2]int `json:"to"`
+	Depth uint64 `json:"depth,omitempty"`
+}
+
+// HashSource returns the source hash recorded in programs.
+func HashSource(source []byte) string {
+	sum := sha256.Sum256(source)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Compile parses source and translates it into a program.
+func Compile(source []byte, opts lambda.TranslatorOptions) (*Program, error) {
+	term, err := lambda.Parse(string(source))
+	if err != nil {
+		return nil, fmt.Errorf("parse error: %w", err)
+	}
+	net := deltanet.NewNetwork()
+	t, err := lambda.NewTranslator(opts).Translate(term, net)
+	if err != nil {
+		return nil, err
+	}
+	p, err := FromTranslation(net, t)
+	if err != nil {
+		return nil, err
+	}
+	p.SourceHash = HashSource(source)
+	return p, nil
+}
+
+// FromTranslation serializes the part of net connected to the
+// translation's output and collects its manifest.
+func FromTranslation(net *deltanet.Network, t *lambda.Translation) (*Program, error) {
+	p := &Program{Format: Format}
+	index := map[uint64]int{t.Output.ID(): 0}
+	queue := []deltanet.Node{t.Output}
+	natives := make(map[string]bool)
+	effects := make(map[string]bool)
+	for i := 0; i < len(queue); i++ {
+		node := queue[i]
+		rec, err := record(node)
+		if err != nil {
+			return nil, err
+		}
+		switch node.Type() {
+		case deltanet.NodeTypePure:
+			natives[node.GetName()] = true
+		case deltanet.NodeTypeEffect:
+			effects[node.GetEffect().Name] = true
+		case deltanet.NodeTypeVar:
+			if name, ok := t.VarNames[node.ID()]; ok {
+				if p.FreeVars == nil {
+					p.FreeVars = make(map[int]string)
+				}
+				p.FreeVars[i] = name
+			}
+		}
+		p.Nodes = append(p.Nodes, rec)
+		for port := range node.Ports() {
+			other, otherPort := net.GetLink(node, port)
+			if other == nil {
+				continue
+			}
+			j, seen := index[other.ID()]
+			if !seen {
+				j = len(queue)
+				index[other.ID()] = j
+				queue = append(queue, other)
+			}
+			// Each wire is recorded once, from its smaller end.
+			if i < j || (i == j && port < otherPort) {
+				p.Wires = append(p.Wires, WireRecord{
+					From:  [2]int{i, port},
+					To:    [2]int{j, otherPort},
+					Depth: net.LinkDepth(node, port),
+				})
+			}
+		}
+	}
+	p.Natives = sortedKeys(natives)
+	p.Effects = sortedKeys(effects)
+	return p, nil
+}
+
+// record converts the network's record of node into its serialized form.
+func record(node deltanet.Node) (NodeRecord, error) {
+	src := deltanet.RecordNode(node)
+	if err := src.CheckSerializable(); err != nil {
+		return NodeRecord{}, err
+	}
+	rec := NodeRecord{Type: src.Type.String()}
+	switch src.Type {
+	case deltanet.NodeTypeReplicator:
+		rec.Level = src.Level
+		rec.Deltas = src.Deltas
+	case deltanet.NodeTypeData:
+		value, err := encodeValue(src.Value)
+		if err != nil {
+			return rec, err
+		}
+		rec.Value = value
+	case deltanet.NodeTypePure:
+		rec.Name = src.Name
+	case deltanet.NodeTypeEffect:
+		rec.Name = src.Effect.Name
+		if src.Effect.Payload != nil {
+			value, err := encodeValue(src.Effect.Payload)
+			if err != nil {
+				return rec, err
+			}
+			rec.Value = value
+		}
+		rec.Row = append([]string(nil), src.EffectRow...)
+	}
+	return rec, nil
+}
+
+// encodeValue prints a Data value in literal syntax. Only values that
+// lambda.ParseLiteral or a quoted string read back exactly are accepted.
+func encodeValue(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case int, *big.Int, *big.Rat, string:
+		return lambda.FormatLiteral(x), nil
+	case float64:
+		if !math.IsInf(x, 0) && !math.IsNaN(x) {
+			return lambda.FormatLiteral(x), nil
+		}
+	}
+	return "", fmt.Errorf("%w: data value %v (%T)", ErrUnserializable, v, v)
+}
+
+func decodeValue(s string) (interface{}, error) {
+	if strings.HasPrefix(s, `"`) {
+		return strconv.Unquote(s)
+	}
+	return lambda.ParseLiteral(s)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Encode writes the program as JSON.
+func (p *Program) Encode(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(p)
+}
+
+// Decode reads a program written by Encode and checks its structure.
+func Decode(r io.Reader) (*Program, error) {
+	var p Program
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// check verifies the layout version and that every reference is in range,
+// so Load rejects a bad program before building any of it.
+func (p *Program) check() error {
+	if p.Format != Format {
+		return fmt.Errorf("%w: format %d, want %d", ErrFormat, p.Format, Format)
+	}
+	ports := make([]int, len(p.Nodes))
+	for i, rec := range p.Nodes {
+		ports[i] = arity(rec)
+		if ports[i] < 0 {
+			return fmt.Errorf("%w: node %d has unknown type %q", ErrFormat, i, rec.Type)
+		}
+	}
+	if p.Output < 0 || p.Output >= len(p.Nodes) || p.Nodes[p.Output].Type != deltanet.NodeTypeVar.String() {
+		return fmt.Errorf("%w: output %d is not a Var node", ErrFormat, p.Output)
+	}
+	used := make(map[[2]int]bool)
+	for _, w := range p.Wires {
+		for _, end := range [][2]int{w.From, w.To} {
+			if end[0] < 0 || end[0] >= len(p.Nodes) || end[1] < 0 || end[1] >= ports[end[0]] {
+				return fmt.Errorf("%w: wire end %v out of range", ErrFormat, end)
+			}
+			if used[end] {
+				return fmt.Errorf("%w: port %v wired twice", ErrFormat, end)
+			}
+			used[end] = true
+		}
+	}
+	for i, name := range p.FreeVars {
+		if i < 0 || i >= len(p.Nodes) || p.Nodes[i].Type != deltanet.NodeTypeVar.String() {
+			return fmt.Errorf("%w: free variable %q at node %d is not a Var node", ErrFormat, name, i)
+		}
+	}
+	return nil
+}
+
+// arity returns the number of ports of a node record, or -1 for an
+// unknown type.
+func arity(rec NodeRecord) int {
+	switch rec.Type {
+	case deltanet.NodeTypeFan.String():
+		return 3
+	case deltanet.NodeTypeEraser.String(), deltanet.NodeTypeVar.String(),
+		deltanet.NodeTypeData.String(), deltanet.NodeTypePure.String(),
+		deltanet.NodeTypeEffect.String():
+		return 1
+	case deltanet.NodeTypeReplicator.String():
+		return len(rec.Deltas) + 1
+	default:
+		return -1
+	}
+}
+
+// CheckSource reports whether source is the text the program was built
+// from. Programs without a source hash accept any source.
+func (p *Program) CheckSource(source []byte) error {
+	if p.SourceHash != "" && p.SourceHash != HashSource(source) {
+		return ErrSourceMismatch
+	}
+	return nil
+}
+
+// Validate checks that net provides every native and effect handler the
+// program requires, honoring the network's profile.
+func (p *Program) Validate(net *deltanet.Network) error {
+	for _, name := range p.Natives {
+		if _, ok := net.GetNative(name); ok {
+			continue
+		}
+		if capability, registered := net.NativeCapability(name); registered {
+			return fmt.Errorf("native %q requires %v: %w", name, capability, deltanet.ErrCapabilityDenied)
+		}
+		return fmt.Errorf("%w: %q", ErrMissingNative, name)
+	}
+	for _, name := range p.Effects {
+		if handlers := net.Handlers(); handlers == nil || !handlers.CanHandle(name) {
+			return fmt.Errorf("%w: %q", ErrMissingEffect, name)
+		}
+	}
+	return nil
+}
+
+// Load validates the program against net and builds its nodes there. The
+// returned translation can be read back with a lambda.Translator once the
+// net is reduced.
+func (p *Program) Load(net *deltanet.Network) (*lambda.Translation, error) {
+	if err := p.check(); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(net); err != nil {
+		return nil, err
+	}
+	nodes := make([]deltanet.Node, len(p.Nodes))
+	for i, rec := range p.Nodes {
+		node, err := build(net, rec)
+		if err != nil {
+			return nil, fmt.Errorf("node %d: %w", i, err)
+		}
+		nodes[i] = node
+	}
+	for _, w := range p.Wires {
+		net.LinkAt(nodes[w.From[0]], w.From[1], nodes[w.To[0]], w.To[1], w.Depth)
+	}
+	varNames := make(map[uint64]string, len(p.FreeVars))
+	for i, name := range p.FreeVars {
+		varNames[nodes[i].ID()] = name
+	}
+	return &lambda.Translation{Output: nodes[p.Output], VarNames: varNames}, nil
+}
+
+func build(net *deltanet.Network, rec NodeRecord) (deltanet.Node, error) {
+	switch rec.Type {
+	case deltanet.NodeTypeFan.String():
+		return net.NewFan(), nil
+	case deltanet.NodeTypeEraser.String():
+		return net.NewEraser(), nil
+	case deltanet.NodeTypeReplicator.String():
+		return net.NewReplicator(rec.Level, append([]int(nil), rec.Deltas...)), nil
+	case deltanet.NodeTypeVar.String():
+		return net.NewVar(), nil
+	case deltanet.NodeTypeData.String():
+		value, err := decodeValue(rec.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+		}
+		return net.NewData(value), nil
+	case deltanet.NodeTypePure.String():
+		return net.NewNative(rec.Name), nil
+	case deltanet.NodeTypeEffect.String():
+		effect := &deltanet.Effect{Name: rec.Name}
+		if rec.Value != "" {
+			payload, err := decodeValue(rec.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+			}
+			effect.Payload = payload
+		}
+		return net.NewIO(effect, deltanet.EffectRow(rec.Row)), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown node type %q", ErrFormat, rec.Type)
+	}
+}
@@ -0,0 +1,139 @@
+package program
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/lambda"
+	"github.com/vic/godnet/pkg/natives"
+)
+
+// TestProgramRoundtrip tests that a compiled program survives encoding and
+// reduces to the same result as the source.
+func TestProgramRoundtrip(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(x: x) y", "y"},
+		{"(f: x: f (f x)) (y: y) a", "a"},
+		{"let id = x: x in id id b", "b"},
+	}
+	for _, tt := range tests {
+		p, err := Compile([]byte(tt.input), lambda.TranslatorOptions{})
+		if err != nil {
+			t.Fatalf("%s: compile: %v", tt.input, err)
+		}
+		var buf bytes.Buffer
+		if err := p.Encode(&buf); err != nil {
+			t.Fatalf("%s: encode: %v", tt.input, err)
+		}
+		decoded, err := Decode(&buf)
+		if err != nil {
+			t.Fatalf("%s: decode: %v", tt.input, err)
+		}
+		if err := decoded.CheckSource([]byte(tt.input)); err != nil {
+			t.Errorf("%s: %v", tt.input, err)
+		}
+
+		net := deltanet.NewNetwork()
+		translation, err := decoded.Load(net)
+		if err != nil {
+			t.Fatalf("%s: load: %v", tt.input, err)
+		}
+		net.ReduceAll()
+		got := lambda.NewTranslator(lambda.TranslatorOptions{}).Readback(net, translation)
+		if got.String() != tt.expected {
+			t.Errorf("%s: got %s, want %s", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestProgramManifest tests that required natives and effects are recorded
+// and validated before loading.
+func TestProgramManifest(t *testing.T) {
+	net := deltanet.NewNetwork()
+	output := net.NewVar()
+	fan := net.NewFan()
+	net.Link(fan, 1, output, 0)
+	net.Link(fan, 0, net.NewNative("neg"), 0)
+	net.Link(fan, 2, net.NewData(5), 0)
+	p, err := FromTranslation(net, &lambda.Translation{Output: output})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if strings.Join(p.Natives, ",") != "neg" {
+		t.Errorf("natives %v, want [neg]", p.Natives)
+	}
+
+	if _, err := p.Load(deltanet.NewNetwork()); !errors.Is(err, ErrMissingNative) {
+		t.Errorf("expected ErrMissingNative, got %v", err)
+	}
+
+	target := deltanet.NewNetworkWith(deltanet.WithNatives(natives.Register))
+	translation, err := p.Load(target)
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	target.ReduceAll()
+	if result, _ := target.GetLink(translation.Output, 0); result == nil || result.GetValue() != -5 {
+		t.Errorf("expected -5, got %v", result)
+	}
+
+	p.Effects = []string{"Console.Print"}
+	if err := p.Validate(target); !errors.Is(err, ErrMissingEffect) {
+		t.Errorf("expected ErrMissingEffect, got %v", err)
+	}
+	handlers := deltanet.NewHandlerScope()
+	handlers.RegisterNamespace("Console", func(deltanet.Effect, *deltanet.Continuation) (interface{}, error) { return nil, nil })
+	handled := deltanet.NewNetworkWith(deltanet.WithNatives(natives.Register), deltanet.WithHandlers(handlers))
+	if err := p.Validate(handled); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+// TestProgramErrors tests rejection of mismatched sources, invalid layouts
+// and nets without a serialized form.
+func TestProgramErrors(t *testing.T) {
+	p, err := Compile([]byte("x: x"), lambda.TranslatorOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+	if err := p.CheckSource([]byte("y: y")); !errors.Is(err, ErrSourceMismatch) {
+		t.Errorf("expected ErrSourceMismatch, got %v", err)
+	}
+
+	for _, input := range []string{
+		`{"format": 99, "nodes": [{"type": "Var"}], "output": 0}`,
+		`{"format": 1, "nodes": [{"type": "Fan"}], "output": 0}`,
+		`{"format": 1, "nodes": [{"type": "Var"}], "wires": [{"from": [0, 0], "to": [0, 1]}]}`,
+		`{"format": 1, "nodes": [{"type": "Var"}, {"type": "Var"}, {"type": "Var"}], "wires": [{"from": [0, 0], "to": [1, 0]}, {"from": [2, 0], "to": [1, 0]}]}`,
+		`{"format": 1, "nodes": [{"type": "Var"}], "output": 0, "free_vars": {"3": "x"}}`,
+		`{"format": 1, "nodes": [{"type": "Var"}, {"type": "Eraser"}], "output": 0, "free_vars": {"1": "x"}}`,
+		`not json`,
+	} {
+		if _, err := Decode(strings.NewReader(input)); !errors.Is(err, ErrFormat) {
+			t.Errorf("%s: expected ErrFormat, got %v", input, err)
+		}
+	}
+
+	net := deltanet.NewNetwork()
+	output := net.NewVar()
+	net.Link(net.NewHandler(deltanet.NewHandlerScope()), 1, output, 0)
+	if _, err := FromTranslation(net, &lambda.Translation{Output: output}); !errors.Is(err, ErrUnserializable) {
+		t.Errorf("expected ErrUnserializable, got %v", err)
+	}
+
+	// Load checks the program before building anything in the net.
+	p.FreeVars = map[int]string{len(p.Nodes): "x"}
+	net = deltanet.NewNetwork()
+	if _, err := p.Load(net); !errors.Is(err, ErrFormat) {
+		t.Errorf("expected ErrFormat, got %v", err)
+	}
+	if got := net.NodeCount(); got != 0 {
+		t.Errorf("rejected program left %d nodes in the net", got)
+	}
+}
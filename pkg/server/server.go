@@ -0,0 +1,249 @@
+// Package server evaluates lambda terms over HTTP. Jobs wait in a bounded
+// queue for one of a fixed number of evaluators, each job runs under an
+// interaction and wall time budget, and requests are rejected with 429 Too
+// Many Requests once the queue is full, so a server stays responsive under
+// load instead of piling up reductions.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/vic/godnet/pkg/deltanet"
+	"github.com/vic/godnet/pkg/frontend"
+	"github.com/vic/godnet/pkg/lambda"
+	"github.com/vic/godnet/pkg/natives"
+)
+
+var (
+	// ErrSaturated is returned when the job queue is full.
+	ErrSaturated = errors.New("evaluation queue is full")
+	// ErrBudgetExceeded is wrapped by the error of a job that runs out of
+	// interactions or wall time before reaching a normal form.
+	ErrBudgetExceeded = deltanet.ErrBudgetExceeded
+	// ErrClosed is returned for jobs submitted after Close.
+	ErrClosed = errors.New("server is closed")
+)
+
+// Budget bounds a single evaluation. Zero fields are unlimited.
+type Budget struct {
+	MaxInteractions uint64
+	Timeout         time.Duration
+}
+
+// within returns b with each limit lowered to the one of max.
+func (b Budget) within(max Budget) Budget {
+	if max.MaxInteractions > 0 && (b.MaxInteractions == 0 || b.MaxInteractions > max.MaxInteractions) {
+		b.MaxInteractions = max.MaxInteractions
+	}
+	if max.Timeout > 0 && (b.Timeout == 0 || b.Timeout > max.Timeout) {
+		b.Timeout = max.Timeout
+	}
+	return b
+}
+
+// Config configures a Server. Zero fields take their defaults.
+type Config struct {
+	Evaluators int               // Concurrent evaluations (default NumCPU)
+	QueueSize  int               // Jobs waiting for an evaluator (default 4 per evaluator)
+	Budget     Budget            // Per-job limit; requests may only lower it (default 10M interactions, 10s)
+	Profile    *deltanet.Profile // Natives visible to jobs (default ProfilePure)
+	MaxBody    int64             // Request body size limit in bytes (default 1 MiB)
+}
+
+func (c Config) withDefaults() Config {
+	if c.Evaluators <= 0 {
+		c.Evaluators = runtime.NumCPU()
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = 4 * c.Evaluators
+	}
+	if c.Budget.MaxInteractions == 0 {
+		c.Budget.MaxInteractions = 10_000_000
+	}
+	if c.Budget.Timeout == 0 {
+		c.Budget.Timeout = 10 * time.Second
+	}
+	if c.Profile == nil {
+		c.Profile = deltanet.ProfilePure
+	}
+	if c.MaxBody <= 0 {
+		c.MaxBody = 1 << 20
+	}
+	return c
+}
+
+// Request is the body of an evaluation request.
+type Request struct {
+	Source          string `json:"source"`
+	Syntax          string `json:"syntax,omitempty"` // Frontend name, see frontend.Names
+	MaxInteractions uint64 `json:"max_interactions,omitempty"`
+	TimeoutMillis   int64  `json:"timeout_ms,omitempty"`
+}
+
+// Response is the body of an evaluation response. Error is set, and
+// Result and Report are not, when the evaluation failed.
+type Response struct {
+	Result string           `json:"result,omitempty"`
+	Report *deltanet.Report `json:"report,omitempty"`
+	Error  string           `json:"error,omitempty"`
+}
+
+type job struct {
+	ctx  context.Context
+	req  Request
+	resp Response
+	err  error
+	done chan struct{}
+}
+
+// Server evaluates jobs on a fixed pool of evaluators.
+type Server struct {
+	cfg      Config
+	jobs     chan *job
+	wg       sync.WaitGroup
+	mu       sync.RWMutex
+	closed   bool
+	pool     *deltanet.Pool
+	evaluate func(context.Context, Request, Budget) (Response, error)
+}
+
+// New starts a server's evaluators.
+func New(cfg Config) *Server {
+	s := &Server{cfg: cfg.withDefaults()}
+	s.jobs = make(chan *job, s.cfg.QueueSize)
+	// Evaluators already run in parallel: one worker per network.
+	s.pool = deltanet.NewPool(s.cfg.Evaluators, deltanet.WithWorkers(1),
+		deltanet.WithNatives(natives.Register), deltanet.WithProfile(s.cfg.Profile))
+	s.evaluate = s.run
+	for i := 0; i < s.cfg.Evaluators; i++ {
+		s.wg.Add(1)
+		go s.evaluator()
+	}
+	return s
+}
+
+// Close stops accepting jobs and waits for queued jobs to finish.
+func (s *Server) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.jobs)
+	}
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) evaluator() {
+	defer s.wg.Done()
+	for j := range s.jobs {
+		if err := j.ctx.Err(); err != nil {
+			j.err = err
+		} else {
+			budget := Budget{
+				MaxInteractions: j.req.MaxInteractions,
+				Timeout:         time.Duration(j.req.TimeoutMillis) * time.Millisecond,
+			}
+			j.resp, j.err = s.evaluate(j.ctx, j.req, budget.within(s.cfg.Budget))
+		}
+		close(j.done)
+	}
+}
+
+// Eval queues a job and waits for its result. It fails immediately with
+// ErrSaturated when the queue is full, and returns ctx's error once ctx is
+// done; the job then ends as soon as an evaluator sees its context done.
+func (s *Server) Eval(ctx context.Context, req Request) (Response, error) {
+	j := &job{ctx: ctx, req: req, done: make(chan struct{})}
+	s.mu.RLock()
+	if s.closed {
+		s.mu.RUnlock()
+		return Response{}, ErrClosed
+	}
+	select {
+	case s.jobs <- j:
+	default:
+		s.mu.RUnlock()
+		return Response{}, ErrSaturated
+	}
+	s.mu.RUnlock()
+	select {
+	case <-j.done:
+		return j.resp, j.err
+	case <-ctx.Done():
+		return Response{}, ctx.Err()
+	}
+}
+
+// run parses, translates and reduces a job on a pooled network.
+func (s *Server) run(ctx context.Context, req Request, budget Budget) (Response, error) {
+	term, err := frontend.Parse(req.Syntax, req.Source)
+	if err != nil {
+		return Response{}, err
+	}
+	net := s.pool.Get()
+	defer s.pool.Put(net)
+	tr := lambda.NewTranslator(lambda.TranslatorOptions{})
+	translation, err := tr.Translate(term, net)
+	if err != nil {
+		return Response{}, err
+	}
+
+	err = net.ReduceWithBudget(ctx, deltanet.Budget{
+		MaxInteractions: budget.MaxInteractions,
+		Timeout:         budget.Timeout,
+	})
+	if err != nil {
+		return Response{}, err
+	}
+
+	report := tr.Report(net, translation)
+	return Response{Result: report.Result, Report: &report}, nil
+}
+
+// ServeHTTP evaluates a JSON Request posted to any path. Bodies over the
+// configured MaxBody are rejected with 413 Request Entity Too Large.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, Response{Error: "method not allowed"})
+		return
+	}
+	var req Request
+	body := http.MaxBytesReader(w, r.Body, s.cfg.MaxBody)
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		status := http.StatusBadRequest
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeJSON(w, status, Response{Error: err.Error()})
+		return
+	}
+
+	resp, err := s.Eval(r.Context(), req)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, resp)
+	case errors.Is(err, ErrSaturated):
+		w.Header().Set("Retry-After", "1")
+		writeJSON(w, http.StatusTooManyRequests, Response{Error: err.Error()})
+	case errors.Is(err, ErrClosed):
+		writeJSON(w, http.StatusServiceUnavailable, Response{Error: err.Error()})
+	case errors.Is(err, ErrBudgetExceeded):
+		writeJSON(w, http.StatusUnprocessableEntity, Response{Error: err.Error()})
+	default:
+		writeJSON(w, http.StatusBadRequest, Response{Error: err.Error()})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
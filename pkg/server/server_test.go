@@ -0,0 +1,139 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func post(t *testing.T, s *Server, req Request) (*httptest.ResponseRecorder, Response) {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body)))
+	var resp Response
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return rec, resp
+}
+
+// TestServerEval tests a successful evaluation and a parse error.
+func TestServerEval(t *testing.T) {
+	s := New(Config{Evaluators: 2})
+	defer s.Close()
+
+	rec, resp := post(t, s, Request{Source: "(x: y: x) a b"})
+	if rec.Code != http.StatusOK || resp.Result != "a" {
+		t.Fatalf("got %d %+v", rec.Code, resp)
+	}
+	if resp.Report == nil || resp.Report.Stats.TotalReductions == 0 {
+		t.Errorf("expected a report with reductions, got %+v", resp.Report)
+	}
+
+	rec, resp = post(t, s, Request{Source: "(x: "})
+	if rec.Code != http.StatusBadRequest || resp.Error == "" {
+		t.Errorf("parse error: got %d %+v", rec.Code, resp)
+	}
+}
+
+// TestServerBudget tests that a divergent term stops at its budget.
+func TestServerBudget(t *testing.T) {
+	s := New(Config{Evaluators: 1, Budget: Budget{MaxInteractions: 50000, Timeout: time.Minute}})
+	defer s.Close()
+
+	rec, resp := post(t, s, Request{Source: "(x: x x) (x: x x)", MaxInteractions: 1 << 40})
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d %+v", rec.Code, resp)
+	}
+}
+
+// TestServerSaturated tests that jobs beyond the queue are rejected with
+// 429 while the evaluators are busy.
+func TestServerSaturated(t *testing.T) {
+	s := New(Config{Evaluators: 1, QueueSize: 1})
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	s.evaluate = func(context.Context, Request, Budget) (Response, error) {
+		started <- struct{}{}
+		<-release
+		return Response{Result: "ok"}, nil
+	}
+
+	results := make(chan error, 2)
+	go func() { _, err := s.Eval(context.Background(), Request{}); results <- err }()
+	<-started // The evaluator is busy, the queue is empty.
+	go func() { _, err := s.Eval(context.Background(), Request{}); results <- err }()
+	for len(s.jobs) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	rec, _ := post(t, s, Request{Source: "x"})
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After, got %d", rec.Code)
+	}
+
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("queued job failed: %v", err)
+		}
+	}
+	s.Close()
+	if _, err := s.Eval(context.Background(), Request{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed after Close, got %v", err)
+	}
+}
+
+// TestServerBodyLimit tests that bodies over MaxBody are rejected with
+// 413 without being evaluated.
+func TestServerBodyLimit(t *testing.T) {
+	s := New(Config{Evaluators: 1, MaxBody: 64})
+	defer s.Close()
+	s.evaluate = func(context.Context, Request, Budget) (Response, error) {
+		t.Error("evaluated a request over the body limit")
+		return Response{}, nil
+	}
+
+	rec, resp := post(t, s, Request{Source: string(bytes.Repeat([]byte("x "), 64))})
+	if rec.Code != http.StatusRequestEntityTooLarge || resp.Error == "" {
+		t.Errorf("expected 413 with an error, got %d %+v", rec.Code, resp)
+	}
+}
+
+// TestServerEvalCanceled tests that Eval returns once its context is
+// done, without waiting for the evaluator.
+func TestServerEvalCanceled(t *testing.T) {
+	s := New(Config{Evaluators: 1})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	s.evaluate = func(context.Context, Request, Budget) (Response, error) {
+		close(started)
+		<-release
+		return Response{Result: "late"}, nil
+	}
+	defer s.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	result := make(chan error, 1)
+	go func() {
+		_, err := s.Eval(ctx, Request{})
+		result <- err
+	}()
+	<-started
+	cancel()
+	select {
+	case err := <-result:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Eval kept waiting after its context was canceled")
+	}
+}